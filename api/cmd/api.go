@@ -7,12 +7,15 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
+	"syscall"
 
 	"github.com/eclipse-symphony/symphony/api/constants"
 	mu "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
@@ -57,8 +60,11 @@ var RootCmd = &cobra.Command{
 			fmt.Println(err)
 			return
 		}
+		// the host shuts down gracefully on interrupt, or when Kubernetes stops the pod
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 		starHost := host.APIHost{}
-		err = starHost.Launch(config, []vf.IVendorFactory{
+		err = starHost.Launch(ctx, config, []vf.IVendorFactory{
 			svf.SymphonyVendorFactory{},
 		}, []mf.IManagerFactroy{
 			&mu.SymphonyManagerFactory{},
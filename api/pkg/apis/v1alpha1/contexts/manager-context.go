@@ -42,9 +42,9 @@ func (v *ManagerContext) Publish(feed string, event v1alpha2.Event) error {
 	return nil
 }
 
-func (v *ManagerContext) Subscribe(feed string, handler v1alpha2.EventHandler) error {
+func (v *ManagerContext) Subscribe(feed string, handler v1alpha2.EventHandler) (pubsub.Subscription, error) {
 	if v.PubsubProvider != nil {
 		return v.PubsubProvider.Subscribe(feed, handler)
 	}
-	return nil
+	return nil, nil
 }
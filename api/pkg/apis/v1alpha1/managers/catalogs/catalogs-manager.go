@@ -10,6 +10,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/graph"
@@ -27,10 +31,17 @@ import (
 
 var log = logger.NewLogger("coa.runtime")
 
+// maxRevisionAttempts is how many times a revision is created before giving up, when other writers keep taking
+// the revision number
+const maxRevisionAttempts = 5
+
 type CatalogsManager struct {
 	managers.Manager
-	StateProvider states.IStateProvider
-	GraphProvider graph.IGraphProvider
+	StateProvider   states.IStateProvider
+	GraphProvider   graph.IGraphProvider
+	MaxRevisions    int
+	revisionLock    sync.Mutex
+	latestRevisions map[string]int64
 }
 
 func (s *CatalogsManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -49,6 +60,12 @@ func (s *CatalogsManager) Init(context *contexts.VendorContext, config managers.
 			s.GraphProvider = cProvider
 		}
 	}
+	if v, ok := config.Properties["maxRevisions"]; ok {
+		s.MaxRevisions, err = strconv.Atoi(v)
+		if err != nil || s.MaxRevisions < 0 {
+			return v1alpha2.NewCOAError(err, "invalid maxRevisions setting of catalogs manager", v1alpha2.BadConfig)
+		}
+	}
 	return nil
 }
 
@@ -172,6 +189,10 @@ func (m *CatalogsManager) UpsertSpec(ctx context.Context, name string, spec mode
 	if err != nil {
 		return err
 	}
+	_, err = m.createRevision(ctx, name, spec)
+	if err != nil {
+		return err
+	}
 	m.Context.Publish("catalog", v1alpha2.Event{
 		Metadata: map[string]string{
 			"objectType": spec.Type,
@@ -202,9 +223,248 @@ func (m *CatalogsManager) DeleteSpec(ctx context.Context, name string) error {
 			"resource": "catalogs",
 		},
 	})
+	if err != nil {
+		return err
+	}
+	m.revisionLock.Lock()
+	defer m.revisionLock.Unlock()
+	delete(m.latestRevisions, name)
+	revisions, err := m.ListRevisions(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		err = m.deleteRevision(ctx, revision.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRevision snapshots a catalog spec as its next revision. Revisions are immutable, so they're written
+// create-only: when another writer has taken the revision number, the next number is tried.
+func (m *CatalogsManager) createRevision(ctx context.Context, name string, spec model.CatalogSpec) (int64, error) {
+	m.revisionLock.Lock()
+	defer m.revisionLock.Unlock()
+
+	latest, ok := m.latestRevisions[name]
+	if !ok {
+		revisions, err := m.ListRevisions(ctx, name)
+		if err != nil {
+			return 0, err
+		}
+		if len(revisions) > 0 {
+			latest = revisions[len(revisions)-1].Spec.Revision
+		}
+	}
+	var err error
+	revision := latest
+	for attempt := 0; attempt < maxRevisionAttempts; attempt++ {
+		revision++
+		err = m.upsertRevision(ctx, name, revision, spec)
+		if coaErr, ok := err.(v1alpha2.COAError); !ok || coaErr.State != v1alpha2.Conflict {
+			break
+		}
+		log.Debugf(" M (Catalogs): revision %d of catalog '%s' already exists, retrying", revision, name)
+		// the known latest revision is stale when other writers have created revisions since
+		revisions, lErr := m.ListRevisions(ctx, name)
+		if lErr == nil && len(revisions) > 0 && revisions[len(revisions)-1].Spec.Revision > revision {
+			revision = revisions[len(revisions)-1].Spec.Revision
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if m.latestRevisions == nil {
+		m.latestRevisions = make(map[string]int64)
+	}
+	m.latestRevisions[name] = revision
+
+	if m.MaxRevisions > 0 {
+		// revisions are numbered without gaps and pruned oldest first, so pruning stops at the first revision
+		// that's already gone
+		for r := revision - int64(m.MaxRevisions); r > 0; r-- {
+			err = m.deleteRevision(ctx, model.CatalogRevisionName(name, r))
+			if v1alpha2.IsNotFound(err) {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return revision, nil
+}
+
+func (m *CatalogsManager) upsertRevision(ctx context.Context, name string, revision int64, spec model.CatalogSpec) error {
+	id := model.CatalogRevisionName(name, revision)
+	upsertRequest := states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: id,
+			Body: map[string]interface{}{
+				"apiVersion": model.FederationGroup + "/v1",
+				"kind":       "CatalogRevision",
+				"metadata": map[string]interface{}{
+					"name": id,
+				},
+				"spec": model.CatalogRevisionSpec{
+					Catalog:   name,
+					Revision:  revision,
+					CreatedAt: time.Now().UTC().Format(time.RFC3339),
+					Snapshot:  spec,
+				},
+			},
+		},
+		Metadata: map[string]string{
+			"template": fmt.Sprintf(`{"apiVersion":"%s/v1", "kind": "CatalogRevision", "metadata": {"name": "${{$catalog()}}"}}`, model.FederationGroup),
+			"scope":    "",
+			"group":    model.FederationGroup,
+			"version":  "v1",
+			"resource": "catalogrevisions",
+		},
+		Options: states.UpsertOption{
+			Concurrency: states.FirstWrite,
+		},
+	}
+	_, err := m.StateProvider.Upsert(ctx, upsertRequest)
 	return err
 }
 
+func (m *CatalogsManager) deleteRevision(ctx context.Context, id string) error {
+	return m.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID: id,
+		Metadata: map[string]string{
+			"scope":    "",
+			"group":    model.FederationGroup,
+			"version":  "v1",
+			"resource": "catalogrevisions",
+		},
+	})
+}
+
+// ListRevisions returns all revisions of a catalog, ordered from the oldest to the latest
+func (m *CatalogsManager) ListRevisions(ctx context.Context, name string) ([]model.CatalogRevisionState, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "ListRevisions",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	listRequest := states.ListRequest{
+		Metadata: map[string]string{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "catalogrevisions",
+		},
+	}
+	entries, _, err := m.StateProvider.List(ctx, listRequest)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.CatalogRevisionState, 0)
+	for _, entry := range entries {
+		var revision model.CatalogRevisionState
+		revision, err = getCatalogRevisionState(entry.ID, entry.Body)
+		if err != nil {
+			return nil, err
+		}
+		// state stores that don't filter lists by resource return catalogs as well, those have no owning catalog
+		if revision.Spec.Catalog != name {
+			continue
+		}
+		ret = append(ret, revision)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Spec.Revision < ret[j].Spec.Revision
+	})
+	return ret, nil
+}
+
+// GetRevision returns a specific revision of a catalog. Revision 0 returns the latest revision.
+func (m *CatalogsManager) GetRevision(ctx context.Context, name string, revision int64) (model.CatalogRevisionState, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "GetRevision",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if revision == 0 {
+		var revisions []model.CatalogRevisionState
+		revisions, err = m.ListRevisions(ctx, name)
+		if err != nil {
+			return model.CatalogRevisionState{}, err
+		}
+		if len(revisions) == 0 {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("catalog '%s' has no revisions", name), v1alpha2.NotFound)
+			return model.CatalogRevisionState{}, err
+		}
+		return revisions[len(revisions)-1], nil
+	}
+	getRequest := states.GetRequest{
+		ID: model.CatalogRevisionName(name, revision),
+		Metadata: map[string]string{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "catalogrevisions",
+		},
+	}
+	entry, err := m.StateProvider.Get(ctx, getRequest)
+	if err != nil {
+		return model.CatalogRevisionState{}, err
+	}
+	ret, err := getCatalogRevisionState(entry.ID, entry.Body)
+	if err != nil {
+		return model.CatalogRevisionState{}, err
+	}
+	if ret.Spec.Catalog != name {
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("revision %d of catalog '%s' is not found", revision, name), v1alpha2.NotFound)
+		return model.CatalogRevisionState{}, err
+	}
+	return ret, nil
+}
+
+// DiffRevisions compares the properties of two revisions of a catalog. Revision 0 stands for the latest revision.
+func (m *CatalogsManager) DiffRevisions(ctx context.Context, name string, from int64, to int64) (model.CatalogDiff, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "DiffRevisions",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	fromRevision, err := m.GetRevision(ctx, name, from)
+	if err != nil {
+		return model.CatalogDiff{}, err
+	}
+	toRevision, err := m.GetRevision(ctx, name, to)
+	if err != nil {
+		return model.CatalogDiff{}, err
+	}
+	return model.CatalogDiff{
+		Catalog: name,
+		From:    fromRevision.Spec.Revision,
+		To:      toRevision.Spec.Revision,
+		Changes: model.DiffProperties(fromRevision.Spec.Snapshot.Properties, toRevision.Spec.Snapshot.Properties),
+	}, nil
+}
+
+func getCatalogRevisionState(id string, body interface{}) (model.CatalogRevisionState, error) {
+	dict, ok := body.(map[string]interface{})
+	if !ok {
+		return model.CatalogRevisionState{}, v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid catalog revision entry '%s'", id), v1alpha2.InternalError)
+	}
+	j, _ := json.Marshal(dict["spec"])
+	var rSpec model.CatalogRevisionSpec
+	err := json.Unmarshal(j, &rSpec)
+	if err != nil {
+		return model.CatalogRevisionState{}, err
+	}
+	return model.CatalogRevisionState{
+		Id:   id,
+		Spec: &rSpec,
+	}, nil
+}
+
 func (t *CatalogsManager) ListSpec(ctx context.Context) ([]model.CatalogState, error) {
 	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
 		"method": "ListSpec",
@@ -225,6 +485,9 @@ func (t *CatalogsManager) ListSpec(ctx context.Context) ([]model.CatalogState, e
 	}
 	ret := make([]model.CatalogState, 0)
 	for _, t := range catalogs {
+		if isCatalogRevisionEntry(t.Body) {
+			continue
+		}
 		var rt model.CatalogState
 		rt, err = getCatalogState(t.ID, t.Body, t.ETag)
 		if err != nil {
@@ -234,6 +497,17 @@ func (t *CatalogsManager) ListSpec(ctx context.Context) ([]model.CatalogState, e
 	}
 	return ret, nil
 }
+
+// isCatalogRevisionEntry checks if a listed entry is a catalog revision. This happens with state stores that keep
+// all resources in a single collection, such as the memory state store.
+func isCatalogRevisionEntry(body interface{}) bool {
+	if dict, ok := body.(map[string]interface{}); ok {
+		if kind, ok := dict["kind"]; ok {
+			return kind == "CatalogRevision"
+		}
+	}
+	return false
+}
 func (g *CatalogsManager) setProviderDataIfNecessary(ctx context.Context) error {
 	if !g.GraphProvider.IsPure() {
 		catalogs, err := g.ListSpec(ctx)
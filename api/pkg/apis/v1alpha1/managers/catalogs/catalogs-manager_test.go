@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCatalogsManager() *CatalogsManager {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := &CatalogsManager{
+		StateProvider: stateProvider,
+	}
+	manager.Context = &contexts.ManagerContext{}
+	return manager
+}
+
+func TestCreateGetDeleteCatalogSpec(t *testing.T) {
+	manager := createCatalogsManager()
+	err := manager.UpsertSpec(context.Background(), "test", model.CatalogSpec{Name: "test", Type: "config"})
+	assert.Nil(t, err)
+	spec, err := manager.GetSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "test", spec.Id)
+	specLists, err := manager.ListSpec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(specLists))
+	assert.Equal(t, "test", specLists[0].Id)
+	err = manager.DeleteSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	revisions, err := manager.ListRevisions(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(revisions))
+}
+
+func TestUpsertCreatesRevisions(t *testing.T) {
+	manager := createCatalogsManager()
+	for _, image := range []string{"app:1.0", "app:1.1", "app:1.2"} {
+		err := manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+			Name:       "app-config",
+			Type:       "config",
+			Properties: map[string]interface{}{"image": image},
+		})
+		assert.Nil(t, err)
+	}
+	err := manager.UpsertSpec(context.Background(), "other-config", model.CatalogSpec{Name: "other-config", Type: "config"})
+	assert.Nil(t, err)
+
+	revisions, err := manager.ListRevisions(context.Background(), "app-config")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	for i, revision := range revisions {
+		assert.Equal(t, int64(i+1), revision.Spec.Revision)
+		assert.Equal(t, "app-config", revision.Spec.Catalog)
+	}
+
+	revision, err := manager.GetRevision(context.Background(), "app-config", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "app:1.1", revision.Spec.Snapshot.Properties["image"])
+
+	latest, err := manager.GetRevision(context.Background(), "app-config", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), latest.Spec.Revision)
+
+	_, err = manager.GetRevision(context.Background(), "app-config", 4)
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	specLists, err := manager.ListSpec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(specLists))
+}
+
+func TestDiffRevisions(t *testing.T) {
+	manager := createCatalogsManager()
+	err := manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+		Name:       "app-config",
+		Type:       "config",
+		Properties: map[string]interface{}{"image": "app:1.0", "debug": "true"},
+	})
+	assert.Nil(t, err)
+	err = manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+		Name:       "app-config",
+		Type:       "config",
+		Properties: map[string]interface{}{"image": "app:1.1"},
+	})
+	assert.Nil(t, err)
+
+	diff, err := manager.DiffRevisions(context.Background(), "app-config", 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), diff.From)
+	assert.Equal(t, int64(2), diff.To)
+	assert.Equal(t, []model.PropertyChange{
+		{Path: "debug", Op: model.PropertyRemoved, OldValue: "true"},
+		{Path: "image", Op: model.PropertyReplaced, OldValue: "app:1.0", NewValue: "app:1.1"},
+	}, diff.Changes)
+}
+
+func TestMaxRevisions(t *testing.T) {
+	manager := createCatalogsManager()
+	manager.MaxRevisions = 2
+	for i := 0; i < 4; i++ {
+		err := manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{Name: "app-config", Type: "config"})
+		assert.Nil(t, err)
+	}
+	revisions, err := manager.ListRevisions(context.Background(), "app-config")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, int64(3), revisions[0].Spec.Revision)
+	assert.Equal(t, int64(4), revisions[1].Spec.Revision)
+}
+
+func TestRevisionsDontOverwriteCatalogs(t *testing.T) {
+	manager := createCatalogsManager()
+	err := manager.UpsertSpec(context.Background(), "app", model.CatalogSpec{Name: "app", Type: "config"})
+	assert.Nil(t, err)
+	err = manager.UpsertSpec(context.Background(), "app-v1", model.CatalogSpec{Name: "app-v1", Type: "config"})
+	assert.Nil(t, err)
+
+	spec, err := manager.GetSpec(context.Background(), "app-v1")
+	assert.Nil(t, err)
+	assert.Equal(t, "app-v1", spec.Spec.Name)
+	revision, err := manager.GetRevision(context.Background(), "app", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", revision.Spec.Catalog)
+	specLists, err := manager.ListSpec(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(specLists))
+}
+
+func TestRevisionsCreatedByOtherWriters(t *testing.T) {
+	manager := createCatalogsManager()
+	other := &CatalogsManager{StateProvider: manager.StateProvider}
+	other.Context = &contexts.ManagerContext{}
+	err := manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+		Name:       "app-config",
+		Type:       "config",
+		Properties: map[string]interface{}{"image": "app:1.0"},
+	})
+	assert.Nil(t, err)
+	for _, image := range []string{"app:1.1", "app:1.2"} {
+		err = other.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+			Name:       "app-config",
+			Type:       "config",
+			Properties: map[string]interface{}{"image": image},
+		})
+		assert.Nil(t, err)
+	}
+
+	// the revisions of the other writer are kept, and the next revision gets the next free number
+	err = manager.UpsertSpec(context.Background(), "app-config", model.CatalogSpec{
+		Name:       "app-config",
+		Type:       "config",
+		Properties: map[string]interface{}{"image": "app:1.3"},
+	})
+	assert.Nil(t, err)
+	revisions, err := manager.ListRevisions(context.Background(), "app-config")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(revisions))
+	for i, image := range []string{"app:1.0", "app:1.1", "app:1.2", "app:1.3"} {
+		assert.Equal(t, int64(i+1), revisions[i].Spec.Revision)
+		assert.Equal(t, image, revisions[i].Spec.Snapshot.Properties["image"])
+	}
+}
@@ -7,10 +7,12 @@
 package solution
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +25,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	config "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config"
@@ -34,9 +37,18 @@ import (
 var log = logger.NewLogger("coa.runtime")
 var lock sync.Mutex
 
+var (
+	reconcileSteps = metrics.NewCounter("reconcile_steps_total",
+		"Number of deployment steps by target provider and result", "provider", "result")
+	reconcileDuration = metrics.NewHistogram("reconcile_duration_seconds",
+		"Duration of deployment steps by target provider and result", metrics.OperationBuckets, "provider", "result")
+)
+
 const (
 	SYMPHONY_AGENT string = "/symphony-agent:"
 	ENV_NAME       string = "SYMPHONY_AGENT_ADDRESS"
+
+	summaryNotificationTimeout = 5 * time.Second
 )
 
 type SolutionManager struct {
@@ -45,11 +57,14 @@ type SolutionManager struct {
 	StateProvider   states.IStateProvider
 	ConfigProvider  config.IExtConfigProvider
 	SecretProvoider secret.ISecretProvider
+	// SummaryWebhookUrl, when set, is notified every time a deployment summary is saved
+	SummaryWebhookUrl string
 }
 
 type SolutionManagerDeploymentState struct {
-	Spec  model.DeploymentSpec  `json:"spec,omitempty"`
-	State model.DeploymentState `json:"state,omitempty"`
+	Spec         model.DeploymentSpec         `json:"spec,omitempty"`
+	State        model.DeploymentState        `json:"state,omitempty"`
+	Dependencies model.DeploymentDependencies `json:"dependencies,omitempty"`
 }
 
 func (s *SolutionManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -86,6 +101,10 @@ func (s *SolutionManager) Init(context *contexts.VendorContext, config managers.
 		return err
 	}
 
+	if v, ok := config.Properties["summaryWebhookUrl"]; ok {
+		s.SummaryWebhookUrl = v
+	}
+
 	return nil
 }
 
@@ -166,6 +185,36 @@ func (s *SolutionManager) sendHeartbeat(id string, remove bool, stopCh chan stru
 	}
 }
 
+// evaluate evaluates the expressions in a deployment, and expands the components that have a forEach
+// or a condition
+func (s *SolutionManager) evaluate(deployment model.DeploymentSpec) (model.DryRunResult, error) {
+	if s.VendorContext == nil || s.VendorContext.EvaluationContext == nil {
+		return model.DryRunResult{Deployment: deployment}, nil
+	}
+	context := s.VendorContext.EvaluationContext.Clone()
+	context.DeploymentSpec = deployment
+	context.Value = deployment
+	context.Component = ""
+	return api_utils.DryRunDeployment(*context)
+}
+
+// DryRun returns the deployment Reconcile would apply, without applying it
+func (s *SolutionManager) DryRun(ctx context.Context, deployment model.DeploymentSpec) (model.DryRunResult, error) {
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "DryRun",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.WithContext(iCtx).Info(" M (Solution): dry run")
+	var result model.DryRunResult
+	result, err = s.evaluate(deployment)
+	if err != nil {
+		log.WithContext(iCtx).Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
+	}
+	return result, err
+}
+
 func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.DeploymentSpec, remove bool, scope string) (model.SummarySpec, error) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -180,7 +229,7 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	log.Info(" M (Solution): reconciling")
+	log.WithContext(iCtx).Info(" M (Solution): reconciling")
 
 	summary := model.SummarySpec{
 		TargetResults: make(map[string]model.TargetResultSpec),
@@ -188,20 +237,16 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		SuccessCount:  0,
 	}
 
-	if s.VendorContext != nil && s.VendorContext.EvaluationContext != nil {
-		context := s.VendorContext.EvaluationContext.Clone()
-		context.DeploymentSpec = deployment
-		context.Value = deployment
-		context.Component = ""
-		deployment, err = api_utils.EvaluateDeployment(*context)
-	}
+	var result model.DryRunResult
+	result, err = s.evaluate(deployment)
+	deployment, dependencies := result.Deployment, result.Dependencies
 
 	if err != nil {
 		if remove {
-			log.Infof(" M (Solution): skipped failure to evaluate deployment spec: %+v", err)
+			log.WithContext(iCtx).Infof(" M (Solution): skipped failure to evaluate deployment spec: %+v", err)
 		} else {
 			summary.SummaryMessage = "failed to evaluate deployment spec: " + err.Error()
-			log.Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
+			log.WithContext(iCtx).Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
 			s.saveSummary(iCtx, deployment, summary, scope)
 			return summary, err
 		}
@@ -211,14 +256,14 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	currentDesiredState, err := NewDeploymentState(deployment)
 	if err != nil {
 		summary.SummaryMessage = "failed to create target manager state from deployment spec: " + err.Error()
-		log.Errorf(" M (Solution): failed to create target manager state from deployment spec: %+v", err)
+		log.WithContext(iCtx).Errorf(" M (Solution): failed to create target manager state from deployment spec: %+v", err)
 		s.saveSummary(iCtx, deployment, summary, scope)
 		return summary, err
 	}
 	currentState, _, err := s.Get(iCtx, deployment)
 	if err != nil {
 		summary.SummaryMessage = "failed to get current state: " + err.Error()
-		log.Errorf(" M (Solution): failed to get current state: %+v", err)
+		log.WithContext(iCtx).Errorf(" M (Solution): failed to get current state: %+v", err)
 		s.saveSummary(iCtx, deployment, summary, scope)
 		return summary, err
 	}
@@ -237,7 +282,7 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	plan, err := PlanForDeployment(deployment, mergedState)
 	if err != nil {
 		summary.SummaryMessage = "failed to plan for deployment: " + err.Error()
-		log.Errorf(" M (Solution): failed to plan for deployment: %+v", err)
+		log.WithContext(iCtx).Errorf(" M (Solution): failed to plan for deployment: %+v", err)
 		s.saveSummary(iCtx, deployment, summary, scope)
 		return summary, err
 	}
@@ -259,22 +304,29 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		if v, ok := s.TargetProviders[step.Target]; ok {
 			override = v
 		}
+		providerType := targetProviderType(deployment.Targets[step.Target], step.Role)
 		var provider providers.IProvider
 		provider, err = sp.CreateProviderForTargetRole(s.Context, step.Role, deployment.Targets[step.Target], override)
 		if err != nil {
 			summary.SummaryMessage = "failed to create provider:" + err.Error()
-			log.Errorf(" M (Solution): failed to create provider: %+v", err)
+			log.WithContext(iCtx).Errorf(" M (Solution): failed to create provider: %+v", err)
+			reconcileSteps.Add(1, providerType, "failed")
 			s.saveSummary(ctx, deployment, summary, scope)
 			return summary, err
 		}
+		if consumer, ok := provider.(tgt.ISecretConsumer); ok && s.SecretProvoider != nil {
+			consumer.SetSecretProvider(s.SecretProvoider)
+		}
 
 		if previousDesiredState != nil {
 			testState := MergeDeploymentStates(&previousDesiredState.State, currentState)
 			if s.canSkipStep(iCtx, step, step.Target, provider.(tgt.ITargetProvider), previousDesiredState.State.Components, testState) {
+				reconcileSteps.Add(1, providerType, "skipped")
 				continue
 			}
 		}
 		someStepsRan = true
+		stepStart := time.Now()
 		retryCount := 1
 		//TODO: set to 1 for now. Although retrying can help to handle transient errors, in more cases
 		// an error condition can't be resolved quickly.
@@ -312,8 +364,14 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 				time.Sleep(5 * time.Second)                                                                                                                      //TODO: make this configurable?
 			}
 		}
+		result := "succeeded"
+		if stepError != nil {
+			result = "failed"
+		}
+		reconcileSteps.Add(1, providerType, result)
+		reconcileDuration.Observe(time.Since(stepStart).Seconds(), providerType, result)
 		if stepError != nil {
-			log.Errorf(" M (Solution): failed to execute deployment step: %+v", stepError)
+			log.WithContext(iCtx).Errorf(" M (Solution): failed to execute deployment step: %+v", stepError)
 			s.saveSummary(iCtx, deployment, summary, scope)
 			err = stepError
 			return summary, err
@@ -322,13 +380,19 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 
 	mergedState.ClearAllRemoved()
 
+	if remove {
+		// a removed deployment no longer needs to follow catalog changes
+		dependencies = model.DeploymentDependencies{}
+	}
+
 	// TODO: delete the state if the mergedState is empty (doesn't have any ComponentTarget assignements)
 	s.StateProvider.Upsert(iCtx, states.UpsertRequest{
 		Value: states.StateEntry{
 			ID: deployment.Instance.Name,
 			Body: SolutionManagerDeploymentState{
-				Spec:  deployment,
-				State: mergedState,
+				Spec:         deployment,
+				State:        mergedState,
+				Dependencies: dependencies,
 			},
 		},
 		Metadata: map[string]string{
@@ -344,6 +408,56 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	s.saveSummary(iCtx, deployment, summary, scope)
 	return summary, nil
 }
+
+// HandleCatalogEvent queues a reconcile job for every deployed instance whose last evaluation read
+// the changed catalog, either directly or as an overlay.
+func (s *SolutionManager) HandleCatalogEvent(ctx context.Context, event v1alpha2.Event) error {
+	ctx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "HandleCatalogEvent",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var job v1alpha2.JobData
+	jData, _ := json.Marshal(event.Body)
+	err = json.Unmarshal(jData, &job)
+	if err != nil || job.Id == "" {
+		err = v1alpha2.NewCOAError(err, "event body is not a catalog job", v1alpha2.BadRequest)
+		return err
+	}
+
+	entries, _, err := s.StateProvider.List(ctx, states.ListRequest{})
+	if err != nil {
+		log.Errorf(" M (Solution): failed to list deployment states: %+v", err)
+		return err
+	}
+	for _, entry := range entries {
+		var managerState SolutionManagerDeploymentState
+		jData, _ := json.Marshal(entry.Body)
+		if json.Unmarshal(jData, &managerState) != nil || managerState.Spec.Instance.Name == "" {
+			continue // not a deployment state, for instance a summary
+		}
+		if !managerState.Dependencies.DependsOn(job.Id) {
+			continue
+		}
+		scope := managerState.Spec.Instance.Scope
+		if scope == "" {
+			scope = "default"
+		}
+		log.Infof(" M (Solution): catalog '%s' changed, queueing instance '%s' in scope '%s'", job.Id, managerState.Spec.Instance.Name, scope)
+		s.Context.Publish("job", v1alpha2.Event{
+			Metadata: map[string]string{
+				"objectType": "instance",
+				"scope":      scope,
+			},
+			Body: v1alpha2.JobData{
+				Id:     managerState.Spec.Instance.Name,
+				Action: "UPDATE",
+			},
+		})
+	}
+	return nil
+}
 func (s *SolutionManager) saveSummary(ctx context.Context, deployment model.DeploymentSpec, summary model.SummarySpec, scope string) {
 	// TODO: delete this state when time expires. This should probably be invoked by the vendor (via GetSummary method, for instance)
 	s.StateProvider.Upsert(ctx, states.UpsertRequest{
@@ -359,6 +473,38 @@ func (s *SolutionManager) saveSummary(ctx context.Context, deployment model.Depl
 			"scope": scope,
 		},
 	})
+	if s.SummaryWebhookUrl != "" {
+		go s.notifySummary(model.SummaryNotification{
+			Instance:   deployment.Instance.Name,
+			Scope:      scope,
+			Generation: deployment.Generation,
+			IsRemoval:  summary.IsRemoval,
+			Time:       time.Now().UTC(),
+		})
+	}
+}
+
+// notifySummary posts a summary notification to the configured webhook. Delivery is best-effort: watchers are
+// expected to fall back to polling the summary when a notification is lost.
+func (s *SolutionManager) notifySummary(notification model.SummaryNotification) {
+	ctx, cancel := context.WithTimeout(context.Background(), summaryNotificationTimeout)
+	defer cancel()
+	data, _ := json.Marshal(notification)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.SummaryWebhookUrl, bytes.NewBuffer(data))
+	if err != nil {
+		log.Errorf(" M (Solution): failed to create summary notification for %s: %+v", notification.Instance, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Errorf(" M (Solution): failed to send summary notification for %s: %+v", notification.Instance, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Errorf(" M (Solution): summary webhook returned %d for %s", resp.StatusCode, notification.Instance)
+	}
 }
 func (s *SolutionManager) canSkipStep(ctx context.Context, step model.DeploymentStep, target string, provider tgt.ITargetProvider, currentComponents []model.ComponentSpec, state model.DeploymentState) bool {
 
@@ -460,6 +606,23 @@ func (s *SolutionManager) Poll() []error {
 func (s *SolutionManager) Reconcil() []error {
 	return nil
 }
+
+// targetProviderType returns the type of the provider bound to a step role, as matched by
+// CreateProviderForTargetRole. It labels the reconcile metrics.
+func targetProviderType(target model.TargetSpec, role string) string {
+	if role == "" || role == "container" {
+		role = "instance"
+	}
+	for _, topology := range target.Topologies {
+		for _, binding := range topology.Bindings {
+			if binding.Role == role {
+				return binding.Provider
+			}
+		}
+	}
+	return "unknown"
+}
+
 func findAgent(target model.TargetSpec) string {
 	for _, c := range target.Components {
 		if v, ok := c.Properties[model.ContainerImage]; ok {
@@ -8,11 +8,20 @@ package solution
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	memory "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -569,3 +578,103 @@ func TestMockApplyWithError(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, summary.SuccessCount)
 }
+
+func TestHandleCatalogEvent(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	pubSubProvider := &memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	manager := SolutionManager{
+		Manager: managers.Manager{
+			Context: &contexts.ManagerContext{
+				PubsubProvider: pubSubProvider,
+			},
+		},
+		StateProvider: stateProvider,
+	}
+	for _, name := range []string{"instance-1", "instance-2"} {
+		dependencies := model.DeploymentDependencies{}
+		if name == "instance-1" {
+			dependencies.Catalogs = []string{"app-config"}
+		}
+		stateProvider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID: name,
+				Body: SolutionManagerDeploymentState{
+					Spec: model.DeploymentSpec{
+						Instance: model.InstanceSpec{
+							Name:  name,
+							Scope: "test-scope",
+						},
+					},
+					Dependencies: dependencies,
+				},
+			},
+		})
+	}
+	sig := make(chan v1alpha2.Event, 2)
+	pubSubProvider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		sig <- event
+		return nil
+	})
+	err := manager.HandleCatalogEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.JobData{
+			Id:     "app-config",
+			Action: "UPDATE",
+		},
+	})
+	assert.Nil(t, err)
+	event := <-sig
+	assert.Equal(t, "instance", event.Metadata["objectType"])
+	assert.Equal(t, "test-scope", event.Metadata["scope"])
+	assert.Equal(t, "instance-1", event.Body.(v1alpha2.JobData).Id)
+	assert.Equal(t, 0, len(sig))
+}
+func TestSummaryNotification(t *testing.T) {
+	notifications := make(chan model.SummaryNotification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification model.SummaryNotification
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&notification))
+		notifications <- notification
+	}))
+	defer server.Close()
+
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		StateProvider:     stateProvider,
+		SummaryWebhookUrl: server.URL,
+	}
+	deployment := model.DeploymentSpec{
+		Instance:   model.InstanceSpec{Name: "instance-1"},
+		Generation: "2",
+	}
+	manager.saveSummary(context.Background(), deployment, model.SummarySpec{IsRemoval: true}, "default")
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, "instance-1", notification.Instance)
+		assert.Equal(t, "default", notification.Scope)
+		assert.Equal(t, "2", notification.Generation)
+		assert.True(t, notification.IsRemoval)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "summary notification was not sent")
+	}
+}
+
+func TestTargetProviderType(t *testing.T) {
+	target := model.TargetSpec{
+		Topologies: []model.TopologySpec{
+			{
+				Bindings: []model.BindingSpec{
+					{Role: "instance", Provider: "providers.target.docker"},
+					{Role: "helm.v3", Provider: "providers.target.helm"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "providers.target.docker", targetProviderType(target, ""))
+	assert.Equal(t, "providers.target.docker", targetProviderType(target, "container"))
+	assert.Equal(t, "providers.target.helm", targetProviderType(target, "helm.v3"))
+	assert.Equal(t, "unknown", targetProviderType(target, "yaml.k8s"))
+}
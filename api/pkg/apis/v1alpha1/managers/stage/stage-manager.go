@@ -13,6 +13,7 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	symproviders "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers"
@@ -23,6 +24,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
@@ -31,6 +33,13 @@ import (
 
 var log = logger.NewLogger("coa.runtime")
 
+var (
+	stageDuration = metrics.NewHistogram("stage_duration_seconds",
+		"Duration of stage executions by stage provider", metrics.OperationBuckets, "provider")
+	stageFailures = metrics.NewCounter("stage_failures_total",
+		"Number of failed stage executions by stage provider", "provider")
+)
+
 type StageManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
@@ -274,7 +283,7 @@ func (s *StageManager) HandleDirectTriggerEvent(ctx context.Context, triggerData
 		return status
 	}
 
-	outputs, _, err := provider.(stage.IStageProvider).Process(ctx, *s.Manager.Context, triggerData.Inputs)
+	outputs, _, err := s.processStage(ctx, provider.(stage.IStageProvider), triggerData.Provider, triggerData.Inputs)
 
 	result := TaskResult{
 		Outputs: outputs,
@@ -302,6 +311,20 @@ func (s *StageManager) HandleDirectTriggerEvent(ctx context.Context, triggerData
 	status.IsActive = false
 	return status
 }
+
+// processStage runs a stage provider, and records how long it ran and whether it failed, either
+// with an error or with an unsuccessful __status output
+func (s *StageManager) processStage(ctx context.Context, provider stage.IStageProvider, providerType string, inputs map[string]interface{}) (map[string]interface{}, bool, error) {
+	start := time.Now()
+	outputs, pause, err := provider.Process(ctx, *s.Manager.Context, inputs)
+	stageDuration.Observe(time.Since(start).Seconds(), providerType)
+	result := TaskResult{Outputs: outputs, Error: err}
+	if result.GetError() != nil {
+		stageFailures.Add(1, providerType)
+	}
+	return outputs, pause, err
+}
+
 func carryOutPutsToErrorStatus(outputs map[string]interface{}, err error, site string) map[string]interface{} {
 	ret := make(map[string]interface{})
 	statusKey := "__status"
@@ -500,7 +523,7 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 				} else {
 					var outputs map[string]interface{}
 					var pause bool
-					outputs, pause, err = provider.(stage.IStageProvider).Process(ctx, *s.Manager.Context, inputCopy)
+					outputs, pause, err = s.processStage(ctx, provider.(stage.IStageProvider), triggerData.Provider, inputCopy)
 
 					if pause {
 						pauseRequested = true
@@ -16,6 +16,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
@@ -26,6 +27,8 @@ import (
 
 var log = logger.NewLogger("coa.runtime")
 
+var queueDepth = metrics.NewGauge("queue_depth", "Number of items waiting in a staging queue", "queue")
+
 type StagingManager struct {
 	managers.Manager
 	QueueProvider queue.IQueueProvider
@@ -68,11 +71,13 @@ func (s *StagingManager) Poll() []error {
 		return nil
 	}
 	site, err := s.QueueProvider.Dequeue(Site_Job_Queue)
+	s.recordQueueDepth(Site_Job_Queue)
 	if err != nil {
 		log.Errorf(" M (Staging): Failed to poll: %s", err.Error())
 		return []error{err}
 	}
 	siteId := site.(string)
+	defer s.recordQueueDepth(siteId)
 	catalogs, err := utils.GetCatalogs(
 		ctx,
 		s.VendorContext.SiteInfo.CurrentSite.BaseUrl,
@@ -142,11 +147,16 @@ func (s *StagingManager) HandleJobEvent(ctx context.Context, event v1alpha2.Even
 		return err
 	}
 	s.QueueProvider.Enqueue(Site_Job_Queue, event.Metadata["site"])
-	return s.QueueProvider.Enqueue(event.Metadata["site"], job)
+	err = s.QueueProvider.Enqueue(event.Metadata["site"], job)
+	s.recordQueueDepth(Site_Job_Queue)
+	s.recordQueueDepth(event.Metadata["site"])
+	return err
 }
 func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.JobData, error) {
 	//TODO: this should return a group of jobs as optimization
 	s.QueueProvider.Enqueue(Site_Job_Queue, site)
+	s.recordQueueDepth(Site_Job_Queue)
+	defer s.recordQueueDepth(site)
 	if s.QueueProvider.Size(site) == 0 {
 		return nil, nil
 	}
@@ -157,7 +167,7 @@ func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.Jo
 		if err != nil {
 			return nil, err
 		}
-		if job, ok := queueElement.(v1alpha2.JobData); ok {
+		if job, ok := toJobData(queueElement); ok {
 			items = append(items, job)
 			itemCount++
 		} else {
@@ -169,3 +179,27 @@ func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.Jo
 	}
 	return items, nil
 }
+
+// toJobData converts a queue element to a job. Queue providers that store elements as JSON, such as the
+// NATS provider, return jobs as maps.
+func toJobData(element interface{}) (v1alpha2.JobData, bool) {
+	switch e := element.(type) {
+	case v1alpha2.JobData:
+		return e, true
+	case map[string]interface{}:
+		var job v1alpha2.JobData
+		data, err := json.Marshal(e)
+		if err != nil {
+			return job, false
+		}
+		if err := json.Unmarshal(data, &job); err != nil || job.Id == "" {
+			return job, false
+		}
+		return job, true
+	}
+	return v1alpha2.JobData{}, false
+}
+
+func (s *StagingManager) recordQueueDepth(queue string) {
+	queueDepth.Set(float64(s.QueueProvider.Size(queue)), queue)
+}
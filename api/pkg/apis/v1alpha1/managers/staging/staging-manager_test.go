@@ -56,6 +56,9 @@ func TestPoll(t *testing.T) {
 
 	item, err := stateProvider.Get(context.Background(), states.GetRequest{
 		ID: "fake-catalog1",
+		Metadata: map[string]string{
+			"resource": "catalogs",
+		},
 	})
 	assert.Nil(t, err)
 	assert.NotNil(t, item)
@@ -137,6 +140,28 @@ func TestGetABatchForSite(t *testing.T) {
 	assert.Equal(t, "UPDATE", jobs[0].Action)
 }
 
+func TestGetABatchForSiteWithDecodedJobs(t *testing.T) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+
+	manager := StagingManager{
+		QueueProvider: queueProvider,
+	}
+
+	// queue providers that store elements as JSON return jobs as maps
+	queueProvider.Enqueue("fake", map[string]interface{}{
+		"id":     "catalog1",
+		"action": "UPDATE",
+		"body":   map[string]interface{}{"name": "catalog1"},
+	})
+	jobs, err := manager.GetABatchForSite("fake", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+	assert.Equal(t, "UPDATE", jobs[0].Action)
+	assert.Equal(t, map[string]interface{}{"name": "catalog1"}, jobs[0].Body)
+}
+
 type AuthResponse struct {
 	AccessToken string   `json:"accessToken"`
 	TokenType   string   `json:"tokenType"`
@@ -8,18 +8,24 @@ package sync
 
 import (
 	"context"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 )
 
+var syncLag = metrics.NewGauge("federation_sync_lag_seconds",
+	"Seconds since the site last synced with its parent site", "site")
+
 type SyncManager struct {
 	managers.Manager
+	lastSync time.Time
 }
 
 func (s *SyncManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -30,6 +36,7 @@ func (s *SyncManager) Init(context *contexts.VendorContext, config managers.Mana
 	if s.Context.SiteInfo.SiteId == "" {
 		return v1alpha2.NewCOAError(nil, "siteId is required", v1alpha2.BadConfig)
 	}
+	s.lastSync = time.Now()
 	return nil
 }
 func (s *SyncManager) Enabled() bool {
@@ -44,6 +51,9 @@ func (s *SyncManager) Poll() []error {
 	if s.VendorContext.SiteInfo.ParentSite.BaseUrl == "" {
 		return nil
 	}
+	defer func() {
+		syncLag.Set(time.Since(s.lastSync).Seconds(), s.VendorContext.SiteInfo.SiteId)
+	}()
 	batch, err := utils.GetABatchForSite(
 		ctx,
 		s.VendorContext.SiteInfo.ParentSite.BaseUrl,
@@ -53,6 +63,7 @@ func (s *SyncManager) Poll() []error {
 	if err != nil {
 		return []error{err}
 	}
+	s.lastSync = time.Now()
 	if batch.Catalogs != nil {
 		for _, catalog := range batch.Catalogs {
 			s.Context.Publish("catalog-sync", v1alpha2.Event{
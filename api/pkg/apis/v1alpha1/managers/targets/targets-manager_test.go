@@ -49,6 +49,12 @@ func TestUpdateTargetStatus(t *testing.T) {
 	state.Id = "test"
 	state.Scope = "default"
 	state.Status = map[string]string{"label": "test"}
+	state.Metadata = map[string]string{
+		"scope":    "default",
+		"group":    model.FabricGroup,
+		"version":  "v1",
+		"resource": "targets",
+	}
 	_, err = manager.ReportState(context.Background(), state)
 	assert.Nil(t, err)
 	spec, err := manager.GetSpec(context.Background(), "test", "default")
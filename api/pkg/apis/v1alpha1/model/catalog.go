@@ -8,7 +8,11 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // TODO: all state objects should converge to this paradigm: id, spec and status
@@ -45,6 +49,40 @@ type CatalogStatus struct {
 	Properties map[string]string `json:"properties"`
 }
 
+// CatalogRevisionState is an immutable snapshot of a catalog, taken every time the catalog is upserted
+type CatalogRevisionState struct {
+	Id   string               `json:"id"`
+	Spec *CatalogRevisionSpec `json:"spec,omitempty"`
+}
+
+type CatalogRevisionSpec struct {
+	Catalog   string      `json:"catalog"`
+	Revision  int64       `json:"revision"`
+	CreatedAt string      `json:"createdAt,omitempty"`
+	Snapshot  CatalogSpec `json:"snapshot"`
+}
+
+// CatalogDiff describes the structural changes of catalog properties between two revisions
+type CatalogDiff struct {
+	Catalog string           `json:"catalog"`
+	From    int64            `json:"from"`
+	To      int64            `json:"to"`
+	Changes []PropertyChange `json:"changes"`
+}
+
+type PropertyChange struct {
+	Path     string      `json:"path"`
+	Op       string      `json:"op"` // add, remove or replace
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+const (
+	PropertyAdded    = "add"
+	PropertyRemoved  = "remove"
+	PropertyReplaced = "replace"
+)
+
 func (c CatalogSpec) DeepEquals(other IDeepEquals) (bool, error) {
 	otherC, ok := other.(CatalogSpec)
 	if !ok {
@@ -74,6 +112,86 @@ func (c CatalogSpec) DeepEquals(other IDeepEquals) (bool, error) {
 	return true, nil
 }
 
+// CatalogRevisionName returns the state ID under which the given revision of a catalog is stored. Revisions are
+// stored as the catalogrevisions resource, which state providers keep apart from catalogs with the same ID.
+func CatalogRevisionName(catalog string, revision int64) string {
+	return fmt.Sprintf("%s-v%d", catalog, revision)
+}
+
+// ParseCatalogReference splits a catalog reference in the form of name@revision into the catalog name and
+// the revision number. A reference without a revision returns 0, which stands for the latest revision.
+func ParseCatalogReference(reference string) (string, int64, error) {
+	name := reference
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		name = name[1 : len(name)-1]
+	}
+	index := strings.LastIndex(name, "@")
+	if index < 0 {
+		return name, 0, nil
+	}
+	revision, err := strconv.ParseInt(name[index+1:], 10, 64)
+	if err != nil || revision <= 0 {
+		return "", 0, fmt.Errorf("invalid catalog revision in '%s'", reference)
+	}
+	return name[:index], revision, nil
+}
+
+// DiffProperties computes the structural changes needed to turn the from properties into the to properties.
+// Nested maps are compared key by key and arrays are compared element by element. Changes are sorted by path.
+func DiffProperties(from map[string]interface{}, to map[string]interface{}) []PropertyChange {
+	changes := make([]PropertyChange, 0)
+	diffValues("", from, to, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffValues(path string, from interface{}, to interface{}, changes *[]PropertyChange) {
+	switch fromVal := from.(type) {
+	case map[string]interface{}:
+		if toVal, ok := to.(map[string]interface{}); ok {
+			for k, v := range fromVal {
+				if tv, ok := toVal[k]; ok {
+					diffValues(joinPropertyPath(path, k), v, tv, changes)
+				} else {
+					*changes = append(*changes, PropertyChange{Path: joinPropertyPath(path, k), Op: PropertyRemoved, OldValue: v})
+				}
+			}
+			for k, v := range toVal {
+				if _, ok := fromVal[k]; !ok {
+					*changes = append(*changes, PropertyChange{Path: joinPropertyPath(path, k), Op: PropertyAdded, NewValue: v})
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if toVal, ok := to.([]interface{}); ok {
+			for i := 0; i < len(fromVal) || i < len(toVal); i++ {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				if i >= len(toVal) {
+					*changes = append(*changes, PropertyChange{Path: itemPath, Op: PropertyRemoved, OldValue: fromVal[i]})
+				} else if i >= len(fromVal) {
+					*changes = append(*changes, PropertyChange{Path: itemPath, Op: PropertyAdded, NewValue: toVal[i]})
+				} else {
+					diffValues(itemPath, fromVal[i], toVal[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, PropertyChange{Path: path, Op: PropertyReplaced, OldValue: from, NewValue: to})
+	}
+}
+
+func joinPropertyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
 // INode interface
 func (s CatalogState) GetId() string {
 	return s.Id
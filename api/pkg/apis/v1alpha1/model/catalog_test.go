@@ -28,3 +28,69 @@ func TestIntefaceConvertion(t *testing.T) {
 	var iNode v1alpha2.INode = val.(v1alpha2.INode)
 	assert.NotNil(t, iNode)
 }
+func TestParseCatalogReference(t *testing.T) {
+	name, revision, err := ParseCatalogReference("app-config")
+	assert.Nil(t, err)
+	assert.Equal(t, "app-config", name)
+	assert.Equal(t, int64(0), revision)
+
+	name, revision, err = ParseCatalogReference("app-config@3")
+	assert.Nil(t, err)
+	assert.Equal(t, "app-config", name)
+	assert.Equal(t, int64(3), revision)
+
+	name, revision, err = ParseCatalogReference("<app-config@12>")
+	assert.Nil(t, err)
+	assert.Equal(t, "app-config", name)
+	assert.Equal(t, int64(12), revision)
+}
+func TestParseCatalogReferenceInvalidRevision(t *testing.T) {
+	_, _, err := ParseCatalogReference("app-config@latest")
+	assert.NotNil(t, err)
+	_, _, err = ParseCatalogReference("app-config@0")
+	assert.NotNil(t, err)
+}
+func TestDiffPropertiesNoChange(t *testing.T) {
+	changes := DiffProperties(map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": []interface{}{"e"}},
+	}, map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": []interface{}{"e"}},
+	})
+	assert.Equal(t, 0, len(changes))
+}
+func TestDiffProperties(t *testing.T) {
+	changes := DiffProperties(map[string]interface{}{
+		"image":   "app:1.0",
+		"removed": "x",
+		"nested": map[string]interface{}{
+			"replicas": float64(1),
+			"ports":    []interface{}{"80", "443"},
+		},
+	}, map[string]interface{}{
+		"image": "app:1.1",
+		"added": true,
+		"nested": map[string]interface{}{
+			"replicas": float64(3),
+			"ports":    []interface{}{"80"},
+		},
+	})
+	assert.Equal(t, []PropertyChange{
+		{Path: "added", Op: PropertyAdded, NewValue: true},
+		{Path: "image", Op: PropertyReplaced, OldValue: "app:1.0", NewValue: "app:1.1"},
+		{Path: "nested.ports[1]", Op: PropertyRemoved, OldValue: "443"},
+		{Path: "nested.replicas", Op: PropertyReplaced, OldValue: float64(1), NewValue: float64(3)},
+		{Path: "removed", Op: PropertyRemoved, OldValue: "x"},
+	}, changes)
+}
+func TestDiffPropertiesTypeChange(t *testing.T) {
+	changes := DiffProperties(map[string]interface{}{
+		"a": map[string]interface{}{"b": "c"},
+	}, map[string]interface{}{
+		"a": "c",
+	})
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "a", changes[0].Path)
+	assert.Equal(t, PropertyReplaced, changes[0].Op)
+}
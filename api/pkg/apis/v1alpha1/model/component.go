@@ -21,6 +21,11 @@ type ComponentSpec struct {
 	Constraints  string                 `json:"constraints,omitempty"`
 	Dependencies []string               `json:"dependencies,omitempty"`
 	Skills       []string               `json:"skills,omitempty"`
+	// ForEach is an expression returning a list. The component is repeated for each item, which nested
+	// expressions read with $item() and $index().
+	ForEach string `json:"forEach,omitempty"`
+	// Condition is an expression. The component is dropped when it evaluates to false.
+	Condition string `json:"condition,omitempty"`
 }
 
 func (c ComponentSpec) DeepEquals(other IDeepEquals) (bool, error) { // avoid using reflect, which has performance problems
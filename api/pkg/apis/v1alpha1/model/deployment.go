@@ -25,6 +25,33 @@ type DeploymentSpec struct {
 	Generation          string                `json:"generation,omitempty"`
 }
 
+// DeploymentDependencies lists the configuration catalogs and overlays read while evaluating a deployment
+type DeploymentDependencies struct {
+	Catalogs []string `json:"catalogs,omitempty"`
+	Overlays []string `json:"overlays,omitempty"`
+}
+
+// ComponentExpansionSpec reports the components generated from a component with a forEach or a condition.
+// Components is empty when the condition dropped the component, or when forEach returned an empty list.
+type ComponentExpansionSpec struct {
+	Component  string   `json:"component"`
+	ForEach    string   `json:"forEach,omitempty"`
+	Condition  string   `json:"condition,omitempty"`
+	Components []string `json:"components"`
+}
+
+// DryRunResult is the deployment a reconcile would apply, after expressions are evaluated
+type DryRunResult struct {
+	Deployment   DeploymentSpec           `json:"deployment"`
+	Dependencies DeploymentDependencies   `json:"dependencies"`
+	Expansions   []ComponentExpansionSpec `json:"expansions,omitempty"`
+}
+
+// DependsOn checks if a catalog was read, either directly or as an overlay, while evaluating a deployment
+func (d DeploymentDependencies) DependsOn(catalog string) bool {
+	return go_slices.Contains(d.Catalogs, catalog) || go_slices.Contains(d.Overlays, catalog)
+}
+
 func (d DeploymentSpec) GetComponentSlice() []ComponentSpec {
 	components := d.Solution.Components
 	if d.ComponentStartIndex >= 0 && d.ComponentEndIndex >= 0 && d.ComponentEndIndex > d.ComponentStartIndex {
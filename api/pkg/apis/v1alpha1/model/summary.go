@@ -35,6 +35,15 @@ type SummaryResult struct {
 	Time       time.Time   `json:"time"`
 }
 
+// SummaryNotification is sent to the summary webhook whenever a deployment summary is saved
+type SummaryNotification struct {
+	Instance   string    `json:"instance"`
+	Scope      string    `json:"scope"`
+	Generation string    `json:"generation"`
+	IsRemoval  bool      `json:"isRemoval"`
+	Time       time.Time `json:"time"`
+}
+
 func (s *SummarySpec) UpdateTargetResult(target string, spec TargetResultSpec) {
 	s.TargetResults[target] = spec
 	count := 0
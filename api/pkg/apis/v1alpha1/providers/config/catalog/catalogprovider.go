@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -87,8 +88,34 @@ func CatalogConfigProviderConfigFromMap(properties map[string]string) (CatalogCo
 	ret.Password = password
 	return ret, nil
 }
+
+// getCatalog reads a catalog by reference. A reference in the form of name@revision pins the
+// catalog to the given immutable revision, otherwise the current catalog is returned.
+func (m *CatalogConfigProvider) getCatalog(object string) (model.CatalogState, error) {
+	name, revision, err := model.ParseCatalogReference(object)
+	if err != nil {
+		return model.CatalogState{}, v1alpha2.NewCOAError(err, "invalid catalog reference", v1alpha2.BadRequest)
+	}
+	if revision == 0 {
+		return utils.GetCatalog(context.TODO(), m.Config.BaseUrl, name, m.Config.User, m.Config.Password)
+	}
+	catalogRevision, err := utils.GetCatalogRevision(context.TODO(), m.Config.BaseUrl, name, revision, m.Config.User, m.Config.Password)
+	if err != nil {
+		return model.CatalogState{}, err
+	}
+	return model.CatalogState{
+		Id:   name,
+		Spec: &catalogRevision.Spec.Snapshot,
+	}, nil
+}
+func checkMutable(object string) error {
+	if _, revision, err := model.ParseCatalogReference(object); err == nil && revision != 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("catalog revision '%s' is immutable", object), v1alpha2.BadRequest)
+	}
+	return nil
+}
 func (m *CatalogConfigProvider) unwindOverrides(override string, field string) (string, error) {
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, override, m.Config.User, m.Config.Password)
+	catalog, err := m.getCatalog(override)
 	if err != nil {
 		return "", err
 	}
@@ -101,7 +128,7 @@ func (m *CatalogConfigProvider) unwindOverrides(override string, field string) (
 	return "", v1alpha2.NewCOAError(nil, fmt.Sprintf("field '%s' is not found in configuration '%s'", field, override), v1alpha2.NotFound)
 }
 func (m *CatalogConfigProvider) Read(object string, field string, localcontext interface{}) (interface{}, error) {
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
+	catalog, err := m.getCatalog(object)
 	if err != nil {
 		return "", err
 	}
@@ -122,7 +149,7 @@ func (m *CatalogConfigProvider) Read(object string, field string, localcontext i
 	return "", v1alpha2.NewCOAError(nil, fmt.Sprintf("field '%s' is not found in configuration '%s'", field, object), v1alpha2.NotFound)
 }
 func (m *CatalogConfigProvider) ReadObject(object string, localcontext interface{}) (map[string]interface{}, error) {
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
+	catalog, err := m.getCatalog(object)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +226,9 @@ func (m *CatalogConfigProvider) traceValue(v interface{}, localcontext interface
 	}
 }
 func (m *CatalogConfigProvider) Set(object string, field string, value interface{}) error {
+	if err := checkMutable(object); err != nil {
+		return err
+	}
 	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
 	if err != nil {
 		return err
@@ -208,6 +238,9 @@ func (m *CatalogConfigProvider) Set(object string, field string, value interface
 	return utils.UpsertCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, data)
 }
 func (m *CatalogConfigProvider) SetObject(object string, value map[string]interface{}) error {
+	if err := checkMutable(object); err != nil {
+		return err
+	}
 	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
 	if err != nil {
 		return err
@@ -220,6 +253,9 @@ func (m *CatalogConfigProvider) SetObject(object string, value map[string]interf
 	return utils.UpsertCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, data)
 }
 func (m *CatalogConfigProvider) Remove(object string, field string) error {
+	if err := checkMutable(object); err != nil {
+		return err
+	}
 	catlog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
 	if err != nil {
 		return err
@@ -232,5 +268,8 @@ func (m *CatalogConfigProvider) Remove(object string, field string) error {
 	return utils.UpsertCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, data)
 }
 func (m *CatalogConfigProvider) RemoveObject(object string) error {
+	if err := checkMutable(object); err != nil {
+		return err
+	}
 	return utils.DeleteCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
 }
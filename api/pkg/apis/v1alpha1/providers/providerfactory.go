@@ -27,6 +27,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/adb"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/azure/adu"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/azure/iotedge"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/compose"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/configmap"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/docker"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/helm"
@@ -39,6 +40,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/proxy"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/script"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/staging"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/systemd"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/win10/sideload"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -47,8 +49,10 @@ import (
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/probe/rtsp"
 	mempubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	natspubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/nats"
 	reidspubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/redis"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
+	natsqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/nats"
 	cvref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/customvision"
 	httpref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/http"
 	k8sref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/k8s"
@@ -187,6 +191,18 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.target.compose":
+		mProvider := &compose.ComposeTargetProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
+	case "providers.target.systemd":
+		mProvider := &systemd.SystemdTargetProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.target.ingress":
 		mProvider := &ingress.IngressTargetProvider{}
 		err = mProvider.Init(config)
@@ -283,6 +299,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.pubsub.nats":
+		mProvider := &natspubsub.NatsPubSubProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.stage.mock":
 		mProvider := &mockstage.MockStageProvider{}
 		err = mProvider.Init(config)
@@ -349,6 +371,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.queue.nats":
+		mProvider := &natsqueue.NatsQueueProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.graph.memory":
 		mProvider := &memorygraph.MemoryGraphProvider{}
 		err = mProvider.Init(config)
@@ -400,6 +428,22 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.target.compose":
+					provider := &compose.ComposeTargetProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
+				case "providers.target.systemd":
+					provider := &systemd.SystemdTargetProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				case "providers.target.ingress":
 					provider := &ingress.IngressTargetProvider{}
 					err := provider.InitWithMap(binding.Config)
@@ -701,6 +745,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.queue.nats":
+					provider := &natsqueue.NatsQueueProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				case "providers.graph.memory":
 					provider := &memorygraph.MemoryGraphProvider{}
 					err := provider.InitWithMap(binding.Config)
@@ -725,6 +777,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.pubsub.nats":
+					provider := &natspubsub.NatsPubSubProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				}
 
 			}
@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -43,9 +44,10 @@ type K8sStateProviderConfig struct {
 }
 
 type K8sStateProvider struct {
-	Config        K8sStateProviderConfig
-	Context       *contexts.ManagerContext
-	DynamicClient dynamic.Interface
+	Config          K8sStateProviderConfig
+	Context         *contexts.ManagerContext
+	DynamicClient   dynamic.Interface
+	DiscoveryClient discovery.DiscoveryInterface
 }
 
 func K8sStateProviderConfigFromMap(properties map[string]string) (K8sStateProviderConfig, error) {
@@ -148,10 +150,23 @@ func (i *K8sStateProvider) Init(config providers.IProviderConfig) error {
 		sLog.Errorf("  P (K8s State): %+v", err)
 		return err
 	}
+	i.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(kConfig)
+	if err != nil {
+		sLog.Errorf("  P (K8s State): %+v", err)
+		return err
+	}
 
 	return nil
 }
 
+// CheckHealth tells if the Kubernetes API server can be reached
+func (s *K8sStateProvider) CheckHealth(ctx context.Context) error {
+	if err := s.DiscoveryClient.RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return v1alpha2.NewCOAError(err, "failed to reach Kubernetes API server", v1alpha2.InternalError)
+	}
+	return nil
+}
+
 func toK8sStateProviderConfig(config providers.IProviderConfig) (K8sStateProviderConfig, error) {
 	ret := K8sStateProviderConfig{}
 	data, err := json.Marshal(config)
@@ -222,10 +237,17 @@ func (s *K8sStateProvider) Upsert(ctx context.Context, entry states.UpsertReques
 		_, err = s.DynamicClient.Resource(resourceId).Namespace(scope).Create(ctx, unc, metav1.CreateOptions{})
 		if err != nil {
 			sLog.Errorf("  P (K8s State): failed to create object: %v", err)
+			if k8s_errors.IsAlreadyExists(err) {
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("object '%s' already exists", entry.Value.ID), v1alpha2.Conflict)
+			}
 			return "", err
 		}
 		//Note: state is ignored for new object
 	} else {
+		if entry.Options.Concurrency == states.FirstWrite && (entry.ETag == nil || *entry.ETag != strconv.FormatInt(item.GetGeneration(), 10)) {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("object '%s' has been modified", entry.Value.ID), v1alpha2.Conflict)
+			return "", err
+		}
 		j, _ := json.Marshal(entry.Value.Body)
 		var dict map[string]interface{}
 		err = json.Unmarshal(j, &dict)
@@ -358,6 +380,9 @@ func (s *K8sStateProvider) Delete(ctx context.Context, request states.DeleteRequ
 	err = s.DynamicClient.Resource(resourceId).Namespace(scope).Delete(ctx, request.ID, metav1.DeleteOptions{})
 	if err != nil {
 		sLog.Errorf("  P (K8s State): failed to delete objects: %v", err)
+		if k8s_errors.IsNotFound(err) {
+			err = v1alpha2.NewCOAError(err, fmt.Sprintf("object '%s' is not found", request.ID), v1alpha2.NotFound)
+		}
 		return err
 	}
 	return nil
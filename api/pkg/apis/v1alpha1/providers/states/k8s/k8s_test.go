@@ -9,6 +9,8 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -72,6 +74,38 @@ func TestInitWithBadData(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestCheckHealth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"major":"1","minor":"25"}`))
+	}))
+	config := K8sStateProviderConfig{
+		ConfigType: "bytes",
+		ConfigData: fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, ts.URL),
+	}
+	provider := K8sStateProvider{}
+	err := provider.Init(config)
+	assert.Nil(t, err)
+	assert.Nil(t, provider.CheckHealth(context.Background()))
+	ts.Close()
+	assert.NotNil(t, provider.CheckHealth(context.Background()))
+}
+
 func TestUpSert(t *testing.T) {
 	testK8s := os.Getenv("TEST_K8S_STATE")
 	if testK8s == "" {
@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -193,11 +194,5 @@ func (i *AdbProvider) Apply(ctx context.Context, deployment model.DeploymentSpec
 }
 
 func (*AdbProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{model.AppPackage, model.AppImage},
-		OptionalProperties:    []string{},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-	}
+	return validation.AdbRule()
 }
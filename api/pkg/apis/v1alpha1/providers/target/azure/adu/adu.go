@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	azureutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/cloudutils/azure"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -307,11 +308,5 @@ func (i *ADUTargetProvider) applyDeployment(deployment azureutils.ADUDeployment)
 	return nil
 }
 func (*ADUTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{"update.provider", "update.name", "update.version"},
-		OptionalProperties:    []string{},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-	}
+	return validation.ADURule()
 }
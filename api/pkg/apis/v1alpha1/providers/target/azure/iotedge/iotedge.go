@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	azureutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/cloudutils/azure"
@@ -299,22 +300,7 @@ func (i *IoTEdgeTargetProvider) Apply(ctx context.Context, deployment model.Depl
 	return ret, nil
 }
 func (*IoTEdgeTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{model.ContainerImage, "container.version", "container.type"},
-		OptionalProperties:    []string{"container.restartPolicy", "container.createOptions", "env.*"},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-		ChangeDetectionProperties: []model.PropertyDesc{
-			{Name: "container.restartPolicy", IgnoreCase: false, SkipIfMissing: true},
-			{Name: "container.createOptions", IgnoreCase: false, SkipIfMissing: true},
-			{Name: "container.version", IgnoreCase: false, SkipIfMissing: true},
-			{Name: "container.type", IgnoreCase: false, SkipIfMissing: true},
-			{Name: model.ContainerImage, IgnoreCase: false, SkipIfMissing: true},
-			{Name: "desired.*", IgnoreCase: false, SkipIfMissing: true},
-			{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
-		},
-	}
+	return validation.IoTEdgeRule()
 }
 
 func toIoTEdgeTargetProviderConfig(config providers.IProviderConfig) (IoTEdgeTargetProviderConfig, error) {
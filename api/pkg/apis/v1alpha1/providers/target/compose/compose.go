@@ -0,0 +1,482 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/docker"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var sLog = logger.NewLogger("coa.runtime")
+
+// The labels docker compose puts on the objects of a project, so that "docker compose ls" and "docker compose ps"
+// show the projects deployed by Symphony
+const (
+	projectLabel         = "com.docker.compose.project"
+	serviceLabel         = "com.docker.compose.service"
+	containerNumberLabel = "com.docker.compose.container-number"
+	oneoffLabel          = "com.docker.compose.oneoff"
+	configHashLabel      = "com.docker.compose.config-hash"
+	networkLabel         = "com.docker.compose.network"
+	// componentLabel is the component a container was rendered from
+	componentLabel = "symphony.component"
+	// propertiesLabel keeps the properties of the component a container was rendered from, for Get to report
+	propertiesLabel = "symphony.properties"
+)
+
+// stopTimeout is how long containers get to stop before they're killed
+var stopTimeout = 10 * time.Second
+
+// DockerClient is the part of the Docker API the compose target uses
+type DockerClient interface {
+	docker.ImageClient
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error)
+	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
+	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error
+	NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
+	NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error)
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	NetworkRemove(ctx context.Context, networkID string) error
+}
+
+type ComposeTargetProviderConfig struct {
+	Name string `json:"name"`
+	// ImagePullSecret is the secret object with the "username" and "password" (and optionally "server") fields used
+	// to pull images
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
+}
+
+type ComposeTargetProvider struct {
+	Config         ComposeTargetProviderConfig
+	Context        *contexts.ManagerContext
+	SecretProvider secret.ISecretProvider
+	// Client is the Docker API client. A client configured from the environment is created when it's not set.
+	Client DockerClient
+}
+
+func ComposeTargetProviderConfigFromMap(properties map[string]string) (ComposeTargetProviderConfig, error) {
+	ret := ComposeTargetProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["imagePullSecret"]; ok {
+		ret.ImagePullSecret = v
+	}
+	return ret, nil
+}
+
+func (i *ComposeTargetProvider) InitWithMap(properties map[string]string) error {
+	config, err := ComposeTargetProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func (i *ComposeTargetProvider) SetContext(ctx *contexts.ManagerContext) {
+	i.Context = ctx
+}
+
+func (i *ComposeTargetProvider) SetSecretProvider(provider secret.ISecretProvider) {
+	i.SecretProvider = provider
+}
+
+func (i *ComposeTargetProvider) Init(config providers.IProviderConfig) error {
+	_, span := observability.StartSpan("Compose Target Provider", context.TODO(), &map[string]string{
+		"method": "Init",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Info("  P (Compose Target): Init()")
+
+	composeConfig, err := toComposeTargetProviderConfig(config)
+	if err != nil {
+		sLog.Errorf("  P (Compose Target): expected ComposeTargetProviderConfig: %+v", err)
+		return err
+	}
+	i.Config = composeConfig
+	return nil
+}
+
+func toComposeTargetProviderConfig(config providers.IProviderConfig) (ComposeTargetProviderConfig, error) {
+	ret := ComposeTargetProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (i *ComposeTargetProvider) getClient() (DockerClient, error) {
+	if i.Client == nil {
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, err
+		}
+		i.Client = cli
+	}
+	return i.Client, nil
+}
+
+func (i *ComposeTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	ctx, span := observability.StartSpan("Compose Target Provider", ctx, &map[string]string{
+		"method": "Get",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Compose Target): getting artifacts: %s - %s", deployment.Instance.Scope, deployment.Instance.Name)
+
+	cli, err := i.getClient()
+	if err != nil {
+		sLog.Errorf("  P (Compose Target): failed to create docker client: %+v", err)
+		return nil, err
+	}
+	var containers []types.Container
+	containers, err = listProjectContainers(ctx, cli, projectName(deployment.Instance.Name))
+	if err != nil {
+		sLog.Errorf("  P (Compose Target): failed to list project containers: %+v", err)
+		return nil, err
+	}
+
+	ret := make([]model.ComponentSpec, 0)
+	for _, ref := range references {
+		var found *types.Container
+		for idx, c := range containers {
+			if c.Labels[componentLabel] == ref.Component.Name {
+				found = &containers[idx]
+				break
+			}
+		}
+		if found == nil {
+			continue
+		}
+		component := model.ComponentSpec{
+			Name:       ref.Component.Name,
+			Type:       ref.Component.Type,
+			Properties: make(map[string]interface{}),
+		}
+		properties := make(map[string]string)
+		if json.Unmarshal([]byte(found.Labels[propertiesLabel]), &properties) == nil {
+			for k, v := range properties {
+				component.Properties[k] = v
+			}
+		}
+		ret = append(ret, component)
+	}
+	return ret, nil
+}
+
+func (i *ComposeTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan("Compose Target Provider", ctx, &map[string]string{
+		"method": "Apply",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Compose Target): applying artifacts: %s - %s", deployment.Instance.Scope, deployment.Instance.Name)
+
+	injections := &model.ValueInjections{
+		InstanceId: deployment.Instance.Name,
+		SolutionId: deployment.Instance.Solution,
+		TargetId:   deployment.ActiveTarget,
+	}
+
+	components := step.GetUpdatedComponents()
+	err = i.GetValidationRule(ctx).Validate(components)
+	if err != nil {
+		return nil, err
+	}
+	if isDryRun {
+		err = nil
+		return nil, nil
+	}
+
+	name := projectName(deployment.Instance.Name)
+	var desired project
+	desired, err = renderProject(name, components, injections)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "invalid compose project", v1alpha2.BadConfig)
+	}
+	var order []string
+	order, err = desired.startOrder()
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "invalid compose project", v1alpha2.BadConfig)
+	}
+
+	ret := step.PrepareResultMap()
+	cli, err := i.getClient()
+	if err != nil {
+		sLog.Errorf("  P (Compose Target): failed to create docker client: %+v", err)
+		return ret, err
+	}
+
+	properties := make(map[string]string)
+	for _, c := range components {
+		data, _ := json.Marshal(reportedProperties(c))
+		properties[c.Name] = string(data)
+	}
+
+	// up: create the services of the updated components in dependency order
+	for _, serviceName := range order {
+		componentName := desired.component[serviceName]
+		err = i.upService(ctx, cli, desired, serviceName, properties[componentName])
+		if err != nil {
+			ret[componentName] = model.ComponentResultSpec{
+				Status:  v1alpha2.UpdateFailed,
+				Message: err.Error(),
+			}
+			sLog.Errorf("  P (Compose Target): failed to start service %s: %+v", serviceName, err)
+			return ret, err
+		}
+	}
+	for _, c := range components {
+		ret[c.Name] = model.ComponentResultSpec{
+			Status:  v1alpha2.Updated,
+			Message: "",
+		}
+	}
+
+	// remove the containers of deleted components, and of services that are no longer in a component's compose file
+	var containers []types.Container
+	containers, err = listProjectContainers(ctx, cli, name)
+	if err != nil {
+		sLog.Errorf("  P (Compose Target): failed to list project containers: %+v", err)
+		return ret, err
+	}
+	deleted := make(map[string]bool)
+	for _, c := range step.Components {
+		if c.Action == "delete" {
+			deleted[c.Component.Name] = true
+		}
+	}
+	remaining := 0
+	for _, c := range containers {
+		component := c.Labels[componentLabel]
+		_, isDesired := desired.Services[c.Labels[serviceLabel]]
+		if deleted[component] || (properties[component] != "" && !isDesired) {
+			err = removeContainer(ctx, cli, c.ID)
+			if err != nil {
+				sLog.Errorf("  P (Compose Target): failed to remove container %s: %+v", c.ID, err)
+				return ret, err
+			}
+			continue
+		}
+		remaining++
+	}
+	for component := range deleted {
+		ret[component] = model.ComponentResultSpec{
+			Status:  v1alpha2.Deleted,
+			Message: "",
+		}
+	}
+
+	// down: remove the project networks once the project has no containers left
+	if remaining == 0 {
+		err = removeProjectNetworks(ctx, cli, name)
+		if err != nil {
+			sLog.Errorf("  P (Compose Target): failed to remove project networks: %+v", err)
+			return ret, err
+		}
+	}
+	err = nil
+	return ret, nil
+}
+
+// upService makes sure a service's container runs with the service's current settings. A container whose settings
+// haven't changed is left alone.
+func (i *ComposeTargetProvider) upService(ctx context.Context, cli DockerClient, p project, serviceName string, properties string) error {
+	service := p.Services[serviceName]
+	hash := service.configHash()
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", projectLabel+"="+p.Name),
+			filters.Arg("label", serviceLabel+"="+serviceName),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if c.Labels[configHashLabel] == hash && c.Labels[propertiesLabel] == properties {
+			if c.State != "running" {
+				return cli.ContainerStart(ctx, c.ID, types.ContainerStartOptions{})
+			}
+			return nil
+		}
+	}
+
+	config, hostConfig, err := service.containerConfig(p.Name, serviceName)
+	if err != nil {
+		return err
+	}
+	// pull the image before the running container is removed, so that a failed pull doesn't take the service down
+	if err := i.pullImage(ctx, cli, service); err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if err := removeContainer(ctx, cli, c.ID); err != nil {
+			return err
+		}
+	}
+
+	networks := service.networks()
+	for _, n := range networks {
+		if err := ensureProjectNetwork(ctx, cli, p.Name, n); err != nil {
+			return err
+		}
+	}
+	config.Labels[projectLabel] = p.Name
+	config.Labels[serviceLabel] = serviceName
+	config.Labels[containerNumberLabel] = "1"
+	config.Labels[oneoffLabel] = "False"
+	config.Labels[configHashLabel] = hash
+	config.Labels[componentLabel] = p.component[serviceName]
+	config.Labels[propertiesLabel] = properties
+	hostConfig.NetworkMode = container.NetworkMode(networkName(p.Name, networks[0]))
+	// services reach each other by service name on the project networks
+	networkingConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkName(p.Name, networks[0]): {Aliases: []string{serviceName}},
+		},
+	}
+	created, err := cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, fmt.Sprintf("%s-%s-1", p.Name, serviceName))
+	if err != nil {
+		return err
+	}
+	for _, n := range networks[1:] {
+		if err := cli.NetworkConnect(ctx, networkName(p.Name, n), created.ID, &network.EndpointSettings{Aliases: []string{serviceName}}); err != nil {
+			return err
+		}
+	}
+	return cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{})
+}
+
+// pullImage pulls the image of a service according to its pull policy, with the provider's image pull secret
+func (i *ComposeTargetProvider) pullImage(ctx context.Context, cli DockerClient, service composeService) error {
+	auth := ""
+	if i.Config.ImagePullSecret != "" {
+		var err error
+		auth, err = docker.RegistryAuth(i.SecretProvider, i.Config.ImagePullSecret)
+		if err != nil {
+			return err
+		}
+	}
+	return docker.PullImage(ctx, cli, service.Image, service.PullPolicy, auth)
+}
+
+func (*ComposeTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	return validation.ComposeRule()
+}
+
+// reportedProperties are the properties of a component that Get reports back
+func reportedProperties(component model.ComponentSpec) map[string]string {
+	ret := make(map[string]string)
+	for k, v := range component.Properties {
+		if strings.HasPrefix(k, "env.") {
+			ret[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	for _, p := range validation.ComposeProperties {
+		if v, ok := component.Properties[p]; ok && v != "" {
+			ret[p] = fmt.Sprintf("%v", v)
+		}
+	}
+	return ret
+}
+
+func networkName(projectName string, network string) string {
+	return projectName + "_" + network
+}
+
+func listProjectContainers(ctx context.Context, cli DockerClient, projectName string) ([]types.Container, error) {
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", projectLabel+"="+projectName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(containers, func(a, b int) bool { return containers[a].ID < containers[b].ID })
+	return containers, nil
+}
+
+func removeContainer(ctx context.Context, cli DockerClient, id string) error {
+	if err := cli.ContainerStop(ctx, id, &stopTimeout); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	if err := cli.ContainerRemove(ctx, id, types.ContainerRemoveOptions{}); err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func ensureProjectNetwork(ctx context.Context, cli DockerClient, projectName string, network string) error {
+	name := networkName(projectName, network)
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", name)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		// the name filter matches substrings
+		if n.Name == name {
+			return nil
+		}
+	}
+	_, err = cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Labels: map[string]string{
+			projectLabel: projectName,
+			networkLabel: network,
+		},
+	})
+	return err
+}
+
+func removeProjectNetworks(ctx context.Context, cli DockerClient, projectName string) error {
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", projectLabel+"="+projectName)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		if err := cli.NetworkRemove(ctx, n.ID); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
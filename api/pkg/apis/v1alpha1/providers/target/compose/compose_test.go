@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient is an in-memory Docker API that keeps track of containers and networks
+type fakeClient struct {
+	containers map[string]*types.Container
+	hostConfig map[string]*container.HostConfig
+	networks   map[string]types.NetworkResource
+	images     map[string]bool
+	pulls      []string
+	created    []string
+	nextId     int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		containers: make(map[string]*types.Container),
+		hostConfig: make(map[string]*container.HostConfig),
+		networks:   make(map[string]types.NetworkResource),
+		images:     make(map[string]bool),
+	}
+}
+
+func matchesLabels(labels map[string]string, options types.ContainerListOptions) bool {
+	for _, l := range options.Filters.Get("label") {
+		k, v, _ := strings.Cut(l, "=")
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *fakeClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	ret := make([]types.Container, 0)
+	for _, container := range c.containers {
+		if matchesLabels(container.Labels, options) {
+			ret = append(ret, *container)
+		}
+	}
+	return ret, nil
+}
+func (c *fakeClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	for _, existing := range c.containers {
+		if existing.Names[0] == "/"+containerName {
+			return container.ContainerCreateCreatedBody{}, fmt.Errorf("container name %s is already in use", containerName)
+		}
+	}
+	if _, ok := c.networks[string(hostConfig.NetworkMode)]; !ok {
+		return container.ContainerCreateCreatedBody{}, errdefs.NotFound(fmt.Errorf("network %s not found", hostConfig.NetworkMode))
+	}
+	c.nextId++
+	id := fmt.Sprintf("c%d", c.nextId)
+	c.containers[id] = &types.Container{ID: id, Names: []string{"/" + containerName}, Image: config.Image, Labels: config.Labels, State: "created"}
+	c.hostConfig[id] = hostConfig
+	c.created = append(c.created, containerName)
+	return container.ContainerCreateCreatedBody{ID: id}, nil
+}
+func (c *fakeClient) ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error {
+	c.containers[containerID].State = "running"
+	return nil
+}
+func (c *fakeClient) ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error {
+	if _, ok := c.containers[containerID]; !ok {
+		return errdefs.NotFound(fmt.Errorf("container %s not found", containerID))
+	}
+	c.containers[containerID].State = "exited"
+	return nil
+}
+func (c *fakeClient) ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error {
+	delete(c.containers, containerID)
+	return nil
+}
+func (c *fakeClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	ret := make([]types.NetworkResource, 0)
+	for _, n := range c.networks {
+		if names := options.Filters.Get("name"); len(names) > 0 && !strings.Contains(n.Name, names[0]) {
+			continue
+		}
+		if matchesLabels(n.Labels, types.ContainerListOptions{Filters: options.Filters}) {
+			ret = append(ret, n)
+		}
+	}
+	return ret, nil
+}
+func (c *fakeClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	c.networks[name] = types.NetworkResource{ID: name, Name: name, Labels: options.Labels}
+	return types.NetworkCreateResponse{ID: name}, nil
+}
+func (c *fakeClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
+	if _, ok := c.networks[networkID]; !ok {
+		return errdefs.NotFound(fmt.Errorf("network %s not found", networkID))
+	}
+	return nil
+}
+func (c *fakeClient) NetworkRemove(ctx context.Context, networkID string) error {
+	delete(c.networks, networkID)
+	return nil
+}
+func (c *fakeClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	if !c.images[imageID] {
+		return types.ImageInspect{}, nil, errdefs.NotFound(fmt.Errorf("no such image: %s", imageID))
+	}
+	return types.ImageInspect{ID: imageID}, nil, nil
+}
+func (c *fakeClient) ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	c.pulls = append(c.pulls, refStr)
+	c.images[refStr] = true
+	return io.NopCloser(strings.NewReader(`{"status":"Pulling from library"}`)), nil
+}
+
+const webCompose = `services:
+  web:
+    image: nginx:1.25
+    ports:
+      - "8080:80"
+    depends_on:
+      - cache
+    environment:
+      CACHE_HOST: cache
+    networks: [front, back]
+  cache:
+    image: redis:7
+    volumes:
+      - data:/data
+    networks: [back]
+`
+
+func composeStep(action string, components ...model.ComponentSpec) model.DeploymentStep {
+	step := model.DeploymentStep{}
+	for _, c := range components {
+		step.Components = append(step.Components, model.ComponentStep{Action: action, Component: c})
+	}
+	return step
+}
+
+func composeDeployment(components ...model.ComponentSpec) model.DeploymentSpec {
+	return model.DeploymentSpec{
+		Instance: model.InstanceSpec{Name: "My_App", Scope: "default"},
+		Solution: model.SolutionSpec{Components: components},
+	}
+}
+
+func TestComposeTargetProviderConfigFromMap(t *testing.T) {
+	config, err := ComposeTargetProviderConfigFromMap(map[string]string{
+		"name":            "compose",
+		"imagePullSecret": "registry",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "compose", config.Name)
+	assert.Equal(t, "registry", config.ImagePullSecret)
+}
+
+func TestComposeTargetProviderInitWithMap(t *testing.T) {
+	provider := ComposeTargetProvider{}
+	err := provider.InitWithMap(map[string]string{"name": "compose"})
+	assert.Nil(t, err)
+	assert.Equal(t, "compose", provider.Config.Name)
+}
+
+func TestComposeUpGetDown(t *testing.T) {
+	cli := newFakeClient()
+	provider := ComposeTargetProvider{Client: cli}
+	assert.Nil(t, provider.Init(ComposeTargetProviderConfig{}))
+
+	app := model.ComponentSpec{
+		Name:       "app",
+		Type:       "docker-compose",
+		Properties: map[string]interface{}{ComposeFile: webCompose},
+	}
+	deployment := composeDeployment(app)
+	step := composeStep("update", app)
+	result, err := provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, result["app"].Status)
+	// dependencies start first
+	assert.Equal(t, []string{"my_app-cache-1", "my_app-web-1"}, cli.created)
+	assert.ElementsMatch(t, []string{"nginx:1.25", "redis:7"}, cli.pulls)
+	assert.Contains(t, cli.networks, "my_app_front")
+	assert.Contains(t, cli.networks, "my_app_back")
+	for id, c := range cli.containers {
+		assert.Equal(t, "running", c.State)
+		assert.Equal(t, "my_app", c.Labels[projectLabel])
+		assert.Equal(t, "app", c.Labels[componentLabel])
+		if c.Labels[serviceLabel] == "cache" {
+			assert.Equal(t, "my_app_data", cli.hostConfig[id].Mounts[0].Source)
+			assert.Equal(t, container.NetworkMode("my_app_back"), cli.hostConfig[id].NetworkMode)
+		}
+	}
+
+	components, err := provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, webCompose, components[0].Properties[ComposeFile])
+	assert.False(t, provider.GetValidationRule(context.Background()).IsComponentChanged(components[0], app))
+
+	// applying the same project again leaves the containers alone
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cli.created))
+
+	// a changed service is recreated, and a service that's no longer in the file is removed
+	app.Properties[ComposeFile] = `services:
+  web:
+    image: nginx:1.26
+`
+	_, err = provider.Apply(context.Background(), composeDeployment(app), composeStep("update", app), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(cli.created))
+	assert.Equal(t, 1, len(cli.containers))
+	for _, c := range cli.containers {
+		assert.Equal(t, "nginx:1.26", c.Image)
+	}
+
+	result, err = provider.Apply(context.Background(), composeDeployment(), composeStep("delete", app), false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Deleted, result["app"].Status)
+	assert.Equal(t, 0, len(cli.containers))
+	assert.Equal(t, 0, len(cli.networks))
+
+	components, err = provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+}
+
+func TestComposeComponentsAsServices(t *testing.T) {
+	cli := newFakeClient()
+	cli.images["redis:7"] = true
+	provider := ComposeTargetProvider{Client: cli}
+	assert.Nil(t, provider.Init(ComposeTargetProviderConfig{}))
+
+	cache := model.ComponentSpec{
+		Name: "cache",
+		Properties: map[string]interface{}{
+			model.ContainerImage: "redis:7",
+		},
+	}
+	web := model.ComponentSpec{
+		Name:         "web",
+		Dependencies: []string{"cache"},
+		Properties: map[string]interface{}{
+			model.ContainerImage: "nginx:1.25",
+			"env.CACHE_HOST":     "cache",
+			"container.ports":    `[{"containerPort":80,"hostPort":8080}]`,
+		},
+	}
+	_, err := provider.Apply(context.Background(), composeDeployment(web, cache), composeStep("update", web, cache), false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"my_app-cache-1", "my_app-web-1"}, cli.created)
+	assert.Equal(t, []string{"nginx:1.25"}, cli.pulls)
+	assert.Contains(t, cli.networks, "my_app_default")
+
+	// deleting one component keeps the rest of the project running
+	_, err = provider.Apply(context.Background(), composeDeployment(cache), composeStep("delete", web), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cli.containers))
+	assert.Contains(t, cli.networks, "my_app_default")
+
+	components, err := provider.Get(context.Background(), composeDeployment(web, cache), composeStep("update", web, cache).Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "cache", components[0].Name)
+	assert.Equal(t, "redis:7", components[0].Properties[model.ContainerImage])
+}
+
+func TestComposeInvalidProject(t *testing.T) {
+	provider := ComposeTargetProvider{Client: newFakeClient()}
+	assert.Nil(t, provider.Init(ComposeTargetProviderConfig{}))
+	app := model.ComponentSpec{
+		Name: "app",
+		Properties: map[string]interface{}{ComposeFile: `services:
+  web:
+    image: nginx
+    depends_on: [db]
+`},
+	}
+	_, err := provider.Apply(context.Background(), composeDeployment(app), composeStep("update", app), false)
+	assert.NotNil(t, err)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func TestComposeTargetProviderLocalDocker(t *testing.T) {
+	testComposeProvider := os.Getenv("TEST_COMPOSE_PROVIDER")
+	if testComposeProvider == "" {
+		t.Skip("Skipping because TEST_COMPOSE_PROVIDER enviornment variable is not set")
+	}
+	provider := ComposeTargetProvider{}
+	assert.Nil(t, provider.Init(ComposeTargetProviderConfig{}))
+	app := model.ComponentSpec{
+		Name:       "app",
+		Properties: map[string]interface{}{ComposeFile: webCompose},
+	}
+	deployment := composeDeployment(app)
+	_, err := provider.Apply(context.Background(), deployment, composeStep("update", app), false)
+	assert.Nil(t, err)
+	components, err := provider.Get(context.Background(), deployment, composeStep("update", app).Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	_, err = provider.Apply(context.Background(), composeDeployment(), composeStep("delete", app), false)
+	assert.Nil(t, err)
+}
+
+func TestConformanceSuite(t *testing.T) {
+	provider := &ComposeTargetProvider{Client: newFakeClient()}
+	err := provider.Init(ComposeTargetProviderConfig{})
+	assert.Nil(t, err)
+	conformance.ConformanceSuite(t, provider)
+}
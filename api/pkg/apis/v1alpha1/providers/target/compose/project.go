@@ -0,0 +1,520 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ComposeFile is the component property with a compose file to deploy instead of the component's container.*
+	// properties
+	ComposeFile = validation.ComposeFile
+
+	defaultNetwork = "default"
+)
+
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// project is the subset of the compose specification the compose target deploys
+type project struct {
+	Name     string                    `json:"name,omitempty"`
+	Services map[string]composeService `json:"services"`
+	// component maps each service to the component it was rendered from
+	component map[string]string
+	// fromFile is true for the services of compose.yaml properties
+	fromFile map[string]bool
+}
+
+type composeService struct {
+	Image       string         `json:"image"`
+	Entrypoint  stringOrList   `json:"entrypoint,omitempty"`
+	Command     stringOrList   `json:"command,omitempty"`
+	Environment mappingOrList  `json:"environment,omitempty"`
+	Ports       []string       `json:"ports,omitempty"`
+	Volumes     []string       `json:"volumes,omitempty"`
+	Networks    stringOrList   `json:"networks,omitempty"`
+	Restart     string         `json:"restart,omitempty"`
+	DependsOn   dependsOn      `json:"depends_on,omitempty"`
+	Healthcheck *composeHealth `json:"healthcheck,omitempty"`
+	Labels      mappingOrList  `json:"labels,omitempty"`
+	PullPolicy  string         `json:"pull_policy,omitempty"`
+	WorkingDir  string         `json:"working_dir,omitempty"`
+	User        string         `json:"user,omitempty"`
+	Privileged  bool           `json:"privileged,omitempty"`
+	ExtraHosts  stringOrList   `json:"extra_hosts,omitempty"`
+	// HostResources are the Docker resource settings of the container.resources property. Compose files don't
+	// have them.
+	HostResources *container.Resources `json:"-"`
+}
+
+type composeHealth struct {
+	Test        stringOrList `json:"test"`
+	Interval    string       `json:"interval,omitempty"`
+	Timeout     string       `json:"timeout,omitempty"`
+	StartPeriod string       `json:"start_period,omitempty"`
+	Retries     int          `json:"retries,omitempty"`
+	Disable     bool         `json:"disable,omitempty"`
+}
+
+// stringOrList is a compose field that is either a string or a list of strings
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = splitCommand(str)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		// networks can also be a map of network names to settings
+		var m map[string]interface{}
+		if e := json.Unmarshal(data, &m); e != nil {
+			return err
+		}
+		for k := range m {
+			list = append(list, k)
+		}
+		sort.Strings(list)
+	}
+	*s = list
+	return nil
+}
+
+// splitCommand splits a command line into words the way a shell would, honoring single and double quotes
+func splitCommand(command string) []string {
+	ret := make([]string, 0)
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				ret = append(ret, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		ret = append(ret, word.String())
+	}
+	return ret
+}
+
+// mappingOrList is a compose field that is either a map or a list of "key=value" strings
+type mappingOrList map[string]string
+
+func (m *mappingOrList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*m = make(map[string]string, len(list))
+		for _, item := range list {
+			k, v, _ := strings.Cut(item, "=")
+			(*m)[k] = v
+		}
+		return nil
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = make(map[string]string, len(raw))
+	for k, v := range raw {
+		if v == nil {
+			(*m)[k] = ""
+		} else {
+			(*m)[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return nil
+}
+
+// dependsOn is the depends_on field, either a list of services or a map of services to conditions
+type dependsOn []string
+
+func (d *dependsOn) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for k := range m {
+		*d = append(*d, k)
+	}
+	sort.Strings(*d)
+	return nil
+}
+
+// projectName turns an instance name into a valid compose project name
+func projectName(instance string) string {
+	name := invalidProjectChars.ReplaceAllString(strings.ToLower(instance), "-")
+	return strings.TrimLeft(name, "-_")
+}
+
+// renderProject renders the components into a compose project. A component with a compose.yaml property
+// contributes the services of the file, any other component becomes a service named after the component.
+func renderProject(name string, components []model.ComponentSpec, injections *model.ValueInjections) (project, error) {
+	ret := project{
+		Name:      name,
+		Services:  make(map[string]composeService),
+		component: make(map[string]string),
+		fromFile:  make(map[string]bool),
+	}
+	for _, c := range components {
+		services, err := componentToServices(c, injections)
+		if err != nil {
+			return ret, fmt.Errorf("component '%s': %v", c.Name, err)
+		}
+		for serviceName, service := range services {
+			if owner, ok := ret.component[serviceName]; ok {
+				return ret, fmt.Errorf("component '%s': service '%s' is already defined by component '%s'", c.Name, serviceName, owner)
+			}
+			ret.Services[serviceName] = service
+			ret.component[serviceName] = c.Name
+			ret.fromFile[serviceName] = model.ReadPropertyCompat(c.Properties, ComposeFile, injections) != ""
+		}
+	}
+	for serviceName, service := range ret.Services {
+		deps := make(dependsOn, 0, len(service.DependsOn))
+		for _, d := range service.DependsOn {
+			if _, ok := ret.Services[d]; ok {
+				deps = append(deps, d)
+			} else if ret.fromFile[serviceName] {
+				return ret, fmt.Errorf("service '%s' depends on unknown service '%s'", serviceName, d)
+			}
+			// otherwise it's a component dependency on a component deployed by another target
+		}
+		service.DependsOn = deps
+		ret.Services[serviceName] = service
+	}
+	return ret, nil
+}
+
+func componentToServices(component model.ComponentSpec, injections *model.ValueInjections) (map[string]composeService, error) {
+	if file := model.ReadPropertyCompat(component.Properties, ComposeFile, injections); file != "" {
+		var p project
+		if err := yaml.Unmarshal([]byte(file), &p); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", ComposeFile, err)
+		}
+		if len(p.Services) == 0 {
+			return nil, fmt.Errorf("%s doesn't define any services", ComposeFile)
+		}
+		for name, s := range p.Services {
+			if s.Image == "" {
+				return nil, fmt.Errorf("service '%s' doesn't have an image", name)
+			}
+		}
+		return p.Services, nil
+	}
+
+	image := model.ReadPropertyCompat(component.Properties, model.ContainerImage, injections)
+	if image == "" {
+		return nil, fmt.Errorf("component needs either a %s or a %s property", model.ContainerImage, ComposeFile)
+	}
+	service := composeService{
+		Image:       image,
+		Environment: make(mappingOrList),
+		DependsOn:   component.Dependencies,
+		Restart:     model.ReadPropertyCompat(component.Properties, "container.restartPolicy", injections),
+		PullPolicy:  model.ReadPropertyCompat(component.Properties, "container.imagePullPolicy", injections),
+	}
+	for k := range component.Properties {
+		if strings.HasPrefix(k, "env.") {
+			service.Environment[strings.TrimPrefix(k, "env.")] = model.ReadPropertyCompat(component.Properties, k, injections)
+		}
+	}
+	for property, target := range map[string]*stringOrList{
+		"container.commands": &service.Entrypoint,
+		"container.args":     &service.Command,
+		"container.networks": &service.Networks,
+	} {
+		if v := model.ReadPropertyCompat(component.Properties, property, injections); v != "" {
+			if err := json.Unmarshal([]byte(v), (*[]string)(target)); err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", property, err)
+			}
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.ports", injections); v != "" {
+		var ports []struct {
+			ContainerPort int    `json:"containerPort"`
+			HostPort      int    `json:"hostPort,omitempty"`
+			HostIP        string `json:"hostIP,omitempty"`
+			Protocol      string `json:"protocol,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(v), &ports); err != nil {
+			return nil, fmt.Errorf("invalid container.ports: %v", err)
+		}
+		for _, p := range ports {
+			spec := fmt.Sprintf("%d", p.ContainerPort)
+			if p.HostPort != 0 {
+				spec = fmt.Sprintf("%d:%s", p.HostPort, spec)
+				if p.HostIP != "" {
+					spec = p.HostIP + ":" + spec
+				}
+			}
+			if p.Protocol != "" {
+				spec += "/" + strings.ToLower(p.Protocol)
+			}
+			service.Ports = append(service.Ports, spec)
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.volumeMounts", injections); v != "" {
+		var mounts []struct {
+			Name      string `json:"name"`
+			MountPath string `json:"mountPath"`
+			ReadOnly  bool   `json:"readOnly,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(v), &mounts); err != nil {
+			return nil, fmt.Errorf("invalid container.volumeMounts: %v", err)
+		}
+		for _, m := range mounts {
+			spec := m.Name + ":" + m.MountPath
+			if m.ReadOnly {
+				spec += ":ro"
+			}
+			service.Volumes = append(service.Volumes, spec)
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.healthCheck", injections); v != "" {
+		// the same format as the docker target's container.healthCheck property
+		var health struct {
+			Test        []string `json:"test"`
+			Interval    string   `json:"interval,omitempty"`
+			Timeout     string   `json:"timeout,omitempty"`
+			StartPeriod string   `json:"startPeriod,omitempty"`
+			Retries     int      `json:"retries,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(v), &health); err != nil {
+			return nil, fmt.Errorf("invalid container.healthCheck: %v", err)
+		}
+		service.Healthcheck = &composeHealth{
+			Test:        health.Test,
+			Interval:    health.Interval,
+			Timeout:     health.Timeout,
+			StartPeriod: health.StartPeriod,
+			Retries:     health.Retries,
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.resources", injections); v != "" {
+		var resources container.Resources
+		if err := json.Unmarshal([]byte(v), &resources); err != nil {
+			return nil, fmt.Errorf("invalid container.resources: %v", err)
+		}
+		service.HostResources = &resources
+	}
+	return map[string]composeService{component.Name: service}, nil
+}
+
+// networks returns the project networks a service is attached to, the default network if it doesn't list any
+func (s composeService) networks() []string {
+	if len(s.Networks) == 0 {
+		return []string{defaultNetwork}
+	}
+	return s.Networks
+}
+
+// configHash identifies the settings of a service, so that a container is only recreated when they change
+func (s composeService) configHash() string {
+	data, _ := json.Marshal(struct {
+		Service   composeService
+		Resources *container.Resources
+	}{s, s.HostResources})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// containerConfig turns a service into the settings of its container
+func (s composeService) containerConfig(projectName string, serviceName string) (*container.Config, *container.HostConfig, error) {
+	config := &container.Config{
+		Image:      s.Image,
+		Entrypoint: []string(s.Entrypoint),
+		Cmd:        []string(s.Command),
+		WorkingDir: s.WorkingDir,
+		User:       s.User,
+		Labels:     make(map[string]string),
+	}
+	hostConfig := &container.HostConfig{
+		Privileged: s.Privileged,
+		ExtraHosts: []string(s.ExtraHosts),
+	}
+	keys := make([]string, 0, len(s.Environment))
+	for k := range s.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		config.Env = append(config.Env, k+"="+s.Environment[k])
+	}
+	for k, v := range s.Labels {
+		config.Labels[k] = v
+	}
+	if len(s.Ports) > 0 {
+		exposed, bindings, err := nat.ParsePortSpecs(s.Ports)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service '%s': invalid ports: %v", serviceName, err)
+		}
+		config.ExposedPorts = exposed
+		hostConfig.PortBindings = bindings
+	}
+	for _, v := range s.Volumes {
+		m, err := toMount(projectName, v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service '%s': %v", serviceName, err)
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, m)
+	}
+	if s.Restart != "" {
+		name, retries, _ := strings.Cut(s.Restart, ":")
+		switch strings.ToLower(name) {
+		case "no", "never":
+			hostConfig.RestartPolicy.Name = "no"
+		case "always", "unless-stopped", "on-failure":
+			hostConfig.RestartPolicy.Name = strings.ToLower(name)
+		case "onfailure":
+			hostConfig.RestartPolicy.Name = "on-failure"
+		default:
+			return nil, nil, fmt.Errorf("service '%s': invalid restart policy '%s'", serviceName, s.Restart)
+		}
+		if retries != "" {
+			if _, err := fmt.Sscanf(retries, "%d", &hostConfig.RestartPolicy.MaximumRetryCount); err != nil {
+				return nil, nil, fmt.Errorf("service '%s': invalid restart policy '%s'", serviceName, s.Restart)
+			}
+		}
+	}
+	if s.Healthcheck != nil {
+		health := &container.HealthConfig{Test: []string(s.Healthcheck.Test), Retries: s.Healthcheck.Retries}
+		if s.Healthcheck.Disable {
+			health.Test = []string{"NONE"}
+		} else if len(health.Test) > 0 && health.Test[0] != "CMD" && health.Test[0] != "CMD-SHELL" && health.Test[0] != "NONE" {
+			// a string test runs in a shell
+			health.Test = []string{"CMD-SHELL", strings.Join(health.Test, " ")}
+		}
+		for _, d := range []struct {
+			value  string
+			target *time.Duration
+		}{
+			{s.Healthcheck.Interval, &health.Interval},
+			{s.Healthcheck.Timeout, &health.Timeout},
+			{s.Healthcheck.StartPeriod, &health.StartPeriod},
+		} {
+			if d.value == "" {
+				continue
+			}
+			duration, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("service '%s': invalid healthcheck: %v", serviceName, err)
+			}
+			*d.target = duration
+		}
+		config.Healthcheck = health
+	}
+	if s.HostResources != nil {
+		hostConfig.Resources = *s.HostResources
+	}
+	return config, hostConfig, nil
+}
+
+// toMount reads a compose volume ("volume:/path", "/host/path:/path:ro" or "/path"). Named volumes are scoped to
+// the project the same way compose does it.
+func toMount(projectName string, volume string) (mount.Mount, error) {
+	parts := strings.Split(volume, ":")
+	ret := mount.Mount{Type: mount.TypeVolume}
+	switch len(parts) {
+	case 1:
+		ret.Target = parts[0]
+		return ret, nil
+	case 2, 3:
+		ret.Source = parts[0]
+		ret.Target = parts[1]
+		if len(parts) == 3 {
+			switch parts[2] {
+			case "ro":
+				ret.ReadOnly = true
+			case "rw":
+			default:
+				return ret, fmt.Errorf("invalid volume '%s': unknown mode '%s'", volume, parts[2])
+			}
+		}
+	default:
+		return ret, fmt.Errorf("invalid volume '%s'", volume)
+	}
+	switch {
+	case strings.HasPrefix(ret.Source, "/"):
+		ret.Type = mount.TypeBind
+	case strings.HasPrefix(ret.Source, "."):
+		return ret, fmt.Errorf("invalid volume '%s': relative host paths aren't supported", volume)
+	default:
+		ret.Source = projectName + "_" + ret.Source
+	}
+	return ret, nil
+}
+
+// startOrder sorts the services so that each service comes after the services it depends on
+func (p project) startOrder() ([]string, error) {
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([]string, 0, len(names))
+	state := make(map[string]int) // 1: visiting, 2: done
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("services have a circular dependency: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, d := range p.Services[name].DependsOn {
+			if err := visit(d, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		ret = append(ret, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectName(t *testing.T) {
+	assert.Equal(t, "my-app_1", projectName("My.App_1"))
+	assert.Equal(t, "app", projectName("--app"))
+}
+
+func TestRenderProjectShortAndLongSyntax(t *testing.T) {
+	p, err := renderProject("app", []model.ComponentSpec{
+		{
+			Name: "app",
+			Properties: map[string]interface{}{ComposeFile: `services:
+  web:
+    image: nginx
+    command: nginx -g "daemon off;"
+    environment:
+      - MODE=prod
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: postgres
+    environment:
+      POSTGRES_PASSWORD: secret
+`},
+		},
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, stringOrList{"nginx", "-g", "daemon off;"}, p.Services["web"].Command)
+	assert.Equal(t, "prod", p.Services["web"].Environment["MODE"])
+	assert.Equal(t, dependsOn{"db"}, p.Services["web"].DependsOn)
+	assert.Equal(t, "secret", p.Services["db"].Environment["POSTGRES_PASSWORD"])
+	order, err := p.startOrder()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"db", "web"}, order)
+}
+
+func TestRenderProjectDuplicateService(t *testing.T) {
+	_, err := renderProject("app", []model.ComponentSpec{
+		{Name: "web", Properties: map[string]interface{}{model.ContainerImage: "nginx"}},
+		{Name: "other", Properties: map[string]interface{}{ComposeFile: "services:\n  web:\n    image: nginx\n"}},
+	}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestStartOrderCycle(t *testing.T) {
+	p := project{Services: map[string]composeService{
+		"a": {Image: "a", DependsOn: dependsOn{"b"}},
+		"b": {Image: "b", DependsOn: dependsOn{"a"}},
+	}}
+	_, err := p.startOrder()
+	assert.NotNil(t, err)
+}
+
+func TestToMount(t *testing.T) {
+	m, err := toMount("app", "data:/data:ro")
+	assert.Nil(t, err)
+	assert.Equal(t, mount.Mount{Type: mount.TypeVolume, Source: "app_data", Target: "/data", ReadOnly: true}, m)
+	m, err = toMount("app", "/srv/html:/usr/share/nginx/html")
+	assert.Nil(t, err)
+	assert.Equal(t, mount.TypeBind, m.Type)
+	_, err = toMount("app", "./html:/usr/share/nginx/html")
+	assert.NotNil(t, err)
+}
@@ -8,31 +8,86 @@ package docker
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 )
 
 var sLog = logger.NewLogger("coa.runtime")
 
+const (
+	// propertiesLabel keeps the container properties a container was created with, after values were injected, so
+	// that Get can report them as they were applied
+	propertiesLabel = "symphony.properties"
+	// componentLabel marks the containers created by the Docker target
+	componentLabel = "symphony.component"
+)
+
 type DockerTargetProviderConfig struct {
 	Name string `json:"name"`
+	// ImagePullSecret is the secret object with the "username" and "password" (and optionally "server") fields used
+	// to pull images. A component can use another secret with the container.imagePullSecret property.
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
 }
 
 type DockerTargetProvider struct {
-	Config  DockerTargetProviderConfig
-	Context *contexts.ManagerContext
+	Config         DockerTargetProviderConfig
+	Context        *contexts.ManagerContext
+	SecretProvider secret.ISecretProvider
+}
+
+// containerPort is an entry of the container.ports property
+type containerPort struct {
+	Name          string `json:"name,omitempty"`
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort,omitempty"`
+	HostIP        string `json:"hostIP,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// volumeMount is an entry of the container.volumeMounts property. A name that is an absolute path is bind-mounted
+// from the host, other names are Docker volumes that are created when they don't exist.
+type volumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// healthCheck is the container.healthCheck property. Durations use Go's duration format, for example "30s".
+type healthCheck struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"startPeriod,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+// containerSpec is everything needed to create a container for a component
+type containerSpec struct {
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Networks   []string
 }
 
 func DockerTargetProviderConfigFromMap(properties map[string]string) (DockerTargetProviderConfig, error) {
@@ -40,6 +95,9 @@ func DockerTargetProviderConfigFromMap(properties map[string]string) (DockerTarg
 	if v, ok := properties["name"]; ok {
 		ret.Name = v
 	}
+	if v, ok := properties["imagePullSecret"]; ok {
+		ret.ImagePullSecret = v
+	}
 	return ret, nil
 }
 func (d *DockerTargetProvider) InitWithMap(properties map[string]string) error {
@@ -53,6 +111,10 @@ func (s *DockerTargetProvider) SetContext(ctx *contexts.ManagerContext) {
 	s.Context = ctx
 }
 
+func (s *DockerTargetProvider) SetSecretProvider(provider secret.ISecretProvider) {
+	s.SecretProvider = provider
+}
+
 func (d *DockerTargetProvider) Init(config providers.IProviderConfig) error {
 	_, span := observability.StartSpan("Docker Target Provider", context.TODO(), &map[string]string{
 		"method": "Init",
@@ -110,37 +172,48 @@ func (i *DockerTargetProvider) Get(ctx context.Context, deployment model.Deploym
 				Name:       name,
 				Properties: make(map[string]interface{}),
 			}
-			// container.args
-			if len(info.Args) > 0 {
-				argsData, _ := json.Marshal(info.Args)
-				component.Properties["container.args"] = string(argsData)
-			}
 			// container.image
 			component.Properties[model.ContainerImage] = info.Config.Image
-			if info.HostConfig != nil {
-				resources, _ := json.Marshal(info.HostConfig.Resources)
-				component.Properties["container.resources"] = string(resources)
-			}
-			// container.ports
-			if info.NetworkSettings != nil && len(info.NetworkSettings.Ports) > 0 {
-				ports, _ := json.Marshal(info.NetworkSettings.Ports)
-				component.Properties["container.ports"] = string(ports)
-			}
-			// container.cmd
-			if len(info.Config.Cmd) > 0 {
-				cmdData, _ := json.Marshal(info.Config.Cmd)
-				component.Properties["container.commands"] = string(cmdData)
-			}
-			// container.volumeMounts
-			if len(info.Mounts) > 0 {
-				volumeData, _ := json.Marshal(info.Mounts)
-				component.Properties["container.volumeMounts"] = string(volumeData)
+			if v, ok := info.Config.Labels[propertiesLabel]; ok {
+				// the container was created from these properties and its settings can't change without recreating
+				// it, so they are reported as they were specified for change detection to compare
+				properties := make(map[string]string)
+				if json.Unmarshal([]byte(v), &properties) == nil {
+					for k, p := range properties {
+						component.Properties[k] = p
+					}
+				}
+			} else {
+				// container.args
+				if len(info.Args) > 0 {
+					argsData, _ := json.Marshal(info.Args)
+					component.Properties["container.args"] = string(argsData)
+				}
+				if info.HostConfig != nil {
+					resources, _ := json.Marshal(info.HostConfig.Resources)
+					component.Properties["container.resources"] = string(resources)
+				}
+				// container.ports
+				if info.NetworkSettings != nil && len(info.NetworkSettings.Ports) > 0 {
+					ports, _ := json.Marshal(info.NetworkSettings.Ports)
+					component.Properties["container.ports"] = string(ports)
+				}
+				// container.cmd
+				if len(info.Config.Cmd) > 0 {
+					cmdData, _ := json.Marshal(info.Config.Cmd)
+					component.Properties["container.commands"] = string(cmdData)
+				}
+				// container.volumeMounts
+				if len(info.Mounts) > 0 {
+					volumeData, _ := json.Marshal(info.Mounts)
+					component.Properties["container.volumeMounts"] = string(volumeData)
+				}
 			}
 			// get environment varibles that are passed in by the reference
 			env := info.Config.Env
 			if len(env) > 0 {
 				for _, e := range env {
-					pair := strings.Split(e, "=")
+					pair := strings.SplitN(e, "=", 2)
 					if len(pair) == 2 {
 						for _, s := range references {
 							if s.Component.Name == component.Name {
@@ -197,14 +270,34 @@ func (i *DockerTargetProvider) Apply(ctx context.Context, deployment model.Deplo
 	for _, component := range step.Components {
 		if component.Action == "update" {
 			image := model.ReadPropertyCompat(component.Component.Properties, model.ContainerImage, injections)
-			resources := model.ReadPropertyCompat(component.Component.Properties, "container.resources", injections)
 			if image == "" {
 				err = errors.New("component doesn't have container.image property")
 				ret[component.Component.Name] = model.ComponentResultSpec{
 					Status:  v1alpha2.UpdateFailed,
 					Message: err.Error(),
 				}
-				sLog.Errorf("  P (Helm Target): component doesn't have container.image property")
+				sLog.Errorf("  P (Docker Target): component doesn't have container.image property")
+				return ret, err
+			}
+			var spec containerSpec
+			spec, err = componentToContainerSpec(component.Component, injections)
+			if err != nil {
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: err.Error(),
+				}
+				sLog.Errorf("  P (Docker Target): failed to read container settings: %+v", err)
+				return ret, err
+			}
+
+			// pull the image before the running container is stopped, so that a failed pull doesn't take it down
+			err = i.pullImage(ctx, cli, image, component.Component, injections)
+			if err != nil {
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: err.Error(),
+				}
+				sLog.Errorf("  P (Docker Target): failed to pull docker image: %+v", err)
 				return ret, err
 			}
 
@@ -214,17 +307,6 @@ func (i *DockerTargetProvider) Apply(ctx context.Context, deployment model.Deplo
 				alreadyRunning = false
 			}
 
-			// TODO: I don't think we need to do an explict image pull here, as Docker will pull the image upon cache miss
-			// reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
-			// if err != nil {
-			// 	observ_utils.CloseSpanWithError(span, &err)
-			// 	sLog.Errorf("  P (Docker Target): failed to pull docker image: %+v", err)
-			// 	return err
-			// }
-
-			// defer reader.Close()
-			// io.Copy(os.Stdout, reader)
-
 			if alreadyRunning {
 				err = cli.ContainerStop(context.TODO(), component.Component.Name, nil)
 				if err != nil {
@@ -244,36 +326,20 @@ func (i *DockerTargetProvider) Apply(ctx context.Context, deployment model.Deplo
 				}
 			}
 
-			// prepare environment variables
-			env := make([]string, 0)
-			for k, v := range component.Component.Properties {
-				if strings.HasPrefix(k, "env.") {
-					env = append(env, strings.TrimPrefix(k, "env.")+"="+v.(string))
-				}
-			}
-
-			containerConfig := container.Config{
-				Image: image,
-				Env:   env,
-			}
-			var hostConfig *container.HostConfig
-			if resources != "" {
-				var resourceSpec container.Resources
-				err = json.Unmarshal([]byte(resources), &resourceSpec)
+			for _, n := range spec.Networks {
+				err = ensureNetwork(ctx, cli, n)
 				if err != nil {
 					ret[component.Component.Name] = model.ComponentResultSpec{
 						Status:  v1alpha2.UpdateFailed,
 						Message: err.Error(),
 					}
-					sLog.Errorf("  P (Docker Target): failed to read container resource settings: %+v", err)
+					sLog.Errorf("  P (Docker Target): failed to create network %s: %+v", n, err)
 					return ret, err
 				}
-				hostConfig = &container.HostConfig{
-					Resources: resourceSpec,
-				}
 			}
+
 			var container container.ContainerCreateCreatedBody
-			container, err = cli.ContainerCreate(context.TODO(), &containerConfig, hostConfig, nil, nil, component.Component.Name)
+			container, err = cli.ContainerCreate(context.TODO(), spec.Config, spec.HostConfig, nil, nil, component.Component.Name)
 			if err != nil {
 				ret[component.Component.Name] = model.ComponentResultSpec{
 					Status:  v1alpha2.UpdateFailed,
@@ -283,6 +349,22 @@ func (i *DockerTargetProvider) Apply(ctx context.Context, deployment model.Deplo
 				return ret, err
 			}
 
+			// the first network is the container's network mode, the other networks are connected before it starts
+			for idx, n := range spec.Networks {
+				if idx == 0 {
+					continue
+				}
+				err = cli.NetworkConnect(ctx, n, container.ID, &network.EndpointSettings{})
+				if err != nil {
+					ret[component.Component.Name] = model.ComponentResultSpec{
+						Status:  v1alpha2.UpdateFailed,
+						Message: err.Error(),
+					}
+					sLog.Errorf("  P (Docker Target): failed to connect container to network %s: %+v", n, err)
+					return ret, err
+				}
+			}
+
 			if err = cli.ContainerStart(context.TODO(), container.ID, types.ContainerStartOptions{}); err != nil {
 				ret[component.Component.Name] = model.ComponentResultSpec{
 					Status:  v1alpha2.UpdateFailed,
@@ -320,16 +402,287 @@ func (i *DockerTargetProvider) Apply(ctx context.Context, deployment model.Deplo
 }
 
 func (*DockerTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{model.ContainerImage},
-		OptionalProperties:    []string{"container.resources"},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-		ChangeDetectionProperties: []model.PropertyDesc{
-			{Name: model.ContainerImage, IgnoreCase: false, SkipIfMissing: false},
-			{Name: "container.ports", IgnoreCase: false, SkipIfMissing: true},
-			{Name: "container.resources", IgnoreCase: false, SkipIfMissing: true},
+	return validation.DockerRule()
+}
+
+// componentToContainerSpec reads the container settings of a component
+func componentToContainerSpec(component model.ComponentSpec, injections *model.ValueInjections) (containerSpec, error) {
+	ret := containerSpec{
+		Config: &container.Config{
+			Image:  model.ReadPropertyCompat(component.Properties, model.ContainerImage, injections),
+			Labels: map[string]string{componentLabel: component.Name},
 		},
+		HostConfig: &container.HostConfig{},
+	}
+	properties := make(map[string]string)
+	for _, p := range validation.ContainerProperties {
+		if v := model.ReadPropertyCompat(component.Properties, p, injections); v != "" {
+			properties[p] = v
+		}
+	}
+	data, _ := json.Marshal(properties)
+	ret.Config.Labels[propertiesLabel] = string(data)
+
+	for k := range component.Properties {
+		if strings.HasPrefix(k, "env.") {
+			ret.Config.Env = append(ret.Config.Env, strings.TrimPrefix(k, "env.")+"="+model.ReadPropertyCompat(component.Properties, k, injections))
+		}
+	}
+	sort.Strings(ret.Config.Env)
+
+	if v := model.ReadPropertyCompat(component.Properties, "container.commands", injections); v != "" {
+		if err := json.Unmarshal([]byte(v), &ret.Config.Entrypoint); err != nil {
+			return ret, fmt.Errorf("invalid container.commands: %v", err)
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.args", injections); v != "" {
+		if err := json.Unmarshal([]byte(v), &ret.Config.Cmd); err != nil {
+			return ret, fmt.Errorf("invalid container.args: %v", err)
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.resources", injections); v != "" {
+		if err := json.Unmarshal([]byte(v), &ret.HostConfig.Resources); err != nil {
+			return ret, fmt.Errorf("invalid container.resources: %v", err)
+		}
 	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.ports", injections); v != "" {
+		var ports []containerPort
+		if err := json.Unmarshal([]byte(v), &ports); err != nil {
+			return ret, fmt.Errorf("invalid container.ports: %v", err)
+		}
+		ret.Config.ExposedPorts = nat.PortSet{}
+		ret.HostConfig.PortBindings = nat.PortMap{}
+		for _, p := range ports {
+			protocol := strings.ToLower(p.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			port, err := nat.NewPort(protocol, fmt.Sprintf("%d", p.ContainerPort))
+			if err != nil {
+				return ret, fmt.Errorf("invalid container.ports: %v", err)
+			}
+			ret.Config.ExposedPorts[port] = struct{}{}
+			if p.HostPort != 0 {
+				ret.HostConfig.PortBindings[port] = append(ret.HostConfig.PortBindings[port], nat.PortBinding{
+					HostIP:   p.HostIP,
+					HostPort: fmt.Sprintf("%d", p.HostPort),
+				})
+			}
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.volumeMounts", injections); v != "" {
+		var mounts []volumeMount
+		if err := json.Unmarshal([]byte(v), &mounts); err != nil {
+			return ret, fmt.Errorf("invalid container.volumeMounts: %v", err)
+		}
+		for _, m := range mounts {
+			mountType := mount.TypeVolume
+			if strings.HasPrefix(m.Name, "/") {
+				mountType = mount.TypeBind
+			}
+			ret.HostConfig.Mounts = append(ret.HostConfig.Mounts, mount.Mount{
+				Type:     mountType,
+				Source:   m.Name,
+				Target:   m.MountPath,
+				ReadOnly: m.ReadOnly,
+			})
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.networks", injections); v != "" {
+		if err := json.Unmarshal([]byte(v), &ret.Networks); err != nil {
+			return ret, fmt.Errorf("invalid container.networks: %v", err)
+		}
+		if len(ret.Networks) > 0 {
+			ret.HostConfig.NetworkMode = container.NetworkMode(ret.Networks[0])
+		}
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.restartPolicy", injections); v != "" {
+		policy, err := toRestartPolicy(v)
+		if err != nil {
+			return ret, err
+		}
+		ret.HostConfig.RestartPolicy = policy
+	}
+	if v := model.ReadPropertyCompat(component.Properties, "container.healthCheck", injections); v != "" {
+		health, err := toHealthConfig(v)
+		if err != nil {
+			return ret, err
+		}
+		ret.Config.Healthcheck = health
+	}
+	return ret, nil
+}
+
+// toRestartPolicy reads a Docker restart policy ("no", "always", "unless-stopped", "on-failure" or
+// "on-failure:<max retries>"). The k8s values "Always", "OnFailure" and "Never" are accepted as well.
+func toRestartPolicy(value string) (container.RestartPolicy, error) {
+	name, retries, _ := strings.Cut(value, ":")
+	ret := container.RestartPolicy{}
+	switch strings.ToLower(name) {
+	case "no", "never":
+		ret.Name = "no"
+	case "always":
+		ret.Name = "always"
+	case "unless-stopped":
+		ret.Name = "unless-stopped"
+	case "on-failure", "onfailure":
+		ret.Name = "on-failure"
+	default:
+		return ret, fmt.Errorf("invalid container.restartPolicy '%s'", value)
+	}
+	if retries != "" {
+		if ret.Name != "on-failure" {
+			return ret, fmt.Errorf("invalid container.restartPolicy '%s': only on-failure takes a retry count", value)
+		}
+		if _, err := fmt.Sscanf(retries, "%d", &ret.MaximumRetryCount); err != nil {
+			return ret, fmt.Errorf("invalid container.restartPolicy '%s': %v", value, err)
+		}
+	}
+	return ret, nil
+}
+
+func toHealthConfig(value string) (*container.HealthConfig, error) {
+	var check healthCheck
+	if err := json.Unmarshal([]byte(value), &check); err != nil {
+		return nil, fmt.Errorf("invalid container.healthCheck: %v", err)
+	}
+	if len(check.Test) == 0 {
+		return nil, fmt.Errorf("invalid container.healthCheck: test is missing")
+	}
+	ret := &container.HealthConfig{
+		Test:    check.Test,
+		Retries: check.Retries,
+	}
+	for _, d := range []struct {
+		value  string
+		target *time.Duration
+		name   string
+	}{
+		{check.Interval, &ret.Interval, "interval"},
+		{check.Timeout, &ret.Timeout, "timeout"},
+		{check.StartPeriod, &ret.StartPeriod, "startPeriod"},
+	} {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container.healthCheck %s: %v", d.name, err)
+		}
+		*d.target = duration
+	}
+	return ret, nil
+}
+
+// pullImage pulls the image of a component according to its container.imagePullPolicy, with the registry credentials
+// of the component's or the provider's image pull secret
+func (i *DockerTargetProvider) pullImage(ctx context.Context, cli *client.Client, image string, component model.ComponentSpec, injections *model.ValueInjections) error {
+	policy := model.ReadPropertyCompat(component.Properties, "container.imagePullPolicy", injections)
+	secretName := model.ReadPropertyCompat(component.Properties, "container.imagePullSecret", injections)
+	if secretName == "" {
+		secretName = i.Config.ImagePullSecret
+	}
+	auth := ""
+	if secretName != "" {
+		var err error
+		auth, err = RegistryAuth(i.SecretProvider, secretName)
+		if err != nil {
+			return err
+		}
+	}
+	return PullImage(ctx, cli, image, policy, auth)
+}
+
+// ImageClient is the part of the Docker client PullImage uses
+type ImageClient interface {
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error)
+}
+
+// PullImage pulls an image according to a pull policy: "IfNotPresent" (the default), "Always" or "Never". The compose
+// spellings "missing" and "if_not_present" work as well. auth is the encoded registry credentials, see RegistryAuth,
+// or empty to pull anonymously. The compose target uses it as well.
+func PullImage(ctx context.Context, cli ImageClient, image string, policy string, auth string) error {
+	switch strings.ToLower(policy) {
+	case "never":
+		return nil
+	case "", "ifnotpresent", "missing", "if_not_present":
+		if _, _, err := cli.ImageInspectWithRaw(ctx, image); err == nil {
+			return nil
+		}
+	case "always":
+	default:
+		return fmt.Errorf("invalid image pull policy '%s'", policy)
+	}
+	reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: auth})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// the pull only completes once its progress stream is read, and errors are reported in the stream
+	decoder := json.NewDecoder(reader)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+	}
+}
+
+// RegistryAuth reads registry credentials from the secret provider and encodes them for the Docker API. The compose
+// target uses it as well.
+func RegistryAuth(secretProvider secret.ISecretProvider, secretName string) (string, error) {
+	if secretProvider == nil {
+		return "", fmt.Errorf("image pull secret '%s' is set but no secret provider is configured", secretName)
+	}
+	username, err := secretProvider.Get(secretName, "username")
+	if err != nil {
+		return "", err
+	}
+	password, err := secretProvider.Get(secretName, "password")
+	if err != nil {
+		return "", err
+	}
+	// the server is optional, Docker uses the registry of the image by default
+	server, _ := secretProvider.Get(secretName, "server")
+	data, err := json.Marshal(types.AuthConfig{
+		Username:      username,
+		Password:      password,
+		ServerAddress: server,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// ensureNetwork creates a user-defined bridge network if it doesn't exist yet
+func ensureNetwork(ctx context.Context, cli *client.Client, name string) error {
+	switch name {
+	case "bridge", "host", "none", "default":
+		return nil
+	}
+	if strings.HasPrefix(name, "container:") {
+		return nil
+	}
+	_, err := cli.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return err
+	}
+	_, err = cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Labels:         map[string]string{componentLabel: ""},
+	})
+	return err
 }
@@ -8,11 +8,22 @@ package docker
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -322,3 +333,144 @@ func TestConformanceSuite(t *testing.T) {
 	assert.Nil(t, err)
 	conformance.ConformanceSuite(t, provider)
 }
+
+func TestComponentToContainerSpec(t *testing.T) {
+	component := model.ComponentSpec{
+		Name: "web",
+		Properties: map[string]interface{}{
+			model.ContainerImage:      "nginx:1.25",
+			"env.MODE":                "edge",
+			"env.URL":                 "http://a?b=c",
+			"container.ports":         `[{"containerPort":80,"hostPort":8080},{"containerPort":53,"protocol":"UDP"}]`,
+			"container.volumeMounts":  `[{"name":"data","mountPath":"/data"},{"name":"/etc/web","mountPath":"/config","readOnly":true}]`,
+			"container.commands":      `["nginx"]`,
+			"container.args":          `["-g","daemon off;"]`,
+			"container.networks":      `["edge","monitoring"]`,
+			"container.restartPolicy": "on-failure:3",
+			"container.healthCheck":   `{"test":["CMD","curl","-f","http://localhost"],"interval":"30s","retries":3}`,
+		},
+	}
+	spec, err := componentToContainerSpec(component, &model.ValueInjections{})
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx:1.25", spec.Config.Image)
+	assert.Equal(t, []string{"MODE=edge", "URL=http://a?b=c"}, spec.Config.Env)
+	assert.Equal(t, []string{"nginx"}, []string(spec.Config.Entrypoint))
+	assert.Equal(t, []string{"-g", "daemon off;"}, []string(spec.Config.Cmd))
+	assert.Contains(t, spec.Config.ExposedPorts, nat.Port("80/tcp"))
+	assert.Contains(t, spec.Config.ExposedPorts, nat.Port("53/udp"))
+	assert.Equal(t, []nat.PortBinding{{HostPort: "8080"}}, spec.HostConfig.PortBindings[nat.Port("80/tcp")])
+	assert.NotContains(t, spec.HostConfig.PortBindings, nat.Port("53/udp"))
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeVolume, Source: "data", Target: "/data"},
+		{Type: mount.TypeBind, Source: "/etc/web", Target: "/config", ReadOnly: true},
+	}, spec.HostConfig.Mounts)
+	assert.Equal(t, []string{"edge", "monitoring"}, spec.Networks)
+	assert.Equal(t, container.NetworkMode("edge"), spec.HostConfig.NetworkMode)
+	assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, spec.HostConfig.RestartPolicy)
+	assert.Equal(t, 30*time.Second, spec.Config.Healthcheck.Interval)
+	assert.Equal(t, 3, spec.Config.Healthcheck.Retries)
+
+	// the label keeps the properties the container was created with for Get to report
+	var properties map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(spec.Config.Labels[propertiesLabel]), &properties))
+	assert.Equal(t, component.Properties["container.ports"], properties["container.ports"])
+	assert.Equal(t, "on-failure:3", properties["container.restartPolicy"])
+	assert.NotContains(t, properties, model.ContainerImage)
+}
+
+func TestComponentToContainerSpecInjectsValues(t *testing.T) {
+	spec, err := componentToContainerSpec(model.ComponentSpec{
+		Name: "web",
+		Properties: map[string]interface{}{
+			model.ContainerImage:     "nginx",
+			"container.volumeMounts": `[{"name":"${{$instance()}}-data","mountPath":"/data"}]`,
+		},
+	}, &model.ValueInjections{InstanceId: "instance-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "instance-1-data", spec.HostConfig.Mounts[0].Source)
+	var properties map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(spec.Config.Labels[propertiesLabel]), &properties))
+	assert.Equal(t, `[{"name":"instance-1-data","mountPath":"/data"}]`, properties["container.volumeMounts"])
+}
+
+func TestComponentToContainerSpecInvalid(t *testing.T) {
+	for property, value := range map[string]string{
+		"container.ports":         `{"containerPort":80}`,
+		"container.restartPolicy": "sometimes",
+		"container.healthCheck":   `{"interval":"30s"}`,
+		"container.args":          "-g",
+	} {
+		_, err := componentToContainerSpec(model.ComponentSpec{
+			Name:       "web",
+			Properties: map[string]interface{}{model.ContainerImage: "nginx", property: value},
+		}, &model.ValueInjections{})
+		assert.NotNil(t, err, property)
+	}
+}
+
+func TestToRestartPolicy(t *testing.T) {
+	policy, err := toRestartPolicy("Always")
+	assert.Nil(t, err)
+	assert.Equal(t, "always", policy.Name)
+	policy, err = toRestartPolicy("Never")
+	assert.Nil(t, err)
+	assert.Equal(t, "no", policy.Name)
+	_, err = toRestartPolicy("always:3")
+	assert.NotNil(t, err)
+}
+
+func TestRegistryAuth(t *testing.T) {
+	_, err := RegistryAuth(nil, "registry")
+	assert.NotNil(t, err)
+
+	auth, err := RegistryAuth(&mock.MockSecretProvider{}, "registry")
+	assert.Nil(t, err)
+	data, err := base64.URLEncoding.DecodeString(auth)
+	assert.Nil(t, err)
+	var config types.AuthConfig
+	assert.Nil(t, json.Unmarshal(data, &config))
+	assert.Equal(t, "registry>>username", config.Username)
+	assert.Equal(t, "registry>>password", config.Password)
+	assert.Equal(t, "registry>>server", config.ServerAddress)
+}
+
+func TestDockerTargetProviderConfigFromMapImagePullSecret(t *testing.T) {
+	config, err := DockerTargetProviderConfigFromMap(map[string]string{"imagePullSecret": "registry"})
+	assert.Nil(t, err)
+	assert.Equal(t, "registry", config.ImagePullSecret)
+}
+
+// fakeImageClient has the images in present and pulls by returning the progress stream in stream
+type fakeImageClient struct {
+	present map[string]bool
+	stream  string
+	pulls   []types.ImagePullOptions
+}
+
+func (c *fakeImageClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	if !c.present[imageID] {
+		return types.ImageInspect{}, nil, fmt.Errorf("no such image: %s", imageID)
+	}
+	return types.ImageInspect{ID: imageID}, nil, nil
+}
+func (c *fakeImageClient) ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	c.pulls = append(c.pulls, options)
+	return io.NopCloser(strings.NewReader(c.stream)), nil
+}
+
+func TestPullImage(t *testing.T) {
+	for policy, pulls := range map[string]int{"": 0, "IfNotPresent": 0, "missing": 0, "Always": 1, "never": 0} {
+		cli := &fakeImageClient{present: map[string]bool{"redis": true}}
+		assert.Nil(t, PullImage(context.Background(), cli, "redis", policy, ""), policy)
+		assert.Equal(t, pulls, len(cli.pulls), policy)
+	}
+
+	cli := &fakeImageClient{stream: `{"status":"Pulling"}{"status":"Downloaded"}`}
+	assert.Nil(t, PullImage(context.Background(), cli, "redis", "if_not_present", "auth"))
+	assert.Equal(t, []types.ImagePullOptions{{RegistryAuth: "auth"}}, cli.pulls)
+
+	cli = &fakeImageClient{stream: `{"status":"Pulling"}{"error":"manifest unknown"}`}
+	assert.EqualError(t, PullImage(context.Background(), cli, "redis", "Always", ""), "manifest unknown")
+
+	assert.NotNil(t, PullImage(context.Background(), &fakeImageClient{}, "redis", "sometimes", ""))
+}
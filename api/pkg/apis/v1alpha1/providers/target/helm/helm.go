@@ -16,15 +16,21 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	"github.com/google/uuid"
 	"helm.sh/helm/v3/pkg/action"
@@ -33,9 +39,11 @@ import (
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
 )
 
 var sLog = logger.NewLogger("coa.runtime")
@@ -43,6 +51,9 @@ var sLog = logger.NewLogger("coa.runtime")
 const (
 	DEFAULT_NAMESPACE = "default"
 	TEMP_CHART_DIR    = "/tmp/symphony/charts"
+	DEFAULT_TIMEOUT   = 5 * time.Minute
+	// maxHistory is how many release revisions are reported in the component results
+	maxHistory = 5
 )
 
 type (
@@ -53,6 +64,10 @@ type (
 		ConfigData string `json:"configData,omitempty"`
 		Context    string `json:"context,omitempty"`
 		InCluster  bool   `json:"inCluster"`
+		// RegistrySecret is the secret object with the "username" and "password" fields used to pull charts
+		RegistrySecret string `json:"registrySecret,omitempty"`
+		// ValuesDir is the directory that values files are read from. Values files are rejected when it isn't set.
+		ValuesDir string `json:"valuesDir,omitempty"`
 	}
 	// HelmTargetProvider is the Helm provider
 	HelmTargetProvider struct {
@@ -62,17 +77,38 @@ type (
 		InstallClient   *action.Install
 		UpgradeClient   *action.Upgrade
 		UninstallClient *action.Uninstall
+		HistoryClient   *action.History
+		SecretProvider  secret.ISecretProvider
 	}
 	// HelmProperty is the property for the Helm chart
 	HelmProperty struct {
 		Chart  HelmChartProperty      `json:"chart"`
 		Values map[string]interface{} `json:"values,omitempty"`
+		// ValuesFrom are merged in order, and the inline Values are merged last
+		ValuesFrom []HelmValuesSource `json:"valuesFrom,omitempty"`
 	}
 	// HelmChartProperty is the property for the Helm Charts
 	HelmChartProperty struct {
 		Repo    string `json:"repo"`
 		Version string `json:"version"`
 		Wait    bool   `json:"wait"`
+		// Atomic rolls a failed upgrade back to the previous release, and uninstalls a failed install
+		Atomic bool `json:"atomic,omitempty"`
+		// Timeout is how long to wait for Kubernetes operations, such as "5m". Defaults to 5 minutes.
+		Timeout string `json:"timeout,omitempty"`
+		// RegistrySecret overrides the RegistrySecret of the provider
+		RegistrySecret string `json:"registrySecret,omitempty"`
+	}
+	// HelmValuesSource is a source of chart values. Exactly one of Catalog, File and Secret is set.
+	HelmValuesSource struct {
+		// Catalog is a catalog whose properties are the values
+		Catalog string `json:"catalog,omitempty"`
+		// File is a YAML values file, relative to the ValuesDir of the provider
+		File string `json:"file,omitempty"`
+		// Secret is a secret object with a YAML values field
+		Secret string `json:"secret,omitempty"`
+		// Key is the field of Secret that holds the values. Defaults to "values.yaml".
+		Key string `json:"key,omitempty"`
 	}
 )
 
@@ -106,6 +142,14 @@ func HelmTargetProviderConfigFromMap(properties map[string]string) (HelmTargetPr
 		}
 	}
 
+	if v, ok := properties["registrySecret"]; ok {
+		ret.RegistrySecret = v
+	}
+
+	if v, ok := properties["valuesDir"]; ok {
+		ret.ValuesDir = v
+	}
+
 	return ret, nil
 }
 
@@ -123,6 +167,10 @@ func (s *HelmTargetProvider) SetContext(ctx *contexts.ManagerContext) {
 	s.Context = ctx
 }
 
+func (s *HelmTargetProvider) SetSecretProvider(provider secret.ISecretProvider) {
+	s.SecretProvider = provider
+}
+
 // Init initializes the HelmTargetProvider
 func (i *HelmTargetProvider) Init(config providers.IProviderConfig) error {
 	_, span := observability.StartSpan(
@@ -194,6 +242,7 @@ func (i *HelmTargetProvider) Init(config providers.IProviderConfig) error {
 	i.InstallClient = action.NewInstall(actionConfig)
 	i.UninstallClient = action.NewUninstall(actionConfig)
 	i.UpgradeClient = action.NewUpgrade(actionConfig)
+	i.HistoryClient = action.NewHistory(actionConfig)
 	return nil
 }
 
@@ -277,25 +326,31 @@ func (i *HelmTargetProvider) Get(ctx context.Context, deployment model.Deploymen
 
 // GetValidationRule returns the validation rule for this provider
 func (*HelmTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{"chart"},
-		OptionalProperties:    []string{"values"},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-		ChangeDetectionProperties: []model.PropertyDesc{
-			{Name: "chart", IgnoreCase: false, SkipIfMissing: true}, //TODO: deep change detection on interface{}
-		},
-	}
+	return validation.HelmRule()
 }
 
 // downloadFile will download a url to a local file. It's efficient because it will
 func downloadFile(url string, fileName string) error {
-	resp, err := http.Get(url)
+	return downloadChart(url, fileName, "", "")
+}
+
+// downloadChart downloads a chart archive, with basic authentication when a username is given
+func downloadChart(url string, fileName string, username string, password string) error {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		request.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download chart '%s': %s", url, resp.Status)
+	}
 
 	fileHandle, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
 	if err != nil {
@@ -345,6 +400,17 @@ func (i *HelmTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 				return ret, err
 			}
 
+			var values map[string]interface{}
+			values, err = i.mergeValues(ctx, helmProp)
+			if err != nil {
+				sLog.Errorf("  P (Helm Target): failed to read chart values: %+v", err)
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: err.Error(),
+				}
+				return ret, err
+			}
+
 			var fileName string
 			fileName, err = i.pullChart(&helmProp.Chart)
 			if err != nil {
@@ -369,21 +435,33 @@ func (i *HelmTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 			}
 
 			chart.Metadata.Tags = "SYM:" + helmProp.Chart.Repo //this is not used by Helm SDK, we use this to carry repo info
-			i.configureUpsertClients(component.Component.Name, &helmProp.Chart, &deployment)
+			err = i.configureUpsertClients(component.Component.Name, &helmProp.Chart, &deployment)
+			if err != nil {
+				sLog.Errorf("  P (Helm Target): invalid chart settings: %+v", err)
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: err.Error(),
+				}
+				return ret, err
+			}
 
-			if _, err = i.UpgradeClient.Run(component.Component.Name, chart, helmProp.Values); err != nil {
-				if _, err = i.InstallClient.Run(chart, helmProp.Values); err != nil {
-					sLog.Errorf("  P (Helm Target): failed to apply: %+v", err)
-					ret[component.Component.Name] = model.ComponentResultSpec{
-						Status:  v1alpha2.UpdateFailed,
-						Message: err.Error(),
-					}
-					return ret, err
+			err = i.upsertRelease(component.Component.Name, chart, values)
+			history := i.releaseHistory(component.Component.Name)
+			if err != nil {
+				sLog.Errorf("  P (Helm Target): failed to apply: %+v", err)
+				message := err.Error()
+				if history != "" {
+					message += "; " + history
+				}
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: message,
 				}
+				return ret, err
 			}
 			ret[component.Component.Name] = model.ComponentResultSpec{
 				Status:  v1alpha2.Updated,
-				Message: "",
+				Message: history,
 			}
 		} else {
 			if component.Component.Type == "helm.v3" {
@@ -412,22 +490,44 @@ func (i *HelmTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 func (i *HelmTargetProvider) pullChart(chart *HelmChartProperty) (fileName string, err error) {
 	fileName = fmt.Sprintf("%s/%s.tgz", TEMP_CHART_DIR, uuid.New().String())
 
+	var username, password string
+	username, password, err = i.registryCredentials(chart)
+	if err != nil {
+		sLog.Errorf("  P (Helm Target): failed to read registry credentials: %+v", err)
+		return "", err
+	}
+
 	var pullRes *registry.PullResult
 	if strings.HasSuffix(chart.Repo, ".tgz") && strings.HasPrefix(chart.Repo, "http") {
-		err = downloadFile(chart.Repo, fileName)
+		err = downloadChart(chart.Repo, fileName, username, password)
 		if err != nil {
 			sLog.Errorf("  P (Helm Target): failed to download chart from repo: %+v", err)
 			return "", err
 		}
 	} else {
+		ref := strings.TrimPrefix(chart.Repo, fmt.Sprintf("%s://", registry.OCIScheme))
 		var regClient *registry.Client
-		regClient, err = registry.NewClient()
+		if username != "" {
+			// log in with a throwaway credentials file, so that the credentials aren't kept on the host
+			credentialsFile := fmt.Sprintf("%s/%s.json", TEMP_CHART_DIR, uuid.New().String())
+			defer os.Remove(credentialsFile)
+			regClient, err = registry.NewClient(registry.ClientOptCredentialsFile(credentialsFile), registry.ClientOptWriter(io.Discard))
+			if err == nil {
+				host, _, _ := strings.Cut(ref, "/")
+				err = regClient.Login(host, registry.LoginOptBasicAuth(username, password))
+			}
+		} else {
+			regClient, err = registry.NewClient()
+		}
 		if err != nil {
 			sLog.Errorf("  P (Helm Target): failed to create registry client: %+v", err)
 			return
 		}
 
-		pullRes, err = regClient.Pull(fmt.Sprintf("%s:%s", chart.Repo, chart.Version), registry.PullOptWithChart(true))
+		if chart.Version != "" {
+			ref = fmt.Sprintf("%s:%s", ref, chart.Version)
+		}
+		pullRes, err = regClient.Pull(ref, registry.PullOptWithChart(true))
 		if err != nil {
 			sLog.Errorf("  P (Helm Target): failed to pull chart from repo: %+v", err)
 			return
@@ -442,7 +542,172 @@ func (i *HelmTargetProvider) pullChart(chart *HelmChartProperty) (fileName strin
 	return fileName, nil
 }
 
-func (i *HelmTargetProvider) configureUpsertClients(name string, componentProps *HelmChartProperty, deployment *model.DeploymentSpec) {
+// registryCredentials reads the credentials of the chart registry from the secret provider
+func (i *HelmTargetProvider) registryCredentials(chart *HelmChartProperty) (string, string, error) {
+	secretName := chart.RegistrySecret
+	if secretName == "" {
+		secretName = i.Config.RegistrySecret
+	}
+	if secretName == "" {
+		return "", "", nil
+	}
+	if i.SecretProvider == nil {
+		return "", "", fmt.Errorf("registry secret '%s' is set but no secret provider is configured", secretName)
+	}
+	username, err := i.SecretProvider.Get(secretName, "username")
+	if err != nil {
+		return "", "", err
+	}
+	password, err := i.SecretProvider.Get(secretName, "password")
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// mergeValues merges the values of the ValuesFrom sources in order, and then the inline values. Maps are merged
+// key by key, like multiple -f options of the Helm CLI.
+func (i *HelmTargetProvider) mergeValues(ctx context.Context, props *HelmProperty) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	for _, source := range props.ValuesFrom {
+		var values map[string]interface{}
+		switch {
+		case source.Catalog != "":
+			if i.Context == nil || i.Context.SiteInfo.CurrentSite.BaseUrl == "" {
+				return nil, fmt.Errorf("can't read values from catalog '%s' without a Symphony API", source.Catalog)
+			}
+			catalog, err := api_utils.GetCatalog(ctx,
+				i.Context.SiteInfo.CurrentSite.BaseUrl,
+				source.Catalog,
+				i.Context.SiteInfo.CurrentSite.Username,
+				i.Context.SiteInfo.CurrentSite.Password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read values from catalog '%s': %v", source.Catalog, err)
+			}
+			if catalog.Spec != nil {
+				values = catalog.Spec.Properties
+			}
+		case source.File != "":
+			path, err := i.valuesFilePath(source.File)
+			if err != nil {
+				return nil, err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read values file: %v", err)
+			}
+			if err := yaml.Unmarshal(data, &values); err != nil {
+				return nil, fmt.Errorf("invalid values file '%s': %v", source.File, err)
+			}
+		case source.Secret != "":
+			if i.SecretProvider == nil {
+				return nil, fmt.Errorf("can't read values from secret '%s' without a secret provider", source.Secret)
+			}
+			key := source.Key
+			if key == "" {
+				key = "values.yaml"
+			}
+			data, err := i.SecretProvider.Get(source.Secret, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read values from secret '%s': %v", source.Secret, err)
+			}
+			if err := yaml.Unmarshal([]byte(data), &values); err != nil {
+				return nil, fmt.Errorf("invalid values in secret '%s': %v", source.Secret, err)
+			}
+		default:
+			return nil, errors.New("a valuesFrom entry needs a catalog, file or secret")
+		}
+		ret = mergeMaps(ret, values)
+	}
+	return mergeMaps(ret, props.Values), nil
+}
+
+// valuesFilePath resolves a values file under the ValuesDir of the provider. Absolute paths and paths that leave
+// ValuesDir, including through symbolic links, are rejected.
+func (i *HelmTargetProvider) valuesFilePath(file string) (string, error) {
+	if i.Config.ValuesDir == "" {
+		return "", fmt.Errorf("can't read values file '%s' without a values directory in the provider config", file)
+	}
+	if filepath.IsAbs(file) || escapesDir(filepath.Clean(file)) {
+		return "", fmt.Errorf("values file '%s' must be a path relative to the values directory", file)
+	}
+	dir, err := filepath.EvalSymlinks(i.Config.ValuesDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid values directory: %v", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read values file: %v", err)
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || escapesDir(rel) {
+		return "", fmt.Errorf("values file '%s' is outside of the values directory", file)
+	}
+	return path, nil
+}
+
+func escapesDir(path string) bool {
+	return path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k]; ok {
+				if bv, ok := bv.(map[string]interface{}); ok {
+					out[k] = mergeMaps(bv, v)
+					continue
+				}
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// upsertRelease upgrades a release, or installs it when it doesn't exist yet
+func (i *HelmTargetProvider) upsertRelease(name string, chart *chart.Chart, values map[string]interface{}) error {
+	if _, err := i.HistoryClient.Run(name); errors.Is(err, driver.ErrReleaseNotFound) {
+		_, err = i.InstallClient.Run(chart, values)
+		return err
+	} else if err != nil {
+		return err
+	}
+	_, err := i.UpgradeClient.Run(name, chart, values)
+	return err
+}
+
+// releaseHistory describes the latest revisions of a release, newest first
+func (i *HelmTargetProvider) releaseHistory(name string) string {
+	releases, err := i.HistoryClient.Run(name)
+	if err != nil || len(releases) == 0 {
+		return ""
+	}
+	sort.Slice(releases, func(a, b int) bool { return releases[a].Version > releases[b].Version })
+	if len(releases) > maxHistory {
+		releases = releases[:maxHistory]
+	}
+	revisions := make([]string, 0, len(releases))
+	for _, r := range releases {
+		revision := fmt.Sprintf("revision %d", r.Version)
+		if r.Info != nil {
+			revision += fmt.Sprintf(" %s", r.Info.Status)
+		}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			revision += fmt.Sprintf(" %s-%s", r.Chart.Metadata.Name, r.Chart.Metadata.Version)
+		}
+		if r.Info != nil && r.Info.Description != "" {
+			revision += fmt.Sprintf(" (%s)", r.Info.Description)
+		}
+		revisions = append(revisions, revision)
+	}
+	return "release history: " + strings.Join(revisions, "; ")
+}
+
+func (i *HelmTargetProvider) configureUpsertClients(name string, componentProps *HelmChartProperty, deployment *model.DeploymentSpec) error {
 	if deployment.Instance.Scope == "" {
 		i.InstallClient.Namespace = DEFAULT_NAMESPACE
 		i.UpgradeClient.Namespace = DEFAULT_NAMESPACE
@@ -451,13 +716,27 @@ func (i *HelmTargetProvider) configureUpsertClients(name string, componentProps
 		i.UpgradeClient.Namespace = deployment.Instance.Scope
 	}
 
+	timeout := DEFAULT_TIMEOUT
+	if componentProps.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(componentProps.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid chart timeout '%s': %v", componentProps.Timeout, err)
+		}
+	}
+
 	i.InstallClient.Wait = componentProps.Wait
 	i.UpgradeClient.Wait = componentProps.Wait
+	i.InstallClient.Atomic = componentProps.Atomic
+	i.UpgradeClient.Atomic = componentProps.Atomic
+	i.InstallClient.Timeout = timeout
+	i.UpgradeClient.Timeout = timeout
 	i.InstallClient.CreateNamespace = true
 	i.InstallClient.ReleaseName = name
 	i.InstallClient.IsUpgrade = true
 	i.UpgradeClient.Install = true
 	i.UpgradeClient.ResetValues = true
+	return nil
 }
 
 func getHelmPropertyFromComponent(component model.ComponentSpec) (*HelmProperty, error) {
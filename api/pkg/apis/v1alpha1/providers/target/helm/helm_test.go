@@ -8,14 +8,29 @@ package helm
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/client-go/rest"
 )
 
@@ -562,3 +577,195 @@ func TestConformanceSuite(t *testing.T) {
 	assert.Nil(t, err)
 	conformance.ConformanceSuite(t, provider)
 }
+
+// failingWaitKubeClient is a fake Kubernetes client whose next Wait fails
+type failingWaitKubeClient struct {
+	kubefake.PrintingKubeClient
+	failNextWait bool
+}
+
+func (c *failingWaitKubeClient) Wait(resources kube.ResourceList, timeout time.Duration) error {
+	if c.failNextWait {
+		c.failNextWait = false
+		return errors.New("timed out waiting for the condition")
+	}
+	return nil
+}
+
+// mapSecretProvider is a secret provider backed by a map of "object/field" keys
+type mapSecretProvider map[string]string
+
+func (m mapSecretProvider) Init(config providers.IProviderConfig) error {
+	return nil
+}
+func (m mapSecretProvider) Get(object string, field string) (string, error) {
+	if v, ok := m[object+"/"+field]; ok {
+		return v, nil
+	}
+	return "", v1alpha2.NewCOAError(nil, "secret not found", v1alpha2.NotFound)
+}
+
+// newMemoryHelmProvider creates a provider that keeps releases in memory instead of a cluster
+func newMemoryHelmProvider(kubeClient kube.Interface) *HelmTargetProvider {
+	actionConfig := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   kubeClient,
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(format string, v ...interface{}) {},
+	}
+	return &HelmTargetProvider{
+		ListClient:      action.NewList(actionConfig),
+		InstallClient:   action.NewInstall(actionConfig),
+		UpgradeClient:   action.NewUpgrade(actionConfig),
+		UninstallClient: action.NewUninstall(actionConfig),
+		HistoryClient:   action.NewHistory(actionConfig),
+	}
+}
+
+// serveTestChart serves a chart archive, that requires basic authentication when a username is given
+func serveTestChart(t *testing.T, username string, password string) *httptest.Server {
+	dir := t.TempDir()
+	path, err := chartutil.Save(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "demo", Version: "0.1.0"},
+		Templates: []*chart.File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\ndata:\n  color: {{ .Values.color }}\n")},
+		},
+	}, dir)
+	assert.Nil(t, err)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeFile(w, r, path)
+	}))
+}
+
+func helmStep(name string, properties map[string]interface{}) model.DeploymentStep {
+	return model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: "update",
+				Component: model.ComponentSpec{
+					Name:       name,
+					Type:       "helm.v3",
+					Properties: properties,
+				},
+			},
+		},
+	}
+}
+
+func TestHelmTargetProviderRegistryCredentials(t *testing.T) {
+	ts := serveTestChart(t, "reader", "s3cret")
+	defer ts.Close()
+
+	provider := newMemoryHelmProvider(&kubefake.PrintingKubeClient{Out: io.Discard})
+	provider.Config.RegistrySecret = "registry"
+	step := helmStep("demo", map[string]interface{}{
+		"chart":  map[string]interface{}{"repo": ts.URL + "/demo-0.1.0.tgz"},
+		"values": map[string]interface{}{"color": "blue"},
+	})
+	_, err := provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.NotNil(t, err)
+
+	provider.SetSecretProvider(mapSecretProvider{"registry/username": "reader", "registry/password": "s3cret"})
+	ret, err := provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, ret["demo"].Status)
+	assert.Equal(t, "release history: revision 1 deployed demo-0.1.0 (Install complete)", ret["demo"].Message)
+}
+
+func TestHelmTargetProviderMergeValues(t *testing.T) {
+	dir := t.TempDir()
+	file := "values.yaml"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, file), []byte("color: red\nreplicas: 2\nimage:\n  tag: v1\n  pullPolicy: Always\n"), 0644))
+
+	provider := newMemoryHelmProvider(&kubefake.PrintingKubeClient{Out: io.Discard})
+	provider.Config.ValuesDir = dir
+	provider.SetSecretProvider(mapSecretProvider{"db/values.yaml": "database:\n  password: s3cret\n"})
+	props, err := getHelmPropertyFromComponent(model.ComponentSpec{
+		Properties: map[string]interface{}{
+			"chart": map[string]interface{}{"repo": "oci://example.azurecr.io/charts/demo", "version": "0.1.0"},
+			"valuesFrom": []interface{}{
+				map[string]interface{}{"file": file},
+				map[string]interface{}{"secret": "db"},
+			},
+			"values": map[string]interface{}{
+				"color": "blue",
+				"image": map[string]interface{}{"tag": "v2"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	values, err := provider.mergeValues(context.Background(), props)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"color":    "blue",
+		"replicas": float64(2),
+		"image":    map[string]interface{}{"tag": "v2", "pullPolicy": "Always"},
+		"database": map[string]interface{}{"password": "s3cret"},
+	}, values)
+
+	props.ValuesFrom = []HelmValuesSource{{}}
+	_, err = provider.mergeValues(context.Background(), props)
+	assert.NotNil(t, err)
+}
+
+func TestHelmTargetProviderValuesFileOutsideValuesDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "values")
+	assert.Nil(t, os.Mkdir(dir, 0755))
+	secret := filepath.Join(root, "secret.yaml")
+	assert.Nil(t, os.WriteFile(secret, []byte("password: s3cret\n"), 0644))
+	assert.Nil(t, os.Symlink(secret, filepath.Join(dir, "link.yaml")))
+
+	provider := newMemoryHelmProvider(&kubefake.PrintingKubeClient{Out: io.Discard})
+	for _, file := range []string{secret, "../secret.yaml", "sub/../../secret.yaml", "link.yaml"} {
+		props := &HelmProperty{ValuesFrom: []HelmValuesSource{{File: file}}}
+		provider.Config.ValuesDir = ""
+		_, err := provider.mergeValues(context.Background(), props)
+		assert.NotNil(t, err, file)
+		provider.Config.ValuesDir = dir
+		_, err = provider.mergeValues(context.Background(), props)
+		assert.NotNil(t, err, file)
+	}
+}
+
+func TestHelmTargetProviderAtomicUpgrade(t *testing.T) {
+	ts := serveTestChart(t, "", "")
+	defer ts.Close()
+
+	kubeClient := &failingWaitKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard}}
+	provider := newMemoryHelmProvider(kubeClient)
+	chartProps := map[string]interface{}{"repo": ts.URL + "/demo-0.1.0.tgz", "atomic": true, "timeout": "10s"}
+	_, err := provider.Apply(context.Background(), model.DeploymentSpec{}, helmStep("demo", map[string]interface{}{
+		"chart":  chartProps,
+		"values": map[string]interface{}{"color": "blue"},
+	}), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, provider.UpgradeClient.Timeout)
+
+	// the upgrade times out, so it's rolled back to the first revision
+	kubeClient.failNextWait = true
+	ret, err := provider.Apply(context.Background(), model.DeploymentSpec{}, helmStep("demo", map[string]interface{}{
+		"chart":  chartProps,
+		"values": map[string]interface{}{"color": "green"},
+	}), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.UpdateFailed, ret["demo"].Status)
+	assert.True(t, strings.Contains(ret["demo"].Message, "release history: revision 3 deployed demo-0.1.0 (Rollback to 1); revision 2 failed"), ret["demo"].Message)
+
+	releases, err := provider.HistoryClient.Run("demo")
+	assert.Nil(t, err)
+	for _, r := range releases {
+		if r.Info.Status == release.StatusDeployed {
+			assert.Equal(t, "blue", r.Config["color"])
+		}
+	}
+
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, helmStep("demo", map[string]interface{}{
+		"chart": map[string]interface{}{"repo": ts.URL + "/demo-0.1.0.tgz", "timeout": "soon"},
+	}), false)
+	assert.NotNil(t, err)
+}
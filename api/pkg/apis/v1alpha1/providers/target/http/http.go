@@ -11,10 +11,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -82,7 +88,7 @@ func toHttpTargetProviderConfig(config providers.IProviderConfig) (HttpTargetPro
 	return ret, err
 }
 func (i *HttpTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
-	_, span := observability.StartSpan("Http Target Provider", ctx, &map[string]string{
+	ctx, span := observability.StartSpan("Http Target Provider", ctx, &map[string]string{
 		"method": "Get",
 	})
 	var err error = nil
@@ -90,8 +96,65 @@ func (i *HttpTargetProvider) Get(ctx context.Context, deployment model.Deploymen
 
 	sLog.Infof("  P(HTTP Target): getting artifacts: %s - %s", deployment.Instance.Scope, deployment.Instance.Name)
 
-	// This provider doesn't remember what it does, so it always return nil when asked
-	return nil, nil
+	injections := &model.ValueInjections{
+		InstanceId: deployment.Instance.Name,
+		SolutionId: deployment.Instance.Solution,
+		TargetId:   deployment.ActiveTarget,
+	}
+
+	ret := make([]model.ComponentSpec, 0)
+	for _, ref := range references {
+		// without a http.getUrl there's no way to tell what the endpoint has, so the component is reported as
+		// missing and reapplied on every reconciliation
+		url := model.ReadPropertyCompat(ref.Component.Properties, "http.getUrl", injections)
+		if url == "" {
+			continue
+		}
+		var status int
+		var body []byte
+		status, body, err = i.sendRequest(ctx, ref.Component, http.MethodGet, url, "", injections)
+		if err != nil {
+			sLog.Errorf("  P(HTTP Target): failed to get component %s: %+v", ref.Component.Name, err)
+			return nil, err
+		}
+		if status == http.StatusNotFound || status == http.StatusGone {
+			continue
+		}
+		var success bool
+		success, err = isSuccess(ref.Component, status, injections)
+		if err == nil && !success {
+			err = fmt.Errorf("HTTP request responded %d: %s", status, string(body))
+		}
+		if err != nil {
+			sLog.Errorf("  P(HTTP Target): failed to get component %s: %+v", ref.Component.Name, err)
+			return nil, err
+		}
+		var observed string
+		observed, err = observedBody(body, model.ReadPropertyCompat(ref.Component.Properties, "http.getJsonPath", injections))
+		if err != nil {
+			sLog.Errorf("  P(HTTP Target): failed to read component %s: %+v", ref.Component.Name, err)
+			return nil, err
+		}
+		component := model.ComponentSpec{
+			Name:       ref.Component.Name,
+			Type:       ref.Component.Type,
+			Properties: make(map[string]interface{}),
+		}
+		for k, v := range ref.Component.Properties {
+			component.Properties[k] = v
+		}
+		// report the desired body when the endpoint has an equivalent one, so that formatting differences aren't
+		// taken for changes
+		desired := model.ReadPropertyCompat(ref.Component.Properties, "http.body", injections)
+		if sameBody(desired, observed) {
+			component.Properties["http.body"] = desired
+		} else {
+			component.Properties["http.body"] = observed
+		}
+		ret = append(ret, component)
+	}
+	err = nil
+	return ret, nil
 }
 
 func (i *HttpTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
@@ -114,6 +177,16 @@ func (i *HttpTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 	if err != nil {
 		return nil, err
 	}
+	for _, component := range components {
+		_, err = isSuccess(component, 0, injections)
+		if err == nil {
+			_, err = readHeaders(component, injections)
+		}
+		if err != nil {
+			err = v1alpha2.NewCOAError(err, fmt.Sprintf("invalid component '%s'", component.Name), v1alpha2.BadConfig)
+			return nil, err
+		}
+	}
 	if isDryRun {
 		err = nil
 		return nil, nil
@@ -121,73 +194,192 @@ func (i *HttpTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 
 	ret := step.PrepareResultMap()
 	for _, component := range step.Components {
-		if component.Action == "update" {
-			body := model.ReadPropertyCompat(component.Component.Properties, "http.body", injections)
-			url := model.ReadPropertyCompat(component.Component.Properties, "http.url", injections)
-			method := model.ReadPropertyCompat(component.Component.Properties, "http.method", injections)
-
+		var url, method, body string
+		successStatus, failedStatus := v1alpha2.Updated, v1alpha2.UpdateFailed
+		if component.Action == "delete" {
+			successStatus, failedStatus = v1alpha2.Deleted, v1alpha2.DeleteFailed
+			url = model.ReadPropertyCompat(component.Component.Properties, "http.deleteUrl", injections)
 			if url == "" {
-				err = errors.New("component doesn't have a http.url property")
+				// there's nothing to call to remove the component
 				ret[component.Component.Name] = model.ComponentResultSpec{
-					Status:  v1alpha2.UpdateFailed,
-					Message: err.Error(),
+					Status:  successStatus,
+					Message: "",
 				}
-				sLog.Errorf("  P(HTTP Target): %v", err)
-				return ret, err
+				continue
 			}
+			method = model.ReadPropertyCompat(component.Component.Properties, "http.deleteMethod", injections)
 			if method == "" {
-				method = "POST"
+				method = http.MethodDelete
 			}
-			jsonData := []byte(body)
-			var request *http.Request
-			request, err = http.NewRequest(method, url, bytes.NewBuffer(jsonData))
-			if err != nil {
+		} else if component.Action == "update" {
+			url = model.ReadPropertyCompat(component.Component.Properties, "http.url", injections)
+			method = model.ReadPropertyCompat(component.Component.Properties, "http.method", injections)
+			body = model.ReadPropertyCompat(component.Component.Properties, "http.body", injections)
+			if url == "" {
+				err = errors.New("component doesn't have a http.url property")
 				ret[component.Component.Name] = model.ComponentResultSpec{
-					Status:  v1alpha2.UpdateFailed,
+					Status:  failedStatus,
 					Message: err.Error(),
 				}
 				sLog.Errorf("  P(HTTP Target): %v", err)
 				return ret, err
 			}
-			request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+			if method == "" {
+				method = http.MethodPost
+			}
+		} else {
+			continue
+		}
 
-			client := &http.Client{}
-			var resp *http.Response
-			resp, err = client.Do(request)
-			if err != nil {
-				ret[component.Component.Name] = model.ComponentResultSpec{
-					Status:  v1alpha2.UpdateFailed,
-					Message: err.Error(),
-				}
-				sLog.Errorf("  P(HTTP Target): %v", err)
-				return ret, err
+		var status int
+		var respBody []byte
+		status, respBody, err = i.sendRequest(ctx, component.Component, method, url, body, injections)
+		if err != nil {
+			ret[component.Component.Name] = model.ComponentResultSpec{
+				Status:  failedStatus,
+				Message: err.Error(),
 			}
-			if resp.StatusCode != http.StatusOK {
-				bodyBytes, err := io.ReadAll(resp.Body)
-				var message string
-				if err != nil {
-					message = err.Error()
-				} else {
-					message = string(bodyBytes)
-				}
-				ret[component.Component.Name] = model.ComponentResultSpec{
-					Status:  v1alpha2.UpdateFailed,
-					Message: message,
-				}
-				err = errors.New("HTTP request didn't respond 200 OK")
-				sLog.Errorf("  P(HTTP Target): %v", err)
-				return ret, err
+			sLog.Errorf("  P(HTTP Target): %v", err)
+			return ret, err
+		}
+		var success bool
+		success, err = isSuccess(component.Component, status, injections)
+		if err == nil && !success {
+			err = fmt.Errorf("HTTP request responded %d", status)
+		}
+		if err != nil {
+			ret[component.Component.Name] = model.ComponentResultSpec{
+				Status:  failedStatus,
+				Message: string(respBody),
 			}
+			sLog.Errorf("  P(HTTP Target): %v", err)
+			return ret, err
+		}
+		ret[component.Component.Name] = model.ComponentResultSpec{
+			Status:  successStatus,
+			Message: "",
 		}
 	}
 	return ret, nil
 }
+
+// sendRequest sends a request with the headers and credentials of a component, and returns the response status
+// and body
+func (i *HttpTargetProvider) sendRequest(ctx context.Context, component model.ComponentSpec, method string, url string, body string, injections *model.ValueInjections) (int, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer([]byte(body)))
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	headers, err := readHeaders(component, injections)
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	token := model.ReadPropertyCompat(component.Properties, "http.auth.token", injections)
+	username := model.ReadPropertyCompat(component.Properties, "http.auth.username", injections)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	} else if username != "" {
+		request.SetBasicAuth(username, model.ReadPropertyCompat(component.Properties, "http.auth.password", injections))
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
+
 func (*HttpTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{"http.url"},
-		OptionalProperties:    []string{"http.method", "http.body"},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
+	return validation.HttpRule()
+}
+
+// readHeaders reads the http.headers property, a map of header names to values. The property is either a map
+// or a JSON object string.
+func readHeaders(component model.ComponentSpec, injections *model.ValueInjections) (map[string]string, error) {
+	ret := make(map[string]string)
+	v, ok := component.Properties["http.headers"]
+	if !ok || v == "" {
+		return ret, nil
+	}
+	var headers map[string]interface{}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		headers = t
+	case string:
+		if err := json.Unmarshal([]byte(t), &headers); err != nil {
+			return nil, fmt.Errorf("http.headers must be a map of header names to values: %v", err)
+		}
+	default:
+		return nil, errors.New("http.headers must be a map of header names to values")
+	}
+	for k, hv := range headers {
+		ret[k] = model.ResolveString(fmt.Sprintf("%v", hv), injections)
+	}
+	return ret, nil
+}
+
+// isSuccess checks a response status against the http.successCodes property, a comma separated list of status
+// codes and ranges such as "200,202" or "200-299". Any 2xx status is a success by default.
+func isSuccess(component model.ComponentSpec, status int, injections *model.ValueInjections) (bool, error) {
+	codes := model.ReadPropertyCompat(component.Properties, "http.successCodes", injections)
+	if codes == "" {
+		codes = "200-299"
+	}
+	success := false
+	for _, c := range strings.Split(codes, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(c), "-")
+		low, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return false, fmt.Errorf("invalid http.successCodes '%s'", codes)
+		}
+		high := low
+		if isRange {
+			if high, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return false, fmt.Errorf("invalid http.successCodes '%s'", codes)
+			}
+		}
+		if status >= low && status <= high {
+			success = true
+		}
+	}
+	return success, nil
+}
+
+// observedBody returns the part of a response body selected by a JsonPath, or the whole body
+func observedBody(body []byte, jsonPath string) (string, error) {
+	if jsonPath == "" {
+		return string(body), nil
+	}
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return "", fmt.Errorf("http.getJsonPath is set but the response isn't JSON: %v", err)
+	}
+	result, err := utils.JsonPathQuery(obj, jsonPath)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := result.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(result)
+	return string(data), err
+}
+
+// sameBody compares two bodies as JSON when they both are, and as text otherwise
+func sameBody(a string, b string) bool {
+	var ja, jb interface{}
+	if json.Unmarshal([]byte(a), &ja) == nil && json.Unmarshal([]byte(b), &jb) == nil {
+		return reflect.DeepEqual(ja, jb)
 	}
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
 }
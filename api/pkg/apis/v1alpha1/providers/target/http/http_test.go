@@ -14,6 +14,7 @@ import (
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -302,6 +303,169 @@ func TestHttpTargetProviderRemove(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// TestHttpTargetProviderHeadersAndAuth tests that Apply sends the http.headers and the credentials of a component
+func TestHttpTargetProviderHeadersAndAuth(t *testing.T) {
+	var received *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	provider := HttpTargetProvider{}
+	err := provider.Init(HttpTargetProviderConfig{})
+	assert.Nil(t, err)
+	component := model.ComponentSpec{
+		Name: "webhook",
+		Properties: map[string]interface{}{
+			"http.url":        ts.URL,
+			"http.body":       `{"name":"webhook"}`,
+			"http.headers":    map[string]interface{}{"X-Tenant": "${{$instance()}}", "Content-Type": "application/merge-patch+json"},
+			"http.auth.token": "s3cret",
+		},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	ret, err := provider.Apply(context.Background(), model.DeploymentSpec{Instance: model.InstanceSpec{Name: "instance-1"}}, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, ret["webhook"].Status)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "instance-1", received.Header.Get("X-Tenant"))
+	assert.Equal(t, "application/merge-patch+json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer s3cret", received.Header.Get("Authorization"))
+
+	delete(component.Properties, "http.auth.token")
+	component.Properties["http.auth.username"] = "admin"
+	component.Properties["http.auth.password"] = "pass"
+	component.Properties["http.headers"] = `{"X-Tenant": "json"}`
+	step.Components[0].Component = component
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	username, password, ok := received.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "admin", username)
+	assert.Equal(t, "pass", password)
+	assert.Equal(t, "json", received.Header.Get("X-Tenant"))
+}
+
+// TestHttpTargetProviderSuccessCodes tests that Apply checks the response status against http.successCodes
+func TestHttpTargetProviderSuccessCodes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	provider := HttpTargetProvider{}
+	err := provider.Init(HttpTargetProviderConfig{})
+	assert.Nil(t, err)
+	component := model.ComponentSpec{
+		Name:       "webhook",
+		Properties: map[string]interface{}{"http.url": ts.URL},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+
+	component.Properties["http.successCodes"] = "200, 204-206"
+	ret, err := provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.UpdateFailed, ret["webhook"].Status)
+
+	component.Properties["http.successCodes"] = "2xx"
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, true)
+	assert.NotNil(t, err)
+}
+
+// TestHttpTargetProviderDelete tests that delete actions call http.deleteUrl, and are no-ops without it
+func TestHttpTargetProviderDelete(t *testing.T) {
+	var received *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	provider := HttpTargetProvider{}
+	err := provider.Init(HttpTargetProviderConfig{})
+	assert.Nil(t, err)
+	component := model.ComponentSpec{
+		Name: "webhook",
+		Properties: map[string]interface{}{
+			"http.url": ts.URL + "/hooks",
+		},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "delete", Component: component}},
+	}
+	ret, err := provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Deleted, ret["webhook"].Status)
+	assert.Nil(t, received)
+
+	component.Properties["http.deleteUrl"] = ts.URL + "/hooks/webhook"
+	ret, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Deleted, ret["webhook"].Status)
+	assert.Equal(t, http.MethodDelete, received.Method)
+	assert.Equal(t, "/hooks/webhook", received.URL.Path)
+
+	component.Properties["http.deleteMethod"] = http.MethodPost
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, received.Method)
+}
+
+// TestHttpTargetProviderGetUrl tests that Get reports the body returned by http.getUrl
+func TestHttpTargetProviderGetUrl(t *testing.T) {
+	state := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if state == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(state))
+	}))
+	defer ts.Close()
+
+	provider := HttpTargetProvider{}
+	err := provider.Init(HttpTargetProviderConfig{})
+	assert.Nil(t, err)
+	component := model.ComponentSpec{
+		Name: "webhook",
+		Properties: map[string]interface{}{
+			"http.url":    ts.URL,
+			"http.body":   `{"name": "webhook", "events": ["push"]}`,
+			"http.getUrl": ts.URL,
+		},
+	}
+	references := []model.ComponentStep{{Action: "update", Component: component}}
+	rule := provider.GetValidationRule(context.Background())
+
+	components, err := provider.Get(context.Background(), model.DeploymentSpec{}, references)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+
+	state = `{"events":["push"],"name":"webhook"}`
+	components, err = provider.Get(context.Background(), model.DeploymentSpec{}, references)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.False(t, rule.IsComponentChanged(components[0], component))
+
+	state = `{"events":["push","pull_request"],"name":"webhook"}`
+	components, err = provider.Get(context.Background(), model.DeploymentSpec{}, references)
+	assert.Nil(t, err)
+	assert.True(t, rule.IsComponentChanged(components[0], component))
+
+	component.Properties["http.getJsonPath"] = "$.config"
+	state = `{"id": 42, "config": {"events":["push"],"name":"webhook"}}`
+	components, err = provider.Get(context.Background(), model.DeploymentSpec{}, []model.ComponentStep{{Action: "update", Component: component}})
+	assert.Nil(t, err)
+	assert.False(t, rule.IsComponentChanged(components[0], component))
+}
+
 // TestReadProperty tests that ReadProperty returns the correct value
 func TestReadProperty(t *testing.T) {
 	url := "https://manual-approval.azurewebsites.net:443/api/approval/triggers/manual/invoke?api-version=2022-05-01&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=<redacted>"
@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -360,23 +361,7 @@ func (k *IngressTargetProvider) ensureNamespace(ctx context.Context, namespace s
 
 // GetValidationRule returns validation rule for the provider
 func (*IngressTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{},
-		OptionalProperties:    []string{},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-		ChangeDetectionProperties: []model.PropertyDesc{
-			{
-				Name: "*", //react to all property changes
-			},
-		},
-		ChangeDetectionMetadata: []model.PropertyDesc{
-			{
-				Name: "annotations.*", //react to all annotation changes
-			},
-		},
-	}
+	return validation.IngressRule()
 }
 
 // deleteConfigMap deletes a configmap
@@ -18,6 +18,7 @@ import (
 	"strconv"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -476,18 +477,7 @@ func (k *KubectlTargetProvider) ensureNamespace(ctx context.Context, namespace s
 
 // GetValidationRule returns validation rule for the provider
 func (*KubectlTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
-	return model.ValidationRule{
-		RequiredProperties:    []string{},
-		OptionalProperties:    []string{"yaml", "resource"},
-		RequiredComponentType: "",
-		RequiredMetadata:      []string{},
-		OptionalMetadata:      []string{},
-		ChangeDetectionProperties: []model.PropertyDesc{
-			{
-				Name: "*", //react to all property changes
-			},
-		},
-	}
+	return validation.KubectlRule()
 }
 
 // ReadYaml reads yaml from url
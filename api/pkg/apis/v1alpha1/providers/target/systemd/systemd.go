@@ -0,0 +1,532 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package systemd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+var sLog = logger.NewLogger("coa.runtime")
+
+const (
+	systemUnitFolder = "/etc/systemd/system"
+	// managedMarker is the first line of the unit files written by the provider. Units without it are never
+	// overwritten or removed.
+	managedMarker = "# Managed by Symphony. Changes to this file are overwritten."
+)
+
+var (
+	validUnitName = regexp.MustCompile(`^[a-zA-Z0-9:_.\\-]+$`)
+	restartValues = []string{"no", "on-success", "on-failure", "on-abnormal", "on-watchdog", "on-abort", "always"}
+)
+
+type SystemdTargetProviderConfig struct {
+	Name string `json:"name"`
+	// UserMode manages the units of the user running Symphony (systemctl --user) instead of system units
+	UserMode bool `json:"userMode,omitempty"`
+	// UnitFolder is where unit files are written. Defaults to /etc/systemd/system, or to the systemd user
+	// folder in user mode.
+	UnitFolder string `json:"unitFolder,omitempty"`
+}
+
+type SystemdTargetProvider struct {
+	Config  SystemdTargetProviderConfig
+	Context *contexts.ManagerContext
+	// Systemctl runs systemctl with the given arguments and returns its output. The systemctl binary is used when
+	// it's not set.
+	Systemctl func(ctx context.Context, args ...string) (string, error)
+}
+
+func SystemdTargetProviderConfigFromMap(properties map[string]string) (SystemdTargetProviderConfig, error) {
+	ret := SystemdTargetProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["userMode"]; ok && v != "" {
+		bVal, err := strconv.ParseBool(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid bool value in the 'userMode' setting of systemd target provider", v1alpha2.BadConfig)
+		}
+		ret.UserMode = bVal
+	}
+	if v, ok := properties["unitFolder"]; ok {
+		ret.UnitFolder = v
+	}
+	return ret, nil
+}
+
+func (i *SystemdTargetProvider) InitWithMap(properties map[string]string) error {
+	config, err := SystemdTargetProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func (i *SystemdTargetProvider) SetContext(ctx *contexts.ManagerContext) {
+	i.Context = ctx
+}
+
+func (i *SystemdTargetProvider) Init(config providers.IProviderConfig) error {
+	_, span := observability.StartSpan("Systemd Target Provider", context.TODO(), &map[string]string{
+		"method": "Init",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Info("  P (Systemd Target): Init()")
+
+	systemdConfig, err := toSystemdTargetProviderConfig(config)
+	if err != nil {
+		sLog.Errorf("  P (Systemd Target): expected SystemdTargetProviderConfig: %+v", err)
+		return err
+	}
+	i.Config = systemdConfig
+	if i.Config.UnitFolder == "" {
+		if i.Config.UserMode {
+			var configDir string
+			configDir, err = os.UserConfigDir()
+			if err != nil {
+				sLog.Errorf("  P (Systemd Target): failed to find the user config folder: %+v", err)
+				return err
+			}
+			i.Config.UnitFolder = filepath.Join(configDir, "systemd", "user")
+		} else {
+			i.Config.UnitFolder = systemUnitFolder
+		}
+	}
+	if i.Systemctl == nil {
+		i.Systemctl = i.runSystemctl
+	}
+	return nil
+}
+
+func toSystemdTargetProviderConfig(config providers.IProviderConfig) (SystemdTargetProviderConfig, error) {
+	ret := SystemdTargetProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (i *SystemdTargetProvider) runSystemctl(ctx context.Context, args ...string) (string, error) {
+	if i.Config.UserMode {
+		args = append([]string{"--user"}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "systemctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// unitName is the name of the unit of a component. It's prefixed with the instance name, so that instances deploying
+// components of the same name to a target don't share their units.
+func unitName(instance string, component string) string {
+	if instance == "" {
+		return component + ".service"
+	}
+	return instance + "-" + component + ".service"
+}
+
+func (i *SystemdTargetProvider) unitPath(unit string) string {
+	return filepath.Join(i.Config.UnitFolder, unit)
+}
+
+func (i *SystemdTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	ctx, span := observability.StartSpan("Systemd Target Provider", ctx, &map[string]string{
+		"method": "Get",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Systemd Target): getting artifacts: %s - %s", deployment.Instance.Scope, deployment.Instance.Name)
+
+	ret := make([]model.ComponentSpec, 0)
+	for _, ref := range references {
+		unit := unitName(deployment.Instance.Name, ref.Component.Name)
+		if !validUnitName.MatchString(unit) {
+			continue
+		}
+		var output string
+		output, err = i.Systemctl(ctx, "show", unit, "--property=LoadState,FragmentPath,ExecStart,Environment,User,Restart,WorkingDirectory,Description")
+		if err != nil {
+			sLog.Errorf("  P (Systemd Target): failed to get unit %s: %+v", unit, err)
+			return nil, err
+		}
+		show := parseShow(output)
+		if show["LoadState"] != "loaded" || show["FragmentPath"] != i.unitPath(unit) {
+			continue
+		}
+		component := model.ComponentSpec{
+			Name:       ref.Component.Name,
+			Type:       ref.Component.Type,
+			Properties: make(map[string]interface{}),
+		}
+		if v := execStartCommand(show["ExecStart"]); v != "" {
+			component.Properties["execStart"] = v
+		}
+		for property, key := range map[string]string{
+			"user":             "User",
+			"restart":          "Restart",
+			"workingDirectory": "WorkingDirectory",
+			"description":      "Description",
+		} {
+			if v := show[key]; v != "" {
+				component.Properties[property] = v
+			}
+		}
+		for _, e := range splitWords(show["Environment"]) {
+			if k, v, ok := strings.Cut(e, "="); ok {
+				component.Properties["env."+k] = v
+			}
+		}
+		ret = append(ret, component)
+	}
+	return ret, nil
+}
+
+func (i *SystemdTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan("Systemd Target Provider", ctx, &map[string]string{
+		"method": "Apply",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Systemd Target): applying artifacts: %s - %s", deployment.Instance.Scope, deployment.Instance.Name)
+
+	injections := &model.ValueInjections{
+		InstanceId: deployment.Instance.Name,
+		SolutionId: deployment.Instance.Solution,
+		TargetId:   deployment.ActiveTarget,
+	}
+
+	err = i.GetValidationRule(ctx).Validate(step.GetComponents())
+	if err != nil {
+		return nil, err
+	}
+	instance := deployment.Instance.Name
+	if instance != "" && !validUnitName.MatchString(instance) {
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("instance name '%s' can't be used in a unit name", instance), v1alpha2.BadConfig)
+		return nil, err
+	}
+	components := step.GetUpdatedComponents()
+	units := make(map[string]string)
+	for _, c := range components {
+		var unit string
+		unit, err = i.renderUnit(c, injections)
+		if err != nil {
+			err = v1alpha2.NewCOAError(err, fmt.Sprintf("invalid component '%s'", c.Name), v1alpha2.BadConfig)
+			return nil, err
+		}
+		units[c.Name] = unit
+	}
+	if isDryRun {
+		err = nil
+		return nil, nil
+	}
+
+	ret := step.PrepareResultMap()
+
+	// write the unit files first, so that a single daemon-reload picks up all of them
+	changed := make(map[string]bool)
+	for _, c := range components {
+		changed[c.Name], err = i.writeUnit(unitName(instance, c.Name), units[c.Name])
+		if err != nil {
+			ret[c.Name] = model.ComponentResultSpec{
+				Status:  v1alpha2.UpdateFailed,
+				Message: err.Error(),
+			}
+			sLog.Errorf("  P (Systemd Target): failed to write unit %s: %+v", unitName(instance, c.Name), err)
+			return ret, err
+		}
+	}
+	if len(components) > 0 {
+		_, err = i.Systemctl(ctx, "daemon-reload")
+		if err != nil {
+			sLog.Errorf("  P (Systemd Target): failed to reload units: %+v", err)
+			return ret, err
+		}
+	}
+	for _, c := range components {
+		action := "start"
+		if changed[c.Name] {
+			action = "restart"
+		}
+		_, err = i.Systemctl(ctx, "enable", unitName(instance, c.Name))
+		if err == nil {
+			_, err = i.Systemctl(ctx, action, unitName(instance, c.Name))
+		}
+		if err != nil {
+			ret[c.Name] = model.ComponentResultSpec{
+				Status:  v1alpha2.UpdateFailed,
+				Message: err.Error(),
+			}
+			sLog.Errorf("  P (Systemd Target): failed to start unit %s: %+v", unitName(instance, c.Name), err)
+			return ret, err
+		}
+		ret[c.Name] = model.ComponentResultSpec{
+			Status:  v1alpha2.Updated,
+			Message: "",
+		}
+	}
+
+	components = step.GetDeletedComponents()
+	removed := false
+	for _, c := range components {
+		var managed bool
+		managed, err = i.isManaged(unitName(instance, c.Name))
+		if err != nil {
+			sLog.Errorf("  P (Systemd Target): failed to read unit %s: %+v", unitName(instance, c.Name), err)
+			return ret, err
+		}
+		if managed {
+			_, err = i.Systemctl(ctx, "disable", "--now", unitName(instance, c.Name))
+			if err == nil {
+				err = os.Remove(i.unitPath(unitName(instance, c.Name)))
+			}
+			if err != nil {
+				ret[c.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.DeleteFailed,
+					Message: err.Error(),
+				}
+				sLog.Errorf("  P (Systemd Target): failed to remove unit %s: %+v", unitName(instance, c.Name), err)
+				return ret, err
+			}
+			removed = true
+		}
+		ret[c.Name] = model.ComponentResultSpec{
+			Status:  v1alpha2.Deleted,
+			Message: "",
+		}
+	}
+	if removed {
+		_, err = i.Systemctl(ctx, "daemon-reload")
+		if err != nil {
+			sLog.Errorf("  P (Systemd Target): failed to reload units: %+v", err)
+			return ret, err
+		}
+	}
+	err = nil
+	return ret, nil
+}
+
+// writeUnit writes a unit file if its content changed, and reports whether it did
+func (i *SystemdTargetProvider) writeUnit(unit string, content string) (bool, error) {
+	path := i.unitPath(unit)
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		if string(existing) == content {
+			return false, nil
+		}
+		if !strings.HasPrefix(string(existing), managedMarker) {
+			return false, fmt.Errorf("unit file %s isn't managed by Symphony", path)
+		}
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(i.Config.UnitFolder, 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, []byte(content), 0644)
+}
+
+// isManaged reports whether a unit file written by the provider exists
+func (i *SystemdTargetProvider) isManaged(unit string) (bool, error) {
+	data, err := os.ReadFile(i.unitPath(unit))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(string(data), managedMarker), nil
+}
+
+// renderUnit renders the unit file of a component
+func (i *SystemdTargetProvider) renderUnit(component model.ComponentSpec, injections *model.ValueInjections) (string, error) {
+	if !validUnitName.MatchString(component.Name) {
+		return "", fmt.Errorf("'%s' isn't a valid unit name", component.Name)
+	}
+	read := func(property string) (string, error) {
+		v := model.ReadPropertyCompat(component.Properties, property, injections)
+		if strings.ContainsAny(v, "\r\n") {
+			return "", fmt.Errorf("property '%s' can't span multiple lines", property)
+		}
+		return v, nil
+	}
+	execStart, err := read("execStart")
+	if err != nil {
+		return "", err
+	}
+	description, err := read("description")
+	if err != nil {
+		return "", err
+	}
+	if description == "" {
+		description = fmt.Sprintf("Symphony component %s", component.Name)
+	}
+	user, err := read("user")
+	if err != nil {
+		return "", err
+	}
+	if user != "" && i.Config.UserMode {
+		return "", fmt.Errorf("property 'user' isn't supported by user units")
+	}
+	restart, err := read("restart")
+	if err != nil {
+		return "", err
+	}
+	if restart != "" && !contains(restartValues, restart) {
+		return "", fmt.Errorf("invalid restart value '%s', expected one of %s", restart, strings.Join(restartValues, ", "))
+	}
+	workingDirectory, err := read("workingDirectory")
+	if err != nil {
+		return "", err
+	}
+	envKeys := make([]string, 0)
+	for k := range component.Properties {
+		if strings.HasPrefix(k, "env.") {
+			envKeys = append(envKeys, k)
+		}
+	}
+	sort.Strings(envKeys)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, managedMarker)
+	fmt.Fprintln(&b, "[Unit]")
+	fmt.Fprintf(&b, "Description=%s\n", description)
+	if !i.Config.UserMode {
+		fmt.Fprintln(&b, "Wants=network-online.target")
+		fmt.Fprintln(&b, "After=network-online.target")
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Service]")
+	fmt.Fprintf(&b, "ExecStart=%s\n", execStart)
+	for _, k := range envKeys {
+		v, err := read(k)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "Environment=%s\n", quoteValue(strings.TrimPrefix(k, "env.")+"="+v))
+	}
+	if user != "" {
+		fmt.Fprintf(&b, "User=%s\n", user)
+	}
+	if restart != "" {
+		fmt.Fprintf(&b, "Restart=%s\n", restart)
+	}
+	if workingDirectory != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", workingDirectory)
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Install]")
+	if i.Config.UserMode {
+		fmt.Fprintln(&b, "WantedBy=default.target")
+	} else {
+		fmt.Fprintln(&b, "WantedBy=multi-user.target")
+	}
+	return b.String(), nil
+}
+
+func (*SystemdTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	return validation.SystemdRule()
+}
+
+// quoteValue quotes an Environment= assignment so that spaces, quotes and backslashes survive systemd's parsing
+func quoteValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// parseShow parses the Key=Value lines printed by systemctl show
+func parseShow(output string) map[string]string {
+	ret := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok {
+			ret[k] = strings.TrimSpace(v)
+		}
+	}
+	return ret
+}
+
+// execStartCommand extracts the command line from the ExecStart value of systemctl show, which looks like
+// "{ path=/usr/bin/app ; argv[]=/usr/bin/app --port 80 ; ignore_errors=no ; ... }"
+func execStartCommand(v string) string {
+	_, argv, ok := strings.Cut(v, "argv[]=")
+	if !ok {
+		return ""
+	}
+	argv, _, _ = strings.Cut(argv, " ; ")
+	return strings.TrimSpace(argv)
+}
+
+// splitWords splits the space separated, optionally double quoted, words printed by systemctl show
+func splitWords(v string) []string {
+	ret := make([]string, 0)
+	var word strings.Builder
+	inWord, quoted, escaped := false, false, false
+	for _, r := range v {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case r == ' ' && !quoted:
+			if inWord {
+				ret = append(ret, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		ret = append(ret, word.String())
+	}
+	return ret
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSystemctl records systemctl calls, and answers "show" from the unit files in the unit folder
+type fakeSystemctl struct {
+	folder string
+	calls  []string
+	active map[string]bool
+}
+
+func (f *fakeSystemctl) run(ctx context.Context, args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch args[0] {
+	case "start", "restart":
+		f.active[args[1]] = true
+	case "disable":
+		delete(f.active, args[2])
+	case "show":
+		path := filepath.Join(f.folder, args[1])
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "LoadState=not-found\nFragmentPath=\n", nil
+		}
+		unit := parseShow(string(data))
+		env := make([]string, 0)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "Environment=") {
+				env = append(env, strings.TrimPrefix(line, "Environment="))
+			}
+		}
+		restart := unit["Restart"]
+		if restart == "" {
+			restart = "no"
+		}
+		return fmt.Sprintf("LoadState=loaded\nFragmentPath=%s\nExecStart={ path=x ; argv[]=%s ; ignore_errors=no ; start_time=[n/a] }\nEnvironment=%s\nUser=%s\nRestart=%s\nWorkingDirectory=%s\nDescription=%s\n",
+			path, unit["ExecStart"], strings.Join(env, " "), unit["User"], restart, unit["WorkingDirectory"], unit["Description"]), nil
+	}
+	return "", nil
+}
+
+func newTestProvider(t *testing.T) (*SystemdTargetProvider, *fakeSystemctl) {
+	folder := t.TempDir()
+	fake := &fakeSystemctl{folder: folder, active: make(map[string]bool)}
+	provider := &SystemdTargetProvider{Systemctl: fake.run}
+	err := provider.Init(SystemdTargetProviderConfig{UnitFolder: folder})
+	assert.Nil(t, err)
+	return provider, fake
+}
+
+func TestSystemdTargetProviderConfigFromMap(t *testing.T) {
+	config, err := SystemdTargetProviderConfigFromMap(map[string]string{
+		"name":       "systemd",
+		"userMode":   "true",
+		"unitFolder": "/tmp/units",
+	})
+	assert.Nil(t, err)
+	assert.True(t, config.UserMode)
+	assert.Equal(t, "/tmp/units", config.UnitFolder)
+
+	_, err = SystemdTargetProviderConfigFromMap(map[string]string{"userMode": "maybe"})
+	assert.NotNil(t, err)
+}
+
+func TestSystemdTargetProviderInitDefaultFolder(t *testing.T) {
+	provider := SystemdTargetProvider{}
+	err := provider.InitWithMap(map[string]string{"name": "systemd"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/etc/systemd/system", provider.Config.UnitFolder)
+
+	t.Setenv("XDG_CONFIG_HOME", "/home/edge/.config")
+	provider = SystemdTargetProvider{}
+	err = provider.InitWithMap(map[string]string{"name": "systemd", "userMode": "true"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/home/edge/.config/systemd/user", provider.Config.UnitFolder)
+}
+
+func TestRenderUnit(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	unit, err := provider.renderUnit(model.ComponentSpec{
+		Name: "sensor",
+		Properties: map[string]interface{}{
+			"execStart":  "/usr/local/bin/sensor --port 9000",
+			"user":       "edge",
+			"restart":    "on-failure",
+			"env.MODE":   "prod",
+			"env.BANNER": `say "hi"`,
+		},
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, managedMarker+`
+[Unit]
+Description=Symphony component sensor
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart=/usr/local/bin/sensor --port 9000
+Environment="BANNER=say \"hi\""
+Environment="MODE=prod"
+User=edge
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`, unit)
+}
+
+func TestRenderUnitInvalid(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	for _, properties := range []map[string]interface{}{
+		{"execStart": "/bin/app", "restart": "sometimes"},
+		{"execStart": "/bin/app\nExecStartPre=/bin/evil"},
+	} {
+		_, err := provider.renderUnit(model.ComponentSpec{Name: "app", Properties: properties}, nil)
+		assert.NotNil(t, err)
+	}
+	_, err := provider.renderUnit(model.ComponentSpec{Name: "../app", Properties: map[string]interface{}{"execStart": "/bin/app"}}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestSystemdApplyGetDelete(t *testing.T) {
+	provider, fake := newTestProvider(t)
+	component := model.ComponentSpec{
+		Name: "sensor",
+		Properties: map[string]interface{}{
+			"execStart": "/usr/local/bin/sensor --port 9000",
+			"restart":   "always",
+			"env.MODE":  "prod mode",
+		},
+	}
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceSpec{Name: "edge"},
+		Solution: model.SolutionSpec{Components: []model.ComponentSpec{component}},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	result, err := provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, result["sensor"].Status)
+	assert.Equal(t, []string{"daemon-reload", "enable edge-sensor.service", "restart edge-sensor.service"}, fake.calls)
+	assert.True(t, fake.active["edge-sensor.service"])
+
+	components, err := provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "/usr/local/bin/sensor --port 9000", components[0].Properties["execStart"])
+	assert.Equal(t, "prod mode", components[0].Properties["env.MODE"])
+	assert.False(t, provider.GetValidationRule(context.Background()).IsComponentChanged(components[0], component))
+
+	// an unchanged unit is started, not restarted
+	fake.calls = nil
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"daemon-reload", "enable edge-sensor.service", "start edge-sensor.service"}, fake.calls)
+
+	fake.calls = nil
+	step.Components[0].Action = "delete"
+	result, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Deleted, result["sensor"].Status)
+	assert.Equal(t, []string{"disable --now edge-sensor.service", "daemon-reload"}, fake.calls)
+	assert.False(t, fake.active["edge-sensor.service"])
+	_, err = os.Stat(filepath.Join(fake.folder, "edge-sensor.service"))
+	assert.True(t, os.IsNotExist(err))
+
+	components, err = provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+}
+
+func TestSystemdUnitsPerInstance(t *testing.T) {
+	provider, fake := newTestProvider(t)
+	component := model.ComponentSpec{
+		Name:       "sensor",
+		Properties: map[string]interface{}{"execStart": "/usr/local/bin/sensor"},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	for _, instance := range []string{"line-1", "line-2"} {
+		_, err := provider.Apply(context.Background(), model.DeploymentSpec{Instance: model.InstanceSpec{Name: instance}}, step, false)
+		assert.Nil(t, err)
+	}
+	assert.True(t, fake.active["line-1-sensor.service"])
+	assert.True(t, fake.active["line-2-sensor.service"])
+
+	// removing the component of one instance leaves the other's unit alone
+	step.Components[0].Action = "delete"
+	_, err := provider.Apply(context.Background(), model.DeploymentSpec{Instance: model.InstanceSpec{Name: "line-1"}}, step, false)
+	assert.Nil(t, err)
+	assert.False(t, fake.active["line-1-sensor.service"])
+	assert.True(t, fake.active["line-2-sensor.service"])
+	_, err = os.Stat(filepath.Join(fake.folder, "line-2-sensor.service"))
+	assert.Nil(t, err)
+
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{Instance: model.InstanceSpec{Name: "../line"}}, step, false)
+	assert.NotNil(t, err)
+}
+
+func TestSystemdKeepsUnmanagedUnits(t *testing.T) {
+	provider, fake := newTestProvider(t)
+	path := filepath.Join(fake.folder, "sshd.service")
+	assert.Nil(t, os.WriteFile(path, []byte("[Service]\nExecStart=/usr/sbin/sshd\n"), 0644))
+	component := model.ComponentSpec{
+		Name:       "sshd",
+		Properties: map[string]interface{}{"execStart": "/bin/false"},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	result, err := provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.UpdateFailed, result["sshd"].Status)
+
+	step.Components[0].Action = "delete"
+	_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, step, false)
+	assert.Nil(t, err)
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+	assert.Empty(t, fake.calls)
+}
+
+func TestSystemdUserUnits(t *testing.T) {
+	testSystemdProvider := os.Getenv("TEST_SYSTEMD_PROVIDER")
+	if testSystemdProvider == "" {
+		t.Skip("Skipping because TEST_SYSTEMD_PROVIDER enviornment variable is not set")
+	}
+	provider := &SystemdTargetProvider{}
+	err := provider.Init(SystemdTargetProviderConfig{UserMode: true})
+	assert.Nil(t, err)
+	component := model.ComponentSpec{
+		Name: "symphony-systemd-test",
+		Properties: map[string]interface{}{
+			"execStart": "/bin/sleep 600",
+			"env.MODE":  "test",
+		},
+	}
+	deployment := model.DeploymentSpec{
+		Solution: model.SolutionSpec{Components: []model.ComponentSpec{component}},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{{Action: "update", Component: component}},
+	}
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	components, err := provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "/bin/sleep 600", components[0].Properties["execStart"])
+	step.Components[0].Action = "delete"
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+}
+
+func TestConformanceSuite(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	conformance.ConformanceSuite(t, provider)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
 )
 
 type ITargetProvider interface {
@@ -22,3 +23,9 @@ type ITargetProvider interface {
 	// apply components to a target
 	Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error)
 }
+
+// ISecretConsumer is implemented by target providers that read secrets, such as registry credentials. The solution
+// manager hands them its secret provider.
+type ISecretConsumer interface {
+	SetSecretProvider(provider secret.ISecretProvider)
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+// Package validation holds the validation rules of the target providers. It doesn't import the providers, so that
+// components can be checked before they're deployed, such as by the admission webhooks, without pulling in the
+// dependencies of every provider.
+package validation
+
+import (
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+)
+
+// ComposeFile is the component property with a compose file to deploy instead of the component's container.*
+// properties
+const ComposeFile = "compose.yaml"
+
+// ContainerProperties are the component properties the Docker target turns into container settings. The property
+// names and formats are the same as the ones the k8s target uses.
+var ContainerProperties = []string{
+	"container.ports",
+	"container.volumeMounts",
+	"container.commands",
+	"container.args",
+	"container.resources",
+	"container.networks",
+	"container.restartPolicy",
+	"container.healthCheck",
+	"container.imagePullPolicy",
+	"container.imagePullSecret",
+}
+
+// ComposeProperties are the component properties the compose target reports by Get and uses for change detection
+var ComposeProperties = []string{
+	ComposeFile,
+	model.ContainerImage,
+	"container.ports",
+	"container.volumeMounts",
+	"container.commands",
+	"container.args",
+	"container.resources",
+	"container.networks",
+	"container.restartPolicy",
+	"container.healthCheck",
+	"container.imagePullPolicy",
+}
+
+var rules = map[string]func() model.ValidationRule{
+	"providers.target.azure.iotedge":  IoTEdgeRule,
+	"providers.target.azure.adu":      ADURule,
+	"providers.target.k8s":            K8sRule,
+	"providers.target.docker":         DockerRule,
+	"providers.target.compose":        ComposeRule,
+	"providers.target.systemd":        SystemdRule,
+	"providers.target.ingress":        IngressRule,
+	"providers.target.kubectl":        KubectlRule,
+	"providers.target.staging":        EmptyRule,
+	"providers.target.script":         EmptyRule,
+	"providers.target.http":           HttpRule,
+	"providers.target.win10.sideload": Win10SideLoadRule,
+	"providers.target.adb":            AdbRule,
+	"providers.target.proxy":          EmptyRule,
+	"providers.target.mqtt":           EmptyRule,
+	"providers.target.mock":           func() model.ValidationRule { return model.ValidationRule{} },
+	"providers.target.configmap":      ConfigMapRule,
+	"providers.target.helm":           HelmRule,
+}
+
+// GetTargetValidationRule returns the validation rule of a target provider type. It returns false for unknown
+// provider types.
+func GetTargetValidationRule(providerType string) (model.ValidationRule, bool) {
+	if rule, ok := rules[providerType]; ok {
+		return rule(), true
+	}
+	return model.ValidationRule{}, false
+}
+
+// EmptyRule accepts any component
+func EmptyRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+	}
+}
+
+func AdbRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{model.AppPackage, model.AppImage},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+	}
+}
+
+func ADURule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{"update.provider", "update.name", "update.version"},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+	}
+}
+
+func IoTEdgeRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{model.ContainerImage, "container.version", "container.type"},
+		OptionalProperties:    []string{"container.restartPolicy", "container.createOptions", "env.*"},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: "container.restartPolicy", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "container.createOptions", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "container.version", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "container.type", IgnoreCase: false, SkipIfMissing: true},
+			{Name: model.ContainerImage, IgnoreCase: false, SkipIfMissing: true},
+			{Name: "desired.*", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
+		},
+	}
+}
+
+func ComposeRule() model.ValidationRule {
+	changeDetection := []model.PropertyDesc{
+		{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
+	}
+	for _, p := range ComposeProperties {
+		changeDetection = append(changeDetection, model.PropertyDesc{Name: p, IgnoreCase: false, SkipIfMissing: true})
+	}
+	return model.ValidationRule{
+		RequiredProperties:        []string{},
+		OptionalProperties:        ComposeProperties,
+		RequiredComponentType:     "",
+		RequiredMetadata:          []string{},
+		OptionalMetadata:          []string{},
+		ChangeDetectionProperties: changeDetection,
+	}
+}
+
+func ConfigMapRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{
+				Name: "*", //react to all property changes
+			},
+		},
+	}
+}
+
+func DockerRule() model.ValidationRule {
+	changeDetection := []model.PropertyDesc{
+		{Name: model.ContainerImage, IgnoreCase: false, SkipIfMissing: false},
+		{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
+	}
+	for _, p := range ContainerProperties {
+		changeDetection = append(changeDetection, model.PropertyDesc{Name: p, IgnoreCase: false, SkipIfMissing: true})
+	}
+	return model.ValidationRule{
+		RequiredProperties:        []string{model.ContainerImage},
+		OptionalProperties:        ContainerProperties,
+		RequiredComponentType:     "",
+		RequiredMetadata:          []string{},
+		OptionalMetadata:          []string{},
+		ChangeDetectionProperties: changeDetection,
+	}
+}
+
+func HelmRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{"chart"},
+		OptionalProperties:    []string{"values"},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: "chart", IgnoreCase: false, SkipIfMissing: true}, //TODO: deep change detection on interface{}
+		},
+	}
+}
+
+func HttpRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties: []string{"http.url"},
+		OptionalProperties: []string{
+			"http.method", "http.body", "http.headers", "http.successCodes",
+			"http.auth.token", "http.auth.username", "http.auth.password",
+			"http.deleteUrl", "http.deleteMethod", "http.getUrl", "http.getJsonPath",
+		},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: "http.body", IgnoreCase: false, SkipIfMissing: true},
+		},
+	}
+}
+
+func IngressRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{
+				Name: "*", //react to all property changes
+			},
+		},
+		ChangeDetectionMetadata: []model.PropertyDesc{
+			{
+				Name: "annotations.*", //react to all annotation changes
+			},
+		},
+	}
+}
+
+func K8sRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{model.ContainerImage},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: model.ContainerImage, IgnoreCase: true, SkipIfMissing: false},
+			{Name: "env.*", IgnoreCase: true, SkipIfMissing: true},
+		},
+	}
+}
+
+func KubectlRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{},
+		OptionalProperties:    []string{"yaml", "resource"},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{
+				Name: "*", //react to all property changes
+			},
+		},
+	}
+}
+
+func SystemdRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{"execStart"},
+		OptionalProperties:    []string{"user", "restart", "workingDirectory", "description"},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: "execStart", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "user", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "restart", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "workingDirectory", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "description", IgnoreCase: false, SkipIfMissing: true},
+			{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
+		},
+	}
+}
+
+func Win10SideLoadRule() model.ValidationRule {
+	return model.ValidationRule{
+		RequiredProperties:    []string{},
+		OptionalProperties:    []string{},
+		RequiredComponentType: "",
+		RequiredMetadata:      []string{},
+		OptionalMetadata:      []string{},
+		ChangeDetectionProperties: []model.PropertyDesc{
+			{Name: "", IsComponentName: true, IgnoreCase: true, PrefixMatch: true},
+		},
+	}
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTargetValidationRule(t *testing.T) {
+	rule, ok := GetTargetValidationRule("providers.target.docker")
+	assert.True(t, ok)
+	assert.Equal(t, []string{model.ContainerImage}, rule.RequiredProperties)
+	assert.Nil(t, rule.Validate([]model.ComponentSpec{
+		{Name: "web", Properties: map[string]interface{}{model.ContainerImage: "nginx"}},
+	}))
+	assert.NotNil(t, rule.Validate([]model.ComponentSpec{{Name: "web"}}))
+
+	_, ok = GetTargetValidationRule("providers.target.unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+)
+
+// evalComponents evaluates the components of a solution. A component with a forEach is repeated for each
+// item of the list its expression returns, and a component with a condition is dropped when the condition
+// evaluates to false. The returned expansions report what happened to these components.
+func evalComponents(context utils.EvaluationContext, components []model.ComponentSpec) ([]model.ComponentSpec, []model.ComponentExpansionSpec, error) {
+	if len(components) == 0 {
+		return components, nil, nil
+	}
+	ret := make([]model.ComponentSpec, 0, len(components))
+	var expansions []model.ComponentExpansionSpec
+	// generated maps the name of a component with forEach or condition to the components it became
+	generated := make(map[string][]string)
+	for _, c := range components {
+		if c.ForEach == "" && c.Condition == "" {
+			component, err := evalComponent(context, c)
+			if err != nil {
+				return nil, expansions, err
+			}
+			ret = append(ret, component)
+			continue
+		}
+		expansion := model.ComponentExpansionSpec{
+			Component:  c.Name,
+			ForEach:    c.ForEach,
+			Condition:  c.Condition,
+			Components: make([]string, 0),
+		}
+		items := []interface{}{nil}
+		if c.ForEach != "" {
+			var err error
+			items, err = evalForEach(context, c)
+			if err != nil {
+				return nil, expansions, err
+			}
+		}
+		for i, item := range items {
+			itemContext := context
+			if c.ForEach != "" {
+				itemContext.Item = item
+				itemContext.Index = i
+			}
+			if c.Condition != "" {
+				include, err := evalCondition(itemContext, c)
+				if err != nil {
+					return nil, expansions, err
+				}
+				if !include {
+					continue
+				}
+			}
+			component := c
+			component.ForEach = ""
+			component.Condition = ""
+			// properties are evaluated in place, so each copy needs its own
+			if c.Metadata != nil {
+				component.Metadata = copyValue(c.Metadata).(map[string]string)
+			}
+			if c.Properties != nil {
+				component.Properties = copyValue(c.Properties).(map[string]interface{})
+			}
+			if c.ForEach != "" {
+				name, err := evalComponentName(itemContext, c.Name, i)
+				if err != nil {
+					return nil, expansions, err
+				}
+				component.Name = name
+			}
+			component, err := evalComponent(itemContext, component)
+			if err != nil {
+				return nil, expansions, err
+			}
+			ret = append(ret, component)
+			expansion.Components = append(expansion.Components, component.Name)
+		}
+		generated[c.Name] = expansion.Components
+		expansions = append(expansions, expansion)
+	}
+	if len(generated) > 0 {
+		// a dependency on a repeated component is a dependency on all of its copies, and a dependency on a
+		// dropped component goes away
+		for i, c := range ret {
+			ret[i].Dependencies = expandDependencies(c.Dependencies, generated)
+		}
+	}
+	names := make(map[string]bool)
+	for _, c := range ret {
+		if names[c.Name] {
+			return nil, expansions, fmt.Errorf("component name '%s' is used more than once", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return ret, expansions, nil
+}
+
+func evalForEach(context utils.EvaluationContext, c model.ComponentSpec) ([]interface{}, error) {
+	parser := NewParser(c.ForEach)
+	val, err := parser.Eval(context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate forEach of component '%s': %s", c.Name, err.Error())
+	}
+	switch v := val.(type) {
+	case []interface{}:
+		return v, nil
+	case []string:
+		ret := make([]interface{}, len(v))
+		for i, s := range v {
+			ret[i] = s
+		}
+		return ret, nil
+	case string:
+		var ret []interface{}
+		if err := json.Unmarshal([]byte(v), &ret); err == nil {
+			return ret, nil
+		}
+	}
+	return nil, fmt.Errorf("forEach of component '%s' must evaluate to a list, found %v", c.Name, val)
+}
+
+func evalCondition(context utils.EvaluationContext, c model.ComponentSpec) (bool, error) {
+	parser := NewParser(c.Condition)
+	val, err := parser.Eval(context)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition of component '%s': %s", c.Name, err.Error())
+	}
+	include, ok := toBool(val)
+	if !ok {
+		return false, fmt.Errorf("condition of component '%s' must evaluate to true or false, found %v", c.Name, val)
+	}
+	return include, nil
+}
+
+// evalComponentName names a copy of a repeated component. A name without expressions gets the item index
+// as a suffix, so that copies don't collide.
+func evalComponentName(context utils.EvaluationContext, name string, index int) (string, error) {
+	if !strings.Contains(name, "${{") {
+		return fmt.Sprintf("%s-%d", name, index), nil
+	}
+	parser := NewParser(name)
+	val, err := parser.Eval(context)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate name of component '%s': %s", name, err.Error())
+	}
+	return FormatAsString(val), nil
+}
+
+// expandAssignments assigns the copies of a repeated component to the targets the component was assigned
+// to, and removes dropped components from target assignments
+func expandAssignments(assignments map[string]string, expansions []model.ComponentExpansionSpec) map[string]string {
+	if len(assignments) == 0 || len(expansions) == 0 {
+		return assignments
+	}
+	ret := make(map[string]string, len(assignments))
+	for target, assignment := range assignments {
+		for _, e := range expansions {
+			var sb strings.Builder
+			for _, name := range e.Components {
+				sb.WriteString("{" + name + "}")
+			}
+			assignment = strings.ReplaceAll(assignment, "{"+e.Component+"}", sb.String())
+		}
+		ret[target] = assignment
+	}
+	return ret
+}
+
+func expandDependencies(dependencies []string, generated map[string][]string) []string {
+	if len(dependencies) == 0 {
+		return dependencies
+	}
+	ret := make([]string, 0, len(dependencies))
+	for _, d := range dependencies {
+		if names, ok := generated[d]; ok {
+			ret = append(ret, names...)
+		} else {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]string:
+		ret := make(map[string]string, len(v))
+		for k, s := range v {
+			ret[k] = s
+		}
+		return ret
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ret[k] = copyValue(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = copyValue(item)
+		}
+		return ret
+	}
+	return val
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config"
+)
+
+// DependencyRecorder wraps a config provider and records the configuration objects and overlays that are read
+// through it. Objects pinned to a revision are not recorded because they never change.
+type DependencyRecorder struct {
+	Provider config.IExtConfigProvider
+	catalogs map[string]bool
+	overlays map[string]bool
+}
+
+func NewDependencyRecorder(provider config.IExtConfigProvider) *DependencyRecorder {
+	return &DependencyRecorder{
+		Provider: provider,
+		catalogs: make(map[string]bool),
+		overlays: make(map[string]bool),
+	}
+}
+
+func (r *DependencyRecorder) Get(object string, field string, overlays []string, localContext interface{}) (interface{}, error) {
+	r.record(object, overlays)
+	return r.Provider.Get(object, field, overlays, localContext)
+}
+
+func (r *DependencyRecorder) GetObject(object string, overlays []string, localContext interface{}) (map[string]interface{}, error) {
+	r.record(object, overlays)
+	return r.Provider.GetObject(object, overlays, localContext)
+}
+
+// Dependencies returns the recorded catalogs and overlays, sorted by name
+func (r *DependencyRecorder) Dependencies() model.DeploymentDependencies {
+	return model.DeploymentDependencies{
+		Catalogs: sortedKeys(r.catalogs),
+		Overlays: sortedKeys(r.overlays),
+	}
+}
+
+func (r *DependencyRecorder) record(object string, overlays []string) {
+	if name, ok := dependencyName(object); ok {
+		r.catalogs[name] = true
+	}
+	for _, overlay := range overlays {
+		if name, ok := dependencyName(overlay); ok {
+			r.overlays[name] = true
+		}
+	}
+}
+
+// dependencyName strips the optional config provider prefix and reference brackets from a config object name
+func dependencyName(object string) (string, bool) {
+	if strings.Index(object, ":") > 0 {
+		object = object[strings.Index(object, ":")+1:]
+	}
+	name, revision, err := model.ParseCatalogReference(object)
+	if err != nil || revision != 0 || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(set))
+	for k := range set {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ExpressionCacheSize is the number of parsed expressions kept in memory
+const ExpressionCacheSize = 4096
+
+// builtinFunctions are the functions implemented in FunctionNode.Eval. Functions in exprFunctions are
+// known as well.
+var builtinFunctions = map[string]bool{
+	"param":    true,
+	"property": true,
+	"input":    true,
+	"output":   true,
+	"equal":    true,
+	"and":      true,
+	"or":       true,
+	"not":      true,
+	"gt":       true,
+	"ge":       true,
+	"if":       true,
+	"in":       true,
+	"lt":       true,
+	"between":  true,
+	"le":       true,
+	"config":   true,
+	"secret":   true,
+	"instance": true,
+	"val":      true,
+	"context":  true,
+	"item":     true,
+	"index":    true,
+	"json":     true,
+	"default":  true,
+	"coalesce": true,
+}
+
+func isKnownFunction(name string) bool {
+	if builtinFunctions[name] {
+		return true
+	}
+	_, ok := exprFunctions[name]
+	return ok
+}
+
+// compiledExpression is the parse result of the text between ${{ and }}
+type compiledExpression struct {
+	nodes []Node
+	err   error
+	// column is the 1-based position in the text of the token where parsing failed
+	column int
+}
+
+// expressionCache is a least recently used cache of compiled expressions, keyed by expression text
+type expressionCache struct {
+	lock     sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type expressionCacheEntry struct {
+	text       string
+	expression *compiledExpression
+}
+
+func newExpressionCache(capacity int) *expressionCache {
+	return &expressionCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *expressionCache) get(text string) (*compiledExpression, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[text]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*expressionCacheEntry).expression, true
+	}
+	return nil, false
+}
+
+func (c *expressionCache) add(text string, expression *compiledExpression) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[text]; ok {
+		c.order.MoveToFront(e)
+		e.Value.(*expressionCacheEntry).expression = expression
+		return
+	}
+	c.items[text] = c.order.PushFront(&expressionCacheEntry{text: text, expression: expression})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*expressionCacheEntry).text)
+	}
+}
+
+func (c *expressionCache) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+var expressions = newExpressionCache(ExpressionCacheSize)
+
+// compile parses the text between ${{ and }}, using the cache when the text has been parsed before.
+// Parse errors are cached as well, as parsing doesn't depend on the evaluation context.
+func compile(text string) *compiledExpression {
+	if c, ok := expressions.get(text); ok {
+		return c
+	}
+	p := newExpressionParser(text)
+	nodes, err := p.parse()
+	c := &compiledExpression{nodes: nodes, err: err}
+	if err != nil {
+		pos := p.s.Position
+		if !pos.IsValid() {
+			pos = p.s.Pos()
+		}
+		c.column = len(text) - len(strings.TrimLeft(text, " \t\r\n")) + pos.Offset + 1
+	}
+	expressions.add(text, c)
+	return c
+}
+
+func compileExpression(text string) ([]Node, error) {
+	c := compile(text)
+	return c.nodes, c.err
+}
+
+// ExpressionError is a problem found in a ${{ }} expression by ValidateExpressions
+type ExpressionError struct {
+	// Path locates the value holding the expression, such as spec.components[0].properties.image
+	Path string `json:"path,omitempty"`
+	// Expression is the full ${{ }} expression
+	Expression string `json:"expression"`
+	// Offset is the 0-based position of the expression in the value
+	Offset int `json:"offset"`
+	// Column is the 1-based position of the error in the expression, when known
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ExpressionError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s: %s: %s", e.Path, e.Expression, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Expression, e.Message)
+}
+
+// ValidateExpression parses all ${{ }} expressions in a string value, and reports syntax errors, unknown
+// function names and unclosed expressions. It doesn't evaluate anything.
+func ValidateExpression(path string, text string) []ExpressionError {
+	errors := make([]ExpressionError, 0)
+	start := 0
+	for _, l := range expressionPattern.FindAllStringIndex(text, -1) {
+		errors = append(errors, unclosedExpressions(path, text[start:l[0]], start)...)
+		start = l[1]
+		expression := text[l[0]:l[1]]
+		c := compile(expression[3 : len(expression)-2])
+		if c.err != nil {
+			errors = append(errors, ExpressionError{
+				Path:       path,
+				Expression: expression,
+				Offset:     l[0],
+				Column:     c.column + 3,
+				Message:    c.err.Error(),
+			})
+			continue
+		}
+		for _, name := range functionNames(c.nodes) {
+			if !isKnownFunction(name) {
+				errors = append(errors, ExpressionError{
+					Path:       path,
+					Expression: expression,
+					Offset:     l[0],
+					Message:    fmt.Sprintf("invalid function name: '%s'", name),
+				})
+			}
+		}
+	}
+	return append(errors, unclosedExpressions(path, text[start:], start)...)
+}
+
+// ValidateExpressions validates the expressions in all string values of an object, such as a solution,
+// campaign or catalog decoded from JSON
+func ValidateExpressions(obj interface{}) []ExpressionError {
+	errors := make([]ExpressionError, 0)
+	walkStrings("", obj, func(path string, value string) {
+		errors = append(errors, ValidateExpression(path, value)...)
+	})
+	return errors
+}
+
+func unclosedExpressions(path string, text string, offset int) []ExpressionError {
+	i := strings.Index(text, "${{")
+	if i < 0 {
+		return nil
+	}
+	return []ExpressionError{{
+		Path:       path,
+		Expression: text[i:],
+		Offset:     offset + i,
+		Message:    "expression is not closed with '}}'",
+	}}
+}
+
+func walkStrings(path string, obj interface{}, visit func(path string, value string)) {
+	switch v := obj.(type) {
+	case string:
+		visit(path, v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkStrings(joinPath(path, k), v[k], visit)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkStrings(fmt.Sprintf("%s[%d]", path, i), item, visit)
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func functionNames(nodes []Node) []string {
+	names := make([]string, 0)
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch v := n.(type) {
+		case *FunctionNode:
+			names = append(names, v.Name)
+			for _, a := range v.Args {
+				walk(a)
+			}
+		case *UnaryNode:
+			walk(v.Expr)
+		case *BinaryNode:
+			walk(v.Left)
+			walk(v.Right)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileIsCached(t *testing.T) {
+	first := compile("$upper(cached)")
+	second := compile("$upper(cached)")
+	assert.Same(t, first, second)
+
+	parser := NewParser("${{$upper(cached)}}")
+	val, err := parser.Eval(utils.EvaluationContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, "CACHED", val)
+}
+
+func TestExpressionCacheEviction(t *testing.T) {
+	cache := newExpressionCache(2)
+	cache.add("a", &compiledExpression{})
+	cache.add("b", &compiledExpression{})
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+	cache.add("c", &compiledExpression{})
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	_, ok = cache.get("c")
+	assert.True(t, ok)
+}
+
+func TestBuiltinFunctionsAreKnown(t *testing.T) {
+	for name := range builtinFunctions {
+		parser := NewParser(fmt.Sprintf("${{$%s()}}", name))
+		_, err := parser.Eval(utils.EvaluationContext{})
+		if err != nil {
+			assert.NotContains(t, err.Error(), "invalid function name", name)
+		}
+	}
+}
+
+func TestValidateExpression(t *testing.T) {
+	errors := ValidateExpression("image", "repo/${{$property(name)}}:${{$default($property(tag), latest)}}")
+	assert.Equal(t, 0, len(errors))
+
+	errors = ValidateExpression("image", "repo/${{$uper(name)}}")
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "image", errors[0].Path)
+	assert.Equal(t, "${{$uper(name)}}", errors[0].Expression)
+	assert.Equal(t, 5, errors[0].Offset)
+	assert.Equal(t, "invalid function name: 'uper'", errors[0].Message)
+
+	errors = ValidateExpression("image", "${{$equal(a, b}}")
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "$equal() is missing a closing parenthesis", errors[0].Message)
+	assert.Equal(t, 15, errors[0].Column)
+
+	errors = ValidateExpression("image", "${{$upper(a)}} and ${{$upper(b)")
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, 19, errors[0].Offset)
+	assert.Equal(t, "expression is not closed with '}}'", errors[0].Message)
+}
+
+func TestValidateExpressions(t *testing.T) {
+	var obj interface{}
+	err := json.Unmarshal([]byte(`{
+		"metadata": {"name": "app"},
+		"spec": {
+			"components": [
+				{"name": "a", "properties": {"image": "${{$param(image)}}"}},
+				{"name": "b", "properties": {"image": "${{$parm(image)}}", "tag": "${{$config(cfg, tag}}"}}
+			]
+		}
+	}`), &obj)
+	assert.Nil(t, err)
+	errors := ValidateExpressions(obj)
+	assert.Equal(t, 2, len(errors))
+	assert.Equal(t, "spec.components[1].properties.image", errors[0].Path)
+	assert.Equal(t, "invalid function name: 'parm'", errors[0].Message)
+	assert.Equal(t, "spec.components[1].properties.tag", errors[1].Path)
+	assert.Equal(t, "$config() is missing a closing parenthesis", errors[1].Message)
+}
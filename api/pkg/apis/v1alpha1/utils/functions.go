@@ -0,0 +1,402 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// exprFunction is an expression function that works on evaluated arguments. Functions that need to
+// see evaluation errors of their arguments, like $default(), are implemented in FunctionNode.Eval.
+type exprFunction struct {
+	minArgs int
+	maxArgs int // -1 for no limit
+	eval    func(name string, args []interface{}) (interface{}, error)
+}
+
+// nowFunc returns the current time. Tests replace it.
+var nowFunc = time.Now
+
+var exprFunctions = map[string]exprFunction{
+	"concat":     {1, -1, concatFunc},
+	"split":      {2, 2, splitFunc},
+	"join":       {2, 2, joinFunc},
+	"upper":      {1, 1, upperFunc},
+	"lower":      {1, 1, lowerFunc},
+	"replace":    {3, 3, replaceFunc},
+	"trim":       {1, 2, trimFunc},
+	"len":        {1, 1, lenFunc},
+	"base64enc":  {1, 1, base64EncFunc},
+	"base64dec":  {1, 1, base64DecFunc},
+	"sha256":     {1, 1, sha256Func},
+	"toInt":      {1, 1, toIntFunc},
+	"toString":   {1, 1, toStringFunc},
+	"keys":       {1, 1, keysFunc},
+	"values":     {1, 1, valuesFunc},
+	"merge":      {2, -1, mergeFunc},
+	"now":        {0, 1, nowFunction},
+	"formatTime": {2, 2, formatTimeFunc},
+	"match":      {2, 2, matchFunc},
+	"capture":    {2, 3, captureFunc},
+}
+
+func (f exprFunction) checkArgs(name string, count int) error {
+	if count >= f.minArgs && (f.maxArgs < 0 || count <= f.maxArgs) {
+		return nil
+	}
+	var expected string
+	switch {
+	case f.maxArgs < 0:
+		expected = fmt.Sprintf("at least %d %s", f.minArgs, plural(f.minArgs, "argument"))
+	case f.minArgs == f.maxArgs:
+		expected = fmt.Sprintf("%d %s", f.minArgs, plural(f.minArgs, "argument"))
+	default:
+		expected = fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+	return fmt.Errorf("$%s() expects %s, found %d", name, expected, count)
+}
+
+func plural(count int, word string) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// isEmpty tells if $default() and $coalesce() should skip a value
+func isEmpty(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// toList reads a list argument, which can also be a JSON array
+func toList(name string, val interface{}) ([]interface{}, error) {
+	switch v := val.(type) {
+	case []interface{}:
+		return v, nil
+	case []string:
+		ret := make([]interface{}, len(v))
+		for i, s := range v {
+			ret[i] = s
+		}
+		return ret, nil
+	case string:
+		var ret []interface{}
+		if err := json.Unmarshal([]byte(v), &ret); err == nil {
+			return ret, nil
+		}
+	}
+	return nil, fmt.Errorf("$%s() expects a list, found %v", name, val)
+}
+
+// toMap reads a map argument, which can also be a JSON object
+func toMap(name string, val interface{}) (map[string]interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case map[string]string:
+		ret := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			ret[k] = s
+		}
+		return ret, nil
+	case string:
+		var ret map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &ret); err == nil && ret != nil {
+			return ret, nil
+		}
+	}
+	return nil, fmt.Errorf("$%s() expects a map, found %v", name, val)
+}
+
+func toInt(name string, val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return int64(f), nil
+		}
+	}
+	return 0, fmt.Errorf("$%s() expects an integer, found %v", name, val)
+}
+
+func concatFunc(name string, args []interface{}) (interface{}, error) {
+	lists := make([]interface{}, 0)
+	allLists := true
+	for _, a := range args {
+		switch v := a.(type) {
+		case []interface{}:
+			lists = append(lists, v...)
+		case []string:
+			for _, s := range v {
+				lists = append(lists, s)
+			}
+		default:
+			allLists = false
+		}
+	}
+	if allLists {
+		return lists, nil
+	}
+	var sb strings.Builder
+	for _, a := range args {
+		sb.WriteString(FormatAsString(a))
+	}
+	return sb.String(), nil
+}
+
+func splitFunc(name string, args []interface{}) (interface{}, error) {
+	parts := strings.Split(FormatAsString(args[0]), FormatAsString(args[1]))
+	ret := make([]interface{}, len(parts))
+	for i, p := range parts {
+		ret[i] = p
+	}
+	return ret, nil
+}
+
+func joinFunc(name string, args []interface{}) (interface{}, error) {
+	list, err := toList(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(list))
+	for i, v := range list {
+		parts[i] = FormatAsString(v)
+	}
+	return strings.Join(parts, FormatAsString(args[1])), nil
+}
+
+func upperFunc(name string, args []interface{}) (interface{}, error) {
+	return strings.ToUpper(FormatAsString(args[0])), nil
+}
+
+func lowerFunc(name string, args []interface{}) (interface{}, error) {
+	return strings.ToLower(FormatAsString(args[0])), nil
+}
+
+func replaceFunc(name string, args []interface{}) (interface{}, error) {
+	return strings.ReplaceAll(FormatAsString(args[0]), FormatAsString(args[1]), FormatAsString(args[2])), nil
+}
+
+func trimFunc(name string, args []interface{}) (interface{}, error) {
+	if len(args) == 2 {
+		return strings.Trim(FormatAsString(args[0]), FormatAsString(args[1])), nil
+	}
+	return strings.TrimSpace(FormatAsString(args[0])), nil
+}
+
+func lenFunc(name string, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case []interface{}:
+		return int64(len(v)), nil
+	case []string:
+		return int64(len(v)), nil
+	case map[string]interface{}:
+		return int64(len(v)), nil
+	case map[string]string:
+		return int64(len(v)), nil
+	}
+	return int64(utf8.RuneCountInString(FormatAsString(args[0]))), nil
+}
+
+func base64EncFunc(name string, args []interface{}) (interface{}, error) {
+	return base64.StdEncoding.EncodeToString([]byte(FormatAsString(args[0]))), nil
+}
+
+func base64DecFunc(name string, args []interface{}) (interface{}, error) {
+	data, err := base64.StdEncoding.DecodeString(FormatAsString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("$%s() expects a base64 string: %s", name, err.Error())
+	}
+	return string(data), nil
+}
+
+func sha256Func(name string, args []interface{}) (interface{}, error) {
+	sum := sha256.Sum256([]byte(FormatAsString(args[0])))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func toIntFunc(name string, args []interface{}) (interface{}, error) {
+	return toInt(name, args[0])
+}
+
+func toStringFunc(name string, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return "", nil
+	}
+	return FormatAsString(args[0]), nil
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func keysFunc(name string, args []interface{}) (interface{}, error) {
+	m, err := toMap(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(m))
+	for _, k := range sortedMapKeys(m) {
+		ret = append(ret, k)
+	}
+	return ret, nil
+}
+
+func valuesFunc(name string, args []interface{}) (interface{}, error) {
+	m, err := toMap(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(m))
+	for _, k := range sortedMapKeys(m) {
+		ret = append(ret, m[k])
+	}
+	return ret, nil
+}
+
+func mergeFunc(name string, args []interface{}) (interface{}, error) {
+	ret := make(map[string]interface{})
+	for _, a := range args {
+		m, err := toMap(name, a)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			ret[k] = v
+		}
+	}
+	return ret, nil
+}
+
+// timeLayout maps the layout names of the time package, and "unix", to layouts
+func timeLayout(layout string) string {
+	switch layout {
+	case "RFC3339":
+		return time.RFC3339
+	case "RFC3339Nano":
+		return time.RFC3339Nano
+	case "RFC1123":
+		return time.RFC1123
+	case "RFC822":
+		return time.RFC822
+	case "Kitchen":
+		return time.Kitchen
+	}
+	return layout
+}
+
+func formatTime(t time.Time, layout string) interface{} {
+	if layout == "unix" {
+		return t.Unix()
+	}
+	return t.Format(timeLayout(layout))
+}
+
+func nowFunction(name string, args []interface{}) (interface{}, error) {
+	layout := "RFC3339"
+	if len(args) == 1 {
+		layout = FormatAsString(args[0])
+	}
+	return formatTime(nowFunc().UTC(), layout), nil
+}
+
+func formatTimeFunc(name string, args []interface{}) (interface{}, error) {
+	var t time.Time
+	switch v := args[0].(type) {
+	case int64:
+		t = time.Unix(v, 0).UTC()
+	case float64:
+		t = time.Unix(int64(v), 0).UTC()
+	default:
+		var err error
+		t, err = time.Parse(time.RFC3339Nano, FormatAsString(v))
+		if err != nil {
+			return nil, fmt.Errorf("$%s() expects an RFC3339 time or unix seconds, found %v", name, v)
+		}
+	}
+	return formatTime(t, FormatAsString(args[1])), nil
+}
+
+func compilePattern(name string, pattern interface{}) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(FormatAsString(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("$%s() has an invalid pattern: %s", name, err.Error())
+	}
+	return re, nil
+}
+
+func matchFunc(name string, args []interface{}) (interface{}, error) {
+	re, err := compilePattern(name, args[1])
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(FormatAsString(args[0])), nil
+}
+
+// captureFunc returns a capturing group, by index or by name, of the first match. It returns an empty
+// string when the pattern doesn't match.
+func captureFunc(name string, args []interface{}) (interface{}, error) {
+	re, err := compilePattern(name, args[1])
+	if err != nil {
+		return nil, err
+	}
+	group := 1
+	if len(args) == 3 {
+		if s, ok := args[2].(string); ok && re.SubexpIndex(s) >= 0 {
+			group = re.SubexpIndex(s)
+		} else {
+			i, err := toInt(name, args[2])
+			if err != nil {
+				return nil, fmt.Errorf("$%s() expects a group index or name, found %v", name, args[2])
+			}
+			group = int(i)
+		}
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return nil, fmt.Errorf("$%s() pattern has no group %d", name, group)
+	}
+	m := re.FindStringSubmatch(FormatAsString(args[0]))
+	if m == nil {
+		return "", nil
+	}
+	return m[group], nil
+}
@@ -828,6 +828,39 @@ func (n *FunctionNode) Eval(context utils.EvaluationContext) (interface{}, error
 			}
 		}
 		return nil, fmt.Errorf("$val() or $context() expects 0 or 1 argument, found %d", len(n.Args))
+	case "item":
+		if context.Item == nil {
+			return nil, errors.New("$item() can only be used in a component with forEach")
+		}
+		if len(n.Args) == 0 {
+			return context.Item, nil
+		}
+		if len(n.Args) == 1 {
+			obj, err := n.Args[0].Eval(context)
+			if err != nil {
+				return nil, err
+			}
+			path := FormatAsString(obj)
+			if strings.HasPrefix(path, "$") || strings.HasPrefix(path, "{$") {
+				return JsonPathQuery(context.Item, path)
+			}
+			if mobj, ok := context.Item.(map[string]interface{}); ok {
+				if v, ok := mobj[path]; ok {
+					return v, nil
+				}
+				return nil, fmt.Errorf("key %s is not found in item", path)
+			}
+			return nil, fmt.Errorf("item '%v' is not a map", context.Item)
+		}
+		return nil, fmt.Errorf("$item() expects 0 or 1 argument, found %d", len(n.Args))
+	case "index":
+		if len(n.Args) == 0 {
+			if context.Item == nil {
+				return nil, errors.New("$index() can only be used in a component with forEach")
+			}
+			return int64(context.Index), nil
+		}
+		return nil, fmt.Errorf("$index() expects 0 arguments, found %d", len(n.Args))
 	case "json":
 		if len(n.Args) == 1 {
 			val, err := n.Args[0].Eval(context)
@@ -841,6 +874,40 @@ func (n *FunctionNode) Eval(context utils.EvaluationContext) (interface{}, error
 			return string(jData), nil
 		}
 		return nil, fmt.Errorf("$json() expects 1 argument, fount %d", len(n.Args))
+	case "default":
+		if len(n.Args) == 2 {
+			val, err := n.Args[0].Eval(context)
+			if err != nil || isEmpty(val) {
+				return n.Args[1].Eval(context)
+			}
+			return val, nil
+		}
+		return nil, fmt.Errorf("$default() expects 2 arguments, found %d", len(n.Args))
+	case "coalesce":
+		if len(n.Args) >= 1 {
+			for _, arg := range n.Args {
+				val, err := arg.Eval(context)
+				if err == nil && !isEmpty(val) {
+					return val, nil
+				}
+			}
+			return "", nil
+		}
+		return nil, fmt.Errorf("$coalesce() expects at least 1 argument, found %d", len(n.Args))
+	}
+	if f, ok := exprFunctions[n.Name]; ok {
+		if err := f.checkArgs(n.Name, len(n.Args)); err != nil {
+			return nil, err
+		}
+		args := make([]interface{}, len(n.Args))
+		for i, arg := range n.Args {
+			val, err := arg.Eval(context)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = val
+		}
+		return f.eval(n.Name, args)
 	}
 	return nil, fmt.Errorf("invalid function name: '%s'", n.Name)
 }
@@ -856,9 +923,10 @@ type ExpressionParser struct {
 	text  string
 }
 
+var expressionPattern = regexp.MustCompile(`(\${{.*?}})`)
+
 func NewParser(text string) *Parser {
-	re := regexp.MustCompile(`(\${{.*?}})`)
-	loc := re.FindAllStringIndex(text, -1)
+	loc := expressionPattern.FindAllStringIndex(text, -1)
 
 	segments := make([]string, 0, len(loc)*2+1)
 	start := 0
@@ -883,9 +951,11 @@ func (p *Parser) Eval(context utils.EvaluationContext) (interface{}, error) {
 	results := make([]interface{}, 0)
 	for _, s := range p.Segments {
 		if strings.HasPrefix(s, "${{") && strings.HasSuffix(s, "}}") {
-			text := s[3 : len(s)-2]
-			parser := newExpressionParser(text)
-			n, err := parser.Eval(context)
+			nodes, err := compileExpression(s[3 : len(s)-2])
+			if err != nil {
+				return nil, err
+			}
+			n, err := evalNodes(nodes, context)
 			if err != nil {
 				return nil, err
 			}
@@ -913,6 +983,9 @@ func newExpressionParser(text string) *ExpressionParser {
 	var s scanner.Scanner // TODO: this is mostly used to scan go code, we should use a custom scanner
 	s.Init(strings.NewReader(strings.TrimSpace(text)))
 	s.Mode = scanner.ScanIdents | scanner.ScanChars | scanner.ScanStrings | scanner.ScanInts
+	// quoted strings are scanned as char literals, so the scanner would report every string longer than
+	// one character, or holding a regular expression escape, as an invalid char literal
+	s.Error = func(*scanner.Scanner, string) {}
 	p := &ExpressionParser{
 		s:    &s,
 		text: text,
@@ -922,61 +995,78 @@ func newExpressionParser(text string) *ExpressionParser {
 }
 
 func (p *ExpressionParser) Eval(context utils.EvaluationContext) (interface{}, error) {
-	var ret interface{}
+	nodes, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return evalNodes(nodes, context)
+}
+
+// parse reads all expressions in the text. Nodes don't hold evaluation state, so the result can be
+// cached and evaluated many times.
+func (p *ExpressionParser) parse() ([]Node, error) {
+	nodes := make([]Node, 0, 1)
 	for {
 		n, err := p.expr(false)
 		if err != nil {
 			return nil, err
 		}
-		if _, ok := n.(*NullNode); !ok {
-			v, r := n.Eval(context)
-			if r != nil {
-				return "", r
-			}
-			if vt, ok := v.([]string); ok {
-				if ret == nil {
-					ret = vt
-				} else if vr, o := ret.([]string); o {
-					vr = append(vr, vt...)
-					ret = vr
-				} else {
-					jData, _ := json.Marshal(v)
-					ret = fmt.Sprintf("%v%v", ret, string(jData))
-				}
-			} else if vt, ok := v.([]interface{}); ok {
-				if ret == nil {
-					ret = vt
-				} else if vr, o := ret.([]interface{}); o {
-					vr = append(vr, vt...)
-					ret = vr
-				} else {
-					jData, _ := json.Marshal(v)
-					ret = fmt.Sprintf("%v%v", ret, string(jData))
-				}
-			} else if vt, ok := v.(map[string]interface{}); ok {
-				if ret == nil {
-					ret = vt
-				} else if vr, o := ret.(map[string]interface{}); o {
-					for k, v := range vt {
-						vr[k] = v
-					}
-					ret = vr
-				} else {
-					jData, _ := json.Marshal(v)
-					ret = fmt.Sprintf("%v%v", ret, string(jData))
-				}
+		if _, ok := n.(*NullNode); ok {
+			return nodes, nil
+		}
+		nodes = append(nodes, n)
+		p.next()
+	}
+}
+
+func evalNodes(nodes []Node, context utils.EvaluationContext) (interface{}, error) {
+	var ret interface{}
+	for _, n := range nodes {
+		v, r := n.Eval(context)
+		if r != nil {
+			return "", r
+		}
+		if vt, ok := v.([]string); ok {
+			if ret == nil {
+				ret = vt
+			} else if vr, o := ret.([]string); o {
+				vr = append(vr, vt...)
+				ret = vr
 			} else {
-				if ret == nil {
-					ret = v
-				} else {
-					ret = fmt.Sprintf("%v%v", ret, v)
+				jData, _ := json.Marshal(v)
+				ret = fmt.Sprintf("%v%v", ret, string(jData))
+			}
+		} else if vt, ok := v.([]interface{}); ok {
+			if ret == nil {
+				ret = vt
+			} else if vr, o := ret.([]interface{}); o {
+				vr = append(vr, vt...)
+				ret = vr
+			} else {
+				jData, _ := json.Marshal(v)
+				ret = fmt.Sprintf("%v%v", ret, string(jData))
+			}
+		} else if vt, ok := v.(map[string]interface{}); ok {
+			if ret == nil {
+				ret = vt
+			} else if vr, o := ret.(map[string]interface{}); o {
+				for k, v := range vt {
+					vr[k] = v
 				}
+				ret = vr
+			} else {
+				jData, _ := json.Marshal(v)
+				ret = fmt.Sprintf("%v%v", ret, string(jData))
 			}
 		} else {
-			return ret, nil
+			if ret == nil {
+				ret = v
+			} else {
+				ret = fmt.Sprintf("%v%v", ret, v)
+			}
 		}
-		p.next()
 	}
+	return ret, nil
 }
 
 func (p *ExpressionParser) next() {
@@ -1259,6 +1349,9 @@ func (p *ExpressionParser) function() (Node, error) {
 	}
 	args := []Node{}
 	for p.token != CPAREN {
+		if p.token == EOF {
+			return nil, fmt.Errorf("$%s() is missing a closing parenthesis", name)
+		}
 		node, err := p.expr(true)
 		if err != nil {
 			return nil, err
@@ -1279,38 +1372,75 @@ func (p *ExpressionParser) function() (Node, error) {
 }
 
 func EvaluateDeployment(context utils.EvaluationContext) (model.DeploymentSpec, error) {
-	if deploymentSpec, ok := context.DeploymentSpec.(model.DeploymentSpec); ok {
-		for ic, c := range deploymentSpec.Solution.Components {
+	deploymentSpec, _, err := EvaluateDeploymentWithDependencies(context)
+	return deploymentSpec, err
+}
 
-			val, err := evalProperties(context, c.Metadata)
-			if err != nil {
-				return deploymentSpec, err
-			}
-			if val != nil {
-				metadata, ok := val.(map[string]string)
-				if !ok {
-					return deploymentSpec, fmt.Errorf("metadata must be a map")
-				}
-				stringMap := make(map[string]string)
-				for k, v := range metadata {
-					stringMap[k] = fmt.Sprintf("%v", v)
-				}
-				deploymentSpec.Solution.Components[ic].Metadata = stringMap
-			}
+// EvaluateDeploymentWithDependencies evaluates a deployment and returns the configuration catalogs and
+// overlays that were read through $config() expressions along the way.
+func EvaluateDeploymentWithDependencies(context utils.EvaluationContext) (model.DeploymentSpec, model.DeploymentDependencies, error) {
+	result, err := DryRunDeployment(context)
+	return result.Deployment, result.Dependencies, err
+}
 
-			val, err = evalProperties(context, c.Properties)
-			if err != nil {
-				return deploymentSpec, err
-			}
-			props, ok := val.(map[string]interface{})
-			if !ok {
-				return deploymentSpec, fmt.Errorf("properties must be a map")
-			}
-			deploymentSpec.Solution.Components[ic].Properties = props
+// DryRunDeployment evaluates a deployment like EvaluateDeploymentWithDependencies, and also reports the
+// components that forEach and condition expressions generated or dropped.
+func DryRunDeployment(context utils.EvaluationContext) (model.DryRunResult, error) {
+	if context.ConfigProvider == nil {
+		deploymentSpec, expansions, err := evaluateDeployment(context)
+		return model.DryRunResult{Deployment: deploymentSpec, Expansions: expansions}, err
+	}
+	recorder := NewDependencyRecorder(context.ConfigProvider)
+	context.ConfigProvider = recorder
+	deploymentSpec, expansions, err := evaluateDeployment(context)
+	return model.DryRunResult{
+		Deployment:   deploymentSpec,
+		Dependencies: recorder.Dependencies(),
+		Expansions:   expansions,
+	}, err
+}
+
+func evaluateDeployment(context utils.EvaluationContext) (model.DeploymentSpec, []model.ComponentExpansionSpec, error) {
+	if deploymentSpec, ok := context.DeploymentSpec.(model.DeploymentSpec); ok {
+		components, expansions, err := evalComponents(context, deploymentSpec.Solution.Components)
+		if err != nil {
+			return deploymentSpec, expansions, err
 		}
-		return deploymentSpec, nil
+		deploymentSpec.Solution.Components = components
+		deploymentSpec.Assignments = expandAssignments(deploymentSpec.Assignments, expansions)
+		return deploymentSpec, expansions, nil
+	}
+	return model.DeploymentSpec{}, nil, errors.New("deployment spec is not found")
+}
+
+// evalComponent evaluates the metadata and properties of a component
+func evalComponent(context utils.EvaluationContext, c model.ComponentSpec) (model.ComponentSpec, error) {
+	val, err := evalProperties(context, c.Metadata)
+	if err != nil {
+		return c, err
+	}
+	if val != nil {
+		metadata, ok := val.(map[string]string)
+		if !ok {
+			return c, fmt.Errorf("metadata must be a map")
+		}
+		stringMap := make(map[string]string)
+		for k, v := range metadata {
+			stringMap[k] = fmt.Sprintf("%v", v)
+		}
+		c.Metadata = stringMap
+	}
+
+	val, err = evalProperties(context, c.Properties)
+	if err != nil {
+		return c, err
+	}
+	props, ok := val.(map[string]interface{})
+	if !ok {
+		return c, fmt.Errorf("properties must be a map")
 	}
-	return model.DeploymentSpec{}, errors.New("deployment spec is not found")
+	c.Properties = props
+	return c, nil
 }
 func compareInterfaces(a, b interface{}) bool {
 	if reflect.TypeOf(a) == reflect.TypeOf(b) {
@@ -8,6 +8,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config/mock"
@@ -2326,3 +2327,281 @@ func TestRecursiveUnsupported(t *testing.T) {
 	_, err := parser.Eval(utils.EvaluationContext{})
 	assert.NotNil(t, err)
 }
+func TestEvaluateDeploymentWithDependencies(t *testing.T) {
+	provider := &mock.MockConfigProvider{}
+	provider.Init(mock.MockConfigProviderConfig{})
+	context := utils.EvaluationContext{
+		ConfigProvider: provider,
+		DeploymentSpec: model.DeploymentSpec{
+			Instance: model.InstanceSpec{
+				Solution: "fake-solution",
+			},
+			SolutionName: "fake-solution",
+			Solution: model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name: "component-1",
+						Properties: map[string]interface{}{
+							"foo":    "${{$config('<app-config>', 'a')}}",
+							"bar":    "${{$config(shared-config, 'b', 'site-overlay')}}",
+							"pinned": "${{$config('<app-config@2>', 'a')}}",
+						},
+					},
+				},
+			},
+		},
+		Component: "component-1",
+	}
+	deployment, dependencies, err := EvaluateDeploymentWithDependencies(context)
+	assert.Nil(t, err)
+	assert.Equal(t, "<app-config>::a", deployment.Solution.Components[0].Properties["foo"])
+	assert.Equal(t, []string{"app-config", "shared-config"}, dependencies.Catalogs)
+	assert.Equal(t, []string{"site-overlay"}, dependencies.Overlays)
+	assert.True(t, dependencies.DependsOn("site-overlay"))
+	assert.False(t, dependencies.DependsOn("other-config"))
+}
+func TestStringFunctions(t *testing.T) {
+	cases := map[string]interface{}{
+		"${{$upper('abc')}}":                                          "ABC",
+		"${{$lower('AbC')}}":                                          "abc",
+		"${{$concat('a', 'b', 1)}}":                                   "ab1",
+		"${{$replace('a-b-c', '-', '.')}}":                            "a.b.c",
+		"${{$trim('  abc ')}}":                                        "abc",
+		"${{$trim('--abc--', '-')}}":                                  "abc",
+		"${{$len('héllo')}}":                                          int64(5),
+		"${{$join($split('a,b,c', ','), '/')}}":                       "a/b/c",
+		"${{$len($split('a,b,c', ','))}}":                             int64(3),
+		"${{$toInt('42') + 1}}":                                       int64(43),
+		"${{$toString(42)}}":                                          "42",
+		"${{$base64dec($base64enc('symphony'))}}":                     "symphony",
+		"${{$base64enc('abc')}}":                                      "YWJj",
+		"${{$sha256('abc')}}":                                         "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"${{$match('v1.2.3', '^v[0-9]+')}}":                           true,
+		"${{$match('1.2.3', '^v[0-9]+')}}":                            false,
+		"${{$capture('v1.2.3', 'v([0-9]+)')}}":                        "1",
+		"${{$capture('v1.2.3', 'v(?P<major>[0-9]+)\\.([0-9]+)', 2)}}": "2",
+		"${{$capture('v1.2.3', 'v(?P<major>[0-9]+)', 'major')}}":      "1",
+		"${{$capture('abc', 'v([0-9]+)')}}":                           "",
+	}
+	for expr, expected := range cases {
+		parser := NewParser(expr)
+		val, err := parser.Eval(utils.EvaluationContext{})
+		assert.Nil(t, err, expr)
+		assert.Equal(t, expected, val, expr)
+	}
+}
+func TestDefaultAndCoalesce(t *testing.T) {
+	context := utils.EvaluationContext{
+		Properties: map[string]string{
+			"foo":   "bar",
+			"empty": "",
+		},
+	}
+	cases := map[string]interface{}{
+		"${{$default($property(foo), 'x')}}":                        "bar",
+		"${{$default($property(missing), 'x')}}":                    "x",
+		"${{$default($property(empty), 'x')}}":                      "x",
+		"${{$coalesce($property(missing), $property(empty), foo)}}": "foo",
+		"${{$coalesce($property(missing))}}":                        "",
+	}
+	for expr, expected := range cases {
+		parser := NewParser(expr)
+		val, err := parser.Eval(context)
+		assert.Nil(t, err, expr)
+		assert.Equal(t, expected, val, expr)
+	}
+}
+func TestCollectionFunctions(t *testing.T) {
+	context := utils.EvaluationContext{
+		Inputs: map[string]interface{}{
+			"labels": map[string]interface{}{
+				"b": "2",
+				"a": "1",
+			},
+			"extra": map[string]interface{}{
+				"b": "3",
+				"c": "4",
+			},
+			"list": []interface{}{"x", "y"},
+		},
+	}
+	cases := map[string]interface{}{
+		"${{$keys($input(labels))}}":                               []interface{}{"a", "b"},
+		"${{$values($input(labels))}}":                             []interface{}{"1", "2"},
+		"${{$len($input(labels))}}":                                int64(2),
+		"${{$merge($input(labels), $input(extra))}}":               map[string]interface{}{"a": "1", "b": "3", "c": "4"},
+		"${{$concat($input(list), $split('z', ','))}}":             []interface{}{"x", "y", "z"},
+		"${{$join($input(list), '-')}}":                            "x-y",
+		"${{$keys($json($merge($input(extra), $input(labels))))}}": []interface{}{"a", "b", "c"},
+	}
+	for expr, expected := range cases {
+		parser := NewParser(expr)
+		val, err := parser.Eval(context)
+		assert.Nil(t, err, expr)
+		assert.Equal(t, expected, val, expr)
+	}
+}
+func TestTimeFunctions(t *testing.T) {
+	nowFunc = func() time.Time {
+		return time.Date(2023, 5, 4, 10, 30, 0, 0, time.UTC)
+	}
+	defer func() { nowFunc = time.Now }()
+	cases := map[string]interface{}{
+		"${{$now()}}":             "2023-05-04T10:30:00Z",
+		"${{$now('unix')}}":       int64(1683196200),
+		"${{$now('2006-01-02')}}": "2023-05-04",
+		"${{$formatTime('2023-05-04T10:30:00Z', 'RFC1123')}}": "Thu, 04 May 2023 10:30:00 UTC",
+		"${{$formatTime(1683196200, '2006-01-02 15:04')}}":    "2023-05-04 10:30",
+	}
+	for expr, expected := range cases {
+		parser := NewParser(expr)
+		val, err := parser.Eval(utils.EvaluationContext{})
+		assert.Nil(t, err, expr)
+		assert.Equal(t, expected, val, expr)
+	}
+}
+func TestFunctionErrors(t *testing.T) {
+	cases := map[string]string{
+		"${{$upper()}}":                       "$upper() expects 1 argument, found 0",
+		"${{$replace(a, b)}}":                 "$replace() expects 3 arguments, found 2",
+		"${{$trim(a, b, c)}}":                 "$trim() expects 1 to 2 arguments, found 3",
+		"${{$merge(a)}}":                      "$merge() expects at least 2 arguments, found 1",
+		"${{$default(a)}}":                    "$default() expects 2 arguments, found 1",
+		"${{$toInt(abc)}}":                    "$toInt() expects an integer, found abc",
+		"${{$keys(abc)}}":                     "$keys() expects a map, found abc",
+		"${{$join(abc, ',')}}":                "$join() expects a list, found abc",
+		"${{$match(abc, '[')}}":               "$match() has an invalid pattern: error parsing regexp: missing closing ]: `[`",
+		"${{$capture(abc, '(a)', 2)}}":        "$capture() pattern has no group 2",
+		"${{$formatTime(yesterday, 'unix')}}": "$formatTime() expects an RFC3339 time or unix seconds, found yesterday",
+	}
+	for expr, expected := range cases {
+		parser := NewParser(expr)
+		_, err := parser.Eval(utils.EvaluationContext{})
+		assert.NotNil(t, err, expr)
+		if err != nil {
+			assert.Equal(t, expected, err.Error(), expr)
+		}
+	}
+}
+func TestEvaluateDeploymentForEach(t *testing.T) {
+	context := utils.EvaluationContext{
+		DeploymentSpec: model.DeploymentSpec{
+			Solution: model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name:    "camera-${{$item(id)}}",
+						ForEach: "${{$json($input(cameras))}}",
+						Properties: map[string]interface{}{
+							"url":   "rtsp://${{$item('$.host')}}",
+							"index": "${{$index()}}",
+						},
+					},
+					{
+						Name:    "sidecar",
+						ForEach: "${{$split('a,b', ',')}}",
+						Properties: map[string]interface{}{
+							"role": "${{$item()}}",
+						},
+					},
+					{
+						Name:         "dashboard",
+						Dependencies: []string{"camera-${{$item(id)}}", "debug"},
+					},
+					{
+						Name:      "debug",
+						Condition: "${{$equal($input(debug), true)}}",
+					},
+				},
+			},
+		},
+		Inputs: map[string]interface{}{
+			"cameras": []interface{}{
+				map[string]interface{}{"id": "front", "host": "10.0.0.1"},
+				map[string]interface{}{"id": "back", "host": "10.0.0.2"},
+			},
+			"debug": "false",
+		},
+	}
+	deployment := context.DeploymentSpec.(model.DeploymentSpec)
+	deployment.Assignments = map[string]string{
+		"T1": "{camera-${{$item(id)}}}{dashboard}",
+		"T2": "{sidecar}{debug}",
+	}
+	context.DeploymentSpec = deployment
+	result, err := DryRunDeployment(context)
+	assert.Nil(t, err)
+	assert.Equal(t, "{camera-front}{camera-back}{dashboard}", result.Deployment.Assignments["T1"])
+	assert.Equal(t, "{sidecar-0}{sidecar-1}", result.Deployment.Assignments["T2"])
+	components := result.Deployment.Solution.Components
+	assert.Equal(t, 5, len(components))
+	assert.Equal(t, "camera-front", components[0].Name)
+	assert.Equal(t, "rtsp://10.0.0.1", components[0].Properties["url"])
+	assert.Equal(t, int64(0), components[0].Properties["index"])
+	assert.Equal(t, "", components[0].ForEach)
+	assert.Equal(t, "camera-back", components[1].Name)
+	assert.Equal(t, int64(1), components[1].Properties["index"])
+	assert.Equal(t, "sidecar-0", components[2].Name)
+	assert.Equal(t, "b", components[3].Properties["role"])
+	assert.Equal(t, "dashboard", components[4].Name)
+	assert.Equal(t, []string{"camera-front", "camera-back"}, components[4].Dependencies)
+
+	assert.Equal(t, 3, len(result.Expansions))
+	assert.Equal(t, []string{"camera-front", "camera-back"}, result.Expansions[0].Components)
+	assert.Equal(t, "debug", result.Expansions[2].Component)
+	assert.Equal(t, []string{}, result.Expansions[2].Components)
+}
+func TestEvaluateDeploymentCondition(t *testing.T) {
+	context := utils.EvaluationContext{
+		DeploymentSpec: model.DeploymentSpec{
+			Solution: model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name:      "debug",
+						Condition: "${{$equal($input(debug), true)}}",
+					},
+					{
+						Name:      "odd",
+						ForEach:   "${{$split('1,2,3', ',')}}",
+						Condition: "${{$not($equal($item(), 2))}}",
+					},
+				},
+			},
+		},
+		Inputs: map[string]interface{}{
+			"debug": "true",
+		},
+	}
+	deployment, err := EvaluateDeployment(context)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(deployment.Solution.Components))
+	assert.Equal(t, "debug", deployment.Solution.Components[0].Name)
+	assert.Equal(t, "odd-0", deployment.Solution.Components[1].Name)
+	assert.Equal(t, "odd-2", deployment.Solution.Components[2].Name)
+}
+func TestEvaluateDeploymentForEachErrors(t *testing.T) {
+	cases := map[string]model.ComponentSpec{
+		"forEach of component 'a' must evaluate to a list, found abc":          {Name: "a", ForEach: "abc"},
+		"condition of component 'a' must evaluate to true or false, found abc": {Name: "a", Condition: "abc"},
+		"component name 'a' is used more than once":                            {Name: "a", ForEach: "${{$split('1,2', ',')}}", Properties: map[string]interface{}{}},
+	}
+	for expected, c := range cases {
+		if c.Properties != nil {
+			c.Name = "${{a}}"
+		}
+		context := utils.EvaluationContext{
+			DeploymentSpec: model.DeploymentSpec{
+				Solution: model.SolutionSpec{
+					Components: []model.ComponentSpec{c},
+				},
+			},
+		}
+		_, err := EvaluateDeployment(context)
+		assert.NotNil(t, err, expected)
+		if err != nil {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+	parser := NewParser("${{$item()}}")
+	_, err := parser.Eval(utils.EvaluationContext{})
+	assert.Equal(t, "$item() can only be used in a component with forEach", err.Error())
+}
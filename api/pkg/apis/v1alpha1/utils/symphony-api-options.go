@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// ClientOptions carries the authentication and TLS settings used when calling the Symphony API.
+// When Token or TokenFile is set, the token is sent as a bearer token instead of exchanging the
+// user name and password through users/auth.
+type ClientOptions struct {
+	Token              string
+	TokenFile          string
+	CACertFile         string
+	InsecureSkipVerify bool
+}
+
+type clientOptionsKey struct{}
+
+var (
+	httpClients     = make(map[string]*http.Client)
+	httpClientsLock sync.Mutex
+)
+
+// WithClientOptions returns a context that makes the Symphony API helpers use the given options
+func WithClientOptions(ctx context.Context, options ClientOptions) context.Context {
+	return context.WithValue(ctx, clientOptionsKey{}, options)
+}
+
+func clientOptionsFromContext(ctx context.Context) ClientOptions {
+	if ctx != nil {
+		if options, ok := ctx.Value(clientOptionsKey{}).(ClientOptions); ok {
+			return options
+		}
+	}
+	return ClientOptions{}
+}
+
+// bearerToken returns the configured token, if any. The token file is read on every call so that
+// rotated secrets and projected service account tokens are picked up without a restart.
+func (o ClientOptions) bearerToken() (string, error) {
+	if o.Token != "" {
+		return o.Token, nil
+	}
+	if o.TokenFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(o.TokenFile)
+	if err != nil {
+		return "", v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read Symphony API token from '%s'", o.TokenFile), v1alpha2.BadConfig)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (o ClientOptions) httpClient() (*http.Client, error) {
+	if o.CACertFile == "" && !o.InsecureSkipVerify {
+		return http.DefaultClient, nil
+	}
+	key := fmt.Sprintf("%s|%t", o.CACertFile, o.InsecureSkipVerify)
+	httpClientsLock.Lock()
+	defer httpClientsLock.Unlock()
+	if client, ok := httpClients[key]; ok {
+		return client, nil
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CACertFile != "" {
+		caCert, err := os.ReadFile(o.CACertFile)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read Symphony API CA certificate from '%s'", o.CACertFile), v1alpha2.BadConfig)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("no valid certificate found in '%s'", o.CACertFile), v1alpha2.BadConfig)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{Transport: transport}
+	httpClients[key] = client
+	return client, nil
+}
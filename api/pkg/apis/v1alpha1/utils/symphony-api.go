@@ -168,6 +168,24 @@ func GetCatalog(context context.Context, baseUrl string, catalog string, user st
 	}
 	return ret, nil
 }
+func GetCatalogRevision(context context.Context, baseUrl string, catalog string, revision int64, user string, password string) (model.CatalogRevisionState, error) {
+	ret := model.CatalogRevisionState{}
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return ret, err
+	}
+
+	response, err := callRestAPI(context, baseUrl, fmt.Sprintf("catalogs/registry/%s/revisions/%d", catalog, revision), "GET", nil, token)
+	if err != nil {
+		return ret, err
+	}
+
+	err = json.Unmarshal(response, &ret)
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
 func GetCampaign(context context.Context, baseUrl string, campaign string, user string, password string) (model.CampaignState, error) {
 	ret := model.CampaignState{}
 	token, err := auth(context, baseUrl, user, password)
@@ -767,6 +785,10 @@ func Reconcile(context context.Context, baseUrl string, user string, password st
 	return summary, nil
 }
 func auth(context context.Context, baseUrl string, user string, password string) (string, error) {
+	token, err := clientOptionsFromContext(context).bearerToken()
+	if err != nil || token != "" {
+		return token, err
+	}
 	request := authRequest{Username: user, Password: password}
 	requestData, _ := json.Marshal(request)
 	ret, err := callRestAPI(context, baseUrl, "users/auth", "POST", requestData, "")
@@ -793,7 +815,10 @@ func callRestAPI(context context.Context, baseUrl string, route string, method s
 
 	log.Infof("Calling Symphony API: %s %s, spanId: %s, traceId: %s", method, baseUrl+route, span.SpanContext().SpanID().String(), span.SpanContext().TraceID().String())
 
-	client := &http.Client{}
+	client, err := clientOptionsFromContext(context).httpClient()
+	if err != nil {
+		return nil, err
+	}
 	rUrl := baseUrl + route
 	var req *http.Request
 	if payload != nil {
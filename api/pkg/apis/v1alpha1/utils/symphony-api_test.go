@@ -9,7 +9,11 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -722,3 +726,34 @@ func TestAssignComponentsToTargetsWithMixedConstraints(t *testing.T) {
 		"target3": "{componentName3}",
 	}, res)
 }
+
+func TestQueueJobWithTokenFileAndCACert(t *testing.T) {
+	var authHeader string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1alpha2/users/auth" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		authHeader = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("my-token\n"), 0600))
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	ctx := WithClientOptions(context.Background(), ClientOptions{
+		TokenFile:  tokenFile,
+		CACertFile: caFile,
+	})
+	err := QueueJob(ctx, server.URL+"/v1alpha2/", "", "", "instance-1", "default", false, false)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer my-token", authHeader)
+
+	// without the CA certificate the server isn't trusted
+	err = QueueJob(context.Background(), server.URL+"/v1alpha2/", "", "", "instance-1", "default", false, false)
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -95,6 +96,19 @@ func (e *CatalogsVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Handler:    e.onCatalogs,
 			Parameters: []string{"name?"},
 		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/registry/{name}/revisions",
+			Version:    e.Version,
+			Handler:    e.onCatalogRevisions,
+			Parameters: []string{"revision?"},
+		},
+		{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   route + "/registry/{name}/diff",
+			Version: e.Version,
+			Handler: e.onCatalogDiff,
+		},
 		{
 			Methods: []string{fasthttp.MethodGet},
 			Route:   route + "/graph",
@@ -309,3 +323,124 @@ func (e *CatalogsVendor) onCatalogs(request v1alpha2.COARequest) v1alpha2.COARes
 	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
 	return resp
 }
+func (e *CatalogsVendor) onCatalogRevisions(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Catalogs Vendor", request.Context, &map[string]string{
+		"method": "onCatalogRevisions",
+	})
+	defer span.End()
+
+	lLog.Info("V (Catalogs Vendor): onCatalogRevisions")
+	switch request.Method {
+	case fasthttp.MethodGet:
+		ctx, span := observability.StartSpan("onCatalogRevisions-GET", pCtx, nil)
+		id := request.Parameters["__name"]
+		var err error
+		var state interface{}
+		isArray := false
+		if request.Parameters["__revision"] == "" {
+			state, err = e.CatalogsManager.ListRevisions(ctx, id)
+			isArray = true
+		} else {
+			var revision int64
+			revision, err = strconv.ParseInt(request.Parameters["__revision"], 10, 64)
+			if err != nil || revision <= 0 {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte("invalid catalog revision"),
+				})
+			}
+			state, err = e.CatalogsManager.GetRevision(ctx, id, revision)
+		}
+		if err != nil {
+			if !v1alpha2.IsNotFound(err) {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.InternalError,
+					Body:  []byte(err.Error()),
+				})
+			} else {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.NotFound,
+					Body:  []byte(err.Error()),
+				})
+			}
+		}
+		jData, _ := utils.FormatObject(state, isArray, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+func (e *CatalogsVendor) onCatalogDiff(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Catalogs Vendor", request.Context, &map[string]string{
+		"method": "onCatalogDiff",
+	})
+	defer span.End()
+
+	lLog.Info("V (Catalogs Vendor): onCatalogDiff")
+	switch request.Method {
+	case fasthttp.MethodGet:
+		ctx, span := observability.StartSpan("onCatalogDiff-GET", pCtx, nil)
+		id := request.Parameters["__name"]
+		// from and to are revision numbers, an omitted to compares against the latest revision
+		var from, to int64
+		var err error
+		if from, err = strconv.ParseInt(request.Parameters["from"], 10, 64); err != nil || from <= 0 {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte("a valid 'from' revision is required"),
+			})
+		}
+		if request.Parameters["to"] != "" {
+			if to, err = strconv.ParseInt(request.Parameters["to"], 10, 64); err != nil || to <= 0 {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte("invalid 'to' revision"),
+				})
+			}
+		}
+		diff, err := e.CatalogsManager.DiffRevisions(ctx, id, from, to)
+		if err != nil {
+			if !v1alpha2.IsNotFound(err) {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.InternalError,
+					Body:  []byte(err.Error()),
+				})
+			} else {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.NotFound,
+					Body:  []byte(err.Error()),
+				})
+			}
+		}
+		jData, _ := utils.FormatObject(diff, false, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
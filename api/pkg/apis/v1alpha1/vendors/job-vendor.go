@@ -9,6 +9,7 @@ package vendors
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/jobs"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -93,6 +94,13 @@ func (o *JobVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Version: o.Version,
 			Handler: o.onHello,
 		},
+		{
+			Methods:    []string{fasthttp.MethodGet, fasthttp.MethodPost, fasthttp.MethodDelete},
+			Route:      route + "/deadletters",
+			Version:    o.Version,
+			Handler:    o.onDeadLetters,
+			Parameters: []string{"name?"},
+		},
 	}
 }
 
@@ -132,3 +140,95 @@ func (c *JobVendor) onHello(request v1alpha2.COARequest) v1alpha2.COAResponse {
 
 	return resp
 }
+
+// onDeadLetters lists the events the pub-sub provider gave up on, replays one of them to its topic with a
+// POST, or discards it with a DELETE. Without a topic parameter, GET lists the dead letters of all topics,
+// and POST and DELETE use the job topic.
+func (c *JobVendor) onDeadLetters(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	_, span := observability.StartSpan("Job Vendor", request.Context, &map[string]string{
+		"method": "onDeadLetters",
+	})
+	defer span.End()
+
+	jLog.Infof("V (Job): onDeadLetters, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+	var provider pubsub.IDeadLetterProvider
+	if c.Vendor.Context != nil {
+		provider, _ = c.Vendor.Context.PubsubProvider.(pubsub.IDeadLetterProvider)
+	}
+	if provider == nil {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.NotFound,
+			Body:        []byte("{\"result\":\"404 - pub-sub provider doesn't keep dead letters\"}"),
+			ContentType: "application/json",
+		})
+	}
+	topic := request.Parameters["topic"]
+	id := request.Parameters["__name"]
+	var err error
+	switch request.Method {
+	case fasthttp.MethodGet:
+		count := 0
+		if v, ok := request.Parameters["count"]; ok {
+			count, err = strconv.Atoi(v)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+		}
+		letters, err := provider.ListDeadLetters(topic, count)
+		if err != nil {
+			jLog.Errorf("V (Job): onDeadLetters failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, deadLetterErrorResponse(err))
+		}
+		data, _ := json.Marshal(letters)
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        data,
+			ContentType: "application/json",
+		})
+	case fasthttp.MethodPost, fasthttp.MethodDelete:
+		if id == "" {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State:       v1alpha2.BadRequest,
+				Body:        []byte("{\"result\":\"400 - dead letter id is missing\"}"),
+				ContentType: "application/json",
+			})
+		}
+		if topic == "" {
+			topic = "job"
+		}
+		if request.Method == fasthttp.MethodPost {
+			err = provider.ReplayDeadLetter(topic, id)
+		} else {
+			err = provider.DeleteDeadLetter(topic, id)
+		}
+		if err != nil {
+			jLog.Errorf("V (Job): onDeadLetters failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, deadLetterErrorResponse(err))
+		}
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.OK,
+		})
+	}
+	jLog.Errorf("V (Job): onDeadLetters failed - 405 method not allowed, traceId: %s", span.SpanContext().TraceID().String())
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+
+func deadLetterErrorResponse(err error) v1alpha2.COAResponse {
+	state := v1alpha2.InternalError
+	if coaErr, ok := err.(v1alpha2.COAError); ok && coaErr.State == v1alpha2.NotFound {
+		state = v1alpha2.NotFound
+	}
+	return v1alpha2.COAResponse{
+		State: state,
+		Body:  []byte(err.Error()),
+	}
+}
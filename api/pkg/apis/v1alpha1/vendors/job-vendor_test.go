@@ -18,6 +18,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
@@ -40,7 +41,7 @@ func TestJobsEndpoints(t *testing.T) {
 	vendor := createJobVendor()
 	vendor.Route = "instances"
 	endpoints := vendor.GetEndpoints()
-	assert.Equal(t, 1, len(endpoints))
+	assert.Equal(t, 2, len(endpoints))
 }
 func TestJobsInfo(t *testing.T) {
 	vendor := createJobVendor()
@@ -120,3 +121,113 @@ func TestJobWrongMethod(t *testing.T) {
 	})
 	assert.Equal(t, v1alpha2.MethodNotAllowed, resp.State)
 }
+
+type deadLetterPubSubProvider struct {
+	memory.InMemoryPubSubProvider
+	letters []pubsub.DeadLetter
+}
+
+func (p *deadLetterPubSubProvider) ListDeadLetters(topic string, count int) ([]pubsub.DeadLetter, error) {
+	ret := make([]pubsub.DeadLetter, 0)
+	for _, l := range p.letters {
+		if topic == "" || l.Topic == topic {
+			ret = append(ret, l)
+		}
+	}
+	return ret, nil
+}
+
+func (p *deadLetterPubSubProvider) ReplayDeadLetter(topic string, id string) error {
+	for k, l := range p.letters {
+		if l.Topic == topic && l.ID == id {
+			p.letters = append(p.letters[:k], p.letters[k+1:]...)
+			return p.Publish(topic, l.Event)
+		}
+	}
+	return v1alpha2.NewCOAError(nil, "dead letter is not found", v1alpha2.NotFound)
+}
+
+func (p *deadLetterPubSubProvider) DeleteDeadLetter(topic string, id string) error {
+	for k, l := range p.letters {
+		if l.Topic == topic && l.ID == id {
+			p.letters = append(p.letters[:k], p.letters[k+1:]...)
+			return nil
+		}
+	}
+	return v1alpha2.NewCOAError(nil, "dead letter is not found", v1alpha2.NotFound)
+}
+
+func TestJobDeadLetters(t *testing.T) {
+	vendor := createJobVendor()
+	vendor.Context = &contexts.VendorContext{}
+	memoryProvider := memory.InMemoryPubSubProvider{}
+	memoryProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor.Context.Init(&memoryProvider)
+	resp := vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.NotFound, resp.State)
+
+	provider := &deadLetterPubSubProvider{
+		letters: []pubsub.DeadLetter{
+			{ID: "1-0", Topic: "job", DeliveryCount: 10, Event: v1alpha2.Event{Body: "job1"}},
+			{ID: "2-0", Topic: "job", DeliveryCount: 10, Event: v1alpha2.Event{Body: "job2"}},
+			{ID: "1-0", Topic: "trigger", DeliveryCount: 10, Event: v1alpha2.Event{Body: "trigger1"}},
+		},
+	}
+	provider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor.Context.Init(provider)
+	replayed := make(chan string, 1)
+	vendor.Context.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		replayed <- event.Body.(string)
+		return nil
+	})
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var letters []pubsub.DeadLetter
+	assert.Nil(t, json.Unmarshal(resp.Body, &letters))
+	assert.Equal(t, 3, len(letters))
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{"topic": "trigger"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Nil(t, json.Unmarshal(resp.Body, &letters))
+	assert.Equal(t, 1, len(letters))
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "1-0"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Equal(t, "job1", <-replayed)
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:     fasthttp.MethodDelete,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "1-0", "topic": "trigger"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Equal(t, 1, len(provider.letters))
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:     fasthttp.MethodDelete,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "1-0"},
+	})
+	assert.Equal(t, v1alpha2.NotFound, resp.State)
+
+	resp = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
@@ -63,6 +63,12 @@ func (o *SettingsVendor) GetEndpoints() []v1alpha2.Endpoint {
 		route = o.Route
 	}
 	return []v1alpha2.Endpoint{
+		{
+			Methods: []string{fasthttp.MethodPost},
+			Route:   route + "/expressions/validate",
+			Version: o.Version,
+			Handler: o.onValidateExpressions,
+		},
 		{
 			Methods:    []string{fasthttp.MethodGet},
 			Route:      route + "/config",
@@ -126,3 +132,47 @@ func (c *SettingsVendor) onConfig(request v1alpha2.COARequest) v1alpha2.COARespo
 	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
 	return resp
 }
+
+// ExpressionValidationResult is the response of the expression validation endpoint
+type ExpressionValidationResult struct {
+	Valid  bool                        `json:"valid"`
+	Errors []api_utils.ExpressionError `json:"errors"`
+}
+
+// onValidateExpressions checks the ${{ }} expressions in a solution, campaign, catalog or any other
+// object without saving or evaluating it
+func (c *SettingsVendor) onValidateExpressions(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	_, span := observability.StartSpan("Settings Vendor", request.Context, &map[string]string{
+		"method": "onValidateExpressions",
+	})
+	defer span.End()
+	csLog.Info("V (Settings): onValidateExpressions")
+	switch request.Method {
+	case fasthttp.MethodPost:
+		var obj interface{}
+		err := json.Unmarshal(request.Body, &obj)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(err.Error()),
+			})
+		}
+		errors := api_utils.ValidateExpressions(obj)
+		data, _ := json.Marshal(ExpressionValidationResult{
+			Valid:  len(errors) == 0,
+			Errors: errors,
+		})
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        data,
+			ContentType: "application/json",
+		})
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
@@ -8,6 +8,7 @@ package vendors
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
@@ -148,3 +149,33 @@ func TestConfigGetField(t *testing.T) {
 	res = vendor.onConfig(*request)
 	assert.Equal(t, v1alpha2.InternalError, res.State)
 }
+
+func TestValidateExpressions(t *testing.T) {
+	vendor := createSettingsVendor()
+	request := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: context.Background(),
+		Body:    []byte(`{"spec":{"components":[{"name":"a","properties":{"image":"${{$param(image)}}","tag":"${{$parm(tag)}}"}}]}}`),
+	}
+	res := vendor.onValidateExpressions(*request)
+	assert.Equal(t, v1alpha2.OK, res.State)
+	var result ExpressionValidationResult
+	err := json.Unmarshal(res.Body, &result)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, 1, len(result.Errors))
+	assert.Equal(t, "spec.components[0].properties.tag", result.Errors[0].Path)
+
+	request.Body = []byte(`{"spec":{"stages":{"s1":{"stageSelector":"${{$output(s1, next)}}"}}}}`)
+	res = vendor.onValidateExpressions(*request)
+	assert.Nil(t, json.Unmarshal(res.Body, &result))
+	assert.True(t, result.Valid)
+
+	request.Body = []byte(`not json`)
+	res = vendor.onValidateExpressions(*request)
+	assert.Equal(t, v1alpha2.BadRequest, res.State)
+
+	request.Method = fasthttp.MethodGet
+	res = vendor.onValidateExpressions(*request)
+	assert.Equal(t, v1alpha2.MethodNotAllowed, res.State)
+}
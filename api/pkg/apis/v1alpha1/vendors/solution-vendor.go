@@ -48,6 +48,9 @@ func (e *SolutionVendor) Init(config vendors.VendorConfig, factories []managers.
 	if e.SolutionManager == nil {
 		return v1alpha2.NewCOAError(nil, "solution manager is not supplied", v1alpha2.MissingConfig)
 	}
+	e.Vendor.Context.Subscribe("catalog", func(topic string, event v1alpha2.Event) error {
+		return e.SolutionManager.HandleCatalogEvent(context.TODO(), event)
+	})
 	return nil
 }
 
@@ -194,6 +197,22 @@ func (c *SolutionVendor) onReconcile(request v1alpha2.COARequest) v1alpha2.COARe
 				Body:  []byte(err.Error()),
 			})
 		}
+		if request.Parameters["dryRun"] == "true" {
+			result, err := c.SolutionManager.DryRun(ctx, deployment)
+			if err != nil {
+				sLog.Infof("V (Solution): onReconcile dry run failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.InternalError,
+					Body:  []byte(err.Error()),
+				})
+			}
+			data, _ := json.Marshal(result)
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State:       v1alpha2.OK,
+				Body:        data,
+				ContentType: "application/json",
+			})
+		}
 		delete := request.Parameters["delete"]
 		summary, err := c.SolutionManager.Reconcile(ctx, deployment, delete == "true", scope)
 		data, _ := json.Marshal(summary)
@@ -23,6 +23,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	mocksecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -428,3 +429,34 @@ func TestSolutionQueueTargetUpdate(t *testing.T) {
 	time.Sleep(time.Second)
 	assert.Equal(t, 1, succeededCount)
 }
+func TestSolutionReconcileDryRun(t *testing.T) {
+	vendor := createSolutionVendor()
+	vendor.SolutionManager.VendorContext = &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+	}
+	deployment := createDeployment2Mocks1Target(uuid.New().String())
+	deployment.Solution.Components[1].ForEach = "${{$split('x,y', ',')}}"
+	deployment.Solution.Components[1].Name = "b-${{$item()}}"
+	deployment.Assignments["T1"] = "{a}{b-${{$item()}}}"
+	data, _ := json.Marshal(deployment)
+	resp := vendor.onReconcile(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"dryRun": "true",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var result model.DryRunResult
+	err := json.Unmarshal(resp.Body, &result)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result.Deployment.Solution.Components))
+	assert.Equal(t, "{a}{b-x}{b-y}", result.Deployment.Assignments["T1"])
+	assert.Equal(t, 1, len(result.Expansions))
+	assert.Equal(t, []string{"b-x", "b-y"}, result.Expansions[0].Components)
+
+	// nothing is deployed
+	_, err = vendor.SolutionManager.GetSummary(context.Background(), "instance1", "default")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestPath          string
+	manifestConfigFile    string
+	manifestConfigContext string
+)
+
+var ApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update the Symphony objects in a file or directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		manifests, c := readManifests(false)
+		for _, m := range manifests {
+			fmt.Printf("%sApplying %s %s%s ... ", utils.ColorCyan(), m.Kind, utils.ColorReset(), m.Name)
+			if err := utils.ApplyManifest(c.Url, c.User, c.Secret, m); err != nil {
+				fmt.Printf("%sfailed\n%s", utils.ColorRed(), utils.ColorReset())
+				exitWithError(fmt.Errorf("failed to apply %s '%s': %s", m.Kind, m.Name, err.Error()))
+			}
+			fmt.Printf("%sdone\n%s", utils.ColorGreen(), utils.ColorReset())
+		}
+	},
+}
+
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete the Symphony objects in a file or directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		manifests, c := readManifests(true)
+		for _, m := range manifests {
+			fmt.Printf("%sDeleting %s %s%s ... ", utils.ColorCyan(), m.Kind, utils.ColorReset(), m.Name)
+			if err := utils.DeleteManifest(c.Url, c.User, c.Secret, m); err != nil {
+				fmt.Printf("%sfailed\n%s", utils.ColorRed(), utils.ColorReset())
+				exitWithError(fmt.Errorf("failed to delete %s '%s': %s", m.Kind, m.Name, err.Error()))
+			}
+			fmt.Printf("%sdone\n%s", utils.ColorGreen(), utils.ColorReset())
+		}
+	},
+}
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the changes applying a file or directory would make. Exits with 1 when there are changes.",
+	Run: func(cmd *cobra.Command, args []string) {
+		manifests, c := readManifests(false)
+		changed := false
+		for _, m := range manifests {
+			current, found, err := utils.GetManifestSpec(c.Url, c.User, c.Secret, m)
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to read %s '%s': %s", m.Kind, m.Name, err.Error()))
+			}
+			if !found {
+				changed = true
+				fmt.Printf("%s%s %s will be created%s\n", utils.ColorGreen(), m.Kind, m.Name, utils.ColorReset())
+				continue
+			}
+			changes, err := utils.DiffSpecs(m.Kind, current, m.Spec)
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to compare %s '%s': %s", m.Kind, m.Name, err.Error()))
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			changed = true
+			fmt.Printf("%s%s %s will be updated%s\n", utils.ColorYellow(), m.Kind, m.Name, utils.ColorReset())
+			for _, change := range changes {
+				color := utils.ColorYellow()
+				if change.Added {
+					color = utils.ColorGreen()
+				} else if change.Removed {
+					color = utils.ColorRed()
+				}
+				fmt.Printf("  %s%s%s\n", color, change.String(), utils.ColorReset())
+			}
+		}
+		if changed {
+			os.Exit(1)
+		}
+	},
+}
+
+// readManifests reads and orders the objects of the -f argument and resolves the Maestro context to call
+func readManifests(forDeletion bool) ([]utils.Manifest, config.MaestroContext) {
+	if manifestPath == "" {
+		exitWithError(fmt.Errorf("please specify a file or directory with -f"))
+	}
+	manifests, err := utils.ReadManifests(manifestPath)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.SortManifests(manifests, forDeletion)
+	return manifests, getMaestroContext(manifestConfigFile, manifestConfigContext)
+}
+
+// getMaestroContext returns the named context of the Maestro configuration, or the default context, and signs the
+// API calls in with its credentials
+func getMaestroContext(configFile string, context string) config.MaestroContext {
+	c := config.GetMaestroConfig(configFile)
+	name := getMaestroContextName(c, context)
+	if err := utils.UseContext(configFile, name, c.Contexts[name]); err != nil {
+		exitWithError(err)
+	}
+	return c.Contexts[name]
+}
+
+func getMaestroContextName(c config.MaestroConfig, context string) string {
+	ctx := c.DefaultContext
+	if context != "" {
+		ctx = context
+	}
+	if ctx == "" {
+		ctx = "default"
+	}
+	return ctx
+}
+
+func exitWithError(err error) {
+	fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+	os.Exit(1)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{ApplyCmd, DeleteCmd, DiffCmd} {
+		c.Flags().StringVarP(&manifestPath, "filename", "f", "", "File or directory with the Symphony objects (YAML or JSON, multiple documents separated by ---)")
+		c.Flags().StringVarP(&manifestConfigFile, "config", "c", "", "Maestro CLI config file")
+		c.Flags().StringVarP(&manifestConfigContext, "context", "", "", "Maestro CLI configuration context")
+		RootCmd.AddCommand(c)
+	}
+}
@@ -41,6 +41,10 @@ var GetCmd = &cobra.Command{
 		if ctx == "" {
 			ctx = "default"
 		}
+		if err := utils.UseContext(configFile, ctx, c.Contexts[ctx]); err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
 
 		for _, a := range args {
 			list, err := utils.Get(
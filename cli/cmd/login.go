@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginConfigFile       string
+	loginConfigContext    string
+	loginUrl              string
+	loginUser             string
+	loginPassword         string
+	loginPasswordStdin    bool
+	loginCredentialHelper string
+	loginOIDCIssuer       string
+	loginOIDCClientId     string
+	loginOIDCScopes       []string
+	loginOIDCUseIdToken   bool
+)
+
+var LoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Sign in to a Symphony API and cache the token in a Maestro context",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := config.GetMaestroConfig(loginConfigFile)
+		name := getMaestroContextName(c, loginConfigContext)
+		ctx := c.Contexts[name]
+		if loginUrl != "" {
+			ctx.Url = loginUrl
+		}
+		if ctx.Url == "" {
+			exitWithError(fmt.Errorf("context '%s' doesn't exist, please specify the Symphony API with --url", name))
+		}
+		if loginUser != "" {
+			ctx.User = loginUser
+		}
+		if cmd.Flags().Changed("credential-helper") {
+			ctx.CredentialHelper = loginCredentialHelper
+		}
+		if loginOIDCIssuer != "" {
+			ctx.OIDC = &config.OIDCConfig{
+				Issuer:     loginOIDCIssuer,
+				ClientId:   loginOIDCClientId,
+				Scopes:     loginOIDCScopes,
+				UseIdToken: loginOIDCUseIdToken,
+			}
+		}
+		if err := config.LoadCredentials(name, &ctx); err != nil {
+			exitWithError(err)
+		}
+
+		var credentials config.Credentials
+		var err error
+		if ctx.OIDC != nil {
+			credentials, err = utils.DeviceLogin(*ctx.OIDC, func(code utils.DeviceCode) {
+				uri := code.VerificationURIComplete
+				if uri == "" {
+					uri = code.VerificationURI
+				}
+				fmt.Printf("%sTo sign in, open %s%s and enter the code %s%s%s\n", utils.ColorCyan(), utils.ColorReset(), uri, utils.ColorYellow(), code.UserCode, utils.ColorReset())
+			})
+		} else {
+			password := ctx.Secret
+			if loginPasswordStdin {
+				data, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					exitWithError(err)
+				}
+				password = strings.TrimRight(string(data), "\r\n")
+			} else if cmd.Flags().Changed("password") {
+				password = loginPassword
+			}
+			credentials, err = utils.RequestToken(ctx.Url, ctx.User, password)
+		}
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to sign in to '%s': %s", ctx.Url, err.Error()))
+		}
+		ctx.Credentials = credentials
+		if err := config.StoreCredentials(loginConfigFile, name, ctx); err != nil {
+			exitWithError(fmt.Errorf("failed to save the credentials of context '%s': %s", name, err.Error()))
+		}
+		fmt.Printf("%sLogged in to %s%s (context %s)\n", utils.ColorGreen(), ctx.Url, utils.ColorReset(), name)
+	},
+}
+
+var LogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the cached token and stored credentials of a Maestro context",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := config.GetMaestroConfig(loginConfigFile)
+		name := getMaestroContextName(c, loginConfigContext)
+		if err := config.EraseCredentials(loginConfigFile, name, c.Contexts[name]); err != nil {
+			exitWithError(fmt.Errorf("failed to remove the credentials of context '%s': %s", name, err.Error()))
+		}
+		fmt.Printf("%sLogged out%s (context %s)\n", utils.ColorGreen(), utils.ColorReset(), name)
+	},
+}
+
+func init() {
+	LoginCmd.Flags().StringVarP(&loginUrl, "url", "", "", "Symphony API URL, for example http://localhost:8080/v1alpha2")
+	LoginCmd.Flags().StringVarP(&loginUser, "user", "u", "", "Symphony user name")
+	LoginCmd.Flags().StringVarP(&loginPassword, "password", "p", "", "Symphony password")
+	LoginCmd.Flags().BoolVarP(&loginPasswordStdin, "password-stdin", "", false, "Read the password from stdin")
+	LoginCmd.Flags().StringVarP(&loginCredentialHelper, "credential-helper", "", "", "Command that stores the credentials instead of the config file (Docker credential helper protocol, for example docker-credential-pass)")
+	LoginCmd.Flags().StringVarP(&loginOIDCIssuer, "oidc-issuer", "", "", "Sign in with the device flow of this OIDC provider")
+	LoginCmd.Flags().StringVarP(&loginOIDCClientId, "oidc-client-id", "", "", "OIDC client id")
+	LoginCmd.Flags().StringSliceVarP(&loginOIDCScopes, "oidc-scopes", "", nil, "OIDC scopes (default openid,offline_access)")
+	LoginCmd.Flags().BoolVarP(&loginOIDCUseIdToken, "oidc-use-id-token", "", false, "Send the ID token instead of the access token to the Symphony API")
+	for _, c := range []*cobra.Command{LoginCmd, LogoutCmd} {
+		c.Flags().StringVarP(&loginConfigFile, "config", "c", "", "Maestro CLI config file")
+		c.Flags().StringVarP(&loginConfigContext, "context", "", "", "Maestro CLI configuration context")
+		RootCmd.AddCommand(c)
+	}
+}
@@ -231,6 +231,9 @@ func removeArtifact(artifact ArtifactSpec) error {
 	if ctx == "" {
 		ctx = "default"
 	}
+	if err := utils.UseContext(sampleConfigFile, ctx, c.Contexts[ctx]); err != nil {
+		return err
+	}
 
 	fmt.Printf("%sRemoving %s %s%s ...", utils.ColorCyan(), artifact.Type, utils.ColorReset(), artifact.Name)
 	err := utils.Remove(
@@ -281,6 +284,9 @@ func runArtifact(samplePath string, artifact ArtifactSpec, paramMap map[string]s
 	if ctx == "" {
 		ctx = "default"
 	}
+	if err := utils.UseContext(sampleConfigFile, ctx, c.Contexts[ctx]); err != nil {
+		return err
+	}
 
 	fmt.Printf("%sCreating %s %s%s ... ", utils.ColorCyan(), artifact.Type, utils.ColorReset(), artifact.Name)
 	err = utils.Upsert(
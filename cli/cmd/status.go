@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusScope         string
+	statusConfigFile    string
+	statusConfigContext string
+)
+
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the deployment status of Symphony objects",
+}
+
+var StatusInstanceCmd = &cobra.Command{
+	Use:   "instance <name>",
+	Short: "Show the result of the last deployment of an instance, per target and per component. Exits with 1 when a target failed.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getMaestroContext(statusConfigFile, statusConfigContext)
+		result, found, err := utils.GetInstanceSummary(c.Url, c.User, c.Secret, args[0], statusScope)
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to read the status of instance '%s': %s", args[0], err.Error()))
+		}
+		if !found {
+			exitWithError(fmt.Errorf("instance '%s' hasn't been deployed", args[0]))
+		}
+		outputSummary(args[0], result)
+		if result.Summary.SuccessCount != result.Summary.TargetCount {
+			os.Exit(1)
+		}
+	},
+}
+
+func outputSummary(name string, result model.SummaryResult) {
+	summary := result.Summary
+	color := utils.ColorGreen()
+	if summary.SuccessCount != summary.TargetCount {
+		color = utils.ColorRed()
+	}
+	action := "deployed"
+	if summary.IsRemoval {
+		action = "removed"
+	}
+	fmt.Printf("%sInstance %s%s (generation %s, %s)\n", utils.ColorCyan(), utils.ColorReset(), name, result.Generation, result.Time.Local().Format(time.RFC3339))
+	fmt.Printf("%s%d of %d targets %s%s\n", color, summary.SuccessCount, summary.TargetCount, action, utils.ColorReset())
+	if summary.Skipped {
+		fmt.Println("No changes were needed")
+	}
+	if summary.SummaryMessage != "" {
+		fmt.Printf("%s%s%s\n", utils.ColorRed(), summary.SummaryMessage, utils.ColorReset())
+	}
+
+	targets := make([]string, 0, len(summary.TargetResults))
+	for k := range summary.TargetResults {
+		targets = append(targets, k)
+	}
+	sort.Strings(targets)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Target", "Component", "Status", "Message"})
+	for _, target := range targets {
+		r := summary.TargetResults[target]
+		t.AppendRow(table.Row{target, "", r.Status, r.Message})
+		components := make([]string, 0, len(r.ComponentResults))
+		for k := range r.ComponentResults {
+			components = append(components, k)
+		}
+		sort.Strings(components)
+		for _, component := range components {
+			cr := r.ComponentResults[component]
+			t.AppendRow(table.Row{"", component, cr.Status.String(), cr.Message})
+		}
+	}
+	t.SetStyle(table.StyleColoredBright)
+	t.Render()
+}
+
+func init() {
+	StatusInstanceCmd.Flags().StringVarP(&statusScope, "scope", "s", "", "Scope (namespace) of the instance")
+	StatusInstanceCmd.Flags().StringVarP(&statusConfigFile, "config", "c", "", "Maestro CLI config file")
+	StatusInstanceCmd.Flags().StringVarP(&statusConfigContext, "context", "", "", "Maestro CLI configuration context")
+	StatusCmd.AddCommand(StatusInstanceCmd)
+	RootCmd.AddCommand(StatusCmd)
+}
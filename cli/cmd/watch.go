@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval      time.Duration
+	watchTimeout       time.Duration
+	watchConfigFile    string
+	watchConfigContext string
+)
+
+var WatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow the progress of Symphony objects",
+}
+
+var WatchActivationCmd = &cobra.Command{
+	Use:   "activation <name>",
+	Short: "Follow an activation through the stages of its campaign until it completes. Exits with 1 when a stage fails.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getMaestroContext(watchConfigFile, watchConfigContext)
+		name := args[0]
+		var deadline time.Time
+		if watchTimeout > 0 {
+			deadline = time.Now().Add(watchTimeout)
+		}
+		var last *model.ActivationStatus
+		for {
+			activation, found, err := utils.GetActivation(c.Url, c.User, c.Secret, name)
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to read activation '%s': %s", name, err.Error()))
+			}
+			if !found {
+				exitWithError(fmt.Errorf("activation '%s' is not found", name))
+			}
+			status := activation.Status
+			if status != nil && activationChanged(last, status) {
+				outputActivationStatus(status)
+				last = status
+			}
+			switch utils.GetActivationPhase(status) {
+			case utils.ActivationSucceeded:
+				fmt.Printf("%sActivation %s completed%s\n", utils.ColorGreen(), name, utils.ColorReset())
+				return
+			case utils.ActivationFailed:
+				exitWithError(fmt.Errorf("activation '%s' failed in stage '%s'", name, status.Stage))
+			}
+			if !deadline.IsZero() && time.Now().After(deadline) {
+				exitWithError(fmt.Errorf("activation '%s' didn't complete within %s", name, watchTimeout))
+			}
+			time.Sleep(watchInterval)
+		}
+	},
+}
+
+// activationChanged returns true when an activation moved to another stage or state since it was last printed
+func activationChanged(last *model.ActivationStatus, current *model.ActivationStatus) bool {
+	return last == nil ||
+		last.Stage != current.Stage ||
+		last.NextStage != current.NextStage ||
+		last.Status != current.Status ||
+		last.IsActive != current.IsActive ||
+		last.UpdateTime != current.UpdateTime
+}
+
+func outputActivationStatus(status *model.ActivationStatus) {
+	color := utils.ColorCyan()
+	if utils.IsErrorState(status.Status) {
+		color = utils.ColorRed()
+	}
+	fmt.Printf("%s%s %s%s\n", color, time.Now().Format(time.RFC3339), utils.FormatActivationTransition(status), utils.ColorReset())
+	if status.ErrorMessage != "" {
+		fmt.Printf("%s  %s%s\n", utils.ColorRed(), status.ErrorMessage, utils.ColorReset())
+	}
+	sites := utils.GetSiteOutputs(status.Outputs)
+	if len(sites) == 0 {
+		return
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Site", "Status", "Error", "Outputs"})
+	for _, s := range sites {
+		t.AppendRow(table.Row{s.Site, s.Status, s.Error, utils.FormatOutputs(s.Outputs)})
+	}
+	t.SetStyle(table.StyleColoredBright)
+	t.Render()
+}
+
+func init() {
+	WatchActivationCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 2*time.Second, "How often to poll the activation")
+	WatchActivationCmd.Flags().DurationVarP(&watchTimeout, "timeout", "t", 0, "How long to wait for the activation to complete (0 waits forever)")
+	WatchActivationCmd.Flags().StringVarP(&watchConfigFile, "config", "c", "", "Maestro CLI config file")
+	WatchActivationCmd.Flags().StringVarP(&watchConfigContext, "context", "", "", "Maestro CLI configuration context")
+	WatchCmd.AddCommand(WatchActivationCmd)
+	RootCmd.AddCommand(WatchCmd)
+}
@@ -15,10 +15,30 @@ import (
 	"strings"
 )
 
+// Credentials are the secret parts of a context. They are saved in the config file, or handed to the context's
+// credential helper when it has one.
+type Credentials struct {
+	Secret       string `json:"secret,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	TokenExpiry  int64  `json:"tokenExpiry,omitempty"`
+}
+
+// OIDCConfig configures a context to sign in with the OAuth 2.0 device flow of an OIDC provider instead of a
+// Symphony user name and password
+type OIDCConfig struct {
+	Issuer     string   `json:"issuer"`
+	ClientId   string   `json:"clientId"`
+	Scopes     []string `json:"scopes,omitempty"`
+	UseIdToken bool     `json:"useIdToken,omitempty"`
+}
+
 type MaestroContext struct {
-	Url    string `json:"url"`
-	User   string `json:"user"`
-	Secret string `json:"secret,omitempty"`
+	Url  string `json:"url"`
+	User string `json:"user"`
+	Credentials
+	CredentialHelper string      `json:"credentialHelper,omitempty"`
+	OIDC             *OIDCConfig `json:"oidc,omitempty"`
 }
 type MaestroConfig struct {
 	DefaultContext string                    `json:"default,omitempty"`
@@ -31,9 +51,8 @@ func UpdateMaestroConfig(context string, address string) error {
 		config.Contexts = make(map[string]MaestroContext)
 	}
 	config.Contexts[context] = MaestroContext{
-		Url:    "http://" + address + ":8080/v1alpha2",
-		User:   "admin",
-		Secret: "",
+		Url:  "http://" + address + ":8080/v1alpha2",
+		User: "admin",
 	}
 	config.DefaultContext = context
 	return SaveMaestroConfig(config)
@@ -43,12 +62,19 @@ func SaveMaestroConfig(config MaestroConfig) error {
 	if err != nil {
 		return err
 	}
-	configFile := filepath.Join(dirname, ".symphony", ".config.json")
-	file, err := os.OpenFile(configFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 064)
+	return saveMaestroConfigFile(filepath.Join(dirname, ".symphony", ".config.json"), config)
+}
+func saveMaestroConfigFile(configFile string, config MaestroConfig) error {
+	file, err := os.OpenFile(configFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	// the config file may hold secrets and tokens, so make sure it's only readable by its owner even if it was
+	// created with looser permissions
+	if err := file.Chmod(0600); err != nil {
+		return err
+	}
 
 	b, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
@@ -74,7 +100,7 @@ func GetMaestroConfig(path string) MaestroConfig {
 		}
 	}
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
-		file, err := os.Create(fileName)
+		file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE, 0600)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -90,28 +116,35 @@ func GetMaestroConfig(path string) MaestroConfig {
 	}
 	for _, f := range files {
 		if f != "" {
-			content, err := ioutil.ReadFile(f)
-			if err == nil {
-				var config MaestroConfig
-				err = json.Unmarshal(content, &config)
-				if err == nil {
-					for k, v := range config.Contexts {
-						ret.Contexts[k] = v
-					}
-					if config.DefaultContext != "" {
-						ret.DefaultContext = config.DefaultContext
-					}
-				}
+			config := readMaestroConfigFile(f)
+			for k, v := range config.Contexts {
+				ret.Contexts[k] = v
+			}
+			if config.DefaultContext != "" {
+				ret.DefaultContext = config.DefaultContext
 			}
 		}
 	}
 	if len(ret.Contexts) == 0 {
 		ret.Contexts["default"] = MaestroContext{
-			Url:    "http://localhost:8080/v1alpha2",
-			User:   "admin",
-			Secret: "",
+			Url:  "http://localhost:8080/v1alpha2",
+			User: "admin",
 		}
 		ret.DefaultContext = "default"
 	}
 	return ret
 }
+
+// readMaestroConfigFile reads a single config file. A missing or invalid file reads as an empty configuration.
+func readMaestroConfigFile(path string) MaestroConfig {
+	var config MaestroConfig
+	if content, err := ioutil.ReadFile(path); err == nil {
+		if json.Unmarshal(content, &config) != nil {
+			config = MaestroConfig{}
+		}
+	}
+	if config.Contexts == nil {
+		config.Contexts = make(map[string]MaestroContext)
+	}
+	return config
+}
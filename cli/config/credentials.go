@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// credentialsNotFound is what credential helpers print when they have nothing stored for a server
+const credentialsNotFound = "credentials not found"
+
+// helperCredentials is the payload of a credential helper. Helpers follow the protocol of the Docker credential
+// helpers (docker-credential-pass, docker-credential-osxkeychain, ...), so any of them can be used: the
+// "get", "store" and "erase" actions take the server URL or this payload on stdin.
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// LoadCredentials fills in the credentials of a context that keeps them in a credential helper. Contexts without a
+// helper already have their credentials from the config file.
+func LoadCredentials(name string, context *MaestroContext) error {
+	if context.CredentialHelper == "" {
+		return nil
+	}
+	out, err := runCredentialHelper(context.CredentialHelper, "get", []byte(helperServerURL(name)))
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), credentialsNotFound) {
+			return nil
+		}
+		return err
+	}
+	var stored helperCredentials
+	if err := json.Unmarshal(out, &stored); err != nil {
+		return fmt.Errorf("credential helper '%s' returned invalid credentials: %v", context.CredentialHelper, err)
+	}
+	if stored.Username != "" {
+		context.User = stored.Username
+	}
+	if err := json.Unmarshal([]byte(stored.Secret), &context.Credentials); err != nil {
+		return fmt.Errorf("credential helper '%s' returned invalid credentials: %v", context.CredentialHelper, err)
+	}
+	return nil
+}
+
+// StoreCredentials saves a context in the config file it was read from, see saveContext. When the context has a
+// credential helper, its credentials are handed to the helper and left out of the file.
+func StoreCredentials(path string, name string, context MaestroContext) error {
+	if context.CredentialHelper != "" {
+		secret, err := json.Marshal(context.Credentials)
+		if err != nil {
+			return err
+		}
+		payload, _ := json.Marshal(helperCredentials{
+			ServerURL: helperServerURL(name),
+			Username:  context.User,
+			Secret:    string(secret),
+		})
+		if _, err := runCredentialHelper(context.CredentialHelper, "store", payload); err != nil {
+			return err
+		}
+		context.Credentials = Credentials{}
+	}
+	return saveContext(path, name, context)
+}
+
+// EraseCredentials removes the credentials of a context from its credential helper and from its config file
+func EraseCredentials(path string, name string, context MaestroContext) error {
+	if context.CredentialHelper != "" {
+		if _, err := runCredentialHelper(context.CredentialHelper, "erase", []byte(helperServerURL(name))); err != nil &&
+			!strings.Contains(strings.ToLower(err.Error()), credentialsNotFound) {
+			return err
+		}
+	}
+	context.Credentials = Credentials{}
+	return saveContext(path, name, context)
+}
+
+// saveContext writes a context back to its config file. path is the --config flag the context was read with: empty
+// for the user's config file, otherwise a ':' separated list of files. The context is written to the last of them
+// that defines it, as that's the one it was read from, and a new context goes to the first one.
+func saveContext(path string, name string, context MaestroContext) error {
+	if path == "" {
+		config := GetMaestroConfig("")
+		setContext(&config, name, context)
+		return SaveMaestroConfig(config)
+	}
+	file := ""
+	for _, f := range strings.Split(path, ":") {
+		if f == "" {
+			continue
+		}
+		if file == "" {
+			file = f
+		}
+		if _, ok := readMaestroConfigFile(f).Contexts[name]; ok {
+			file = f
+		}
+	}
+	if file == "" {
+		return fmt.Errorf("config file '%s' is invalid", path)
+	}
+	config := readMaestroConfigFile(file)
+	setContext(&config, name, context)
+	return saveMaestroConfigFile(file, config)
+}
+
+func setContext(config *MaestroConfig, name string, context MaestroContext) {
+	config.Contexts[name] = context
+	if config.DefaultContext == "" {
+		config.DefaultContext = name
+	}
+}
+
+// helperServerURL is the key the credentials of a context are stored under in a credential helper
+func helperServerURL(name string) string {
+	return "symphony-maestro://" + name
+}
+
+func runCredentialHelper(helper string, action string, input []byte) ([]byte, error) {
+	args := strings.Fields(helper)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("credential helper is empty")
+	}
+	cmd := exec.Command(args[0], append(args[1:], action)...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String() + " " + stderr.String())
+		return nil, fmt.Errorf("credential helper '%s %s' failed: %v %s", helper, action, err, msg)
+	}
+	return stdout.Bytes(), nil
+}
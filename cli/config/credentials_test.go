@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeHelper is a credential helper that keeps the last stored payload in a file next to it
+const fakeHelper = `#!/bin/sh
+store="$(dirname "$0")/store.json"
+case "$1" in
+  store) cat > "$store" ;;
+  get) if [ -f "$store" ]; then cat "$store"; else echo "credentials not found in native keychain"; exit 1; fi ;;
+  erase) rm -f "$store" ;;
+esac
+`
+
+func TestSaveMaestroConfigIsPrivate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	GetMaestroConfig("")
+	configFile := filepath.Join(home, ".symphony", ".config.json")
+	assert.Nil(t, os.Chmod(configFile, 0644))
+
+	assert.Nil(t, StoreCredentials("", "dev", MaestroContext{
+		Url:         "http://localhost:8080/v1alpha2",
+		User:        "admin",
+		Credentials: Credentials{Secret: "s3cret", Token: "token"},
+	}))
+	info, err := os.Stat(configFile)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	c := GetMaestroConfig("")
+	assert.Equal(t, "s3cret", c.Contexts["dev"].Secret)
+	assert.Equal(t, "token", c.Contexts["dev"].Token)
+}
+
+func TestCredentialHelper(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	helper := filepath.Join(t.TempDir(), "fake-helper")
+	assert.Nil(t, os.WriteFile(helper, []byte(fakeHelper), 0700))
+
+	context := MaestroContext{Url: "http://localhost:8080/v1alpha2", User: "admin", CredentialHelper: helper}
+	assert.Nil(t, LoadCredentials("dev", &context))
+	assert.Equal(t, "", context.Token)
+
+	context.Credentials = Credentials{Secret: "s3cret", Token: "token", TokenExpiry: 100}
+	assert.Nil(t, StoreCredentials("", "dev", context))
+
+	saved := GetMaestroConfig("").Contexts["dev"]
+	assert.Equal(t, helper, saved.CredentialHelper)
+	assert.Equal(t, Credentials{}, saved.Credentials)
+
+	assert.Nil(t, LoadCredentials("dev", &saved))
+	assert.Equal(t, "s3cret", saved.Secret)
+	assert.Equal(t, "token", saved.Token)
+	assert.Equal(t, int64(100), saved.TokenExpiry)
+
+	assert.Nil(t, EraseCredentials("", "dev", saved))
+	loaded := GetMaestroConfig("").Contexts["dev"]
+	assert.Nil(t, LoadCredentials("dev", &loaded))
+	assert.Equal(t, "", loaded.Token)
+}
+
+func TestStoreCredentialsInConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	assert.Nil(t, os.WriteFile(first, []byte(`{"default":"prod","contexts":{"prod":{"url":"http://prod"}}}`), 0600))
+	assert.Nil(t, os.WriteFile(second, []byte(`{"contexts":{"dev":{"url":"http://dev"}}}`), 0600))
+	path := first + ":" + second
+
+	// a context is saved to the file it's defined in
+	assert.Nil(t, StoreCredentials(path, "dev", MaestroContext{
+		Url:         "http://dev",
+		Credentials: Credentials{Token: "token"},
+	}))
+	assert.Equal(t, "token", GetMaestroConfig(second).Contexts["dev"].Token)
+	assert.NotContains(t, GetMaestroConfig(first).Contexts, "dev")
+	assert.NotContains(t, GetMaestroConfig("").Contexts, "dev")
+
+	// a new context goes to the first file
+	assert.Nil(t, StoreCredentials(path, "test", MaestroContext{Url: "http://test"}))
+	c := GetMaestroConfig(first)
+	assert.Equal(t, "http://test", c.Contexts["test"].Url)
+	assert.Equal(t, "http://prod", c.Contexts["prod"].Url)
+	assert.Equal(t, "prod", c.DefaultContext)
+
+	assert.Nil(t, EraseCredentials(path, "dev", GetMaestroConfig(path).Contexts["dev"]))
+	assert.Equal(t, "", GetMaestroConfig(second).Contexts["dev"].Token)
+	assert.NotContains(t, GetMaestroConfig("").Contexts, "dev")
+}
@@ -9,18 +9,24 @@ replace github.com/eclipse-symphony/symphony/coa => ../coa
 require github.com/spf13/cobra v1.6.1
 
 require (
-	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
-	github.com/eclipse-symphony/symphony/coa v0.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/exp v0.0.0-20220929160808-de9c53c655b9 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 	helm.sh/helm/v3 v3.10.0 // indirect
 )
 
-require github.com/princjef/mageutil v1.0.0
+require (
+	github.com/cenkalti/backoff/v4 v4.2.1
+	github.com/eclipse-symphony/symphony/coa v0.0.0
+	github.com/princjef/mageutil v1.0.0
+	github.com/stretchr/testify v1.8.2
+)
 
 require (
 	github.com/eclipse-symphony/symphony/api v0.0.0
@@ -29,6 +35,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.7.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
 	sigs.k8s.io/yaml v1.3.0
 )
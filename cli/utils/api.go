@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"sigs.k8s.io/yaml"
 )
 
@@ -28,19 +29,11 @@ type authResponse struct {
 }
 
 func Remove(url string, username string, password string, objType string, objName string) error {
-	token, err := Login(url, username, password)
+	token, err := getToken(url, username, password)
 	if err != nil {
 		return err
 	}
-	route := ""
-	switch objType {
-	case "target", "targets":
-		route = "/targets/registry"
-	case "solution", "solutions":
-		route = "/solutions"
-	case "instance", "instances":
-		route = "/instances"
-	}
+	route := objectRoute(objType)
 	if objName == "" {
 		return errors.New("object name is missing")
 	}
@@ -52,19 +45,11 @@ func Remove(url string, username string, password string, objType string, objNam
 	return nil
 }
 func Upsert(url string, username string, password string, objType string, objName string, payload []byte) error {
-	token, err := Login(url, username, password)
+	token, err := getToken(url, username, password)
 	if err != nil {
 		return err
 	}
-	route := ""
-	switch objType {
-	case "target", "targets":
-		route = "/targets/registry"
-	case "solution", "solutions":
-		route = "/solutions"
-	case "instance", "instances":
-		route = "/instances"
-	}
+	route := objectRoute(objType)
 	if objName == "" {
 		return errors.New("object name is missing")
 	}
@@ -80,6 +65,112 @@ func Upsert(url string, username string, password string, objType string, objNam
 	return nil
 }
 
+// ApplyManifest creates or updates the object on the server
+func ApplyManifest(url string, username string, password string, manifest Manifest) error {
+	token, err := getToken(url, username, password)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(manifest.Spec)
+	if err != nil {
+		return err
+	}
+	_, err = callRestAPI(url, objectRoute(manifest.Kind)+"/"+manifest.Name, "POST", payload, token, scopeParameters(manifest))
+	return err
+}
+
+// DeleteManifest deletes the object from the server. Deleting an object that doesn't exist isn't an error.
+func DeleteManifest(url string, username string, password string, manifest Manifest) error {
+	token, err := getToken(url, username, password)
+	if err != nil {
+		return err
+	}
+	_, err = callRestAPI(url, objectRoute(manifest.Kind)+"/"+manifest.Name, "DELETE", nil, token, scopeParameters(manifest))
+	return err
+}
+
+// GetManifestSpec reads the current spec of the object from the server. It returns false if the object doesn't exist.
+func GetManifestSpec(url string, username string, password string, manifest Manifest) (interface{}, bool, error) {
+	token, err := getToken(url, username, password)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := callRestAPI(url, objectRoute(manifest.Kind)+"/"+manifest.Name, "GET", nil, token, scopeParameters(manifest))
+	if err != nil {
+		return nil, false, err
+	}
+	if resp == nil {
+		return nil, false, nil
+	}
+	var state struct {
+		Spec interface{} `json:"spec"`
+	}
+	if err := json.Unmarshal(resp, &state); err != nil {
+		return nil, false, err
+	}
+	return state.Spec, true, nil
+}
+
+// GetInstanceSummary reads the summary of the last deployment of an instance. It returns false if the instance
+// hasn't been deployed.
+func GetInstanceSummary(url string, username string, password string, name string, scope string) (model.SummaryResult, bool, error) {
+	var summary model.SummaryResult
+	token, err := getToken(url, username, password)
+	if err != nil {
+		return summary, false, err
+	}
+	params := map[string]string{"instance": name}
+	if scope != "" {
+		params["scope"] = scope
+	}
+	resp, err := callRestAPI(url, "/solution/queue", "GET", nil, token, params)
+	if err != nil || resp == nil {
+		return summary, false, err
+	}
+	err = json.Unmarshal(resp, &summary)
+	return summary, err == nil, err
+}
+
+// GetActivation reads an activation and its status. It returns false if the activation doesn't exist.
+func GetActivation(url string, username string, password string, name string) (model.ActivationState, bool, error) {
+	var activation model.ActivationState
+	token, err := getToken(url, username, password)
+	if err != nil {
+		return activation, false, err
+	}
+	resp, err := callRestAPI(url, "/activations/registry/"+name, "GET", nil, token, nil)
+	if err != nil || resp == nil {
+		return activation, false, err
+	}
+	err = json.Unmarshal(resp, &activation)
+	return activation, err == nil, err
+}
+
+func objectRoute(objType string) string {
+	switch objType {
+	case "target", "targets":
+		return "/targets/registry"
+	case "device", "devices":
+		return "/devices"
+	case "solution", "solutions":
+		return "/solutions"
+	case "instance", "instances":
+		return "/instances"
+	case "catalog", "catalogs":
+		return "/catalogs/registry"
+	case "campaign", "campaigns":
+		return "/campaigns"
+	}
+	return ""
+}
+
+func scopeParameters(manifest Manifest) map[string]string {
+	if manifest.Scope == "" {
+		return nil
+	}
+	return map[string]string{"scope": manifest.Scope}
+}
+
 type YamlArtifact struct {
 	APIVersion string            `json:"apiVersion"`
 	Kind       string            `json:"kind"`
@@ -97,21 +188,11 @@ func yamlToJson(payload []byte) ([]byte, error) {
 }
 
 func Get(url string, username string, password string, objType string, path string, docType string, objName string) ([]interface{}, error) {
-	token, err := Login(url, username, password)
+	token, err := getToken(url, username, password)
 	if err != nil {
 		return nil, err
 	}
-	route := ""
-	switch objType {
-	case "target", "targets":
-		route = "/targets/registry"
-	case "device", "devices":
-		route = "/devices"
-	case "solution", "solutions":
-		route = "/solutions"
-	case "instance", "instances":
-		route = "/instances"
-	}
+	route := objectRoute(objType)
 	if objName != "" {
 		route += "/" + objName
 	}
@@ -144,22 +225,12 @@ func Get(url string, username string, password string, objType string, path stri
 }
 
 func Login(url string, username string, password string) (string, error) {
-	data, _ := json.Marshal(authRequest{
-		UserName: username,
-		Password: password,
-	})
-	resp, err := callRestAPI(url, "/users/auth", "POST", data, "", nil)
+	credentials, err := RequestToken(url, username, password)
 	if err != nil {
 		return "", err
 	}
-	var authResp authResponse
-	err = json.Unmarshal(resp, &authResp)
-	if err != nil {
-		return "", err
-	}
-	return "Bearer " + authResp.AccessToken, nil
+	return "Bearer " + credentials.Token, nil
 }
-
 func callRestAPI(url string, route string, method string, payload []byte, token string, parameters map[string]string) ([]byte, error) {
 	client := &http.Client{}
 	rUrl := url + route
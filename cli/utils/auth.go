@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+)
+
+// tokenRefreshMargin is how long before its expiry a cached token is replaced, so that it doesn't expire in flight
+const tokenRefreshMargin = time.Minute
+
+// session authenticates the API calls made to the URL of the context in use
+type session struct {
+	configFile string
+	name       string
+	context    config.MaestroContext
+}
+
+var currentSession *session
+
+// UseContext makes the API calls to the context's URL use the context's cached token. An expired token is replaced
+// through the context's refresh token or stored password, and the new token is saved back to where the context
+// keeps its credentials. configFile is the config file the context was read from, as given to --config.
+func UseContext(configFile string, name string, context config.MaestroContext) error {
+	if err := config.LoadCredentials(name, &context); err != nil {
+		return err
+	}
+	currentSession = &session{configFile: configFile, name: name, context: context}
+	return nil
+}
+
+// RequestToken exchanges a user name and password for a Symphony token. The token's expiry is read from its claims.
+func RequestToken(url string, username string, password string) (config.Credentials, error) {
+	data, _ := json.Marshal(authRequest{
+		UserName: username,
+		Password: password,
+	})
+	resp, err := callRestAPI(url, "/users/auth", "POST", data, "", nil)
+	if err != nil {
+		return config.Credentials{}, err
+	}
+	var authResp authResponse
+	if err := json.Unmarshal(resp, &authResp); err != nil {
+		return config.Credentials{}, err
+	}
+	return config.Credentials{
+		Secret:      password,
+		Token:       authResp.AccessToken,
+		TokenExpiry: tokenExpiry(authResp.AccessToken),
+	}, nil
+}
+
+// getToken returns the Authorization header for a call to the Symphony API
+func getToken(url string, username string, password string) (string, error) {
+	if s := currentSession; s != nil && s.context.Url == url {
+		return s.token()
+	}
+	return Login(url, username, password)
+}
+
+func (s *session) token() (string, error) {
+	c := &s.context
+	if c.Token != "" && (c.TokenExpiry == 0 || time.Unix(c.TokenExpiry, 0).After(time.Now().Add(tokenRefreshMargin))) {
+		return "Bearer " + c.Token, nil
+	}
+	// only contexts signed in with "maestro login" cache their tokens
+	cached := c.Token != ""
+	var credentials config.Credentials
+	var err error
+	if c.OIDC != nil {
+		if c.RefreshToken == "" {
+			return "", fmt.Errorf("the session of context '%s' has expired, please run 'maestro login --context %s'", s.name, s.name)
+		}
+		credentials, err = RefreshOIDCToken(*c.OIDC, c.RefreshToken)
+	} else {
+		credentials, err = RequestToken(c.Url, c.User, c.Secret)
+	}
+	if err != nil {
+		return "", err
+	}
+	if credentials.RefreshToken == "" {
+		credentials.RefreshToken = c.RefreshToken
+	}
+	c.Credentials = credentials
+	if cached {
+		if err := config.StoreCredentials(s.configFile, s.name, *c); err != nil {
+			return "", err
+		}
+	}
+	return "Bearer " + c.Token, nil
+}
+
+// tokenExpiry reads the "exp" claim of a JWT without verifying the token. It returns 0 if the token isn't a JWT or
+// doesn't expire.
+func tokenExpiry(token string) int64 {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return 0
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return 0
+	}
+	return int64(claims.Exp)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testJWT(expiry time.Time) string {
+	claims, _ := json.Marshal(map[string]interface{}{"sub": "admin", "exp": expiry.Unix()})
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(claims) + ".signature"
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	assert.Equal(t, expiry.Unix(), tokenExpiry(testJWT(expiry)))
+	assert.Equal(t, int64(0), tokenExpiry("opaque-token"))
+}
+
+func TestSessionRefreshesExpiredToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logins := 0
+	fresh := testJWT(time.Now().Add(time.Hour))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req authRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "s3cret", req.Password)
+		logins++
+		fmt.Fprintf(w, `{"accessToken":"%s","tokenType":"Bearer"}`, fresh)
+	}))
+	defer server.Close()
+
+	context := config.MaestroContext{
+		Url:         server.URL,
+		User:        "admin",
+		Credentials: config.Credentials{Secret: "s3cret", Token: "expired", TokenExpiry: time.Now().Add(-time.Hour).Unix()},
+	}
+	assert.Nil(t, UseContext("", "dev", context))
+	defer func() { currentSession = nil }()
+
+	token, err := getToken(server.URL, "admin", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer "+fresh, token)
+	token, err = getToken(server.URL, "admin", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer "+fresh, token)
+	assert.Equal(t, 1, logins)
+
+	// the refreshed token is saved for the next command
+	assert.Equal(t, fresh, config.GetMaestroConfig("").Contexts["dev"].Token)
+}
+
+func TestSessionSavesTokenToConfigFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fresh := testJWT(time.Now().Add(time.Hour))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"accessToken":"%s","tokenType":"Bearer"}`, fresh)
+	}))
+	defer server.Close()
+
+	configFile := filepath.Join(t.TempDir(), "maestro.json")
+	context := config.MaestroContext{
+		Url:         server.URL,
+		User:        "admin",
+		Credentials: config.Credentials{Secret: "s3cret", Token: "expired", TokenExpiry: time.Now().Add(-time.Hour).Unix()},
+	}
+	data, _ := json.Marshal(config.MaestroConfig{Contexts: map[string]config.MaestroContext{"dev": context}})
+	assert.Nil(t, os.WriteFile(configFile, data, 0600))
+
+	assert.Nil(t, UseContext(configFile, "dev", context))
+	defer func() { currentSession = nil }()
+	_, err := getToken(server.URL, "admin", "")
+	assert.Nil(t, err)
+
+	assert.Equal(t, fresh, config.GetMaestroConfig(configFile).Contexts["dev"].Token)
+	assert.NotContains(t, config.GetMaestroConfig("").Contexts, "dev")
+}
+
+func TestDeviceLogin(t *testing.T) {
+	defaultDevicePollInterval = 10 * time.Millisecond
+	polls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"device_authorization_endpoint":"%s/device","token_endpoint":"%s/token"}`, server.URL, server.URL)
+		case "/device":
+			assert.Equal(t, "maestro", r.FormValue("client_id"))
+			assert.Equal(t, "openid offline_access", r.FormValue("scope"))
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"ABCD-EFGH","verification_uri":"https://login/device","expires_in":60}`)
+		case "/token":
+			assert.Equal(t, deviceCodeGrantType, r.FormValue("grant_type"))
+			assert.Equal(t, "dc", r.FormValue("device_code"))
+			polls++
+			if polls < 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"authorization_pending"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"access","id_token":"id","refresh_token":"refresh","expires_in":3600}`)
+		}
+	}))
+	defer server.Close()
+
+	var prompted DeviceCode
+	credentials, err := DeviceLogin(config.OIDCConfig{Issuer: server.URL, ClientId: "maestro"}, func(code DeviceCode) {
+		prompted = code
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "ABCD-EFGH", prompted.UserCode)
+	assert.Equal(t, 2, polls)
+	assert.Equal(t, "access", credentials.Token)
+	assert.Equal(t, "refresh", credentials.RefreshToken)
+	assert.InDelta(t, time.Now().Unix()+3600, credentials.TokenExpiry, 5)
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is a Symphony object read from a YAML or JSON document
+type Manifest struct {
+	Kind   string
+	Name   string
+	Scope  string
+	Spec   interface{}
+	Source string
+}
+
+type manifestDocument struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"metadata"`
+	Spec interface{} `json:"spec"`
+}
+
+// kindOrder is the order objects are applied in, so that an object is created after the objects it refers to.
+// Objects are deleted in the reverse order.
+var kindOrder = map[string]int{
+	"catalog":  0,
+	"target":   1,
+	"solution": 2,
+	"instance": 3,
+	"campaign": 4,
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// ReadManifests reads the objects in a file, or in the .yaml, .yml and .json files of a directory. A file may
+// contain multiple YAML documents separated by "---".
+func ReadManifests(path string) ([]Manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = make([]string, 0)
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			if !e.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	ret := make([]Manifest, 0)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		manifests, err := ParseManifests(data, f)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, manifests...)
+	}
+	return ret, nil
+}
+
+// ParseManifests parses the documents in a (multi-document) YAML or JSON payload
+func ParseManifests(data []byte, source string) ([]Manifest, error) {
+	ret := make([]Manifest, 0)
+	for i, doc := range documentSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var d manifestDocument
+		if err := yaml.Unmarshal([]byte(doc), &d); err != nil {
+			return nil, fmt.Errorf("%s: document %d: %v", source, i+1, err)
+		}
+		if d.Kind == "" && d.Metadata.Name == "" && d.Spec == nil {
+			// a document with only comments
+			continue
+		}
+		kind := strings.ToLower(d.Kind)
+		if _, ok := kindOrder[kind]; !ok {
+			return nil, fmt.Errorf("%s: document %d: unsupported kind '%s'", source, i+1, d.Kind)
+		}
+		if d.Metadata.Name == "" {
+			return nil, fmt.Errorf("%s: document %d: %s name is missing", source, i+1, d.Kind)
+		}
+		ret = append(ret, Manifest{
+			Kind:   kind,
+			Name:   d.Metadata.Name,
+			Scope:  d.Metadata.Namespace,
+			Spec:   d.Spec,
+			Source: source,
+		})
+	}
+	return ret, nil
+}
+
+// SortManifests orders the objects so that catalogs and targets come before the solutions and instances that use
+// them. When reverse is true, the objects are ordered for deletion instead.
+func SortManifests(manifests []Manifest, reverse bool) {
+	sort.SliceStable(manifests, func(i, j int) bool {
+		if reverse {
+			return kindOrder[manifests[i].Kind] > kindOrder[manifests[j].Kind]
+		}
+		return kindOrder[manifests[i].Kind] < kindOrder[manifests[j].Kind]
+	})
+}
+
+// SpecChange is a field-level difference between a local object and the object on the server
+type SpecChange struct {
+	Path     string
+	Old      interface{}
+	New      interface{}
+	Added    bool
+	Removed  bool
+	Modified bool
+}
+
+func (c SpecChange) String() string {
+	switch {
+	case c.Added:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatValue(c.New))
+	case c.Removed:
+		return fmt.Sprintf("- %s: %s", c.Path, formatValue(c.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, formatValue(c.Old), formatValue(c.New))
+}
+
+// DiffSpecs compares the current spec on the server with the local spec. Both are first normalized through the
+// object's model so that defaults and omitted empty values don't show up as changes. Fields the server manages,
+// such as the generation, are ignored.
+func DiffSpecs(kind string, current interface{}, desired interface{}) ([]SpecChange, error) {
+	currentMap, err := normalizeSpec(kind, current)
+	if err != nil {
+		return nil, err
+	}
+	desiredMap, err := normalizeSpec(kind, desired)
+	if err != nil {
+		return nil, err
+	}
+	delete(currentMap, "generation")
+	delete(desiredMap, "generation")
+	changes := make([]SpecChange, 0)
+	for _, c := range model.DiffProperties(pruneEmptyValues(currentMap), pruneEmptyValues(desiredMap)) {
+		changes = append(changes, SpecChange{
+			Path:     "spec." + c.Path,
+			Old:      c.OldValue,
+			New:      c.NewValue,
+			Added:    c.Op == model.PropertyAdded,
+			Removed:  c.Op == model.PropertyRemoved,
+			Modified: c.Op == model.PropertyReplaced,
+		})
+	}
+	return changes, nil
+}
+
+func normalizeSpec(kind string, spec interface{}) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	if spec == nil {
+		return ret, nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var typed interface{}
+	switch kind {
+	case "catalog":
+		typed = &model.CatalogSpec{}
+	case "target":
+		typed = &model.TargetSpec{}
+	case "solution":
+		typed = &model.SolutionSpec{}
+	case "instance":
+		typed = &model.InstanceSpec{}
+	case "campaign":
+		typed = &model.CampaignSpec{}
+	}
+	if typed != nil {
+		if err := json.Unmarshal(data, typed); err != nil {
+			return nil, fmt.Errorf("invalid %s spec: %v", kind, err)
+		}
+		data, _ = json.Marshal(typed)
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// pruneEmptyValues drops the fields with empty values, so that a field that's empty on one side and omitted on the
+// other isn't reported as a change
+func pruneEmptyValues(m map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		v = pruneValue(v)
+		if !isEmptyValue(v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+func pruneValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return pruneEmptyValues(t)
+	case []interface{}:
+		ret := make([]interface{}, len(t))
+		for i, item := range t {
+			ret[i] = pruneValue(item)
+		}
+		return ret
+	}
+	return v
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	case float64:
+		return t == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifests = `
+apiVersion: solution.symphony/v1
+kind: Instance
+metadata:
+  name: instance-1
+  namespace: tenant-a
+spec:
+  solution: solution-1
+  target:
+    name: target-1
+---
+# the solution used by instance-1
+apiVersion: solution.symphony/v1
+kind: Solution
+metadata:
+  name: solution-1
+spec:
+  components:
+  - name: web
+    type: helm.v3
+    properties:
+      chart:
+        repo: oci://registry/web
+        version: "1.0"
+---
+apiVersion: fabric.symphony/v1
+kind: Target
+metadata:
+  name: target-1
+spec:
+  properties:
+    OS: linux
+`
+
+func TestParseManifests(t *testing.T) {
+	manifests, err := ParseManifests([]byte(testManifests), "test.yaml")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 3)
+	assert.Equal(t, "instance", manifests[0].Kind)
+	assert.Equal(t, "instance-1", manifests[0].Name)
+	assert.Equal(t, "tenant-a", manifests[0].Scope)
+
+	SortManifests(manifests, false)
+	assert.Equal(t, []string{"target", "solution", "instance"}, kinds(manifests))
+	SortManifests(manifests, true)
+	assert.Equal(t, []string{"instance", "solution", "target"}, kinds(manifests))
+}
+
+func TestParseManifestsUnsupportedKind(t *testing.T) {
+	_, err := ParseManifests([]byte("kind: Device\nmetadata:\n  name: d1\n"), "test.yaml")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported kind 'Device'")
+}
+
+func TestReadManifestsFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "all.yaml"), []byte(testManifests), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "catalog.json"), []byte(`{"kind":"Catalog","metadata":{"name":"config-1"},"spec":{"type":"config"}}`), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0600))
+	manifests, err := ReadManifests(dir)
+	assert.Nil(t, err)
+	SortManifests(manifests, false)
+	assert.Equal(t, []string{"catalog", "target", "solution", "instance"}, kinds(manifests))
+}
+
+func TestDiffSpecs(t *testing.T) {
+	current := map[string]interface{}{
+		"generation": "3",
+		"components": []interface{}{
+			map[string]interface{}{"name": "web", "type": "helm.v3", "properties": map[string]interface{}{"chart": map[string]interface{}{"version": "1.0"}}},
+			map[string]interface{}{"name": "db", "type": "helm.v3"},
+		},
+	}
+	desired := map[string]interface{}{
+		"displayName": "solution-1",
+		"components": []interface{}{
+			map[string]interface{}{"name": "web", "type": "helm.v3", "properties": map[string]interface{}{"chart": map[string]interface{}{"version": "1.1"}}},
+		},
+	}
+	changes, err := DiffSpecs("solution", current, desired)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "~ spec.components[0].properties.chart.version: \"1.0\" -> \"1.1\"", changes[0].String())
+	assert.True(t, changes[1].Removed)
+	assert.Equal(t, "spec.components[1]", changes[1].Path)
+	assert.Equal(t, "+ spec.displayName: \"solution-1\"", changes[2].String())
+}
+
+func TestDiffSpecsNoChanges(t *testing.T) {
+	spec := map[string]interface{}{"solution": "solution-1", "target": map[string]interface{}{"name": "target-1"}}
+	changes, err := DiffSpecs("instance", spec, spec)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 0)
+}
+
+func kinds(manifests []Manifest) []string {
+	ret := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		ret = append(ret, m.Kind)
+	}
+	return ret
+}
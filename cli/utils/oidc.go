@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// defaultDevicePollInterval is used when the provider doesn't say how often to poll for the device flow's token
+var defaultDevicePollInterval = 5 * time.Second
+
+var defaultOIDCScopes = []string{"openid", "offline_access"}
+
+// DeviceCode is the code a user enters at the provider's verification page to sign in the CLI
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+type oidcDiscovery struct {
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token,omitempty"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	ExpiresIn        int64  `json:"expires_in,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// DeviceLogin signs in with the OAuth 2.0 device authorization grant (RFC 8628) of an OIDC provider. prompt is
+// called with the code the user has to enter, and DeviceLogin returns once the user has signed in, declined or let
+// the code expire.
+func DeviceLogin(oidc config.OIDCConfig, prompt func(DeviceCode)) (config.Credentials, error) {
+	endpoints, err := discoverOIDC(oidc.Issuer)
+	if err != nil {
+		return config.Credentials{}, err
+	}
+	if endpoints.DeviceAuthorizationEndpoint == "" {
+		return config.Credentials{}, fmt.Errorf("OIDC provider '%s' doesn't support the device flow", oidc.Issuer)
+	}
+	scopes := oidc.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+	var code DeviceCode
+	err = postForm(endpoints.DeviceAuthorizationEndpoint, url.Values{
+		"client_id": {oidc.ClientId},
+		"scope":     {strings.Join(scopes, " ")},
+	}, &code)
+	if err != nil {
+		return config.Credentials{}, err
+	}
+	prompt(code)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for code.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+		resp, err := requestOIDCToken(endpoints.TokenEndpoint, url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"device_code": {code.DeviceCode},
+			"client_id":   {oidc.ClientId},
+		})
+		if err != nil {
+			return config.Credentials{}, err
+		}
+		switch resp.Error {
+		case "":
+			return oidcCredentials(oidc, resp), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return config.Credentials{}, fmt.Errorf("OIDC sign-in failed: %s %s", resp.Error, resp.ErrorDescription)
+		}
+	}
+	return config.Credentials{}, fmt.Errorf("OIDC sign-in failed: the device code has expired")
+}
+
+// RefreshOIDCToken replaces an expired OIDC token with the refresh token from the last sign-in
+func RefreshOIDCToken(oidc config.OIDCConfig, refreshToken string) (config.Credentials, error) {
+	endpoints, err := discoverOIDC(oidc.Issuer)
+	if err != nil {
+		return config.Credentials{}, err
+	}
+	resp, err := requestOIDCToken(endpoints.TokenEndpoint, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {oidc.ClientId},
+	})
+	if err != nil {
+		return config.Credentials{}, err
+	}
+	if resp.Error != "" {
+		return config.Credentials{}, fmt.Errorf("failed to refresh OIDC token: %s %s", resp.Error, resp.ErrorDescription)
+	}
+	return oidcCredentials(oidc, resp), nil
+}
+
+func oidcCredentials(oidc config.OIDCConfig, resp oidcTokenResponse) config.Credentials {
+	token := resp.AccessToken
+	if oidc.UseIdToken {
+		token = resp.IdToken
+	}
+	expiry := tokenExpiry(token)
+	if expiry == 0 && resp.ExpiresIn > 0 {
+		expiry = time.Now().Unix() + resp.ExpiresIn
+	}
+	return config.Credentials{
+		Token:        token,
+		RefreshToken: resp.RefreshToken,
+		TokenExpiry:  expiry,
+	}
+}
+
+func discoverOIDC(issuer string) (oidcDiscovery, error) {
+	var ret oidcDiscovery
+	resp, err := http.Get(strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return ret, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ret, fmt.Errorf("failed to read the OIDC configuration of '%s': [%d]", issuer, resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&ret)
+	return ret, err
+}
+
+// requestOIDCToken calls a token endpoint. OAuth errors such as "authorization_pending" come back with a 400 status
+// and are returned in the response rather than as an error.
+func requestOIDCToken(endpoint string, form url.Values) (oidcTokenResponse, error) {
+	var ret oidcTokenResponse
+	resp, err := http.PostForm(endpoint, form)
+	if err != nil {
+		return ret, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ret, err
+	}
+	if err := json.Unmarshal(body, &ret); err != nil || (resp.StatusCode >= 300 && ret.Error == "") {
+		return ret, fmt.Errorf("failed to request OIDC token: [%d] - %s", resp.StatusCode, string(body))
+	}
+	return ret, nil
+}
+
+func postForm(endpoint string, form url.Values, result interface{}) error {
+	resp, err := http.PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to invoke '%s': [%d] - %s", endpoint, resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, result)
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// ActivationPhase is where an activation is in its campaign, as seen by a watcher
+type ActivationPhase int
+
+const (
+	// ActivationPending means the activation hasn't finished yet
+	ActivationPending ActivationPhase = iota
+	// ActivationSucceeded means the last stage of the campaign is done
+	ActivationSucceeded
+	// ActivationFailed means a stage failed and the campaign stopped
+	ActivationFailed
+)
+
+// housekeepingOutputs are the outputs the stage manager adds to every stage
+var housekeepingOutputs = map[string]bool{
+	"__campaign":             true,
+	"__activation":           true,
+	"__activationGeneration": true,
+	"__stage":                true,
+	"__site":                 true,
+}
+
+// SiteOutput is the outcome of a stage on one site
+type SiteOutput struct {
+	Site    string
+	Status  string
+	Error   string
+	Outputs map[string]interface{}
+}
+
+// GetActivationPhase classifies an activation status
+func GetActivationPhase(status *model.ActivationStatus) ActivationPhase {
+	if status == nil {
+		return ActivationPending
+	}
+	if IsErrorState(status.Status) {
+		return ActivationFailed
+	}
+	if status.Status == v1alpha2.Done && status.NextStage == "" && !status.IsActive {
+		return ActivationSucceeded
+	}
+	return ActivationPending
+}
+
+// IsErrorState returns true for the states a stage or a deployment ends with when it fails
+func IsErrorState(state v1alpha2.State) bool {
+	switch state {
+	case v1alpha2.APIRedirect, v1alpha2.DeleteRequested, v1alpha2.Updated, v1alpha2.Deleted,
+		v1alpha2.Running, v1alpha2.Paused, v1alpha2.Done, v1alpha2.Delayed, v1alpha2.Untouched:
+		return false
+	}
+	return state >= v1alpha2.BadRequest
+}
+
+// FormatActivationTransition describes the stage an activation is in, for example "deploy: Done -> test"
+func FormatActivationTransition(status *model.ActivationStatus) string {
+	if status == nil {
+		return "waiting for the activation to start"
+	}
+	ret := fmt.Sprintf("%s: %s", status.Stage, status.Status.String())
+	if status.NextStage != "" {
+		ret += " -> " + status.NextStage
+	}
+	return ret
+}
+
+// GetSiteOutputs groups the outputs of a stage by the site that produced them. Outputs of remote sites are prefixed
+// with the site name and the site's status is reported as "<site>.__status". Outputs the stage manager adds to
+// every stage are left out.
+func GetSiteOutputs(outputs map[string]interface{}) []SiteOutput {
+	localSite := ""
+	if v, ok := outputs["__site"].(string); ok {
+		localSite = v
+	}
+	sites := make(map[string]*SiteOutput)
+	for k := range outputs {
+		if strings.HasSuffix(k, ".__status") {
+			site := strings.TrimSuffix(k, ".__status")
+			sites[site] = &SiteOutput{Site: site, Outputs: make(map[string]interface{})}
+		}
+	}
+	local := &SiteOutput{Site: localSite, Outputs: make(map[string]interface{})}
+	for k, v := range outputs {
+		if housekeepingOutputs[k] {
+			continue
+		}
+		target, key := local, k
+		if i := strings.Index(k, "."); i > 0 {
+			if s, ok := sites[k[:i]]; ok {
+				target, key = s, k[i+1:]
+			}
+		}
+		switch {
+		case key == "__status" || strings.HasPrefix(key, "__status."):
+			target.Status = formatStatus(v)
+		case key == "__error" || strings.HasPrefix(key, "__error."):
+			target.Error = fmt.Sprintf("%v", v)
+		default:
+			target.Outputs[key] = v
+		}
+	}
+	ret := make([]SiteOutput, 0, len(sites)+1)
+	if local.Status != "" || local.Error != "" || len(local.Outputs) > 0 {
+		ret = append(ret, *local)
+	}
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ret = append(ret, *sites[name])
+	}
+	return ret
+}
+
+// FormatOutputs renders outputs as sorted key=value lines
+func FormatOutputs(outputs map[string]interface{}) string {
+	keys := make([]string, 0, len(outputs))
+	for k := range outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%v", k, outputs[k]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatStatus(v interface{}) string {
+	// states are serialized as numbers and read back as float64
+	if f, ok := v.(float64); ok {
+		return v1alpha2.State(int(f)).String()
+	}
+	if s, ok := v.(v1alpha2.State); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
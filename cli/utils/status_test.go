@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActivationPhase(t *testing.T) {
+	assert.Equal(t, ActivationPending, GetActivationPhase(nil))
+	assert.Equal(t, ActivationPending, GetActivationPhase(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Running, IsActive: true}))
+	assert.Equal(t, ActivationPending, GetActivationPhase(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Done, NextStage: "test"}))
+	assert.Equal(t, ActivationPending, GetActivationPhase(&model.ActivationStatus{Stage: "approve", Status: v1alpha2.Paused}))
+	assert.Equal(t, ActivationSucceeded, GetActivationPhase(&model.ActivationStatus{Stage: "test", Status: v1alpha2.Done}))
+	assert.Equal(t, ActivationFailed, GetActivationPhase(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.InternalError, ErrorMessage: "failed"}))
+}
+
+func TestGetSiteOutputs(t *testing.T) {
+	// outputs go through JSON on their way to the CLI, so states are read back as numbers
+	var outputs map[string]interface{}
+	data, _ := json.Marshal(map[string]interface{}{
+		"__site":           "hq",
+		"__stage":          "deploy",
+		"__status":         v1alpha2.OK,
+		"count":            2,
+		"site-b.__status":  v1alpha2.InternalError,
+		"site-b.__error":   "connection refused",
+		"site-a.__status":  v1alpha2.OK,
+		"site-a.version":   "1.2",
+		"unrelated.output": "kept",
+	})
+	assert.Nil(t, json.Unmarshal(data, &outputs))
+
+	sites := GetSiteOutputs(outputs)
+	assert.Len(t, sites, 3)
+	assert.Equal(t, "hq", sites[0].Site)
+	assert.Equal(t, "OK", sites[0].Status)
+	assert.Equal(t, "count=2\nunrelated.output=kept", FormatOutputs(sites[0].Outputs))
+	assert.Equal(t, "site-a", sites[1].Site)
+	assert.Equal(t, "version=1.2", FormatOutputs(sites[1].Outputs))
+	assert.Equal(t, "site-b", sites[2].Site)
+	assert.Equal(t, "Internal Error", sites[2].Status)
+	assert.Equal(t, "connection refused", sites[2].Error)
+}
+
+func TestFormatActivationTransition(t *testing.T) {
+	assert.Equal(t, "deploy: Done -> test", FormatActivationTransition(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Done, NextStage: "test"}))
+	assert.Equal(t, "test: Internal Error", FormatActivationTransition(&model.ActivationStatus{Stage: "test", Status: v1alpha2.InternalError}))
+}
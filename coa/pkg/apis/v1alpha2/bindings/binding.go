@@ -6,5 +6,9 @@
 
 package bindings
 
+import "context"
+
 type IBinding interface {
+	// Shutdown stops taking requests, and waits for the ones in progress until ctx is done
+	Shutdown(ctx context.Context) error
 }
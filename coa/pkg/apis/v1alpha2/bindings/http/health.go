@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// Health serves the liveness of the host at /healthz and its readiness at /readyz, with a 503 status when
+// it's unavailable. Other requests are passed on.
+type Health struct {
+	Registry *health.Registry
+}
+
+func (h Health) Health(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !ctx.IsGet() && !ctx.IsHead() {
+			next(ctx)
+			return
+		}
+		var report health.Report
+		switch string(ctx.Path()) {
+		case livenessPath:
+			report = h.Registry.Liveness()
+		case readinessPath:
+			// the checks time out by themselves, and fasthttp request contexts can't be canceled
+			report = h.Registry.Readiness(context.Background())
+		default:
+			next(ctx)
+			return
+		}
+		data, _ := json.Marshal(report)
+		ctx.SetContentType("application/json")
+		ctx.SetBody(data)
+		if report.Status == health.StatusUnavailable {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+		} else {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func serveHealth(handler fasthttp.RequestHandler, method string, path string) (int, health.Report) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	handler(ctx)
+	report := health.Report{}
+	json.Unmarshal(ctx.Response.Body(), &report)
+	return ctx.Response.StatusCode(), report
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	registry := health.NewRegistry()
+	passed := false
+	handler := Health{Registry: registry}.Health(func(ctx *fasthttp.RequestCtx) {
+		passed = true
+	})
+
+	status, report := serveHealth(handler, fasthttp.MethodGet, "/healthz")
+	assert.Equal(t, fasthttp.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+
+	// the host isn't ready until it's launched
+	status, report = serveHealth(handler, fasthttp.MethodGet, "/readyz")
+	assert.Equal(t, fasthttp.StatusServiceUnavailable, status)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	registry.SetReady(true)
+	status, _ = serveHealth(handler, fasthttp.MethodGet, "/readyz")
+	assert.Equal(t, fasthttp.StatusOK, status)
+
+	registry.AddCheck("providers/solution/solution-manager/state", func(ctx context.Context) error {
+		return fmt.Errorf("connection refused")
+	})
+	status, report = serveHealth(handler, fasthttp.MethodGet, "/readyz")
+	assert.Equal(t, fasthttp.StatusServiceUnavailable, status)
+	assert.Equal(t, "connection refused", report.Components["providers/solution/solution-manager/state"].Error)
+
+	assert.False(t, passed)
+	serveHealth(handler, fasthttp.MethodPost, "/readyz")
+	assert.True(t, passed)
+}
+
+func TestLaunchAndShutdown(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.SetReady(true)
+	binding := HttpBinding{}
+	err := binding.Launch(HttpBindingConfig{Port: 0, Health: registry}, []v1alpha2.Endpoint{
+		{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   "greetings",
+			Version: "v1alpha2",
+			Handler: func(request v1alpha2.COARequest) v1alpha2.COAResponse {
+				time.Sleep(200 * time.Millisecond)
+				return v1alpha2.COAResponse{State: v1alpha2.OK, Body: []byte("hello")}
+			},
+		},
+	}, nil)
+	assert.Nil(t, err)
+	address := fmt.Sprintf("127.0.0.1:%d", binding.listener.Addr().(*net.TCPAddr).Port)
+
+	status, _, err := fasthttp.Get(nil, fmt.Sprintf("http://%s/readyz", address))
+	assert.Nil(t, err)
+	assert.Equal(t, fasthttp.StatusOK, status)
+
+	// a request in progress completes while the binding shuts down
+	type result struct {
+		status int
+		body   []byte
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		status, body, err := fasthttp.Get(nil, fmt.Sprintf("http://%s/v1alpha2/greetings", address))
+		results <- result{status, body, err}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, binding.Shutdown(ctx))
+	r := <-results
+	assert.Nil(t, r.err)
+	assert.Equal(t, fasthttp.StatusOK, r.status)
+	assert.Equal(t, "hello", string(r.body))
+
+	_, _, err = fasthttp.Get(nil, fmt.Sprintf("http://%s/readyz", address))
+	assert.NotNil(t, err)
+}
@@ -7,11 +7,14 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 
 	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs"
 	autogen "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs/autogen"
@@ -38,11 +41,18 @@ type HttpBindingConfig struct {
 	Pipeline     []MiddlewareConfig `json:"pipeline"`
 	TLS          bool               `json:"tls"`
 	CertProvider CertProviderConfig `json:"certProvider"`
+	// SiteInfo is set by the host, for middleware that describes the site, such as tracing
+	SiteInfo v1alpha2.SiteInfo `json:"-"`
+	// Health is set by the host, to serve its liveness and readiness at /healthz and /readyz
+	Health *health.Registry `json:"-"`
 }
 
 // HttpBinding provides service endpoints as a fasthttp web server
 type HttpBinding struct {
 	CertProvider certs.ICertProvider
+	server       *fasthttp.Server
+	listener     net.Listener
+	pipeline     Pipeline
 }
 
 // Launch fasthttp server
@@ -69,23 +79,64 @@ func (h *HttpBinding) Launch(config HttpBindingConfig, endpoints []v1alpha2.Endp
 		}
 	}
 
+	handler = pipeline.Apply(handler)
+	if config.Health != nil {
+		// probes are served ahead of the pipeline, so they don't need credentials
+		handler = Health{Registry: config.Health}.Health(handler)
+	}
+	h.pipeline = pipeline
+	h.server = &fasthttp.Server{Handler: handler}
+	// listens before returning, so the port is taken when the host is marked as ready
+	h.listener, err = net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to listen on port %d", config.Port), v1alpha2.InternalError)
+	}
 	go func() {
+		var err error
 		if config.TLS {
 			cert, key, _ := h.CertProvider.GetCert("localhost") //TODO: user proper host/DNS name
-			fasthttp.ListenAndServeTLSEmbed(fmt.Sprintf(":%d", config.Port), cert, key, pipeline.Apply(handler))
+			err = h.server.ServeTLSEmbed(h.listener, cert, key)
 		} else {
-			fasthttp.ListenAndServe(fmt.Sprintf(":%d", config.Port), pipeline.Apply(handler))
+			err = h.server.Serve(h.listener)
+		}
+		if err != nil {
+			log.Errorf("HTTP binding on port %d stopped: %+v", config.Port, err)
 		}
 	}()
 	return nil
 }
 
+// Shutdown stops taking connections, waits for the requests in progress to complete and flushes the traces
+// of the pipeline
+func (h *HttpBinding) Shutdown(ctx context.Context) error {
+	var ret error
+	if h.server != nil {
+		done := make(chan error, 1)
+		go func() {
+			done <- h.server.Shutdown()
+		}()
+		select {
+		case ret = <-done:
+		case <-ctx.Done():
+			ret = v1alpha2.NewCOAError(ctx.Err(), "timed out waiting for HTTP requests to complete", v1alpha2.InternalError)
+		}
+		// the server doesn't know the listener when it's shut down before it starts serving
+		h.listener.Close()
+	}
+	if err := h.pipeline.Shutdown(ctx); err != nil && ret == nil {
+		ret = err
+	}
+	return ret
+}
+
 func (h *HttpBinding) useRouter(endpoints []v1alpha2.Endpoint) fasthttp.RequestHandler {
 	router := h.getRouter(endpoints)
 	return router.Handler
 }
 func (h *HttpBinding) getRouter(endpoints []v1alpha2.Endpoint) *routing.Router {
 	router := routing.New()
+	// lets the metrics middleware label requests by route template
+	router.SaveMatchedRoutePath = true
 	for _, e := range endpoints {
 		path := fmt.Sprintf("/%s/%s", e.Version, e.Route)
 		for _, p := range e.Parameters {
@@ -98,6 +149,18 @@ func (h *HttpBinding) getRouter(endpoints []v1alpha2.Endpoint) *routing.Router {
 	return router
 }
 
+// routeParameters returns the parameters embedded in the endpoint route, such as {name} in
+// "catalogs/registry/{name}/revisions", followed by the parameters appended to the route.
+func routeParameters(endpoint v1alpha2.Endpoint) []string {
+	ret := make([]string, 0)
+	for _, segment := range strings.Split(endpoint.Route, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			ret = append(ret, segment[1:len(segment)-1])
+		}
+	}
+	return append(ret, endpoint.Parameters...)
+}
+
 func wrapAsHTTPHandler(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler) fasthttp.RequestHandler {
 	return func(reqCtx *fasthttp.RequestCtx) {
 		req := v1alpha2.COARequest{
@@ -114,7 +177,7 @@ func wrapAsHTTPHandler(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler)
 		}
 		req.Parameters = make(map[string]string)
 
-		for _, p := range endpoint.Parameters {
+		for _, p := range routeParameters(endpoint) {
 			k := p
 			if strings.HasSuffix(p, "?") {
 				k = k[:len(p)-1]
@@ -7,6 +7,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -22,6 +23,8 @@ type Middleware func(h fasthttp.RequestHandler) fasthttp.RequestHandler
 
 type Pipeline struct {
 	Handlers []Middleware
+	// observabilities are the tracing setups of the pipeline, to flush on shutdown
+	observabilities []*observability.Observability
 }
 
 func BuildPipeline(config HttpBindingConfig, pubsubProvider pubsub.IPubSubProvider) (Pipeline, error) {
@@ -42,21 +45,28 @@ func BuildPipeline(config HttpBindingConfig, pubsubProvider pubsub.IPubSubProvid
 			telemetry := Telemetry{Properties: c.Properties}
 			ret.Handlers = append(ret.Handlers, telemetry.Telemetry)
 		case "middleware.http.jwt":
-			jwts := JWT{}
-			jData, _ := json.Marshal(c.Properties)
-			err := json.Unmarshal(jData, &jwts)
+			jwts, err := NewJWT(c.Properties)
 			if err != nil {
-				return ret, v1alpha2.NewCOAError(nil, "incorrect jwt pipeline configuration format", v1alpha2.BadConfig)
-			}
-			if jwts.AuthHeader == "" {
-				jwts.AuthHeader = "Authorization"
+				return ret, err
 			}
 			ret.Handlers = append(ret.Handlers, jwts.JWT)
+		case "middleware.http.metrics":
+			metrics := Metrics{}
+			if v, ok := c.Properties["path"]; ok {
+				if path, ok := v.(string); ok {
+					metrics.Path = path
+				} else {
+					return ret, v1alpha2.NewCOAError(nil, "metrics path must be a string", v1alpha2.BadConfig)
+				}
+			}
+			ret.Handlers = append(ret.Handlers, metrics.Metrics)
 		case "middleware.http.tracing":
-			tracing := Tracing{
+			tracing := &Tracing{
 				Observability: observability.Observability{},
 			}
-			config := observability.ObservabilityConfig{}
+			tracingConfig := observability.ObservabilityConfig{
+				SiteInfo: config.SiteInfo,
+			}
 			if p, ok := c.Properties["pipeline"]; ok {
 				data, _ := json.Marshal(p)
 				pipelines := make([]observability.PipelineConfig, 0)
@@ -64,13 +74,24 @@ func BuildPipeline(config HttpBindingConfig, pubsubProvider pubsub.IPubSubProvid
 				if err != nil {
 					return ret, v1alpha2.NewCOAError(nil, "incorrect tracing pipeline configuration format", v1alpha2.BadConfig)
 				}
-				config.Pipelines = pipelines
+				tracingConfig.Pipelines = pipelines
+			}
+			if v, ok := c.Properties["serviceName"]; ok {
+				tracingConfig.ServiceName = fmt.Sprintf("%v", v)
+			}
+			if v, ok := c.Properties["resource"]; ok {
+				data, _ := json.Marshal(v)
+				err := json.Unmarshal(data, &tracingConfig.Resource)
+				if err != nil {
+					return ret, v1alpha2.NewCOAError(nil, "tracing resource attributes must be strings", v1alpha2.BadConfig)
+				}
 			}
-			err := tracing.Observability.Init(config)
+			err := tracing.Observability.Init(tracingConfig)
 			if err != nil {
-				return ret, v1alpha2.NewCOAError(nil, "failed to initialize tracing middleware", v1alpha2.InternalError)
+				return ret, v1alpha2.NewCOAError(err, "failed to initialize tracing middleware", v1alpha2.InternalError)
 			}
 			ret.Handlers = append(ret.Handlers, tracing.Tracing)
+			ret.observabilities = append(ret.observabilities, &tracing.Observability)
 		default:
 			return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("middleware type '%s' is not recognized", c.Type), v1alpha2.BadConfig)
 		}
@@ -84,3 +105,14 @@ func (p Pipeline) Apply(handler fasthttp.RequestHandler) fasthttp.RequestHandler
 	}
 	return handler
 }
+
+// Shutdown sends the pending traces of the tracing middleware
+func (p Pipeline) Shutdown(ctx context.Context) error {
+	var ret error
+	for _, o := range p.observabilities {
+		if err := o.Shutdown(ctx); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"testing"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRouteParameters(t *testing.T) {
+	params := routeParameters(v1alpha2.Endpoint{
+		Route:      "catalogs/registry/{name}/revisions",
+		Parameters: []string{"revision?"},
+	})
+	assert.Equal(t, []string{"name", "revision?"}, params)
+}
+
+func TestRouteWithEmbeddedParameters(t *testing.T) {
+	var captured map[string]string
+	handler := func(request v1alpha2.COARequest) v1alpha2.COAResponse {
+		captured = request.Parameters
+		return v1alpha2.COAResponse{State: v1alpha2.OK}
+	}
+	binding := HttpBinding{}
+	router := binding.getRouter([]v1alpha2.Endpoint{
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      "catalogs/registry",
+			Version:    "v1alpha2",
+			Handler:    handler,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      "catalogs/registry/{name}/revisions",
+			Version:    "v1alpha2",
+			Handler:    handler,
+			Parameters: []string{"revision?"},
+		},
+	})
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/v1alpha2/catalogs/registry/app-config/revisions/3")
+	router.Handler(ctx)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "app-config", captured["__name"])
+	assert.Equal(t, "3", captured["__revision"])
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/v1alpha2/catalogs/registry/app-config")
+	router.Handler(ctx)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "app-config", captured["__name"])
+}
@@ -8,6 +8,7 @@ package http
 
 import (
 	"crypto/rsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -37,61 +38,89 @@ type Policy struct {
 	Items map[string]string `json:"items"`
 }
 
+// NewJWT reads the properties of a JWT middleware. The token is read from the Authorization header unless
+// authHeader is set.
+func NewJWT(properties map[string]interface{}) (JWT, error) {
+	ret := JWT{}
+	jData, _ := json.Marshal(properties)
+	err := json.Unmarshal(jData, &ret)
+	if err != nil {
+		return ret, v1alpha2.NewCOAError(nil, "incorrect jwt pipeline configuration format", v1alpha2.BadConfig)
+	}
+	if ret.AuthHeader == "" {
+		ret.AuthHeader = "Authorization"
+	}
+	return ret, nil
+}
+
 func (j JWT) JWT(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
-		if j.IgnorePaths != nil {
-			for _, p := range j.IgnorePaths {
-				if p == string(ctx.Path()) {
-					next(ctx)
-					return
-				}
-			}
+		if j.IsIgnoredPath(string(ctx.Path())) {
+			next(ctx)
+			return
 		}
 		if ctx.IsOptions() {
 			next(ctx)
 			return
 		}
-		tokenStr := j.readAuthHeader(ctx)
-		if tokenStr == "" {
-			ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
-		} else {
-			_, roles, err := j.validateToken(tokenStr)
-			if err != nil {
-				ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
-			} else {
-				if j.EnableRBAC {
-					path := string(ctx.Path())
-					method := string(ctx.Method())
-					for _, role := range roles {
-						if v, ok := j.Policy[role]; ok {
-							for key, val := range v.Items {
-								if key == "*" || strings.HasPrefix(path, key) {
-									if val == "*" || strings.Contains(val, method) {
-										next(ctx)
-										return
-									}
-								}
-							}
-						}
+		if j.Authorize(j.readAuthHeader(ctx), string(ctx.Path()), string(ctx.Method())) {
+			next(ctx)
+			return
+		}
+		ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
+	}
+}
+
+// IsIgnoredPath tells if requests to path are let through without a token
+func (j JWT) IsIgnoredPath(path string) bool {
+	for _, p := range j.IgnorePaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize tells if a token is valid and, when RBAC is enabled, if its roles allow method on path. Bindings
+// other than HTTP use it to apply the same rules to their requests.
+func (j *JWT) Authorize(tokenStr string, path string, method string) bool {
+	if tokenStr == "" {
+		return false
+	}
+	_, roles, err := j.validateToken(tokenStr)
+	if err != nil {
+		return false
+	}
+	if !j.EnableRBAC {
+		return true
+	}
+	for _, role := range roles {
+		if v, ok := j.Policy[role]; ok {
+			for key, val := range v.Items {
+				if key == "*" || strings.HasPrefix(path, key) {
+					if val == "*" || strings.Contains(val, method) {
+						return true
 					}
-					ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
-					return
 				}
-				next(ctx)
 			}
 		}
 	}
+	return false
 }
+
 func (j JWT) readAuthHeader(ctx *fasthttp.RequestCtx) string {
 	v := ctx.Request.Header.Peek(j.AuthHeader)
 	if v != nil {
-		tokenStr := string(v)
-		token := strings.Split(tokenStr, "Bearer ")
-		if len(token) == 2 {
-			return strings.TrimSpace(token[1])
-		} else {
-			return ""
-		}
+		return BearerToken(string(v))
+	}
+	return ""
+}
+
+// BearerToken returns the token of an authorization header value in the "Bearer <token>" form
+func BearerToken(value string) string {
+	token := strings.Split(value, "Bearer ")
+	if len(token) == 2 {
+		return strings.TrimSpace(token[1])
 	}
 	return ""
 }
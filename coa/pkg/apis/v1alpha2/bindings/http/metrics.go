@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
+	routing "github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const defaultMetricsPath = "/metrics"
+
+var (
+	apiRequests = metrics.NewCounter("api_requests_total",
+		"Number of API requests by route, method and status code", "route", "method", "status")
+	apiRequestDuration = metrics.NewHistogram("api_request_duration_seconds",
+		"API request latency by route and method", metrics.RequestBuckets, "route", "method")
+)
+
+// Metrics middleware serves the collected metrics on Path, and records the count and latency of
+// the other requests. Requests are labeled by route template, such as /v1alpha2/solutions/{name},
+// so that object names don't end up in label values.
+type Metrics struct {
+	Path string
+}
+
+func (m Metrics) Metrics(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	path := m.Path
+	if path == "" {
+		path = defaultMetricsPath
+	}
+	metricsHandler := fasthttpadaptor.NewFastHTTPHandler(metrics.Handler())
+	return func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == path && ctx.IsGet() {
+			metricsHandler(ctx)
+			return
+		}
+		start := time.Now()
+		next(ctx)
+		route := "unmatched"
+		if r, ok := ctx.UserValue(routing.MatchedRoutePathParam).(string); ok {
+			route = r
+		}
+		method := string(ctx.Method())
+		apiRequests.Add(1, route, method, strconv.Itoa(ctx.Response.StatusCode()))
+		apiRequestDuration.Observe(time.Since(start).Seconds(), route, method)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"testing"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	binding := HttpBinding{}
+	router := binding.getRouter([]v1alpha2.Endpoint{
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      "solutions",
+			Version:    "v1alpha2",
+			Parameters: []string{"name?"},
+			Handler: func(request v1alpha2.COARequest) v1alpha2.COAResponse {
+				return v1alpha2.COAResponse{State: v1alpha2.NotFound}
+			},
+		},
+	})
+	pipeline, err := BuildPipeline(HttpBindingConfig{
+		Pipeline: []MiddlewareConfig{
+			{Type: "middleware.http.metrics", Properties: map[string]interface{}{"path": "/v1alpha2/metrics"}},
+		},
+	}, nil)
+	assert.Nil(t, err)
+	handler := pipeline.Apply(router.Handler)
+
+	for _, name := range []string{"app-1", "app-2"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+		ctx.Request.SetRequestURI("/v1alpha2/solutions/" + name)
+		handler(ctx)
+		assert.Equal(t, fasthttp.StatusNotFound, ctx.Response.StatusCode())
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/v1alpha2/metrics")
+	handler(ctx)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	body := string(ctx.Response.Body())
+	assert.Contains(t, body, `symphony_api_requests_total{method="GET",route="/v1alpha2/solutions/{name?}",status="404"} 2`)
+	assert.Contains(t, body, `symphony_api_request_duration_seconds_count{method="GET",route="/v1alpha2/solutions/{name?}"} 2`)
+	assert.NotContains(t, body, "app-1")
+}
+
+func TestMetricsMiddlewareBadPath(t *testing.T) {
+	_, err := BuildPipeline(HttpBindingConfig{
+		Pipeline: []MiddlewareConfig{
+			{Type: "middleware.http.metrics", Properties: map[string]interface{}{"path": 8080}},
+		},
+	}, nil)
+	assert.NotNil(t, err)
+}
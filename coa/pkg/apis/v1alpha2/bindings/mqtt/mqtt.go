@@ -8,11 +8,18 @@ package mqtt
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings/http"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 )
@@ -24,70 +31,256 @@ type MQTTBindingConfig struct {
 	ClientID      string `json:"clientID"`
 	RequestTopic  string `json:"requestTopic"`
 	ResponseTopic string `json:"responseTopic"`
+	// QoS is the MQTT quality of service of requests and responses: 0, 1 or 2
+	QoS      byte   `json:"qos,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// CACert is the PEM file of the certificate authorities of the broker. The system ones are used when it's
+	// not set.
+	CACert string `json:"caCert,omitempty"`
+	// ClientCert and ClientKey are the PEM files of the certificate the binding authenticates with
+	ClientCert         string `json:"clientCert,omitempty"`
+	ClientKey          string `json:"clientKey,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	// Pipeline is the middleware applied to requests. The MQTT binding supports middleware.http.jwt.
+	Pipeline []http.MiddlewareConfig `json:"pipeline,omitempty"`
 }
 
 type MQTTBinding struct {
-	MQTTClient gmqtt.Client
+	MQTTClient   gmqtt.Client
+	router       *router
+	jwt          *http.JWT
+	requestTopic string
+	// requests are the requests in progress, which shutdown waits for
+	requests sync.WaitGroup
+	// closing is set by shutdown, after which new requests are dropped instead of added to requests
+	closing bool
+	lock    sync.Mutex
 }
 
-var routeTable map[string]v1alpha2.Endpoint
-
 func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endpoint) error {
-	routeTable = make(map[string]v1alpha2.Endpoint)
-	for _, endpoint := range endpoints {
-		route := endpoint.Route
-		lastSlash := strings.LastIndex(endpoint.Route, "/")
-		if lastSlash > 0 {
-			route = strings.TrimPrefix(route, route[:lastSlash+1])
-		}
-		routeTable[route] = endpoint
+	if config.QoS > 2 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("MQTT QoS %d is not valid, it should be 0, 1 or 2", config.QoS), v1alpha2.BadConfig)
 	}
+	if err := m.usePipeline(config.Pipeline); err != nil {
+		return err
+	}
+	m.router = newRouter(endpoints)
+	m.requestTopic = config.RequestTopic
+	m.lock.Lock()
+	m.closing = false
+	m.lock.Unlock()
 
 	opts := gmqtt.NewClientOptions().AddBroker(config.BrokerAddress).SetClientID(config.ClientID)
 	opts.SetKeepAlive(2 * time.Second)
 	opts.SetPingTimeout(1 * time.Second)
 	opts.CleanSession = false
+	if config.Username != "" {
+		opts.SetUsername(config.Username)
+		opts.SetPassword(config.Password)
+	}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
 	m.MQTTClient = gmqtt.NewClient(opts)
 	if token := m.MQTTClient.Connect(); token.Wait() && token.Error() != nil {
 		return v1alpha2.NewCOAError(token.Error(), "failed to connect to MQTT broker", v1alpha2.InternalError)
 	}
 
-	if token := m.MQTTClient.Subscribe(config.RequestTopic, 0, func(client gmqtt.Client, msg gmqtt.Message) {
-		var request v1alpha2.COARequest
-		var response v1alpha2.COAResponse
-		request.Context = context.TODO()
-		err := json.Unmarshal(msg.Payload(), &request)
-		if err != nil {
-			response = v1alpha2.COAResponse{
-				State:       v1alpha2.BadRequest,
-				ContentType: "application/text",
-				Body:        []byte(err.Error()),
+	if token := m.MQTTClient.Subscribe(config.RequestTopic, config.QoS, func(client gmqtt.Client, msg gmqtt.Message) {
+		if !m.beginRequest() {
+			log.Debugf("dropped request from MQTT because the binding is shutting down")
+			return
+		}
+		defer m.requests.Done()
+		data, _ := json.Marshal(m.handleRequest(msg.Payload()))
+		if token := client.Publish(config.ResponseTopic, config.QoS, false, data); token.Wait() && token.Error() != nil {
+			log.Errorf("failed to handle request from MQTT: %s", token.Error())
+		}
+	}); token.Wait() && token.Error() != nil {
+		if token.Error().Error() != "subscription exists" {
+			log.Errorf("  P (MQTT Target): failed to connect to subscribe to request topic - %+v", token.Error())
+			return v1alpha2.NewCOAError(token.Error(), "failed to subscribe to request topic", v1alpha2.InternalError)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown unsubscribes from the request topic, and disconnects from the broker once the requests in
+// progress have sent their responses or ctx is done
+func (m *MQTTBinding) Shutdown(ctx context.Context) error {
+	m.stopRequests()
+	if m.MQTTClient == nil || !m.MQTTClient.IsConnected() {
+		return nil
+	}
+	var ret error
+	token := m.MQTTClient.Unsubscribe(m.requestTopic)
+	select {
+	case <-token.Done():
+		if token.Error() != nil {
+			ret = v1alpha2.NewCOAError(token.Error(), "failed to unsubscribe from request topic", v1alpha2.InternalError)
+		}
+	case <-ctx.Done():
+	}
+	done := make(chan struct{})
+	go func() {
+		m.requests.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if ret == nil {
+			ret = v1alpha2.NewCOAError(ctx.Err(), "timed out waiting for MQTT requests to complete", v1alpha2.InternalError)
+		}
+	}
+	m.MQTTClient.Disconnect(250)
+	return ret
+}
+
+// beginRequest adds a request in progress. It returns false once shutdown has started, so that requests aren't
+// added while shutdown waits for them.
+func (m *MQTTBinding) beginRequest() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closing {
+		return false
+	}
+	m.requests.Add(1)
+	return true
+}
+
+func (m *MQTTBinding) stopRequests() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closing = true
+}
+
+func (m *MQTTBinding) usePipeline(pipeline []http.MiddlewareConfig) error {
+	for _, c := range pipeline {
+		switch c.Type {
+		case "middleware.http.jwt":
+			jwts, err := http.NewJWT(c.Properties)
+			if err != nil {
+				return err
 			}
-		} else {
-			response = routeTable[request.Route].Handler(request)
+			m.jwt = &jwts
+		default:
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("middleware type '%s' is not supported by MQTT binding", c.Type), v1alpha2.BadConfig)
 		}
+	}
+	return nil
+}
 
-		// needs to carry call-context from request into response
-		if request.Metadata != nil {
-			if v, ok := request.Metadata["call-context"]; ok {
-				if response.Metadata == nil {
-					response.Metadata = make(map[string]string)
-				}
-				response.Metadata["call-context"] = v
+// handleRequest routes a request the way the HTTP binding does, and returns the response to publish
+func (m *MQTTBinding) handleRequest(payload []byte) v1alpha2.COAResponse {
+	var request v1alpha2.COARequest
+	err := json.Unmarshal(payload, &request)
+	if err != nil {
+		return v1alpha2.COAResponse{
+			State:       v1alpha2.BadRequest,
+			ContentType: "application/text",
+			Body:        []byte(err.Error()),
+		}
+	}
+	response := m.serve(request)
+	// needs to carry call-context from request into response
+	if request.Metadata != nil {
+		if v, ok := request.Metadata["call-context"]; ok {
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]string)
 			}
+			response.Metadata["call-context"] = v
 		}
+	}
+	return response
+}
 
-		data, _ := json.Marshal(response)
+func (m *MQTTBinding) serve(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	request.Context = context.TODO()
+	path, query, _ := strings.Cut(request.Route, "?")
+	endpoint, parameters, fullPath, state := m.router.lookup(request.Method, path)
+	switch state {
+	case v1alpha2.NotFound:
+		return errorResponse(state, fmt.Sprintf("route '%s' is not found", request.Route))
+	case v1alpha2.MethodNotAllowed:
+		return errorResponse(state, fmt.Sprintf("method '%s' is not allowed on route '%s'", request.Method, request.Route))
+	case v1alpha2.BadRequest:
+		return errorResponse(state, fmt.Sprintf("route '%s' matches more than one endpoint", request.Route))
+	}
+	if m.jwt != nil && !m.jwt.IsIgnoredPath(fullPath) {
+		token := http.BearerToken(request.Metadata[m.jwt.AuthHeader])
+		if !m.jwt.Authorize(token, fullPath, request.Method) {
+			return errorResponse(v1alpha2.Unauthorized, "access is denied")
+		}
+	}
+	request.Route = fullPath
+	if request.Parameters == nil {
+		request.Parameters = make(map[string]string)
+	}
+	for k, v := range parameters {
+		request.Parameters["__"+k] = v
+	}
+	if values, err := url.ParseQuery(query); err == nil {
+		for k := range values {
+			request.Parameters[k] = values.Get(k)
+		}
+	}
+	return endpoint.Handler(request)
+}
 
-		if token := client.Publish(config.ResponseTopic, 0, false, data); token.Wait() && token.Error() != nil {
-			log.Errorf("failed to handle request from MOTT: %s", token.Error())
+func errorResponse(state v1alpha2.State, message string) v1alpha2.COAResponse {
+	return v1alpha2.COAResponse{
+		State:       state,
+		ContentType: "application/text",
+		Body:        []byte(message),
+	}
+}
+
+// newTLSConfig returns the TLS settings of the broker connection, or nil when none are set. The broker
+// address has to use a TLS scheme, such as ssl://, for them to apply.
+func newTLSConfig(config MQTTBindingConfig) (*tls.Config, error) {
+	if config.CACert == "" && config.ClientCert == "" && config.ClientKey == "" && !config.InsecureSkipVerify {
+		return nil, nil
+	}
+	if !isTLSAddress(config.BrokerAddress) {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("MQTT broker address '%s' should use ssl://, tls:// or mqtts:// with TLS settings", config.BrokerAddress), v1alpha2.BadConfig)
+	}
+	ret := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.CACert != "" {
+		data, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to read MQTT CA certificate", v1alpha2.BadConfig)
 		}
-	}); token.Wait() && token.Error() != nil {
-		if token.Error().Error() != "subscription exists" {
-			log.Errorf("  P (MQTT Target): faild to connect to subscribe to request topic - %+v", token.Error())
-			return v1alpha2.NewCOAError(token.Error(), "failed to subscribe to request topic", v1alpha2.InternalError)
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, v1alpha2.NewCOAError(nil, "MQTT CA certificate has no PEM certificates", v1alpha2.BadConfig)
 		}
+		ret.RootCAs = pool
 	}
+	if config.ClientCert != "" || config.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to load MQTT client certificate", v1alpha2.BadConfig)
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+	return ret, nil
+}
 
-	return nil
+func isTLSAddress(address string) bool {
+	for _, scheme := range []string{"ssl://", "tls://", "mqtts://", "tcps://", "wss://"} {
+		if strings.HasPrefix(strings.ToLower(address), scheme) {
+			return true
+		}
+	}
+	return false
 }
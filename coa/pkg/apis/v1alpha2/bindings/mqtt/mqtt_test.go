@@ -7,13 +7,23 @@
 package mqtt
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings/http"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
+	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,3 +82,204 @@ func TestMQTTEcho(t *testing.T) {
 	token.Wait()
 	<-sig
 }
+
+func echoEndpoint(version string, route string, methods []string, parameters []string) v1alpha2.Endpoint {
+	return v1alpha2.Endpoint{
+		Version:    version,
+		Methods:    methods,
+		Route:      route,
+		Parameters: parameters,
+		Handler: func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+			data, _ := json.Marshal(c.Parameters)
+			return v1alpha2.COAResponse{
+				State: v1alpha2.OK,
+				Body:  []byte(c.Route + " " + string(data)),
+			}
+		},
+	}
+}
+
+func request(t *testing.T, binding *MQTTBinding, request v1alpha2.COARequest) v1alpha2.COAResponse {
+	data, err := json.Marshal(request)
+	assert.Nil(t, err)
+	return binding.handleRequest(data)
+}
+
+func TestRouteMatching(t *testing.T) {
+	binding := &MQTTBinding{router: newRouter([]v1alpha2.Endpoint{
+		echoEndpoint("v1alpha2", "catalogs/registry", []string{"GET", "POST"}, []string{"name?"}),
+		echoEndpoint("v1alpha2", "targets/registry", []string{"GET"}, []string{"name?"}),
+		echoEndpoint("v1alpha2", "catalogs/registry/{name}/revisions", []string{"GET"}, nil),
+		echoEndpoint("v1alpha2", "solution/instances", []string{"GET"}, nil),
+	})}
+
+	// vendors with the same last route segment don't collide
+	response := request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/targets/registry/t1"})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	assert.Equal(t, `/v1alpha2/targets/registry/t1 {"__name":"t1"}`, string(response.Body))
+
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/catalogs/registry"})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	assert.Equal(t, `/v1alpha2/catalogs/registry {"__name":""}`, string(response.Body))
+
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/catalogs/registry/c1/revisions?limit=2"})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	assert.Equal(t, `/v1alpha2/catalogs/registry/c1/revisions {"__name":"c1","limit":"2"}`, string(response.Body))
+
+	// relative routes of older clients match the end of a single endpoint
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "instances"})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	assert.Equal(t, `/v1alpha2/solution/instances {}`, string(response.Body))
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "registry"})
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/devices/registry"})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/targets/registry/t1/extra"})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+	response = request(t, binding, v1alpha2.COARequest{Method: "DELETE", Route: "/v1alpha2/catalogs/registry/c1"})
+	assert.Equal(t, v1alpha2.MethodNotAllowed, response.State)
+}
+
+func TestLiteralRoutesWin(t *testing.T) {
+	binding := &MQTTBinding{router: newRouter([]v1alpha2.Endpoint{
+		echoEndpoint("v1alpha2", "solutions", []string{"GET"}, []string{"name"}),
+		echoEndpoint("v1alpha2", "solutions/queue", []string{"GET"}, nil),
+	})}
+	response := request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/solutions/queue"})
+	assert.Equal(t, `/v1alpha2/solutions/queue {}`, string(response.Body))
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/solutions/s1"})
+	assert.Equal(t, `/v1alpha2/solutions/s1 {"__name":"s1"}`, string(response.Body))
+}
+
+func TestCallContext(t *testing.T) {
+	binding := &MQTTBinding{router: newRouter([]v1alpha2.Endpoint{})}
+	response := request(t, binding, v1alpha2.COARequest{
+		Method:   "GET",
+		Route:    "/v1alpha2/unknown",
+		Metadata: map[string]string{"call-context": "abc"},
+	})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+	assert.Equal(t, "abc", response.Metadata["call-context"])
+
+	response = binding.handleRequest([]byte("not json"))
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+}
+
+func TestJWT(t *testing.T) {
+	binding := &MQTTBinding{router: newRouter([]v1alpha2.Endpoint{
+		echoEndpoint("v1alpha2", "greetings", []string{"GET"}, nil),
+		echoEndpoint("v1alpha2", "solutions", []string{"GET", "POST"}, nil),
+	})}
+	err := binding.usePipeline([]http.MiddlewareConfig{
+		{
+			Type: "middleware.http.jwt",
+			Properties: map[string]interface{}{
+				"verifyKey":   "SymphonyKey",
+				"ignorePaths": []string{"/v1alpha2/greetings"},
+				"enableRBAC":  true,
+				"roles": []map[string]string{
+					{"role": "reader", "claim": "user", "value": "reader"},
+				},
+				"policy": map[string]interface{}{
+					"reader": map[string]interface{}{
+						"items": map[string]string{"/v1alpha2/solutions": "GET"},
+					},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user": "reader"}).SignedString([]byte("SymphonyKey"))
+	assert.Nil(t, err)
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	response := request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/greetings"})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/solutions"})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "/v1alpha2/solutions", Metadata: auth})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	// relative routes are authorized with their full path
+	response = request(t, binding, v1alpha2.COARequest{Method: "GET", Route: "solutions", Metadata: auth})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = request(t, binding, v1alpha2.COARequest{Method: "POST", Route: "/v1alpha2/solutions", Metadata: auth})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+}
+
+func TestUnsupportedMiddleware(t *testing.T) {
+	binding := MQTTBinding{}
+	err := binding.Launch(MQTTBindingConfig{
+		Pipeline: []http.MiddlewareConfig{{Type: "middleware.http.cors"}},
+	}, nil)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func TestInvalidQoS(t *testing.T) {
+	binding := MQTTBinding{}
+	err := binding.Launch(MQTTBindingConfig{QoS: 3}, nil)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func writeCert(t *testing.T, dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "symphony"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir())
+
+	tlsConfig, err := newTLSConfig(MQTTBindingConfig{BrokerAddress: "tcp://localhost:1883"})
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	tlsConfig, err = newTLSConfig(MQTTBindingConfig{
+		BrokerAddress: "ssl://localhost:8883",
+		CACert:        certFile,
+		ClientCert:    certFile,
+		ClientKey:     keyFile,
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Equal(t, 1, len(tlsConfig.Certificates))
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+
+	// TLS settings need a TLS broker address
+	_, err = newTLSConfig(MQTTBindingConfig{BrokerAddress: "tcp://localhost:1883", CACert: certFile})
+	assert.NotNil(t, err)
+	// a key file is not a certificate
+	_, err = newTLSConfig(MQTTBindingConfig{BrokerAddress: "ssl://localhost:8883", CACert: keyFile})
+	assert.NotNil(t, err)
+	_, err = newTLSConfig(MQTTBindingConfig{BrokerAddress: "ssl://localhost:8883", ClientCert: certFile})
+	assert.NotNil(t, err)
+}
+
+func TestShutdownWithoutConnection(t *testing.T) {
+	binding := MQTTBinding{}
+	assert.Nil(t, binding.Shutdown(context.Background()))
+}
+
+func TestNoRequestsAfterShutdown(t *testing.T) {
+	binding := MQTTBinding{}
+	assert.True(t, binding.beginRequest())
+	binding.requests.Done()
+	assert.Nil(t, binding.Shutdown(context.Background()))
+	assert.False(t, binding.beginRequest())
+}
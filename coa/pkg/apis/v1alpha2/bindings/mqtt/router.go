@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// segment kinds, ordered by how specific they are when several routes match a path
+const (
+	optionalParameter = iota
+	parameter
+	literal
+)
+
+type routeSegment struct {
+	kind  int
+	value string
+}
+
+type route struct {
+	endpoint v1alpha2.Endpoint
+	segments []routeSegment
+}
+
+// router matches request routes to endpoints the way the HTTP binding does: an endpoint is served at
+// /<version>/<route>, followed by a segment per parameter. Routes can embed parameters, such as {name}, and
+// parameters that end with ? are optional. Literal segments win over parameters when several routes match.
+type router struct {
+	routes []route
+}
+
+type routeMatch struct {
+	route      route
+	parameters map[string]string
+}
+
+func newRouter(endpoints []v1alpha2.Endpoint) *router {
+	ret := &router{routes: make([]route, 0, len(endpoints))}
+	for _, e := range endpoints {
+		path := fmt.Sprintf("/%s/%s", e.Version, e.Route)
+		for _, p := range e.Parameters {
+			path += "/{" + p + "}"
+		}
+		r := route{endpoint: e}
+		for _, s := range splitPath(path) {
+			switch {
+			case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "?}"):
+				r.segments = append(r.segments, routeSegment{kind: optionalParameter, value: s[1 : len(s)-2]})
+			case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+				r.segments = append(r.segments, routeSegment{kind: parameter, value: s[1 : len(s)-1]})
+			default:
+				r.segments = append(r.segments, routeSegment{kind: literal, value: s})
+			}
+		}
+		ret.routes = append(ret.routes, r)
+	}
+	return ret
+}
+
+// lookup returns the endpoint that serves method on path, with the values of its parameters and the full
+// path of the request. The state is NotFound when no endpoint has the path, and MethodNotAllowed when none
+// of them takes the method.
+//
+// A path without a leading / is relative: it's matched against the end of the endpoint paths, as clients
+// such as the MQTT proxy provider send routes like "instances". A relative path must match a single
+// endpoint.
+func (r *router) lookup(method string, path string) (v1alpha2.Endpoint, map[string]string, string, v1alpha2.State) {
+	segments := splitPath(path)
+	matches := r.match(segments, false)
+	if len(matches) == 0 && !strings.HasPrefix(path, "/") {
+		matches = r.match(segments, true)
+		if len(matches) > 1 {
+			return v1alpha2.Endpoint{}, nil, "", v1alpha2.BadRequest
+		}
+	}
+	if len(matches) == 0 {
+		return v1alpha2.Endpoint{}, nil, "", v1alpha2.NotFound
+	}
+	var best *routeMatch
+	for k := range matches {
+		m := &matches[k]
+		if !hasMethod(m.route.endpoint, method) {
+			continue
+		}
+		if best == nil || moreSpecific(m.route, best.route) {
+			best = m
+		}
+	}
+	if best == nil {
+		return v1alpha2.Endpoint{}, nil, "", v1alpha2.MethodNotAllowed
+	}
+	return best.route.endpoint, best.parameters, best.path(), v1alpha2.OK
+}
+
+// path returns the full path of a matched request, so a relative path is authorized like the full one
+func (m routeMatch) path() string {
+	ret := ""
+	for _, s := range m.route.segments {
+		if s.kind == literal {
+			ret += "/" + s.value
+		} else if v := m.parameters[s.value]; v != "" {
+			ret += "/" + v
+		}
+	}
+	return ret
+}
+
+// match returns the routes that match the path segments. With suffix set, the segments can match the end
+// of a route, after its version.
+func (r *router) match(segments []string, suffix bool) []routeMatch {
+	ret := make([]routeMatch, 0)
+	for _, rt := range r.routes {
+		if !suffix {
+			if params, ok := matchSegments(rt.segments, segments); ok {
+				ret = append(ret, routeMatch{route: rt, parameters: params})
+			}
+			continue
+		}
+		for start := 1; start < len(rt.segments); start++ {
+			if rt.segments[start].kind != literal {
+				continue
+			}
+			if params, ok := matchSegments(rt.segments[start:], segments); ok {
+				// parameters before the start of the match are missing from the path
+				for _, s := range rt.segments[:start] {
+					if s.kind != literal {
+						params[s.value] = ""
+					}
+				}
+				ret = append(ret, routeMatch{route: rt, parameters: params})
+				break
+			}
+		}
+	}
+	return ret
+}
+
+func matchSegments(routeSegments []routeSegment, segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for k, s := range routeSegments {
+		if k >= len(segments) {
+			if s.kind != optionalParameter {
+				return nil, false
+			}
+			params[s.value] = ""
+			continue
+		}
+		switch s.kind {
+		case literal:
+			if s.value != segments[k] {
+				return nil, false
+			}
+		default:
+			params[s.value] = segments[k]
+		}
+	}
+	if len(segments) > len(routeSegments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// moreSpecific tells if a route should win over another that matches the same path, comparing their
+// segments from the start
+func moreSpecific(a route, b route) bool {
+	for k := 0; k < len(a.segments) && k < len(b.segments); k++ {
+		if a.segments[k].kind != b.segments[k].kind {
+			return a.segments[k].kind > b.segments[k].kind
+		}
+	}
+	return len(a.segments) < len(b.segments)
+}
+
+func hasMethod(endpoint v1alpha2.Endpoint, method string) bool {
+	for _, m := range endpoint.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitPath(path string) []string {
+	ret := make([]string, 0)
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
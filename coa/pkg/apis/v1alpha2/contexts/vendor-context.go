@@ -33,9 +33,9 @@ func (v *VendorContext) Publish(feed string, event v1alpha2.Event) error {
 	return nil
 }
 
-func (v *VendorContext) Subscribe(feed string, handler v1alpha2.EventHandler) error {
+func (v *VendorContext) Subscribe(feed string, handler v1alpha2.EventHandler) (pubsub.Subscription, error) {
 	if v.PubsubProvider != nil {
 		return v.PubsubProvider.Subscribe(feed, handler)
 	}
-	return nil
+	return nil, nil
 }
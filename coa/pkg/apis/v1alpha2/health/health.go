@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusDegraded    Status = "degraded"
+	StatusUnavailable Status = "unavailable"
+)
+
+const (
+	// DefaultCheckTimeout is how long a readiness check can take before it's reported as failed
+	DefaultCheckTimeout = 5 * time.Second
+	// DefaultMaxBackoff caps the wait between the runs of a failing component
+	DefaultMaxBackoff = 5 * time.Minute
+)
+
+// CheckFunc tells if a dependency, such as a state store or a message broker, can be reached
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus is the health of a check, a loop or a component that reports its runs
+type ComponentStatus struct {
+	Status      Status     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Failures    int        `json:"failures,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	NextRun     *time.Time `json:"nextRun,omitempty"`
+}
+
+// Report is the health of the host, as served by the liveness and readiness endpoints
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+type loop struct {
+	lastBeat time.Time
+	timeout  time.Duration
+}
+
+type component struct {
+	failures    int
+	lastError   error
+	lastSuccess time.Time
+	nextRun     time.Time
+}
+
+// Registry collects the health of a host:
+//   - loops, such as the vendor manager loops, send heartbeats. The host isn't live when a loop misses its
+//     heartbeats, which means it's stuck.
+//   - components, such as managers, report the results of their runs. A failing component is retried with
+//     an exponential backoff, and makes the host degraded but still ready.
+//   - checks tell if the dependencies of the host can be reached. The host isn't ready when a check fails,
+//     or before it's marked as ready.
+type Registry struct {
+	CheckTimeout time.Duration
+	MaxBackoff   time.Duration
+	lock         sync.RWMutex
+	ready        bool
+	checks       map[string]CheckFunc
+	loops        map[string]*loop
+	components   map[string]*component
+	now          func() time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		CheckTimeout: DefaultCheckTimeout,
+		MaxBackoff:   DefaultMaxBackoff,
+		checks:       make(map[string]CheckFunc),
+		loops:        make(map[string]*loop),
+		components:   make(map[string]*component),
+		now:          time.Now,
+	}
+}
+
+// SetReady marks the host as ready to serve requests, or as not ready, such as when it shuts down
+func (r *Registry) SetReady(ready bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ready = ready
+}
+
+// AddCheck adds a readiness check, replacing the one with the same name
+func (r *Registry) AddCheck(name string, check CheckFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.checks[name] = check
+}
+
+// Heartbeat records that a loop is running. The loop is stalled when it doesn't send another heartbeat
+// within timeout.
+func (r *Registry) Heartbeat(name string, timeout time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.loops[name] = &loop{lastBeat: r.now(), timeout: timeout}
+}
+
+// RemoveLoop stops watching a loop that has exited
+func (r *Registry) RemoveLoop(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.loops, name)
+}
+
+// ReportSuccess records a successful run of a component, which resets its backoff
+func (r *Registry) ReportSuccess(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.components[name] = &component{lastSuccess: r.now()}
+}
+
+// ReportFailure records a failed run of a component, and returns how long to wait before running it again.
+// The wait starts at interval and doubles with every consecutive failure, up to MaxBackoff.
+func (r *Registry) ReportFailure(name string, err error, interval time.Duration) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	c, ok := r.components[name]
+	if !ok {
+		c = &component{}
+		r.components[name] = c
+	}
+	c.failures++
+	c.lastError = err
+	backoff := interval
+	for k := 1; k < c.failures && backoff < r.MaxBackoff; k++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	c.nextRun = r.now().Add(backoff)
+	return backoff
+}
+
+// Due tells if a component should run, which is false while it backs off from failures
+func (r *Registry) Due(name string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if c, ok := r.components[name]; ok {
+		return !r.now().Before(c.nextRun)
+	}
+	return true
+}
+
+// Liveness reports if all loops are sending heartbeats
+func (r *Registry) Liveness() Report {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	ret := Report{Status: StatusOK, Components: make(map[string]ComponentStatus)}
+	now := r.now()
+	for name, l := range r.loops {
+		lastBeat := l.lastBeat
+		status := ComponentStatus{Status: StatusOK, LastSuccess: &lastBeat}
+		if now.Sub(l.lastBeat) > l.timeout {
+			status.Status = StatusUnavailable
+			status.Error = fmt.Sprintf("no heartbeat for %s", now.Sub(l.lastBeat).Round(time.Second))
+			ret.Status = StatusUnavailable
+		}
+		ret.Components[name] = status
+	}
+	return ret
+}
+
+// Readiness runs the checks in parallel, and reports if the host is ready and if its components are failing
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.lock.RLock()
+	ready := r.ready
+	checks := make(map[string]CheckFunc, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	timeout := r.CheckTimeout
+	r.lock.RUnlock()
+
+	ret := Report{Status: StatusOK, Components: make(map[string]ComponentStatus)}
+	var wg sync.WaitGroup
+	var resultLock sync.Mutex
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			status := runCheck(ctx, check, timeout)
+			resultLock.Lock()
+			defer resultLock.Unlock()
+			ret.Components[name] = status
+		}(name, check)
+	}
+	wg.Wait()
+	for _, status := range ret.Components {
+		if status.Status != StatusOK {
+			ret.Status = StatusUnavailable
+		}
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for name, c := range r.components {
+		status := ComponentStatus{Status: StatusOK}
+		if !c.lastSuccess.IsZero() {
+			lastSuccess := c.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		if c.failures > 0 {
+			nextRun := c.nextRun
+			status.Status = StatusDegraded
+			status.Failures = c.failures
+			if c.lastError != nil {
+				status.Error = c.lastError.Error()
+			}
+			status.NextRun = &nextRun
+			if ret.Status == StatusOK {
+				ret.Status = StatusDegraded
+			}
+		}
+		ret.Components[name] = status
+	}
+	if !ready {
+		ret.Status = StatusUnavailable
+	}
+	return ret
+}
+
+func runCheck(ctx context.Context, check CheckFunc, timeout time.Duration) ComponentStatus {
+	cCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(cCtx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-cCtx.Done():
+		err = fmt.Errorf("check timed out after %s", timeout)
+	}
+	if err != nil {
+		return ComponentStatus{Status: StatusUnavailable, Error: err.Error()}
+	}
+	return ComponentStatus{Status: StatusOK}
+}
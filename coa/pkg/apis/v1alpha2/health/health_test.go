@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestRegistry() (*Registry, *clock) {
+	c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	registry := NewRegistry()
+	registry.now = c.Now
+	return registry, c
+}
+
+func TestNotReadyUntilMarked(t *testing.T) {
+	registry, _ := newTestRegistry()
+	assert.Equal(t, StatusUnavailable, registry.Readiness(context.Background()).Status)
+	registry.SetReady(true)
+	assert.Equal(t, StatusOK, registry.Readiness(context.Background()).Status)
+	registry.SetReady(false)
+	assert.Equal(t, StatusUnavailable, registry.Readiness(context.Background()).Status)
+}
+
+func TestFailedCheck(t *testing.T) {
+	registry, _ := newTestRegistry()
+	registry.SetReady(true)
+	registry.AddCheck("states", func(ctx context.Context) error {
+		return nil
+	})
+	registry.AddCheck("pubsub", func(ctx context.Context) error {
+		return fmt.Errorf("connection refused")
+	})
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.Equal(t, StatusOK, report.Components["states"].Status)
+	assert.Equal(t, StatusUnavailable, report.Components["pubsub"].Status)
+	assert.Equal(t, "connection refused", report.Components["pubsub"].Error)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	registry, _ := newTestRegistry()
+	registry.SetReady(true)
+	registry.CheckTimeout = 10 * time.Millisecond
+	registry.AddCheck("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.Contains(t, report.Components["stuck"].Error, "timed out")
+}
+
+func TestStalledLoop(t *testing.T) {
+	registry, c := newTestRegistry()
+	registry.Heartbeat("solution", time.Minute)
+	assert.Equal(t, StatusOK, registry.Liveness().Status)
+	c.now = c.now.Add(2 * time.Minute)
+	report := registry.Liveness()
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.Equal(t, "no heartbeat for 2m0s", report.Components["solution"].Error)
+	registry.RemoveLoop("solution")
+	assert.Equal(t, StatusOK, registry.Liveness().Status)
+}
+
+func TestFailureBackoff(t *testing.T) {
+	registry, c := newTestRegistry()
+	registry.SetReady(true)
+	registry.MaxBackoff = 30 * time.Second
+	err := fmt.Errorf("poll failed")
+	assert.Equal(t, 5*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+	assert.False(t, registry.Due("job-manager"))
+	c.now = c.now.Add(5 * time.Second)
+	assert.True(t, registry.Due("job-manager"))
+	assert.Equal(t, 10*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+	assert.Equal(t, 20*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+	assert.Equal(t, 30*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+	assert.Equal(t, 30*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+
+	// a failing component degrades the host, which is still ready
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, 5, report.Components["job-manager"].Failures)
+	assert.Equal(t, "poll failed", report.Components["job-manager"].Error)
+
+	registry.ReportSuccess("job-manager")
+	assert.True(t, registry.Due("job-manager"))
+	report = registry.Readiness(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, 0, report.Components["job-manager"].Failures)
+	assert.Equal(t, 5*time.Second, registry.ReportFailure("job-manager", err, 5*time.Second))
+}
@@ -7,6 +7,7 @@
 package host
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -16,6 +17,7 @@ import (
 	bindings "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings"
 	http "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings/http"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings/mqtt"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
 	mf "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	pf "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
 	pv "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
@@ -27,6 +29,10 @@ import (
 
 var log = logger.NewLogger("coa.runtime")
 
+// ShutdownTimeout is how long a host that waits for its context waits for the requests in progress and the
+// manager loops when it shuts down
+const ShutdownTimeout = 30 * time.Second
+
 type HostConfig struct {
 	SiteInfo v1alpha2.SiteInfo `json:"siteInfo"`
 	API      APIConfig         `json:"api"`
@@ -54,14 +60,24 @@ type APIHost struct {
 	Vendors              []VendorSpec
 	Bindings             []bindings.IBinding
 	SharedPubSubProvider pv.IProvider
+	// Health collects the liveness and readiness of the host, which the HTTP bindings serve
+	Health     *health.Registry
+	cancelLoop context.CancelFunc
+	loops      sync.WaitGroup
 }
 
-func (h *APIHost) Launch(config HostConfig,
+// Launch creates the vendors, starts their manager loops and launches the bindings. When wait is set, it
+// returns once ctx is done and the host has shut down. Otherwise the manager loops run until ctx is done,
+// and the caller shuts the host down.
+func (h *APIHost) Launch(ctx context.Context, config HostConfig,
 	vendorFactories []vendors.IVendorFactory,
 	managerFactories []mf.IManagerFactroy,
 	providerFactories []pf.IProviderFactory, wait bool) error {
 	h.Vendors = make([]VendorSpec, 0)
 	h.Bindings = make([]bindings.IBinding, 0)
+	if h.Health == nil {
+		h.Health = health.NewRegistry()
+	}
 	log.Info("--- launching COA host ---")
 	if config.SiteInfo.SiteId == "" {
 		return v1alpha2.NewCOAError(nil, "siteId is not specified", v1alpha2.BadConfig)
@@ -91,6 +107,9 @@ func (h *APIHost) Launch(config HostConfig,
 							pubsubProvider = mProvider
 							if config.API.PubSub.Shared {
 								h.SharedPubSubProvider = pubsubProvider
+								h.addHealthCheck("pubsub", pubsubProvider)
+							} else {
+								h.addHealthCheck("pubsub/"+v.Route, pubsubProvider)
 							}
 							break
 						}
@@ -126,6 +145,11 @@ func (h *APIHost) Launch(config HostConfig,
 				if err != nil {
 					return err
 				}
+				for manager, mProviders := range providers {
+					for name, provider := range mProviders {
+						h.addHealthCheck(fmt.Sprintf("providers/%s/%s/%s", v.Route, manager, name), provider)
+					}
+				}
 				h.Vendors = append(h.Vendors, VendorSpec{Vendor: vendor, LoopInterval: v.LoopInterval})
 				created = true
 				break
@@ -150,73 +174,129 @@ func (h *APIHost) Launch(config HostConfig,
 				v.Vendor.SetEvaluationContext(evaluationContext)
 			}
 		}
-		var wg sync.WaitGroup
+		loopCtx, cancel := context.WithCancel(ctx)
+		h.cancelLoop = cancel
 		for _, v := range h.Vendors {
 			if v.LoopInterval > 0 {
-				if wait {
-					wg.Add(1)
-				}
+				h.loops.Add(1)
 				go func(v VendorSpec) {
-					v.Vendor.RunLoop(time.Duration(v.LoopInterval) * time.Second)
+					defer h.loops.Done()
+					v.Vendor.RunLoop(loopCtx, time.Duration(v.LoopInterval)*time.Second, h.Health)
 				}(v)
 			}
 		}
-		if len(config.Bindings) > 0 {
-			endpoints := make([]v1alpha2.Endpoint, 0)
-			for _, v := range h.Vendors {
-				endpoints = append(endpoints, v.Vendor.GetEndpoints()...)
-			}
+		if err := h.launchBindings(config, providerFactories); err != nil {
+			sCtx, sCancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+			defer sCancel()
+			h.Shutdown(sCtx)
+			return err
+		}
+		h.Health.SetReady(true)
+		log.Info("--- COA host is ready ---")
+		if wait {
+			<-ctx.Done()
+			sCtx, sCancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+			defer sCancel()
+			return h.Shutdown(sCtx)
+		}
+		return nil
+	} else {
+		return v1alpha2.NewCOAError(nil, "no vendors are found", v1alpha2.MissingConfig)
+	}
+}
 
-			for _, b := range config.Bindings {
-				switch b.Type {
-				case "bindings.http":
-					if wait {
-						wg.Add(1)
-					}
-					var binding bindings.IBinding
-					var err error
-					if h.SharedPubSubProvider != nil {
-						binding, err = h.launchHTTP(b.Config, endpoints, h.SharedPubSubProvider.(pubsub.IPubSubProvider))
-					} else {
-						var bindingPubsub pv.IProvider
-						for _, providerFactory := range providerFactories {
-							mProvider, err := providerFactory.CreateProvider(
-								config.API.PubSub.Provider.Type,
-								config.API.PubSub.Provider.Config)
-							if err != nil {
-								return err
-							}
-							bindingPubsub = mProvider
-							break
-						}
-						binding, err = h.launchHTTP(b.Config, endpoints, bindingPubsub.(pubsub.IPubSubProvider))
-					}
-					if err != nil {
-						return err
-					}
-					h.Bindings = append(h.Bindings, binding)
-				case "bindings.mqtt":
-					if wait {
-						wg.Add(1)
-					}
-					binding, err := h.launchMQTT(b.Config, endpoints)
+func (h *APIHost) launchBindings(config HostConfig, providerFactories []pf.IProviderFactory) error {
+	if len(config.Bindings) == 0 {
+		return nil
+	}
+	endpoints := make([]v1alpha2.Endpoint, 0)
+	for _, v := range h.Vendors {
+		endpoints = append(endpoints, v.Vendor.GetEndpoints()...)
+	}
+
+	for _, b := range config.Bindings {
+		switch b.Type {
+		case "bindings.http":
+			var binding bindings.IBinding
+			var err error
+			if h.SharedPubSubProvider != nil {
+				binding, err = h.launchHTTP(b.Config, config.SiteInfo, endpoints, h.SharedPubSubProvider.(pubsub.IPubSubProvider))
+			} else {
+				var bindingPubsub pv.IProvider
+				for _, providerFactory := range providerFactories {
+					mProvider, err := providerFactory.CreateProvider(
+						config.API.PubSub.Provider.Type,
+						config.API.PubSub.Provider.Config)
 					if err != nil {
 						return err
 					}
-					h.Bindings = append(h.Bindings, binding)
-				default:
-					return v1alpha2.NewCOAError(nil, fmt.Sprintf("binding type '%s' is not recognized", b.Type), v1alpha2.BadConfig)
+					bindingPubsub = mProvider
+					break
 				}
+				binding, err = h.launchHTTP(b.Config, config.SiteInfo, endpoints, bindingPubsub.(pubsub.IPubSubProvider))
 			}
+			if err != nil {
+				return err
+			}
+			h.Bindings = append(h.Bindings, binding)
+		case "bindings.mqtt":
+			binding, err := h.launchMQTT(b.Config, endpoints)
+			if err != nil {
+				return err
+			}
+			h.Bindings = append(h.Bindings, binding)
+		default:
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("binding type '%s' is not recognized", b.Type), v1alpha2.BadConfig)
 		}
-		wg.Wait()
-		return nil
-	} else {
-		return v1alpha2.NewCOAError(nil, "no vendors are found", v1alpha2.MissingConfig)
+	}
+	return nil
+}
+
+// Shutdown marks the host as not ready, stops the manager loops, and waits until ctx is done for the
+// bindings to complete the requests in progress and for the loops to complete their polls
+func (h *APIHost) Shutdown(ctx context.Context) error {
+	log.Info("--- shutting down COA host ---")
+	if h.Health != nil {
+		h.Health.SetReady(false)
+	}
+	if h.cancelLoop != nil {
+		h.cancelLoop()
+	}
+	var ret error
+	for _, b := range h.Bindings {
+		if err := b.Shutdown(ctx); err != nil {
+			log.Errorf("failed to shut down binding: %+v", err)
+			if ret == nil {
+				ret = err
+			}
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		h.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if ret == nil {
+			ret = v1alpha2.NewCOAError(ctx.Err(), "timed out waiting for manager loops to stop", v1alpha2.InternalError)
+		}
+	}
+	log.Info("--- COA host is shut down ---")
+	return ret
+}
+
+// addHealthCheck makes the readiness of the host depend on a provider that checks its service, such as a
+// state store
+func (h *APIHost) addHealthCheck(name string, provider pv.IProvider) {
+	if checker, ok := provider.(pv.IHealthCheckProvider); ok {
+		log.Debugf("--- health check '%s' is added ---", name)
+		h.Health.AddCheck(name, checker.CheckHealth)
 	}
 }
 
-func (h *APIHost) launchHTTP(config interface{}, endpoints []v1alpha2.Endpoint, pubsubProvider pubsub.IPubSubProvider) (bindings.IBinding, error) {
+func (h *APIHost) launchHTTP(config interface{}, siteInfo v1alpha2.SiteInfo, endpoints []v1alpha2.Endpoint, pubsubProvider pubsub.IPubSubProvider) (bindings.IBinding, error) {
 	data, err := json.Marshal(config)
 	if err != nil {
 		return nil, err
@@ -226,7 +306,9 @@ func (h *APIHost) launchHTTP(config interface{}, endpoints []v1alpha2.Endpoint,
 	if err != nil {
 		return nil, err
 	}
-	binding := http.HttpBinding{}
+	httpConfig.SiteInfo = siteInfo
+	httpConfig.Health = h.Health
+	binding := &http.HttpBinding{}
 	return binding, binding.Launch(httpConfig, endpoints, pubsubProvider)
 }
 func (h *APIHost) launchMQTT(config interface{}, endpoints []v1alpha2.Endpoint) (bindings.IBinding, error) {
@@ -239,6 +321,6 @@ func (h *APIHost) launchMQTT(config interface{}, endpoints []v1alpha2.Endpoint)
 	if err != nil {
 		return nil, err
 	}
-	binding := mqtt.MQTTBinding{}
+	binding := &mqtt.MQTTBinding{}
 	return binding, binding.Launch(mqttConfig, endpoints)
 }
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package host
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
+	mf "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	pf "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
+	pv "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testVendor struct {
+	vendors.Vendor
+}
+
+func (v *testVendor) GetInfo() vendors.VendorInfo {
+	return vendors.VendorInfo{Name: "test"}
+}
+func (v *testVendor) GetEndpoints() []v1alpha2.Endpoint {
+	return nil
+}
+
+type testVendorFactory struct{}
+
+func (f testVendorFactory) CreateVendor(config vendors.VendorConfig) (vendors.IVendor, error) {
+	return &testVendor{}, nil
+}
+
+type testProvider struct {
+	err error
+}
+
+func (p *testProvider) Init(config pv.IProviderConfig) error {
+	return nil
+}
+func (p *testProvider) CheckHealth(ctx context.Context) error {
+	return p.err
+}
+
+type testProviderFactory struct {
+	provider pv.IProvider
+}
+
+func (f testProviderFactory) CreateProviders(config vendors.VendorConfig) (map[string]map[string]pv.IProvider, error) {
+	return map[string]map[string]pv.IProvider{
+		"test-manager": {"state": f.provider},
+	}, nil
+}
+func (f testProviderFactory) CreateProvider(providerType string, config pv.IProviderConfig) (pv.IProvider, error) {
+	return nil, nil
+}
+
+func testConfig() HostConfig {
+	return HostConfig{
+		SiteInfo: v1alpha2.SiteInfo{SiteId: "test"},
+		API: APIConfig{
+			Vendors: []vendors.VendorConfig{
+				{Type: "vendors.test", Route: "test", LoopInterval: 1},
+			},
+		},
+	}
+}
+
+func TestLaunchAndShutdown(t *testing.T) {
+	provider := &testProvider{}
+	host := APIHost{Health: health.NewRegistry()}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- host.Launch(ctx, testConfig(), []vendors.IVendorFactory{testVendorFactory{}}, []mf.IManagerFactroy{},
+			[]pf.IProviderFactory{testProviderFactory{provider: provider}}, true)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return host.Health.Readiness(context.Background()).Status == health.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, health.StatusOK, host.Health.Liveness().Status)
+	assert.Contains(t, host.Health.Liveness().Components, "loops/test")
+
+	// the readiness of the host follows its providers
+	provider.err = fmt.Errorf("connection refused")
+	report := host.Health.Readiness(context.Background())
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, "connection refused", report.Components["providers/test/test-manager/state"].Error)
+	provider.err = nil
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("host didn't shut down")
+	}
+	assert.Equal(t, health.StatusUnavailable, host.Health.Readiness(context.Background()).Status)
+	assert.NotContains(t, host.Health.Liveness().Components, "loops/test")
+}
+
+func TestLaunchWithoutWaiting(t *testing.T) {
+	host := APIHost{}
+	err := host.Launch(context.Background(), testConfig(), []vendors.IVendorFactory{testVendorFactory{}}, []mf.IManagerFactroy{},
+		[]pf.IProviderFactory{testProviderFactory{provider: &testProvider{}}}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, health.StatusOK, host.Health.Readiness(context.Background()).Status)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.Nil(t, host.Shutdown(ctx))
+	assert.Equal(t, health.StatusUnavailable, host.Health.Readiness(context.Background()).Status)
+}
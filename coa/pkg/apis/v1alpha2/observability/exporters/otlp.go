@@ -0,0 +1,388 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcinsecure "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultTracesPath = "/v1/traces"
+	defaultLogsPath   = "/v1/logs"
+
+	logQueueSize     = 2048
+	logBatchSize     = 512
+	logBatchInterval = 2 * time.Second
+	logExportTimeout = 10 * time.Second
+)
+
+// parseEndpoint splits an OTLP backend URL, such as http://otel-collector:4318/v1/traces, into the
+// host:port and the path. A backend URL without a scheme, such as otel-collector:4317, is a host:port.
+// The connection is plain text for http:// URLs, or when insecure is set.
+func parseEndpoint(backendUrl string, insecure bool) (string, string, bool, error) {
+	if backendUrl == "" {
+		return "", "", false, fmt.Errorf("OTLP exporter requires a backendUrl")
+	}
+	if !strings.Contains(backendUrl, "://") {
+		return backendUrl, "", insecure, nil
+	}
+	u, err := url.Parse(backendUrl)
+	if err != nil {
+		return "", "", false, err
+	}
+	switch u.Scheme {
+	case "http":
+		insecure = true
+	case "https":
+	default:
+		return "", "", false, fmt.Errorf("OTLP backendUrl scheme '%s' is not supported", u.Scheme)
+	}
+	return u.Host, u.Path, insecure, nil
+}
+
+func NewOTLPgRPCTraceExporter(backendUrl string, insecure bool, headers map[string]string) (sdktrace.SpanExporter, error) {
+	endpoint, _, insecure, err := parseEndpoint(backendUrl, insecure)
+	if err != nil {
+		return nil, err
+	}
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithHeaders(headers),
+	}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(context.Background(), options...)
+}
+
+func NewOTLPHTTPTraceExporter(backendUrl string, insecure bool, headers map[string]string) (sdktrace.SpanExporter, error) {
+	endpoint, path, insecure, err := parseEndpoint(backendUrl, insecure)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" || path == "/" {
+		path = defaultTracesPath
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithURLPath(path),
+		otlptracehttp.WithHeaders(headers),
+	}
+	if insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+type logClient interface {
+	upload(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error
+	close() error
+}
+
+type grpcLogClient struct {
+	conn    *grpc.ClientConn
+	client  collogspb.LogsServiceClient
+	headers map[string]string
+}
+
+func (c *grpcLogClient) upload(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+	if len(c.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(c.headers))
+	}
+	_, err := c.client.Export(ctx, request)
+	return err
+}
+
+func (c *grpcLogClient) close() error {
+	return c.conn.Close()
+}
+
+type httpLogClient struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (c *httpLogClient) upload(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("OTLP log export failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *httpLogClient) close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// OTLPLogExporter batches log records, and ships them to an OTLP endpoint such as an OpenTelemetry collector.
+// Records are dropped when the queue is full, so logging never waits on the backend.
+type OTLPLogExporter struct {
+	client    logClient
+	resource  *resourcepb.Resource
+	records   chan logger.Record
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewOTLPgRPCLogExporter(backendUrl string, insecure bool, headers map[string]string, res *resource.Resource) (*OTLPLogExporter, error) {
+	endpoint, _, insecure, err := parseEndpoint(backendUrl, insecure)
+	if err != nil {
+		return nil, err
+	}
+	creds := credentials.NewTLS(&tls.Config{})
+	if insecure {
+		creds = grpcinsecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return newOTLPLogExporter(&grpcLogClient{
+		conn:    conn,
+		client:  collogspb.NewLogsServiceClient(conn),
+		headers: headers,
+	}, res), nil
+}
+
+func NewOTLPHTTPLogExporter(backendUrl string, insecure bool, headers map[string]string, res *resource.Resource) (*OTLPLogExporter, error) {
+	endpoint, path, insecure, err := parseEndpoint(backendUrl, insecure)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" || path == "/" {
+		path = defaultLogsPath
+	}
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	return newOTLPLogExporter(&httpLogClient{
+		url:     fmt.Sprintf("%s://%s%s", scheme, endpoint, path),
+		headers: headers,
+		client:  &http.Client{Timeout: logExportTimeout},
+	}, res), nil
+}
+
+func newOTLPLogExporter(client logClient, res *resource.Resource) *OTLPLogExporter {
+	e := &OTLPLogExporter{
+		client:   client,
+		resource: toResourceProto(res),
+		records:  make(chan logger.Record, logQueueSize),
+		done:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+// Export queues a log record
+func (e *OTLPLogExporter) Export(record logger.Record) {
+	select {
+	case <-e.done:
+	case e.records <- record:
+	default:
+	}
+}
+
+// Shutdown sends the queued records, and closes the connection to the backend
+func (e *OTLPLogExporter) Shutdown(ctx context.Context) error {
+	var err error
+	e.closeOnce.Do(func() {
+		close(e.done)
+		finished := make(chan struct{})
+		go func() {
+			e.wg.Wait()
+			close(finished)
+		}()
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if cErr := e.client.close(); err == nil {
+			err = cErr
+		}
+	})
+	return err
+}
+
+func (e *OTLPLogExporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(logBatchInterval)
+	defer ticker.Stop()
+	batch := make([]logger.Record, 0, logBatchSize)
+	for {
+		select {
+		case record := <-e.records:
+			batch = append(batch, record)
+			if len(batch) >= logBatchSize {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case <-e.done:
+			for {
+				select {
+				case record := <-e.records:
+					batch = append(batch, record)
+				default:
+					if len(batch) > 0 {
+						e.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *OTLPLogExporter) send(batch []logger.Record) {
+	records := make([]*logspb.LogRecord, 0, len(batch))
+	for _, r := range batch {
+		records = append(records, toLogRecordProto(r))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), logExportTimeout)
+	defer cancel()
+	err := e.client.upload(ctx, &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: e.resource,
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope:      &commonpb.InstrumentationScope{Name: "symphony"},
+						LogRecords: records,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		// not logged through the logger, as that would export the failure again
+		fmt.Fprintf(os.Stderr, "failed to export %d log records: %s\n", len(batch), err.Error())
+	}
+}
+
+func toResourceProto(res *resource.Resource) *resourcepb.Resource {
+	ret := &resourcepb.Resource{}
+	if res == nil {
+		return ret
+	}
+	for _, kv := range res.Attributes() {
+		ret.Attributes = append(ret.Attributes, &commonpb.KeyValue{
+			Key:   string(kv.Key),
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: kv.Value.Emit()}},
+		})
+	}
+	return ret
+}
+
+func toLogRecordProto(record logger.Record) *logspb.LogRecord {
+	ret := &logspb.LogRecord{
+		TimeUnixNano:         uint64(record.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(record.Time.UnixNano()),
+		SeverityNumber:       toSeverityNumber(record.Level),
+		SeverityText:         string(record.Level),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: record.Message}},
+	}
+	if record.Scope != "" {
+		ret.Attributes = append(ret.Attributes, &commonpb.KeyValue{
+			Key:   "scope",
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: record.Scope}},
+		})
+	}
+	for k, v := range record.Fields {
+		ret.Attributes = append(ret.Attributes, &commonpb.KeyValue{Key: k, Value: toAnyValue(v)})
+	}
+	if record.TraceID.IsValid() {
+		ret.TraceId = record.TraceID[:]
+	}
+	if record.SpanID.IsValid() {
+		ret.SpanId = record.SpanID[:]
+	}
+	return ret
+}
+
+func toAnyValue(v interface{}) *commonpb.AnyValue {
+	switch tv := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: tv}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: tv}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(tv)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: tv}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: tv}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+}
+
+func toSeverityNumber(level logger.LogLevel) logspb.SeverityNumber {
+	switch level {
+	case logger.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case logger.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case logger.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case logger.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case logger.FatalLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package exporters
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	endpoint, path, insecure, err := parseEndpoint("otel-collector:4317", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "otel-collector:4317", endpoint)
+	assert.Equal(t, "", path)
+	assert.False(t, insecure)
+
+	endpoint, path, insecure, err = parseEndpoint("http://otel-collector:4318/custom/traces", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "otel-collector:4318", endpoint)
+	assert.Equal(t, "/custom/traces", path)
+	assert.True(t, insecure)
+
+	_, _, insecure, err = parseEndpoint("https://otel-collector:4318", false)
+	assert.Nil(t, err)
+	assert.False(t, insecure)
+
+	_, _, _, err = parseEndpoint("ftp://otel-collector", false)
+	assert.NotNil(t, err)
+	_, _, _, err = parseEndpoint("", false)
+	assert.NotNil(t, err)
+}
+
+func TestOTLPHTTPLogExporter(t *testing.T) {
+	requests := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		data, _ := io.ReadAll(r.Body)
+		request := &collogspb.ExportLogsServiceRequest{}
+		assert.Nil(t, proto.Unmarshal(data, request))
+		requests <- request
+	}))
+	defer server.Close()
+
+	res := resource.NewSchemaless(attribute.String("service.name", "Symphony API"))
+	exporter, err := NewOTLPHTTPLogExporter(server.URL, false, map[string]string{"api-key": "secret"}, res)
+	assert.Nil(t, err)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	exporter.Export(logger.Record{
+		Time:    time.Now(),
+		Level:   logger.WarnLevel,
+		Scope:   "coa.runtime",
+		Message: "reconcile is slow",
+		Fields:  map[string]interface{}{"instance": "node-1"},
+		TraceID: traceID,
+		SpanID:  spanID,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, exporter.Shutdown(ctx))
+
+	request := <-requests
+	assert.Equal(t, "service.name", request.ResourceLogs[0].Resource.Attributes[0].Key)
+	record := request.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "reconcile is slow", record.Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, record.SeverityNumber)
+	assert.Equal(t, traceID[:], record.TraceId)
+	assert.Equal(t, spanID[:], record.SpanId)
+	attributes := make(map[string]string)
+	for _, kv := range record.Attributes {
+		attributes[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, "coa.runtime", attributes["scope"])
+	assert.Equal(t, "node-1", attributes["instance"])
+
+	// records after shutdown are dropped
+	exporter.Export(logger.Record{Message: "late"})
+}
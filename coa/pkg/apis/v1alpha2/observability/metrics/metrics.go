@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var log = logger.NewLogger("coa.runtime")
+
+// Namespace prefixes the names of all Symphony metrics
+const Namespace = "symphony"
+
+var (
+	// RequestBuckets are histogram buckets, in seconds, suited for API calls
+	RequestBuckets = prometheus.DefBuckets
+	// OperationBuckets are histogram buckets, in seconds, suited for long running operations such as deployments
+	OperationBuckets = prometheus.ExponentialBuckets(0.1, 2, 12)
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Counter is a metric that only goes up, such as a number of requests
+type Counter interface {
+	Add(value float64, labels ...string)
+}
+
+// Gauge is a metric that can go up and down, such as a queue depth
+type Gauge interface {
+	Set(value float64, labels ...string)
+	// Add adds value, which can be negative, to the gauge
+	Add(value float64, labels ...string)
+}
+
+// Histogram samples observations, such as durations in seconds, into buckets
+type Histogram interface {
+	Observe(value float64, labels ...string)
+}
+
+type counter struct {
+	vec *prometheus.CounterVec
+}
+
+func (c counter) Add(value float64, labels ...string) {
+	if value < 0 {
+		return
+	}
+	m, err := c.vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		log.Errorf("metrics: failed to record counter: %s", err.Error())
+		return
+	}
+	m.Add(value)
+}
+
+type gauge struct {
+	vec *prometheus.GaugeVec
+}
+
+func (g gauge) Set(value float64, labels ...string) {
+	m, err := g.vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		log.Errorf("metrics: failed to record gauge: %s", err.Error())
+		return
+	}
+	m.Set(value)
+}
+
+func (g gauge) Add(value float64, labels ...string) {
+	m, err := g.vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		log.Errorf("metrics: failed to record gauge: %s", err.Error())
+		return
+	}
+	m.Add(value)
+}
+
+type histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+func (h histogram) Observe(value float64, labels ...string) {
+	m, err := h.vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		log.Errorf("metrics: failed to record histogram: %s", err.Error())
+		return
+	}
+	m.Observe(value)
+}
+
+// NewCounter creates a counter named symphony_<name>. Creating a counter with the name of an existing
+// counter returns the existing one, so managers can create their metrics in Init.
+func NewCounter(name string, help string, labelNames ...string) Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, labelNames)
+	if existing, ok := register(vec).(*prometheus.CounterVec); ok {
+		vec = existing
+	}
+	return counter{vec: vec}
+}
+
+// NewGauge creates a gauge named symphony_<name>
+func NewGauge(name string, help string, labelNames ...string) Gauge {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, labelNames)
+	if existing, ok := register(vec).(*prometheus.GaugeVec); ok {
+		vec = existing
+	}
+	return gauge{vec: vec}
+}
+
+// NewHistogram creates a histogram named symphony_<name>. When buckets is nil, RequestBuckets are used.
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram {
+	if buckets == nil {
+		buckets = RequestBuckets
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labelNames)
+	if existing, ok := register(vec).(*prometheus.HistogramVec); ok {
+		vec = existing
+	}
+	return histogram{vec: vec}
+}
+
+func register(collector prometheus.Collector) prometheus.Collector {
+	err := registry.Register(collector)
+	if err == nil {
+		return collector
+	}
+	if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return are.ExistingCollector
+	}
+	// the metric stays usable, it's just not exposed
+	log.Errorf("metrics: failed to register metric: %s", err.Error())
+	return collector
+}
+
+// Handler serves all registered metrics in the Prometheus text format, or in the OpenMetrics format
+// when the scraper asks for it
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
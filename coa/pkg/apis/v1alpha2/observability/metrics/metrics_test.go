@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	data, err := io.ReadAll(recorder.Body)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestMetrics(t *testing.T) {
+	counter := NewCounter("test_events_total", "test events", "kind")
+	counter.Add(2, "a")
+	counter.Add(-1, "a")
+	// a second counter with the same name shares the series
+	NewCounter("test_events_total", "test events", "kind").Add(1, "a")
+	gauge := NewGauge("test_depth", "test depth", "queue")
+	gauge.Set(3, "q1")
+	gauge.Set(2, "q2")
+	gauge.Add(-1, "q2")
+	histogram := NewHistogram("test_duration_seconds", "test duration", []float64{1, 10})
+	histogram.Observe(5)
+
+	body := scrape(t)
+	assert.Contains(t, body, `symphony_test_events_total{kind="a"} 3`)
+	assert.Contains(t, body, `symphony_test_depth{queue="q1"} 3`)
+	assert.Contains(t, body, `symphony_test_depth{queue="q2"} 1`)
+	assert.Contains(t, body, `symphony_test_duration_seconds_bucket{le="10"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetricsWrongLabels(t *testing.T) {
+	counter := NewCounter("test_labels_total", "test labels", "kind")
+	// a label mismatch is logged, never a panic
+	counter.Add(1)
+	counter.Add(1, "a", "b")
+	assert.NotContains(t, scrape(t), "symphony_test_labels_total{")
+}
@@ -8,6 +8,8 @@ package observability
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"bytes"
 	"context"
@@ -19,6 +21,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	"go.opentelemetry.io/otel/attribute"
 	resource "go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
@@ -27,6 +30,12 @@ import (
 
 type ObservabilityConfig struct {
 	Pipelines []PipelineConfig `json:"pipelines"`
+	// ServiceName is the service.name resource attribute. Default is "Symphony API".
+	ServiceName string `json:"serviceName,omitempty"`
+	// Resource holds additional resource attributes, such as deployment.environment
+	Resource map[string]string `json:"resource,omitempty"`
+	// SiteInfo is set by the host. It adds the site ID, the node name and the version to the resource.
+	SiteInfo v1alpha2.SiteInfo `json:"-"`
 }
 
 type PipelineConfig struct {
@@ -36,12 +45,18 @@ type ExporterConfig struct {
 	Type       string        `json:"type"`
 	BackendUrl string        `json:"backendUrl"`
 	Sampler    SamplerConfig `json:"sampler"`
+	// Insecure disables TLS for OTLP backends given as host:port
+	Insecure bool `json:"insecure,omitempty"`
+	// Headers are sent with every OTLP export, such as an API key of the backend
+	Headers map[string]string `json:"headers,omitempty"`
 }
 type ProcessorConfig struct {
 }
 type ProviderConfig struct {
 }
 type SamplerConfig struct {
+	// SampleRate is "always", "never", or the ratio of traces to sample, such as "0.25". A span always
+	// follows the sampling decision of its parent.
 	SampleRate string `json:"sampleRate"`
 }
 
@@ -49,6 +64,7 @@ type Observability struct {
 	Tracer         trace.Tracer
 	TracerProvider trace.TracerProvider
 	Buffer         *bytes.Buffer
+	LogExporters   []*exporters.OTLPLogExporter
 }
 
 func StartSpan(name string, ctx context.Context, attributes *map[string]string) (context.Context, trace.Span) {
@@ -75,53 +91,149 @@ func EndSpan(ctx context.Context) {
 	span.End()
 }
 func (o *Observability) Init(config ObservabilityConfig) error {
+	res := buildResource(config)
+	processors := make([]sdktrace.SpanProcessor, 0)
+	var sampler sdktrace.Sampler
 	for _, p := range config.Pipelines {
-		err := o.createPipeline(p)
-		if err != nil {
-			return err
+		switch p.Exporter.Type {
+		case v1alpha2.LogExporterOTLPgRPC, v1alpha2.LogExporterOTLPHTTP:
+			exporter, err := o.createLogExporter(p.Exporter, res)
+			if err != nil {
+				return err
+			}
+			logger.AddExporter(exporter)
+			o.LogExporters = append(o.LogExporters, exporter)
+		default:
+			exporter, err := o.createExporter(p.Exporter)
+			if err != nil {
+				return err
+			}
+			processors = append(processors, sdktrace.NewBatchSpanProcessor(exporter))
+			// spans are sampled once for all exporters, so the first sample rate applies
+			if sampler == nil && p.Exporter.Sampler.SampleRate != "" {
+				sampler, err = createSampler(p.Exporter.Sampler.SampleRate)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(processors) > 0 {
+		if sampler == nil {
+			sampler = sdktrace.ParentBased(sdktrace.AlwaysSample())
+		}
+		options := []sdktrace.TracerProviderOption{
+			sdktrace.WithSampler(sampler),
+			sdktrace.WithResource(res),
+		}
+		for _, p := range processors {
+			options = append(options, sdktrace.WithSpanProcessor(p))
 		}
+		provider := sdktrace.NewTracerProvider(options...)
+		otel.SetTracerProvider(provider)
+		o.TracerProvider = provider
 	}
 	propagator := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{})
 	otel.SetTextMapPropagator(propagator)
 	return nil
 }
-func (o *Observability) createPipeline(config PipelineConfig) error {
-	err := o.createExporter(config.Exporter)
-	if err != nil {
-		return err
+
+// Shutdown sends the pending spans and log records
+func (o *Observability) Shutdown(ctx context.Context) error {
+	var ret error
+	if provider, ok := o.TracerProvider.(*sdktrace.TracerProvider); ok {
+		ret = provider.Shutdown(ctx)
 	}
-	return nil
+	for _, e := range o.LogExporters {
+		logger.RemoveExporter(e)
+		if err := e.Shutdown(ctx); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
 }
-func (o *Observability) createExporter(config ExporterConfig) error {
-	var exporter sdktrace.SpanExporter
-	var err error
+
+func (o *Observability) createExporter(config ExporterConfig) (sdktrace.SpanExporter, error) {
 	switch config.Type {
 	case v1alpha2.TracingExporterConsole:
 		if o.Buffer == nil {
-			exporter, err = exporters.NewConsoleExporter(nil)
+			return exporters.NewConsoleExporter(nil)
 		} else {
-			exporter, err = exporters.NewConsoleExporter(o.Buffer)
+			return exporters.NewConsoleExporter(o.Buffer)
 		}
+	case v1alpha2.TracingExporterZipkin:
+		return exporters.NewZipkinExporter(config.BackendUrl, config.Sampler.SampleRate)
+	case v1alpha2.TracingExporterOTLPgRPC:
+		exporter, err := exporters.NewOTLPgRPCTraceExporter(config.BackendUrl, config.Insecure, config.Headers)
 		if err != nil {
-			return err
+			return nil, v1alpha2.NewCOAError(err, "failed to create OTLP trace exporter", v1alpha2.BadConfig)
 		}
-	case v1alpha2.TracingExporterZipkin:
-		exporter, err = exporters.NewZipkinExporter(config.BackendUrl, config.Sampler.SampleRate)
+		return exporter, nil
+	case v1alpha2.TracingExporterOTLPHTTP:
+		exporter, err := exporters.NewOTLPHTTPTraceExporter(config.BackendUrl, config.Insecure, config.Headers)
 		if err != nil {
-			return err
+			return nil, v1alpha2.NewCOAError(err, "failed to create OTLP trace exporter", v1alpha2.BadConfig)
 		}
+		return exporter, nil
 	default:
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("exporter type '%s' is not supported", config.Type), v1alpha2.BadConfig)
-	}
-	batcher := sdktrace.NewBatchSpanProcessor(exporter)
-	//otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(batcher)))
-	//res, _ := resource.New(context.TODO(), resource.WithAttributes(attribute.String("service.name", "Symphony API (PAI)")))
-	//otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(batcher), sdktrace.WithResource(res)))
-	otel.SetTracerProvider(sdktrace.NewTracerProvider(
-		sdktrace.WithSpanProcessor(batcher),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("Symphony API"),
-		))))
-	return nil
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("exporter type '%s' is not supported", config.Type), v1alpha2.BadConfig)
+	}
+}
+
+func (o *Observability) createLogExporter(config ExporterConfig, res *resource.Resource) (*exporters.OTLPLogExporter, error) {
+	var exporter *exporters.OTLPLogExporter
+	var err error
+	if config.Type == v1alpha2.LogExporterOTLPgRPC {
+		exporter, err = exporters.NewOTLPgRPCLogExporter(config.BackendUrl, config.Insecure, config.Headers, res)
+	} else {
+		exporter, err = exporters.NewOTLPHTTPLogExporter(config.BackendUrl, config.Insecure, config.Headers, res)
+	}
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to create OTLP log exporter", v1alpha2.BadConfig)
+	}
+	return exporter, nil
+}
+
+func createSampler(sampleRate string) (sdktrace.Sampler, error) {
+	switch sampleRate {
+	case "always":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "never":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	}
+	ratio, err := strconv.ParseFloat(sampleRate, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("sample rate '%s' is not 'always', 'never' or a ratio between 0 and 1", sampleRate), v1alpha2.BadConfig)
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+}
+
+func buildResource(config ObservabilityConfig) *resource.Resource {
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "Symphony API"
+	}
+	attributes := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(serviceName),
+	}
+	if config.SiteInfo.SiteId != "" {
+		attributes = append(attributes, attribute.String("symphony.site.id", config.SiteInfo.SiteId))
+	}
+	nodeName := config.SiteInfo.Properties["nodeName"]
+	if nodeName == "" {
+		nodeName = os.Getenv("NODE_NAME")
+	}
+	if nodeName == "" {
+		nodeName, _ = os.Hostname()
+	}
+	if nodeName != "" {
+		attributes = append(attributes, semconv.HostNameKey.String(nodeName))
+	}
+	if version := config.SiteInfo.Properties["version"]; version != "" {
+		attributes = append(attributes, semconv.ServiceVersionKey.String(version))
+	}
+	for k, v := range config.Resource {
+		attributes = append(attributes, attribute.String(k, v))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attributes...)
 }
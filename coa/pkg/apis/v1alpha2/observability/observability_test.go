@@ -7,7 +7,9 @@
 package observability
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +49,82 @@ func TestZipkinPipeline(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+func TestOTLPPipelines(t *testing.T) {
+	ob := Observability{}
+	err := ob.Init(ObservabilityConfig{
+		Pipelines: []PipelineConfig{
+			{
+				Exporter: ExporterConfig{
+					Type:       v1alpha2.TracingExporterOTLPgRPC,
+					BackendUrl: "localhost:4317",
+					Insecure:   true,
+					Sampler: SamplerConfig{
+						SampleRate: "0.25",
+					},
+				},
+			},
+			{
+				Exporter: ExporterConfig{
+					Type:       v1alpha2.TracingExporterOTLPHTTP,
+					BackendUrl: "http://localhost:4318",
+					Headers:    map[string]string{"api-key": "secret"},
+				},
+			},
+			{
+				Exporter: ExporterConfig{
+					Type:       v1alpha2.LogExporterOTLPHTTP,
+					BackendUrl: "http://localhost:4318/v1/logs",
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ob.LogExporters))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ob.Shutdown(ctx)
+}
+func TestSampleRate(t *testing.T) {
+	for _, rate := range []string{"always", "never", "0", "0.5", "1"} {
+		_, err := createSampler(rate)
+		assert.Nil(t, err, rate)
+	}
+	for _, rate := range []string{"sometimes", "1.5", "-0.1"} {
+		_, err := createSampler(rate)
+		assert.NotNil(t, err, rate)
+	}
+	ob := Observability{}
+	err := ob.Init(ObservabilityConfig{
+		Pipelines: []PipelineConfig{
+			{
+				Exporter: ExporterConfig{
+					Type:    v1alpha2.TracingExporterConsole,
+					Sampler: SamplerConfig{SampleRate: "half"},
+				},
+			},
+		},
+	})
+	assert.NotNil(t, err)
+}
+func TestBuildResource(t *testing.T) {
+	res := buildResource(ObservabilityConfig{
+		ServiceName: "Symphony Agent",
+		Resource:    map[string]string{"deployment.environment": "test"},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "hq",
+			Properties: map[string]string{
+				"nodeName": "node-1",
+				"version":  "0.48.0",
+			},
+		},
+	})
+	attributes := make(map[string]string)
+	for _, kv := range res.Attributes() {
+		attributes[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "Symphony Agent", attributes["service.name"])
+	assert.Equal(t, "hq", attributes["symphony.site.id"])
+	assert.Equal(t, "node-1", attributes["host.name"])
+	assert.Equal(t, "0.48.0", attributes["service.version"])
+	assert.Equal(t, "test", attributes["deployment.environment"])
+}
@@ -6,9 +6,17 @@
 
 package providers
 
+import "context"
+
 type IProviderConfig interface {
 }
 
 type IProvider interface {
 	Init(config IProviderConfig) error
 }
+
+// IHealthCheckProvider is implemented by providers that depend on a service, such as a state store or a
+// message broker. CheckHealth tells if the service can be reached, for the readiness of the host.
+type IHealthCheckProvider interface {
+	CheckHealth(ctx context.Context) error
+}
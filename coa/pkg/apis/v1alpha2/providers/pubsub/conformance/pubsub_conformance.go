@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package conformance
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+// DeliveryTimeout is how long the suite waits for an event to be delivered
+var DeliveryTimeout = 15 * time.Second
+
+// QuietPeriod is how long the suite waits to make sure an event is not delivered
+var QuietPeriod = 500 * time.Millisecond
+
+// uniqueTopic returns a topic that has not been used before, so providers backed by a persistent broker
+// don't deliver events left over from earlier runs
+func uniqueTopic(name string) string {
+	return fmt.Sprintf("conformance-%s-%d", name, time.Now().UnixNano())
+}
+
+func receive(t *testing.T, ch chan string, timeout time.Duration) (string, bool) {
+	select {
+	case msg := <-ch:
+		return msg, true
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+func PublishSubscribe[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	topic := uniqueTopic("basic")
+	ch := make(chan string, 1)
+	sub, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+		ch <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	err = p.Publish(topic, v1alpha2.Event{Body: "TEST"})
+	assert.Nil(t, err)
+	msg, ok := receive(t, ch, DeliveryTimeout)
+	assert.True(t, ok)
+	assert.Equal(t, "TEST", msg)
+}
+
+func MultipleSubscribers[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	topic := uniqueTopic("multiple")
+	ch1 := make(chan string, 1)
+	ch2 := make(chan string, 1)
+	sub1, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+		ch1 <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub1.Unsubscribe()
+	sub2, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+		ch2 <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub2.Unsubscribe()
+	err = p.Publish(topic, v1alpha2.Event{Body: "TEST"})
+	assert.Nil(t, err)
+	msg1, ok := receive(t, ch1, DeliveryTimeout)
+	assert.True(t, ok)
+	assert.Equal(t, "TEST", msg1)
+	msg2, ok := receive(t, ch2, DeliveryTimeout)
+	assert.True(t, ok)
+	assert.Equal(t, "TEST", msg2)
+}
+
+func WildcardTopics[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	prefix := uniqueTopic("wildcard")
+	ch := make(chan string, 3)
+	sub, err := p.Subscribe(prefix+".job.*", func(topic string, event v1alpha2.Event) error {
+		ch <- topic
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	assert.Nil(t, p.Publish(prefix+".job.1.status", v1alpha2.Event{Body: "TEST"}))
+	assert.Nil(t, p.Publish(prefix+".other.1", v1alpha2.Event{Body: "TEST"}))
+	assert.Nil(t, p.Publish(prefix+".job.1", v1alpha2.Event{Body: "TEST"}))
+	topic, ok := receive(t, ch, DeliveryTimeout)
+	assert.True(t, ok)
+	assert.Equal(t, prefix+".job.1", topic)
+	_, ok = receive(t, ch, QuietPeriod)
+	assert.False(t, ok)
+}
+
+func Unsubscribe[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	topic := uniqueTopic("unsubscribe")
+	ch := make(chan string, 1)
+	sub, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+		ch <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sub.Unsubscribe())
+	assert.Nil(t, sub.Unsubscribe())
+	err = p.Publish(topic, v1alpha2.Event{Body: "TEST"})
+	assert.Nil(t, err)
+	_, ok := receive(t, ch, QuietPeriod)
+	assert.False(t, ok)
+}
+
+// RetryFailedDelivery expects the provider to be configured with at least one retry
+func RetryFailedDelivery[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	topic := uniqueTopic("retry")
+	ch := make(chan string, 1)
+	var attempts int32
+	sub, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return fmt.Errorf("first attempt fails")
+		}
+		ch <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	err = p.Publish(topic, v1alpha2.Event{Body: "TEST"})
+	assert.Nil(t, err)
+	msg, ok := receive(t, ch, DeliveryTimeout)
+	assert.True(t, ok)
+	assert.Equal(t, "TEST", msg)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func ConcurrentSubscribers[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	topic := uniqueTopic("concurrent")
+	var wg sync.WaitGroup
+	for k := 0; k < 10; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub, err := p.Subscribe(topic, func(topic string, event v1alpha2.Event) error {
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Nil(t, p.Publish(topic, v1alpha2.Event{Body: "TEST"}))
+			assert.Nil(t, sub.Unsubscribe())
+		}()
+	}
+	wg.Wait()
+}
+
+func ConformanceSuite[P pubsub.IPubSubProvider](t *testing.T, p P) {
+	t.Run("Level=Default", func(t *testing.T) {
+		PublishSubscribe(t, p)
+		MultipleSubscribers(t, p)
+		WildcardTopics(t, p)
+		Unsubscribe(t, p)
+		RetryFailedDelivery(t, p)
+		ConcurrentSubscribers(t, p)
+	})
+}
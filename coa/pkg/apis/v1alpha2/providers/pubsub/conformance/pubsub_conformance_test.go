@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package conformance
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/nats"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/redis"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformanceSuiteMemory(t *testing.T) {
+	provider := &memory.InMemoryPubSubProvider{}
+	err := provider.Init(memory.InMemoryPubSubConfig{
+		Name:          "test",
+		MaxRetries:    1,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	ConformanceSuite(t, provider)
+}
+
+func TestConformanceSuiteRedis(t *testing.T) {
+	testRedis := os.Getenv("TEST_REDIS")
+	if testRedis == "" {
+		t.Skip("Skipping because TEST_REDIS enviornment variable is not set")
+	}
+	provider := &redis.RedisPubSubProvider{}
+	err := provider.Init(redis.RedisPubSubProviderConfig{
+		Name:              "test",
+		Host:              "localhost:6379",
+		ConsumerID:        "conformance",
+		NumberOfWorkers:   1,
+		MaxRetries:        1,
+		RetryInterval:     10 * time.Millisecond,
+		DiscoveryInterval: 100 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	ConformanceSuite(t, provider)
+}
+
+func TestConformanceSuiteNats(t *testing.T) {
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	assert.Nil(t, err)
+	go s.Start()
+	defer s.Shutdown()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("NATS server is not ready")
+	}
+	provider := &nats.NatsPubSubProvider{}
+	err = provider.Init(nats.NatsPubSubProviderConfig{
+		Name:          "test",
+		URL:           s.ClientURL(),
+		ConsumerID:    "conformance",
+		MaxRetries:    1,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	defer provider.Conn.Close()
+	defer provider.Cancel()
+	ConformanceSuite(t, provider)
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package pubsub
+
+import (
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// DeadLetter is an event that was given up on after it failed to be delivered too many times
+type DeadLetter struct {
+	ID            string         `json:"id"`
+	Topic         string         `json:"topic"`
+	MessageID     string         `json:"messageId"`
+	DeliveryCount int64          `json:"deliveryCount"`
+	Error         string         `json:"error,omitempty"`
+	Time          time.Time      `json:"time"`
+	Event         v1alpha2.Event `json:"event"`
+}
+
+// IDeadLetterProvider is implemented by pub-sub providers that keep the events they give up on
+type IDeadLetterProvider interface {
+	// ListDeadLetters returns up to count dead letters of a topic, oldest first. An empty topic lists the
+	// dead letters of all topics.
+	ListDeadLetters(topic string, count int) ([]DeadLetter, error)
+	// ReplayDeadLetter publishes a dead letter to its topic again and removes it
+	ReplayDeadLetter(topic string, id string) error
+	// DeleteDeadLetter removes a dead letter
+	DeleteDeadLetter(topic string, id string) error
+}
@@ -0,0 +1,218 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+var log = logger.NewLogger("coa.runtime")
+
+// ErrSubscriptionClosed is the result of events that were queued for a subscription that was closed before
+// they were delivered
+var ErrSubscriptionClosed = errors.New("subscription is closed")
+
+var (
+	// queueLength is the total of the queues of the subscriptions to a topic, so each dispatcher adds and removes
+	// its own events instead of setting the length of its queue
+	queueLength = metrics.NewGauge("pubsub_queue_length",
+		"Number of events waiting in subscriber queues", "provider", "topic")
+	backpressure = metrics.NewCounter("pubsub_backpressure_total",
+		"Number of events that had to wait for room in a full subscriber queue", "provider", "topic")
+	dropped = metrics.NewCounter("pubsub_dropped_total",
+		"Number of events that were not queued because a subscriber queue stayed full", "provider", "topic")
+	deliveryRetries = metrics.NewCounter("pubsub_delivery_retries_total",
+		"Number of times a failed event delivery was retried", "provider", "topic")
+	deliveryFailures = metrics.NewCounter("pubsub_delivery_failures_total",
+		"Number of events a subscriber failed to handle after all retries", "provider", "topic")
+)
+
+// DeliveryConfig controls how a Dispatcher delivers events to a handler
+type DeliveryConfig struct {
+	// NumberOfWorkers is the number of events a subscriber handles at the same time
+	NumberOfWorkers int
+	// QueueDepth is the number of events waiting for a worker before publishers have to wait
+	QueueDepth int
+	// MaxRetries is the number of times a failed delivery is retried
+	MaxRetries int
+	// RetryInterval is the wait between retries of a failed delivery
+	RetryInterval time.Duration
+}
+
+// Dispatcher delivers the events of a subscription to its handler with a fixed number of workers that read
+// from a bounded queue, so slow handlers push back on publishers instead of piling up goroutines
+type Dispatcher struct {
+	Provider string
+	Topic    string
+	handler  v1alpha2.EventHandler
+	config   DeliveryConfig
+	queue    chan delivery
+	done     chan struct{}
+	once     sync.Once
+}
+
+type delivery struct {
+	topic string
+	event v1alpha2.Event
+	ack   func(error)
+}
+
+// NewDispatcher starts the workers of a subscription. provider is the provider type used in metrics, and
+// topic is the subscription topic, which can be a pattern.
+func NewDispatcher(provider string, topic string, handler v1alpha2.EventHandler, config DeliveryConfig) *Dispatcher {
+	if config.NumberOfWorkers <= 0 {
+		config.NumberOfWorkers = 1
+	}
+	if config.QueueDepth < 0 {
+		config.QueueDepth = 0
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	d := &Dispatcher{
+		Provider: provider,
+		Topic:    topic,
+		handler:  handler,
+		config:   config,
+		queue:    make(chan delivery, config.QueueDepth),
+		done:     make(chan struct{}),
+	}
+	for i := 0; i < config.NumberOfWorkers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Matches tells if an event published to topic is for this subscription
+func (d *Dispatcher) Matches(topic string) bool {
+	return MatchTopic(d.Topic, topic)
+}
+
+// Enqueue queues an event for delivery. When the queue is full, Enqueue waits for room until ctx is done
+// and then gives up with an error. ack, when set, is called with the result of the delivery once the
+// handler succeeds, all retries have failed or the subscription is closed; it's not called for events that
+// Enqueue returns an error for.
+func (d *Dispatcher) Enqueue(ctx context.Context, topic string, event v1alpha2.Event, ack func(error)) error {
+	item := delivery{topic: topic, event: event, ack: ack}
+	select {
+	case <-d.done:
+		return v1alpha2.NewCOAError(ErrSubscriptionClosed, fmt.Sprintf("subscription to topic '%s' is closed", d.Topic), v1alpha2.InternalError)
+	default:
+	}
+	// the event is counted before it's queued, so that a worker can't take it off the count first
+	queueLength.Add(1, d.Provider, d.Topic)
+	select {
+	case d.queue <- item:
+		return nil
+	default:
+	}
+	backpressure.Add(1, d.Provider, d.Topic)
+	select {
+	case d.queue <- item:
+		return nil
+	case <-d.done:
+		queueLength.Add(-1, d.Provider, d.Topic)
+		return v1alpha2.NewCOAError(ErrSubscriptionClosed, fmt.Sprintf("subscription to topic '%s' is closed", d.Topic), v1alpha2.InternalError)
+	case <-ctx.Done():
+		queueLength.Add(-1, d.Provider, d.Topic)
+		dropped.Add(1, d.Provider, d.Topic)
+		return v1alpha2.NewCOAError(ctx.Err(), fmt.Sprintf("queue of subscription to topic '%s' is full", d.Topic), v1alpha2.InternalError)
+	}
+}
+
+// Close stops the workers. Deliveries in progress finish, and queued events are discarded with
+// ErrSubscriptionClosed.
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.done)
+		for {
+			select {
+			case item := <-d.queue:
+				queueLength.Add(-1, d.Provider, d.Topic)
+				if item.ack != nil {
+					item.ack(ErrSubscriptionClosed)
+				}
+			default:
+				return
+			}
+		}
+	})
+}
+
+func (d *Dispatcher) worker() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case item := <-d.queue:
+			queueLength.Add(-1, d.Provider, d.Topic)
+			err := d.deliver(item)
+			if item.ack != nil {
+				item.ack(err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(item delivery) error {
+	for attempt := 0; ; attempt++ {
+		err := d.handler(item.topic, item.event)
+		if err == nil {
+			return nil
+		}
+		if attempt >= d.config.MaxRetries {
+			deliveryFailures.Add(1, d.Provider, d.Topic)
+			log.Debugf("  P (PubSub) : %s failed to handle event on topic %s after %d attempts: %v", d.Provider, item.topic, attempt+1, err)
+			return err
+		}
+		deliveryRetries.Add(1, d.Provider, d.Topic)
+		timer := time.NewTimer(d.config.RetryInterval)
+		select {
+		case <-timer.C:
+		case <-d.done:
+			timer.Stop()
+			return ErrSubscriptionClosed
+		}
+	}
+}
+
+// PendingAck completes a message that was queued for several subscriptions once all of them are done with
+// it. The result is the first error any of them reported.
+type PendingAck struct {
+	lock      sync.Mutex
+	remaining int
+	err       error
+	complete  func(err error)
+}
+
+// NewPendingAck returns a PendingAck that calls complete after Done has been called count times
+func NewPendingAck(count int, complete func(err error)) *PendingAck {
+	return &PendingAck{remaining: count, complete: complete}
+}
+
+// Done records the result of one subscription. It can be passed to Dispatcher.Enqueue as ack.
+func (a *PendingAck) Done(err error) {
+	a.lock.Lock()
+	if err != nil && a.err == nil {
+		a.err = err
+	}
+	a.remaining--
+	finished := a.remaining == 0
+	result := a.err
+	a.lock.Unlock()
+	if finished {
+		a.complete(result)
+	}
+}
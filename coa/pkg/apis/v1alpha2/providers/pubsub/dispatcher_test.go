@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package pubsub
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func queueLengthMetric(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	data, err := io.ReadAll(recorder.Body)
+	assert.Nil(t, err)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, `symphony_pubsub_queue_length{provider="dispatcher-test",topic="job"}`) {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestQueueLengthOfSubscribers(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(topic string, event v1alpha2.Event) error {
+		<-release
+		return nil
+	}
+	config := DeliveryConfig{NumberOfWorkers: 1, QueueDepth: 5}
+	d1 := NewDispatcher("dispatcher-test", "job", handler, config)
+	d2 := NewDispatcher("dispatcher-test", "job", handler, config)
+	defer close(release)
+
+	// each worker takes an event and waits, so the queues of the subscribers hold 2 and 1 events
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, d1.Enqueue(context.Background(), "job", v1alpha2.Event{}, nil))
+	}
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, d2.Enqueue(context.Background(), "job", v1alpha2.Event{}, nil))
+	}
+	assert.Eventually(t, func() bool {
+		return strings.HasSuffix(queueLengthMetric(t), "} 3")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	d1.Close()
+	assert.True(t, strings.HasSuffix(queueLengthMetric(t), "} 1"), queueLengthMetric(t))
+	d2.Close()
+	assert.True(t, strings.HasSuffix(queueLengthMetric(t), "} 0"), queueLengthMetric(t))
+}
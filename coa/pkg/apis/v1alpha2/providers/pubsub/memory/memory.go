@@ -7,21 +7,65 @@
 package memory
 
 import (
+	"context"
 	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	contexts "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	providers "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+)
+
+const (
+	defaultNumberOfWorkers = 16
+	defaultQueueDepth      = 1000
+	defaultPublishTimeout  = 5 * time.Second
 )
 
 type InMemoryPubSubProvider struct {
-	Config      InMemoryPubSubConfig               `json:"config"`
-	Subscribers map[string][]v1alpha2.EventHandler `json:"subscribers"`
-	Context     *contexts.ManagerContext
+	Config        InMemoryPubSubConfig `json:"config"`
+	Context       *contexts.ManagerContext
+	lock          sync.RWMutex
+	subscriptions []*pubsub.Dispatcher
 }
 
 type InMemoryPubSubConfig struct {
 	Name string `json:"name"`
+	// NumberOfWorkers is the number of events each subscriber handles at the same time
+	NumberOfWorkers int `json:"numberOfWorkers,omitempty"`
+	// QueueDepth is the number of events each subscriber can queue
+	QueueDepth int `json:"queueDepth,omitempty"`
+	// MaxRetries is the number of times a failed delivery is retried
+	MaxRetries    int           `json:"maxRetries,omitempty"`
+	RetryInterval time.Duration `json:"retryInterval,omitempty"`
+	// PublishTimeout is how long Publish waits for room in a full subscriber queue
+	PublishTimeout time.Duration `json:"publishTimeout,omitempty"`
+}
+
+// UnmarshalJSON reads the durations of the config as duration strings such as "5s", or as numbers of nanoseconds
+func (c *InMemoryPubSubConfig) UnmarshalJSON(data []byte) error {
+	type config InMemoryPubSubConfig
+	aux := struct {
+		*config
+		RetryInterval  json.RawMessage `json:"retryInterval,omitempty"`
+		PublishTimeout json.RawMessage `json:"publishTimeout,omitempty"`
+	}{config: (*config)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return utils.UnmarshalDurations(data, map[string]*time.Duration{
+		"retryInterval":  &c.RetryInterval,
+		"publishTimeout": &c.PublishTimeout,
+	})
+}
+
+type subscription struct {
+	provider   *InMemoryPubSubProvider
+	dispatcher *pubsub.Dispatcher
 }
 
 func InMemoryPubSubConfigFromMap(properties map[string]string) (InMemoryPubSubConfig, error) {
@@ -29,6 +73,37 @@ func InMemoryPubSubConfigFromMap(properties map[string]string) (InMemoryPubSubCo
 	if v, ok := properties["name"]; ok {
 		ret.Name = v
 	}
+	for _, setting := range []struct {
+		key   string
+		value *int
+	}{
+		{"numberOfWorkers", &ret.NumberOfWorkers},
+		{"queueDepth", &ret.QueueDepth},
+		{"maxRetries", &ret.MaxRetries},
+	} {
+		if v, ok := properties[setting.key]; ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(err, "invalid int value in the '"+setting.key+"' setting of in-memory pub-sub provider", v1alpha2.BadConfig)
+			}
+			*setting.value = n
+		}
+	}
+	for _, setting := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"retryInterval", &ret.RetryInterval},
+		{"publishTimeout", &ret.PublishTimeout},
+	} {
+		if v, ok := properties[setting.key]; ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(err, "invalid duration value in the '"+setting.key+"' setting of in-memory pub-sub provider", v1alpha2.BadConfig)
+			}
+			*setting.value = d
+		}
+	}
 	return ret, nil
 }
 
@@ -53,28 +128,72 @@ func (i *InMemoryPubSubProvider) Init(config providers.IProviderConfig) error {
 	if err != nil {
 		return v1alpha2.NewCOAError(nil, "provided config is not a valid in-memory pub-sub provider config", v1alpha2.BadConfig)
 	}
+	if vConfig.NumberOfWorkers <= 0 {
+		vConfig.NumberOfWorkers = defaultNumberOfWorkers
+	}
+	if vConfig.QueueDepth <= 0 {
+		vConfig.QueueDepth = defaultQueueDepth
+	}
+	if vConfig.PublishTimeout <= 0 {
+		vConfig.PublishTimeout = defaultPublishTimeout
+	}
 	i.Config = vConfig
-	i.Subscribers = make(map[string][]v1alpha2.EventHandler)
+	i.lock.Lock()
+	for _, d := range i.subscriptions {
+		d.Close()
+	}
+	i.subscriptions = nil
+	i.lock.Unlock()
 	return nil
 }
+
+// Publish queues the event for every subscriber of the topic. When a subscriber queue is full, Publish waits
+// up to PublishTimeout for room and reports an error if the event couldn't be queued for all subscribers.
 func (i *InMemoryPubSubProvider) Publish(topic string, event v1alpha2.Event) error {
-	arr, ok := i.Subscribers[topic]
-	if ok && arr != nil {
-		for _, s := range arr {
-			go func(handler v1alpha2.EventHandler, topic string, event v1alpha2.Event) {
-				handler(topic, event)
-			}(s, topic, event)
+	i.lock.RLock()
+	matches := make([]*pubsub.Dispatcher, 0, len(i.subscriptions))
+	for _, d := range i.subscriptions {
+		if d.Matches(topic) {
+			matches = append(matches, d)
 		}
 	}
-	return nil
-}
-func (i *InMemoryPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) error {
-	arr, ok := i.Subscribers[topic]
-	if !ok || arr == nil {
-		i.Subscribers[topic] = make([]v1alpha2.EventHandler, 0)
+	i.lock.RUnlock()
+	if len(matches) == 0 {
+		return nil
 	}
-	i.Subscribers[topic] = append(i.Subscribers[topic], handler)
+	ctx, cancel := context.WithTimeout(context.Background(), i.Config.PublishTimeout)
+	defer cancel()
+	var ret error
+	for _, d := range matches {
+		if err := d.Enqueue(ctx, topic, event, nil); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
+}
+func (i *InMemoryPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) (pubsub.Subscription, error) {
+	d := pubsub.NewDispatcher("providers.pubsub.memory", topic, handler, pubsub.DeliveryConfig{
+		NumberOfWorkers: i.Config.NumberOfWorkers,
+		QueueDepth:      i.Config.QueueDepth,
+		MaxRetries:      i.Config.MaxRetries,
+		RetryInterval:   i.Config.RetryInterval,
+	})
+	i.lock.Lock()
+	i.subscriptions = append(i.subscriptions, d)
+	i.lock.Unlock()
+	return &subscription{provider: i, dispatcher: d}, nil
+}
 
+func (s *subscription) Unsubscribe() error {
+	s.provider.lock.Lock()
+	for k, d := range s.provider.subscriptions {
+		if d == s.dispatcher {
+			s.provider.subscriptions = append(s.provider.subscriptions[:k], s.provider.subscriptions[k+1:]...)
+			break
+		}
+	}
+	s.provider.lock.Unlock()
+	s.dispatcher.Close()
 	return nil
 }
 
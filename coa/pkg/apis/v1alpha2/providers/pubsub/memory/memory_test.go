@@ -7,7 +7,9 @@
 package memory
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/assert"
@@ -108,3 +110,88 @@ func TestClone(t *testing.T) {
 	assert.NotNil(t, pc)
 	assert.Nil(t, err)
 }
+
+func TestPublishToFullQueue(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{
+		Name:            "test",
+		NumberOfWorkers: 1,
+		QueueDepth:      1,
+		PublishTimeout:  50 * time.Millisecond,
+	})
+	started := make(chan int, 1)
+	release := make(chan int)
+	sub, err := provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		started <- 1
+		<-release
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	assert.Nil(t, provider.Publish("test", v1alpha2.Event{Body: "1"}))
+	<-started
+	assert.Nil(t, provider.Publish("test", v1alpha2.Event{Body: "2"}))
+	// the worker is busy and the queue is full
+	assert.NotNil(t, provider.Publish("test", v1alpha2.Event{Body: "3"}))
+	close(release)
+}
+
+func TestRetryFailedDelivery(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{
+		Name:       "test",
+		MaxRetries: 2,
+	})
+	attempts := make(chan int, 3)
+	sub, err := provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		attempts <- 1
+		return fmt.Errorf("failed")
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	assert.Nil(t, provider.Publish("test", v1alpha2.Event{Body: "TEST"}))
+	for k := 0; k < 3; k++ {
+		<-attempts
+	}
+	select {
+	case <-attempts:
+		assert.Fail(t, "delivery retried more than MaxRetries times")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMemoryPubsubProviderConfigFromMapDelivery(t *testing.T) {
+	config, err := InMemoryPubSubConfigFromMap(map[string]string{
+		"numberOfWorkers": "4",
+		"queueDepth":      "10",
+		"maxRetries":      "3",
+		"retryInterval":   "2s",
+		"publishTimeout":  "1s",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, config.NumberOfWorkers)
+	assert.Equal(t, 10, config.QueueDepth)
+	assert.Equal(t, 3, config.MaxRetries)
+	assert.Equal(t, 2*time.Second, config.RetryInterval)
+	assert.Equal(t, time.Second, config.PublishTimeout)
+
+	_, err = InMemoryPubSubConfigFromMap(map[string]string{"queueDepth": "abc"})
+	assert.NotNil(t, err)
+	_, err = InMemoryPubSubConfigFromMap(map[string]string{"retryInterval": "abc"})
+	assert.NotNil(t, err)
+}
+
+func TestInitWithDurationStrings(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	err := provider.Init(map[string]interface{}{
+		"name":           "test",
+		"retryInterval":  "2s",
+		"publishTimeout": "1s",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Second, provider.Config.RetryInterval)
+	assert.Equal(t, time.Second, provider.Config.PublishTimeout)
+
+	err = provider.Init(map[string]interface{}{"name": "test", "retryInterval": "soon"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,567 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/nats-io/nats.go"
+)
+
+var mLog = logger.NewLogger("coa.runtime")
+
+const (
+	defaultURL               = nats.DefaultURL
+	defaultConsumerID        = "symphony"
+	defaultStream            = "SYMPHONY_PUBSUB"
+	defaultSubjectPrefix     = "symphony.pubsub"
+	defaultProcessingTimeout = 60 * time.Second
+	defaultRedeliverInterval = 15 * time.Second
+	defaultMaxDeliveryCount  = 10
+	// defaultBatchSize is the number of messages fetched at a time when QueueDepth is not set
+	defaultBatchSize = 100
+	// fetchWaitTime bounds how long a fetch waits for new messages, so closed consumers are noticed
+	fetchWaitTime = 5 * time.Second
+)
+
+type NatsPubSubProvider struct {
+	Config    NatsPubSubProviderConfig `json:"config"`
+	Conn      *nats.Conn
+	JetStream nats.JetStreamContext
+	Ctx       context.Context
+	Cancel    context.CancelFunc
+	Context   *contexts.ManagerContext
+	lock      sync.Mutex
+	// consumers are the durable consumers being read, per subscription topic
+	consumers map[string]*consumer
+}
+
+type NatsPubSubProviderConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// ConsumerID names the durable consumers of the provider. Providers with the same ConsumerID share the
+	// messages of a topic, and providers with different ones each get all of them.
+	ConsumerID string `json:"consumerID"`
+	// Stream is the JetStream stream that keeps the events
+	Stream string `json:"stream,omitempty"`
+	// SubjectPrefix is prepended to topics to make the subjects of the stream
+	SubjectPrefix   string        `json:"subjectPrefix,omitempty"`
+	NumberOfWorkers int           `json:"numberOfWorkers,omitempty"`
+	QueueDepth      int           `json:"queueDepth,omitempty"`
+	MaxRetries      int           `json:"maxRetries,omitempty"`
+	RetryInterval   time.Duration `json:"retryInterval,omitempty"`
+	// ProcessingTimeout is how long a message can go unacknowledged before it's delivered again
+	ProcessingTimeout time.Duration `json:"processingTimeout,omitempty"`
+	// RedeliverInterval is how long a message that failed to be handled waits before it's delivered again
+	RedeliverInterval time.Duration `json:"redeliverInterval,omitempty"`
+	// MaxDeliveryCount is the number of times a message is delivered before JetStream gives up on it. A
+	// negative value keeps delivering failing messages forever.
+	MaxDeliveryCount int `json:"maxDeliveryCount,omitempty"`
+	// MaxAge is how long the stream keeps events. Zero keeps them until the stream limits are reached.
+	MaxAge time.Duration `json:"maxAge,omitempty"`
+	// MemoryStorage keeps the stream in memory instead of files
+	MemoryStorage bool `json:"memoryStorage,omitempty"`
+}
+
+// consumer reads a durable consumer and delivers its messages to all the subscriptions to its topic
+type consumer struct {
+	topic       string
+	durable     string
+	sub         *nats.Subscription
+	cancel      context.CancelFunc
+	dispatchers []*pubsub.Dispatcher
+	// inFlight are the messages being handled
+	inFlight   map[*nats.Msg]struct{}
+	flightLock sync.Mutex
+}
+
+// UnmarshalJSON reads the durations of the config as duration strings such as "5s", or as numbers of nanoseconds
+func (c *NatsPubSubProviderConfig) UnmarshalJSON(data []byte) error {
+	type config NatsPubSubProviderConfig
+	aux := struct {
+		*config
+		RetryInterval     json.RawMessage `json:"retryInterval,omitempty"`
+		ProcessingTimeout json.RawMessage `json:"processingTimeout,omitempty"`
+		RedeliverInterval json.RawMessage `json:"redeliverInterval,omitempty"`
+		MaxAge            json.RawMessage `json:"maxAge,omitempty"`
+	}{config: (*config)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return utils.UnmarshalDurations(data, map[string]*time.Duration{
+		"retryInterval":     &c.RetryInterval,
+		"processingTimeout": &c.ProcessingTimeout,
+		"redeliverInterval": &c.RedeliverInterval,
+		"maxAge":            &c.MaxAge,
+	})
+}
+
+type subscription struct {
+	provider   *NatsPubSubProvider
+	dispatcher *pubsub.Dispatcher
+}
+
+func NatsPubSubProviderConfigFromMap(properties map[string]string) (NatsPubSubProviderConfig, error) {
+	ret := NatsPubSubProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["url"]; ok {
+		ret.URL = v
+	}
+	if v, ok := properties["consumerID"]; ok {
+		ret.ConsumerID = v
+	}
+	if v, ok := properties["stream"]; ok {
+		ret.Stream = v
+	}
+	if v, ok := properties["subjectPrefix"]; ok {
+		ret.SubjectPrefix = v
+	}
+	if v, ok := properties["memoryStorage"]; ok && v != "" {
+		bVal, err := strconv.ParseBool(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid bool value in the 'memoryStorage' setting of NATS pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.MemoryStorage = bVal
+	}
+	for _, setting := range []struct {
+		key   string
+		value *int
+	}{
+		{"numberOfWorkers", &ret.NumberOfWorkers},
+		{"queueDepth", &ret.QueueDepth},
+		{"maxRetries", &ret.MaxRetries},
+		{"maxDeliveryCount", &ret.MaxDeliveryCount},
+	} {
+		if v, ok := properties[setting.key]; ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(err, "invalid int value in the '"+setting.key+"' setting of NATS pub-sub provider", v1alpha2.BadConfig)
+			}
+			*setting.value = n
+		}
+	}
+	for _, setting := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"retryInterval", &ret.RetryInterval},
+		{"processingTimeout", &ret.ProcessingTimeout},
+		{"redeliverInterval", &ret.RedeliverInterval},
+		{"maxAge", &ret.MaxAge},
+	} {
+		if v, ok := properties[setting.key]; ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(err, "invalid duration value in the '"+setting.key+"' setting of NATS pub-sub provider", v1alpha2.BadConfig)
+			}
+			*setting.value = d
+		}
+	}
+	if ret.NumberOfWorkers <= 0 {
+		ret.NumberOfWorkers = 1
+	}
+	return ret, nil
+}
+
+func (v *NatsPubSubProvider) ID() string {
+	return v.Config.Name
+}
+
+func (s *NatsPubSubProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *NatsPubSubProvider) InitWithMap(properties map[string]string) error {
+	config, err := NatsPubSubProviderConfigFromMap(properties)
+	if err != nil {
+		mLog.Debugf("  P (NATS PubSub) : failed to initialize provider %v", err)
+		return err
+	}
+	return i.Init(config)
+}
+
+func (i *NatsPubSubProvider) Init(config providers.IProviderConfig) error {
+	vConfig, err := toNatsPubSubProviderConfig(config)
+	if err != nil {
+		return v1alpha2.NewCOAError(nil, "provided config is not a valid NATS pub-sub provider config", v1alpha2.BadConfig)
+	}
+	i.Config = vConfig
+	if i.Config.URL == "" {
+		i.Config.URL = defaultURL
+	}
+	if i.Config.ConsumerID == "" {
+		i.Config.ConsumerID = defaultConsumerID
+	}
+	if i.Config.Stream == "" {
+		i.Config.Stream = defaultStream
+	}
+	if i.Config.SubjectPrefix == "" {
+		i.Config.SubjectPrefix = defaultSubjectPrefix
+	}
+	if i.Config.ProcessingTimeout <= 0 {
+		i.Config.ProcessingTimeout = defaultProcessingTimeout
+	}
+	if i.Config.RedeliverInterval <= 0 {
+		i.Config.RedeliverInterval = defaultRedeliverInterval
+	}
+	if i.Config.MaxDeliveryCount == 0 {
+		i.Config.MaxDeliveryCount = defaultMaxDeliveryCount
+	} else if i.Config.MaxDeliveryCount < 0 {
+		// JetStream takes -1 as no limit
+		i.Config.MaxDeliveryCount = -1
+	}
+
+	conn, err := nats.Connect(i.Config.URL, nats.Name("symphony-"+i.Config.Name), nats.MaxReconnects(-1))
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: error connecting to %s", i.Config.URL), v1alpha2.InternalError)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return v1alpha2.NewCOAError(err, "NATS: failed to get JetStream context", v1alpha2.InternalError)
+	}
+	storage := nats.FileStorage
+	if i.Config.MemoryStorage {
+		storage = nats.MemoryStorage
+	}
+	if err := ensureStream(js, &nats.StreamConfig{
+		Name:     i.Config.Stream,
+		Subjects: []string{i.Config.SubjectPrefix + ".>"},
+		Storage:  storage,
+		MaxAge:   i.Config.MaxAge,
+	}); err != nil {
+		conn.Close()
+		return err
+	}
+	i.Conn = conn
+	i.JetStream = js
+	i.Ctx, i.Cancel = context.WithCancel(context.Background())
+	i.consumers = make(map[string]*consumer)
+	return nil
+}
+
+// CheckHealth tells if the provider is connected to the NATS server and can read its stream
+func (i *NatsPubSubProvider) CheckHealth(ctx context.Context) error {
+	if !i.Conn.IsConnected() {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("not connected to NATS server at %s", i.Config.URL), v1alpha2.InternalError)
+	}
+	if _, err := i.JetStream.StreamInfo(i.Config.Stream, nats.Context(ctx)); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read NATS stream '%s'", i.Config.Stream), v1alpha2.InternalError)
+	}
+	return nil
+}
+
+// ensureStream creates a stream unless it already exists. An existing stream is left as it is.
+func ensureStream(js nats.JetStreamContext, config *nats.StreamConfig) error {
+	_, err := js.StreamInfo(config.Name)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: failed to get stream %s", config.Name), v1alpha2.InternalError)
+	}
+	if _, err := js.AddStream(config); err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: failed to create stream %s", config.Name), v1alpha2.InternalError)
+	}
+	return nil
+}
+
+func (i *NatsPubSubProvider) Publish(topic string, event v1alpha2.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "failed to marshal event", v1alpha2.InternalError)
+	}
+	if _, err := i.JetStream.Publish(i.subject(topic), data); err != nil {
+		mLog.Debugf("  P (NATS PubSub) : failed to publish message %v", err)
+		return v1alpha2.NewCOAError(err, "failed to publish message", v1alpha2.InternalError)
+	}
+	return nil
+}
+
+// Subscribe reads the topic through a durable consumer named after ConsumerID, so providers with the same
+// ConsumerID share the messages of a topic and providers with different ones each get all of them. NATS
+// subject wildcards match a single token, so wildcard topics are filtered by JetStream itself.
+func (i *NatsPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) (pubsub.Subscription, error) {
+	s := &subscription{
+		provider: i,
+		dispatcher: pubsub.NewDispatcher("providers.pubsub.nats", topic, handler, pubsub.DeliveryConfig{
+			NumberOfWorkers: i.Config.NumberOfWorkers,
+			QueueDepth:      i.Config.QueueDepth,
+			MaxRetries:      i.Config.MaxRetries,
+			RetryInterval:   i.Config.RetryInterval,
+		}),
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	c, ok := i.consumers[topic]
+	if !ok {
+		var err error
+		c, err = i.startConsumer(topic)
+		if err != nil {
+			s.dispatcher.Close()
+			return nil, err
+		}
+		i.consumers[topic] = c
+	}
+	c.dispatchers = append(c.dispatchers, s.dispatcher)
+	return s, nil
+}
+
+func (s *subscription) Unsubscribe() error {
+	i := s.provider
+	s.dispatcher.Close()
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	c, ok := i.consumers[s.dispatcher.Topic]
+	if !ok {
+		return nil
+	}
+	for k, d := range c.dispatchers {
+		if d == s.dispatcher {
+			c.dispatchers = append(c.dispatchers[:k], c.dispatchers[k+1:]...)
+			break
+		}
+	}
+	// stop reading the consumer once no subscription is interested in it. The durable consumer stays, so
+	// messages published in the meantime are delivered when the topic is subscribed to again.
+	if len(c.dispatchers) == 0 {
+		c.cancel()
+		delete(i.consumers, s.dispatcher.Topic)
+		if err := c.sub.Unsubscribe(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+			mLog.Debugf("  P (NATS PubSub) : failed to unsubscribe from %s: %v", c.topic, err)
+		}
+	}
+	return nil
+}
+
+// startConsumer creates the durable consumer of a topic and starts reading it. It must be called with the
+// lock held.
+func (i *NatsPubSubProvider) startConsumer(topic string) (*consumer, error) {
+	durable := durableName(i.Config.ConsumerID, topic)
+	_, err := i.JetStream.ConsumerInfo(i.Config.Stream, durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = i.JetStream.AddConsumer(i.Config.Stream, &nats.ConsumerConfig{
+			Durable:       durable,
+			Description:   fmt.Sprintf("%s on %s", i.Config.ConsumerID, topic),
+			DeliverPolicy: nats.DeliverAllPolicy,
+			AckPolicy:     nats.AckExplicitPolicy,
+			AckWait:       i.Config.ProcessingTimeout,
+			MaxDeliver:    i.Config.MaxDeliveryCount,
+			FilterSubject: i.subject(topic),
+		})
+	}
+	if err != nil {
+		mLog.Debugf("  P (NATS PubSub) : failed to subscribe %v", err)
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to subscribe to topic %s", topic), v1alpha2.InternalError)
+	}
+	// binding to the consumer, instead of letting the client create it, keeps it when unsubscribing
+	sub, err := i.JetStream.PullSubscribe(i.subject(topic), durable, nats.Bind(i.Config.Stream, durable))
+	if err != nil {
+		mLog.Debugf("  P (NATS PubSub) : failed to subscribe %v", err)
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to subscribe to topic %s", topic), v1alpha2.InternalError)
+	}
+	ctx, cancel := context.WithCancel(i.Ctx)
+	c := &consumer{
+		topic:    topic,
+		durable:  durable,
+		sub:      sub,
+		cancel:   cancel,
+		inFlight: make(map[*nats.Msg]struct{}),
+	}
+	go i.fetchLoop(ctx, c)
+	go i.keepInFlightLoop(ctx, c)
+	return c, nil
+}
+
+func (i *NatsPubSubProvider) fetchLoop(ctx context.Context, c *consumer) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchWaitTime)
+		msgs, err := c.sub.Fetch(i.batchSize(), nats.Context(fetchCtx))
+		cancel()
+		if ctx.Err() != nil {
+			for _, msg := range msgs {
+				msg.Nak()
+			}
+			return
+		}
+		if err != nil && len(msgs) == 0 {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+				continue
+			}
+			mLog.Debugf("  P (NATS PubSub) : failed to fetch messages of %s: %v", c.topic, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		i.dispatchMessages(ctx, c, msgs)
+	}
+}
+
+// dispatchMessages queues messages for all the subscriptions of their consumer, waiting for room in full
+// queues. A message is acknowledged once every subscription has handled it. A message that failed is
+// delivered again after RedeliverInterval, until JetStream has delivered it MaxDeliveryCount times.
+func (i *NatsPubSubProvider) dispatchMessages(ctx context.Context, c *consumer, msgs []*nats.Msg) {
+	for _, msg := range msgs {
+		msg := msg
+		if ctx.Err() != nil {
+			msg.Nak()
+			continue
+		}
+		var evt v1alpha2.Event
+		if err := json.Unmarshal(msg.Data, &evt); err != nil {
+			// a message that can't be parsed will never be handled, so it's not delivered again
+			mLog.Errorf("  P (NATS PubSub) : failed to parse message on %s, dropping it: %v", msg.Subject, err)
+			msg.Term()
+			continue
+		}
+		i.lock.Lock()
+		dispatchers := make([]*pubsub.Dispatcher, len(c.dispatchers))
+		copy(dispatchers, c.dispatchers)
+		i.lock.Unlock()
+		if len(dispatchers) == 0 {
+			msg.Nak()
+			continue
+		}
+		c.track(msg)
+		ack := pubsub.NewPendingAck(len(dispatchers), func(err error) {
+			i.complete(c, msg, err)
+		})
+		topic := strings.TrimPrefix(msg.Subject, i.Config.SubjectPrefix+".")
+		for _, d := range dispatchers {
+			if err := d.Enqueue(ctx, topic, evt, ack.Done); err != nil {
+				// the message was not delivered, which doesn't count as a failed delivery
+				ack.Done(pubsub.ErrSubscriptionClosed)
+			}
+		}
+	}
+}
+
+// complete acknowledges a message that was handled, and asks JetStream to deliver it again otherwise
+func (i *NatsPubSubProvider) complete(c *consumer, msg *nats.Msg, err error) {
+	defer c.untrack(msg)
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			mLog.Debugf("  P (NATS PubSub) : failed to acknowledge message on %s: %v", msg.Subject, err)
+		}
+		return
+	}
+	if errors.Is(err, pubsub.ErrSubscriptionClosed) {
+		msg.Nak()
+		return
+	}
+	if meta, mErr := msg.Metadata(); mErr == nil && i.Config.MaxDeliveryCount > 0 && meta.NumDelivered >= uint64(i.Config.MaxDeliveryCount) {
+		mLog.Errorf("  P (NATS PubSub) : giving up on message %d of %s after %d deliveries: %v", meta.Sequence.Stream, msg.Subject, meta.NumDelivered, err)
+		msg.Term()
+		return
+	}
+	if err := msg.NakWithDelay(i.Config.RedeliverInterval); err != nil {
+		mLog.Debugf("  P (NATS PubSub) : failed to reject message on %s: %v", msg.Subject, err)
+	}
+}
+
+// keepInFlightLoop tells JetStream that the messages being handled are still in progress, so that slow
+// handlers don't get their messages delivered to other providers with the same ConsumerID
+func (i *NatsPubSubProvider) keepInFlightLoop(ctx context.Context, c *consumer) {
+	ticker := time.NewTicker(i.Config.ProcessingTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, msg := range c.inFlightMessages() {
+				if err := msg.InProgress(); err != nil {
+					mLog.Debugf("  P (NATS PubSub) : failed to extend message in flight %v", err)
+				}
+			}
+		}
+	}
+}
+
+func (c *consumer) track(msg *nats.Msg) {
+	c.flightLock.Lock()
+	defer c.flightLock.Unlock()
+	c.inFlight[msg] = struct{}{}
+}
+
+func (c *consumer) untrack(msg *nats.Msg) {
+	c.flightLock.Lock()
+	defer c.flightLock.Unlock()
+	delete(c.inFlight, msg)
+}
+
+func (c *consumer) inFlightMessages() []*nats.Msg {
+	c.flightLock.Lock()
+	defer c.flightLock.Unlock()
+	ret := make([]*nats.Msg, 0, len(c.inFlight))
+	for msg := range c.inFlight {
+		ret = append(ret, msg)
+	}
+	return ret
+}
+
+func (i *NatsPubSubProvider) subject(topic string) string {
+	return i.Config.SubjectPrefix + "." + topic
+}
+
+func (i *NatsPubSubProvider) batchSize() int {
+	if i.Config.QueueDepth > 0 {
+		return i.Config.QueueDepth
+	}
+	return defaultBatchSize
+}
+
+// durableName returns the name of the durable consumer of a consumer ID and a topic. Durable names can't
+// have dots or wildcards, so the topic is hashed.
+func durableName(consumerID string, topic string) string {
+	h := fnv.New64a()
+	h.Write([]byte(consumerID))
+	h.Write([]byte{0})
+	h.Write([]byte(topic))
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, consumerID)
+	return fmt.Sprintf("%s_%x", name, h.Sum64())
+}
+
+func toNatsPubSubProviderConfig(config providers.IProviderConfig) (NatsPubSubProviderConfig, error) {
+	ret := NatsPubSubProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	if ret.NumberOfWorkers <= 0 {
+		ret.NumberOfWorkers = 1
+	}
+	return ret, err
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func runServer(t *testing.T) string {
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	assert.Nil(t, err)
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("NATS server is not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s.ClientURL()
+}
+
+func newProvider(t *testing.T, config NatsPubSubProviderConfig) *NatsPubSubProvider {
+	provider := &NatsPubSubProvider{}
+	err := provider.Init(config)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		provider.Cancel()
+		provider.Conn.Close()
+	})
+	return provider
+}
+
+func TestConfigFromMap(t *testing.T) {
+	config, err := NatsPubSubProviderConfigFromMap(map[string]string{
+		"name":              "test",
+		"url":               "nats://localhost:4222",
+		"consumerID":        "c1",
+		"stream":            "EVENTS",
+		"subjectPrefix":     "events",
+		"queueDepth":        "10",
+		"maxRetries":        "2",
+		"retryInterval":     "1s",
+		"processingTimeout": "30s",
+		"redeliverInterval": "5s",
+		"maxDeliveryCount":  "3",
+		"maxAge":            "24h",
+		"memoryStorage":     "true",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "c1", config.ConsumerID)
+	assert.Equal(t, "EVENTS", config.Stream)
+	assert.Equal(t, "events", config.SubjectPrefix)
+	assert.Equal(t, 1, config.NumberOfWorkers)
+	assert.Equal(t, 10, config.QueueDepth)
+	assert.Equal(t, 2, config.MaxRetries)
+	assert.Equal(t, time.Second, config.RetryInterval)
+	assert.Equal(t, 30*time.Second, config.ProcessingTimeout)
+	assert.Equal(t, 5*time.Second, config.RedeliverInterval)
+	assert.Equal(t, 3, config.MaxDeliveryCount)
+	assert.Equal(t, 24*time.Hour, config.MaxAge)
+	assert.True(t, config.MemoryStorage)
+}
+
+func TestConfigFromMapWithBadValues(t *testing.T) {
+	for _, properties := range []map[string]string{
+		{"queueDepth": "deep"},
+		{"processingTimeout": "10"},
+		{"memoryStorage": "maybe"},
+	} {
+		_, err := NatsPubSubProviderConfigFromMap(properties)
+		coaErr, ok := err.(v1alpha2.COAError)
+		assert.True(t, ok)
+		assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+	}
+}
+
+func TestUnmarshalConfigDurations(t *testing.T) {
+	var config NatsPubSubProviderConfig
+	err := json.Unmarshal([]byte(`{"name":"test","retryInterval":"2s","processingTimeout":"1m","redeliverInterval":1000000,"maxAge":"24h"}`), &config)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", config.Name)
+	assert.Equal(t, 2*time.Second, config.RetryInterval)
+	assert.Equal(t, time.Minute, config.ProcessingTimeout)
+	assert.Equal(t, time.Millisecond, config.RedeliverInterval)
+	assert.Equal(t, 24*time.Hour, config.MaxAge)
+
+	err = json.Unmarshal([]byte(`{"maxAge":"forever"}`), &config)
+	assert.NotNil(t, err)
+}
+
+func TestInitWithUnreachableServer(t *testing.T) {
+	provider := NatsPubSubProvider{}
+	err := provider.Init(NatsPubSubProviderConfig{
+		Name: "test",
+		URL:  "nats://127.0.0.1:1",
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.InternalError, coaErr.State)
+}
+
+func TestInitDefaults(t *testing.T) {
+	provider := newProvider(t, NatsPubSubProviderConfig{
+		Name: "test",
+		URL:  runServer(t),
+	})
+	assert.Equal(t, "symphony", provider.Config.ConsumerID)
+	assert.Equal(t, "SYMPHONY_PUBSUB", provider.Config.Stream)
+	assert.Equal(t, "symphony.pubsub", provider.Config.SubjectPrefix)
+	assert.Equal(t, 10, provider.Config.MaxDeliveryCount)
+	info, err := provider.JetStream.StreamInfo("SYMPHONY_PUBSUB")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"symphony.pubsub.>"}, info.Config.Subjects)
+}
+
+func TestDurableName(t *testing.T) {
+	name := durableName("my.consumer", "job.*")
+	assert.Regexp(t, "^my_consumer_[0-9a-f]+$", name)
+	assert.Equal(t, name, durableName("my.consumer", "job.*"))
+	assert.NotEqual(t, name, durableName("my.consumer", "job.>"))
+	assert.NotEqual(t, name, durableName("my_consumer", "job.*"))
+}
+
+func TestConsumerGroups(t *testing.T) {
+	url := runServer(t)
+	config := NatsPubSubProviderConfig{Name: "test", URL: url, ConsumerID: "group1"}
+	provider1 := newProvider(t, config)
+	provider2 := newProvider(t, config)
+	config.ConsumerID = "group2"
+	other := newProvider(t, config)
+
+	var shared, all int32
+	count := func(counter *int32) v1alpha2.EventHandler {
+		return func(topic string, event v1alpha2.Event) error {
+			atomic.AddInt32(counter, 1)
+			return nil
+		}
+	}
+	for _, p := range []*NatsPubSubProvider{provider1, provider2} {
+		_, err := p.Subscribe("job", count(&shared))
+		assert.Nil(t, err)
+	}
+	_, err := other.Subscribe("job", count(&all))
+	assert.Nil(t, err)
+
+	for k := 0; k < 10; k++ {
+		assert.Nil(t, provider1.Publish("job", v1alpha2.Event{Body: fmt.Sprintf("job %d", k)}))
+	}
+	// providers with the same consumer ID share the events, and the one with another ID gets all of them
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&shared) == 10 && atomic.LoadInt32(&all) == 10
+	}, 10*time.Second, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&shared))
+}
+
+func TestRedeliverFailedMessages(t *testing.T) {
+	provider := newProvider(t, NatsPubSubProviderConfig{
+		Name:              "test",
+		URL:               runServer(t),
+		RedeliverInterval: 10 * time.Millisecond,
+		MaxDeliveryCount:  3,
+	})
+	var attempts int32
+	_, err := provider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		atomic.AddInt32(&attempts, 1)
+		return fmt.Errorf("always fails")
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, provider.Publish("job", v1alpha2.Event{Body: "TEST"}))
+	// JetStream gives up on the message after MaxDeliveryCount deliveries
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&attempts) == 3
+	}, 10*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestResubscribeGetsMissedEvents(t *testing.T) {
+	provider := newProvider(t, NatsPubSubProviderConfig{
+		Name: "test",
+		URL:  runServer(t),
+	})
+	sub, err := provider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sub.Unsubscribe())
+	assert.Nil(t, provider.Publish("job", v1alpha2.Event{Body: "TEST"}))
+
+	// the durable consumer kept its position while nobody was subscribed
+	ch := make(chan string, 1)
+	sub, err = provider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		ch <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "TEST", msg)
+	case <-time.After(10 * time.Second):
+		t.Fatal("missed event was not delivered")
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	provider := newProvider(t, NatsPubSubProviderConfig{
+		Name: "test",
+		URL:  runServer(t),
+	})
+	assert.Nil(t, provider.CheckHealth(context.Background()))
+	provider.Conn.Close()
+	assert.NotNil(t, provider.CheckHealth(context.Background()))
+}
@@ -14,5 +14,14 @@ import (
 type IPubSubProvider interface {
 	Init(config providers.IProviderConfig) error
 	Publish(topic string, message v1alpha2.Event) error
-	Subscribe(topic string, handler v1alpha2.EventHandler) error
+	// Subscribe registers a handler for a topic. The topic can be a pattern in which a * segment matches
+	// any single segment, such as job.*. The returned subscription stops the deliveries.
+	Subscribe(topic string, handler v1alpha2.EventHandler) (Subscription, error)
+}
+
+// Subscription is a handler registered with a pub-sub provider
+type Subscription interface {
+	// Unsubscribe stops delivering events to the handler. Events that are queued but not yet delivered
+	// are discarded. Calling Unsubscribe more than once is a no-op.
+	Unsubscribe() error
 }
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/metrics"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
+	"github.com/go-redis/redis/v7"
+)
+
+// DeadLetterPrefix prefixes the names of dead-letter streams. A prefix, rather than a suffix, keeps dead-letter
+// streams from matching wildcard subscriptions such as job.*
+const DeadLetterPrefix = "deadletter:"
+
+const maxDeliveryCountReached = "maximum delivery count reached"
+
+var deadLetters = metrics.NewCounter("pubsub_dead_letters_total",
+	"Number of messages moved to a dead-letter stream", "provider", "topic")
+
+// DeadLetterStream returns the name of the stream that keeps the dead letters of a topic
+func DeadLetterStream(topic string) string {
+	return DeadLetterPrefix + topic
+}
+
+// deadLetter moves a message to the dead-letter stream of its topic and acknowledges it. The message stays
+// pending if it can't be moved.
+func (i *RedisPubSubProvider) deadLetter(stream string, msg redis.XMessage, deliveryCount int64, reason string) {
+	data, _ := msg.Values["data"].(string)
+	err := i.Client.XAdd(&redis.XAddArgs{
+		Stream:       DeadLetterStream(stream),
+		MaxLenApprox: i.Config.DeadLetterMaxLen,
+		Values: map[string]interface{}{
+			"data":          data,
+			"topic":         stream,
+			"messageID":     msg.ID,
+			"deliveryCount": deliveryCount,
+			"error":         reason,
+			"consumerID":    i.Config.ConsumerID,
+			"time":          time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		mLog.Errorf("  P (Redis PubSub) : failed to move message %s of %s to dead letters: %v", msg.ID, stream, err)
+		return
+	}
+	deadLetters.Add(1, "providers.pubsub.redis", stream)
+	mLog.Infof("  P (Redis PubSub) : moved message %s of %s to dead letters after %d deliveries: %s", msg.ID, stream, deliveryCount, reason)
+	if err := i.Client.XAck(stream, i.Config.ConsumerID, msg.ID).Err(); err != nil {
+		mLog.Debugf("  P (Redis PubSub) : failed to acknowledge message %s: %v", msg.ID, err)
+	}
+}
+
+// deadLetterPending moves a pending message that has been delivered too many times to dead letters without
+// delivering it again
+func (i *RedisPubSubProvider) deadLetterPending(stream string, id string, deliveryCount int64) {
+	msgs, err := i.Client.XRangeN(stream, id, id, 1).Result()
+	if err != nil {
+		mLog.Debugf("  P (Redis PubSub) : failed to read pending message %s: %v", id, err)
+		return
+	}
+	if len(msgs) == 0 {
+		// the message was deleted from the stream, so there's nothing to keep
+		if err := i.Client.XAck(stream, i.Config.ConsumerID, id).Err(); err != nil {
+			mLog.Debugf("  P (Redis PubSub) : failed to acknowledge message %s: %v", id, err)
+		}
+		return
+	}
+	i.deadLetter(stream, msgs[0], deliveryCount, maxDeliveryCountReached)
+}
+
+func (i *RedisPubSubProvider) ListDeadLetters(topic string, count int) ([]pubsub.DeadLetter, error) {
+	if count <= 0 {
+		count = defaultBatchSize
+	}
+	streams := []string{DeadLetterStream(topic)}
+	if topic == "" {
+		streams = make([]string, 0)
+		iter := i.Client.Scan(0, DeadLetterPrefix+"*", 100).Iterator()
+		for iter.Next() {
+			streams = append(streams, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to look for dead-letter streams", v1alpha2.InternalError)
+		}
+	}
+	ret := make([]pubsub.DeadLetter, 0)
+	for _, stream := range streams {
+		msgs, err := i.Client.XRangeN(stream, "-", "+", int64(count-len(ret))).Result()
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read dead letters of %s", strings.TrimPrefix(stream, DeadLetterPrefix)), v1alpha2.InternalError)
+		}
+		for _, msg := range msgs {
+			ret = append(ret, toDeadLetter(stream, msg))
+		}
+		if len(ret) >= count {
+			break
+		}
+	}
+	return ret, nil
+}
+
+func (i *RedisPubSubProvider) ReplayDeadLetter(topic string, id string) error {
+	stream := DeadLetterStream(topic)
+	msgs, err := i.Client.XRangeN(stream, id, id, 1).Result()
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read dead letter %s of %s", id, topic), v1alpha2.InternalError)
+	}
+	if len(msgs) == 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("dead letter %s of %s is not found", id, topic), v1alpha2.NotFound)
+	}
+	data, _ := msgs[0].Values["data"].(string)
+	err = i.Client.XAdd(&redis.XAddArgs{
+		Stream: topic,
+		Values: map[string]interface{}{"data": data},
+	}).Err()
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to replay dead letter %s of %s", id, topic), v1alpha2.InternalError)
+	}
+	if err := i.Client.XDel(stream, id).Err(); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to delete dead letter %s of %s", id, topic), v1alpha2.InternalError)
+	}
+	return nil
+}
+
+func (i *RedisPubSubProvider) DeleteDeadLetter(topic string, id string) error {
+	n, err := i.Client.XDel(DeadLetterStream(topic), id).Result()
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to delete dead letter %s of %s", id, topic), v1alpha2.InternalError)
+	}
+	if n == 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("dead letter %s of %s is not found", id, topic), v1alpha2.NotFound)
+	}
+	return nil
+}
+
+func toDeadLetter(stream string, msg redis.XMessage) pubsub.DeadLetter {
+	ret := pubsub.DeadLetter{
+		ID:    msg.ID,
+		Topic: strings.TrimPrefix(stream, DeadLetterPrefix),
+	}
+	if v, ok := msg.Values["messageID"].(string); ok {
+		ret.MessageID = v
+	}
+	if v, ok := msg.Values["deliveryCount"].(string); ok {
+		ret.DeliveryCount, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v, ok := msg.Values["error"].(string); ok {
+		ret.Error = v
+	}
+	if v, ok := msg.Values["time"].(string); ok {
+		ret.Time, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	if evt, err := parseMessage(msg); err == nil {
+		ret.Event = evt
+	}
+	return ret
+}
@@ -13,11 +13,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	"github.com/go-redis/redis/v7"
@@ -25,21 +28,31 @@ import (
 
 var mLog = logger.NewLogger("coa.runtime")
 
-type RedisPubSubProvider struct {
-	Config      RedisPubSubProviderConfig          `json:"config"`
-	Subscribers map[string][]v1alpha2.EventHandler `json:"subscribers"`
-	Client      *redis.Client
-	Queue       chan RedisMessageWrapper
-	Ctx         context.Context
-	Cancel      context.CancelFunc
-	Context     *contexts.ManagerContext
-}
+const (
+	defaultDiscoveryInterval = 5 * time.Second
+	defaultProcessingTimeout = 60 * time.Second
+	defaultRedeliverInterval = 15 * time.Second
+	defaultMaxDeliveryCount  = 10
+	defaultDeadLetterMaxLen  = 10000
+	// defaultBatchSize is the number of pending messages looked at a time when QueueDepth is not set
+	defaultBatchSize = 100
+	// pollBlockTime bounds how long a poll waits for new messages, so closed streams are noticed
+	pollBlockTime = 5 * time.Second
+)
 
-type RedisMessageWrapper struct {
-	MessageID string
-	Topic     string
-	Message   interface{}
-	Handler   v1alpha2.EventHandler
+type RedisPubSubProvider struct {
+	Config        RedisPubSubProviderConfig `json:"config"`
+	Client        *redis.Client
+	Ctx           context.Context
+	Cancel        context.CancelFunc
+	Context       *contexts.ManagerContext
+	lock          sync.RWMutex
+	subscriptions []*subscription
+	// streams are the streams being read, with the functions that stop reading them
+	streams map[string]context.CancelFunc
+	// inFlight are the IDs of the messages being handled, per stream
+	inFlight   map[string]map[string]struct{}
+	flightLock sync.Mutex
 }
 
 type RedisPubSubProviderConfig struct {
@@ -52,6 +65,42 @@ type RedisPubSubProviderConfig struct {
 	ConsumerID        string        `json:"consumerID"`
 	ProcessingTimeout time.Duration `json:"processingTimeout,omitempty"`
 	RedeliverInterval time.Duration `json:"redeliverInterval,omitempty"`
+	MaxRetries        int           `json:"maxRetries,omitempty"`
+	RetryInterval     time.Duration `json:"retryInterval,omitempty"`
+	// DiscoveryInterval is how often streams matching wildcard subscriptions are looked for
+	DiscoveryInterval time.Duration `json:"discoveryInterval,omitempty"`
+	// MaxDeliveryCount is the number of times a message is delivered before it's moved to the dead-letter
+	// stream of its topic. A negative value keeps failing messages pending forever.
+	MaxDeliveryCount int `json:"maxDeliveryCount,omitempty"`
+	// DeadLetterMaxLen is the approximate number of dead letters kept per topic
+	DeadLetterMaxLen int64 `json:"deadLetterMaxLen,omitempty"`
+}
+
+// UnmarshalJSON reads the durations of the config as duration strings such as "5s", or as numbers of nanoseconds
+func (c *RedisPubSubProviderConfig) UnmarshalJSON(data []byte) error {
+	type config RedisPubSubProviderConfig
+	aux := struct {
+		*config
+		ProcessingTimeout json.RawMessage `json:"processingTimeout,omitempty"`
+		RedeliverInterval json.RawMessage `json:"redeliverInterval,omitempty"`
+		RetryInterval     json.RawMessage `json:"retryInterval,omitempty"`
+		DiscoveryInterval json.RawMessage `json:"discoveryInterval,omitempty"`
+	}{config: (*config)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return utils.UnmarshalDurations(data, map[string]*time.Duration{
+		"processingTimeout": &c.ProcessingTimeout,
+		"redeliverInterval": &c.RedeliverInterval,
+		"retryInterval":     &c.RetryInterval,
+		"discoveryInterval": &c.DiscoveryInterval,
+	})
+}
+
+type subscription struct {
+	provider   *RedisPubSubProvider
+	dispatcher *pubsub.Dispatcher
+	cancel     context.CancelFunc
 }
 
 func RedisPubSubProviderConfigFromMap(properties map[string]string) (RedisPubSubProviderConfig, error) {
@@ -125,6 +174,43 @@ func RedisPubSubProviderConfigFromMap(properties map[string]string) (RedisPubSub
 		}
 	}
 
+	if v, ok := properties["maxRetries"]; ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'maxRetries' setting of Redis pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.MaxRetries = n
+	}
+	if v, ok := properties["maxDeliveryCount"]; ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'maxDeliveryCount' setting of Redis pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.MaxDeliveryCount = n
+	}
+	if v, ok := properties["deadLetterMaxLen"]; ok && v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'deadLetterMaxLen' setting of Redis pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.DeadLetterMaxLen = n
+	}
+	for _, setting := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"retryInterval", &ret.RetryInterval},
+		{"discoveryInterval", &ret.DiscoveryInterval},
+	} {
+		if v, ok := properties[setting.key]; ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(err, "invalid duration value in the '"+setting.key+"' setting of Redis pub-sub provider", v1alpha2.BadConfig)
+			}
+			*setting.value = d
+		}
+	}
+
 	if ret.NumberOfWorkers <= 0 {
 		ret.NumberOfWorkers = 1
 	}
@@ -159,7 +245,6 @@ func (i *RedisPubSubProvider) Init(config providers.IProviderConfig) error {
 		return v1alpha2.NewCOAError(nil, "Redis host is not supplied", v1alpha2.MissingConfig)
 	}
 
-	i.Subscribers = make(map[string][]v1alpha2.EventHandler)
 	options := &redis.Options{
 		Addr:            i.Config.Host,
 		Password:        i.Config.Password,
@@ -176,36 +261,32 @@ func (i *RedisPubSubProvider) Init(config providers.IProviderConfig) error {
 	if _, err := client.Ping().Result(); err != nil {
 		return v1alpha2.NewCOAError(err, fmt.Sprintf("redis stream: error connecting to redis at %s", i.Config.Host), v1alpha2.InternalError)
 	}
+	if i.Config.DiscoveryInterval <= 0 {
+		i.Config.DiscoveryInterval = defaultDiscoveryInterval
+	}
+	if i.Config.ProcessingTimeout == 0 {
+		i.Config.ProcessingTimeout = defaultProcessingTimeout
+	}
+	if i.Config.RedeliverInterval == 0 {
+		i.Config.RedeliverInterval = defaultRedeliverInterval
+	}
+	if i.Config.MaxDeliveryCount == 0 {
+		i.Config.MaxDeliveryCount = defaultMaxDeliveryCount
+	}
+	if i.Config.DeadLetterMaxLen <= 0 {
+		i.Config.DeadLetterMaxLen = defaultDeadLetterMaxLen
+	}
 	i.Client = client
 	i.Ctx, i.Cancel = context.WithCancel(context.Background())
-	i.Queue = make(chan RedisMessageWrapper, int(i.Config.QueueDepth))
-	for k := uint(0); k < uint(i.Config.NumberOfWorkers); k++ {
-		go i.worker()
-	}
+	i.streams = make(map[string]context.CancelFunc)
+	i.inFlight = make(map[string]map[string]struct{})
 	return nil
 }
 
-func (i *RedisPubSubProvider) worker() {
-	for {
-		select {
-		case <-i.Ctx.Done():
-			return
-		case msg := <-i.Queue:
-			i.processMessage(msg)
-		}
-	}
-}
-func (i *RedisPubSubProvider) processMessage(msg RedisMessageWrapper) error {
-	var evt v1alpha2.Event
-	err := json.Unmarshal([]byte(msg.Message.(string)), &evt)
-	if err != nil {
-		return v1alpha2.NewCOAError(err, "failed to unmarshal event", v1alpha2.InternalError)
-	}
-	if err := msg.Handler(msg.Topic, evt); err != nil {
-		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to handle message %s", msg.MessageID), v1alpha2.InternalError)
-	}
-	if err := i.Client.XAck(msg.Topic, i.Config.ConsumerID, msg.MessageID).Err(); err != nil {
-		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to acknowledge message %s", msg.MessageID), v1alpha2.InternalError)
+// CheckHealth pings the Redis server
+func (i *RedisPubSubProvider) CheckHealth(ctx context.Context) error {
+	if err := i.Client.WithContext(ctx).Ping().Err(); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("redis stream: failed to reach redis at %s", i.Config.Host), v1alpha2.InternalError)
 	}
 	return nil
 }
@@ -221,134 +302,379 @@ func (i *RedisPubSubProvider) Publish(topic string, event v1alpha2.Event) error
 	}
 	return nil
 }
-func (i *RedisPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) error {
-	err := i.Client.XGroupCreateMkStream(topic, i.Config.ConsumerID, "0").Err()
+
+// Subscribe reads the stream of the topic as a member of the ConsumerID consumer group, so providers with the
+// same ConsumerID share the messages of a topic and providers with different ones each get all of them. A
+// wildcard topic subscribes to every existing stream that matches it, and to matching streams created later.
+func (i *RedisPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) (pubsub.Subscription, error) {
+	ctx, cancel := context.WithCancel(i.Ctx)
+	s := &subscription{
+		provider: i,
+		dispatcher: pubsub.NewDispatcher("providers.pubsub.redis", topic, handler, pubsub.DeliveryConfig{
+			NumberOfWorkers: i.Config.NumberOfWorkers,
+			QueueDepth:      i.Config.QueueDepth,
+			MaxRetries:      i.Config.MaxRetries,
+			RetryInterval:   i.Config.RetryInterval,
+		}),
+		cancel: cancel,
+	}
+	i.lock.Lock()
+	i.subscriptions = append(i.subscriptions, s)
+	i.lock.Unlock()
+	if !pubsub.IsTopicPattern(topic) {
+		if err := i.readStream(topic); err != nil {
+			s.Unsubscribe()
+			return nil, err
+		}
+		return s, nil
+	}
+	if err := i.discoverStreams(topic); err != nil {
+		s.Unsubscribe()
+		return nil, err
+	}
+	go i.discoverStreamsLoop(ctx, topic)
+	return s, nil
+}
+
+func (s *subscription) Unsubscribe() error {
+	i := s.provider
+	s.cancel()
+	s.dispatcher.Close()
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	for k, sub := range i.subscriptions {
+		if sub == s {
+			i.subscriptions = append(i.subscriptions[:k], i.subscriptions[k+1:]...)
+			break
+		}
+	}
+	// stop reading streams no other subscription is interested in
+	for stream, stop := range i.streams {
+		if len(i.matchingDispatchers(stream)) == 0 {
+			stop()
+			delete(i.streams, stream)
+		}
+	}
+	return nil
+}
+
+// matchingDispatchers must be called with the lock held
+func (i *RedisPubSubProvider) matchingDispatchers(stream string) []*pubsub.Dispatcher {
+	ret := make([]*pubsub.Dispatcher, 0)
+	for _, s := range i.subscriptions {
+		if s.dispatcher.Matches(stream) {
+			ret = append(ret, s.dispatcher)
+		}
+	}
+	return ret
+}
+
+// readStream joins the consumer group of a stream and starts reading it, unless it's already being read
+func (i *RedisPubSubProvider) readStream(stream string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if _, ok := i.streams[stream]; ok {
+		return nil
+	}
+	err := i.Client.XGroupCreateMkStream(stream, i.Config.ConsumerID, "0").Err()
 	//Ignore BUSYGROUP errors
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		mLog.Debugf("  P (Redis PubSub) : failed to subscribe %v", err)
-		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to subsceribe to topic %s", topic), v1alpha2.InternalError)
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to subsceribe to topic %s", stream), v1alpha2.InternalError)
+	}
+	ctx, cancel := context.WithCancel(i.Ctx)
+	i.streams[stream] = cancel
+	go i.pollNewMessagesLoop(ctx, stream)
+	go i.reclaimPendingMessagesLoop(ctx, stream)
+	return nil
+}
+
+func (i *RedisPubSubProvider) discoverStreamsLoop(ctx context.Context, pattern string) {
+	ticker := time.NewTicker(i.Config.DiscoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.discoverStreams(pattern); err != nil {
+				mLog.Debugf("  P (Redis PubSub) : failed to discover streams of %s: %v", pattern, err)
+			}
+		}
+	}
+}
+
+// discoverStreams reads the streams that match a wildcard topic. Redis glob patterns let * match dots, so
+// the keys Redis returns are filtered again with the topic rules.
+func (i *RedisPubSubProvider) discoverStreams(pattern string) error {
+	iter := i.Client.Scan(0, pattern, 100).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		if !pubsub.MatchTopic(pattern, key) || strings.HasPrefix(key, DeadLetterPrefix) {
+			continue
+		}
+		i.lock.RLock()
+		_, ok := i.streams[key]
+		i.lock.RUnlock()
+		if ok {
+			continue
+		}
+		keyType, err := i.Client.Type(key).Result()
+		if err != nil || keyType != "stream" {
+			continue
+		}
+		if err := i.readStream(key); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to look for streams matching %s", pattern), v1alpha2.InternalError)
 	}
-	go i.pollNewMessagesLoop(topic, handler)
-	go i.reclaimPendingMessagesLoop(topic, handler)
 	return nil
 }
 
-func (i *RedisPubSubProvider) pollNewMessagesLoop(topic string, handler v1alpha2.EventHandler) {
+func (i *RedisPubSubProvider) pollNewMessagesLoop(ctx context.Context, stream string) {
 	for {
-		if i.Ctx.Err() != nil {
+		if ctx.Err() != nil {
 			return
 		}
 		streams, err := i.Client.XReadGroup(&redis.XReadGroupArgs{
 			Group:    i.Config.ConsumerID,
 			Consumer: i.Config.ConsumerID,
-			Streams:  []string{topic, ">"},
+			Streams:  []string{stream, ">"},
 			Count:    int64(i.Config.QueueDepth),
-			Block:    0,
+			Block:    pollBlockTime,
 		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
 		if err != nil {
 			mLog.Debugf("  P (Redis PubSub) : failed to poll message %v", err)
-			time.Sleep(30 * time.Second)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+			}
 			continue
 		}
 		for _, s := range streams {
-			i.enqueueMessages(s.Stream, handler, s.Messages)
+			i.dispatchMessages(ctx, s.Stream, s.Messages, nil)
 		}
 	}
 }
 
-func (i *RedisPubSubProvider) enqueueMessages(topic string, handler v1alpha2.EventHandler, msgs []redis.XMessage) {
+// dispatchMessages queues messages for all the subscriptions of their stream, waiting for room in full
+// queues. A message is acknowledged once every subscription has handled it. Otherwise it stays pending
+// until it's reclaimed, or it's moved to the dead-letter stream once it has been delivered MaxDeliveryCount
+// times. deliveryCounts has the number of times reclaimed messages were delivered, including this time.
+func (i *RedisPubSubProvider) dispatchMessages(ctx context.Context, stream string, msgs []redis.XMessage, deliveryCounts map[string]int64) {
 	for _, msg := range msgs {
-		rmsg := createRedisMessageWrapper(topic, handler, msg)
-		select {
-		case i.Queue <- rmsg:
-		case <-i.Ctx.Done():
+		msg := msg
+		deliveryCount := int64(1)
+		if n, ok := deliveryCounts[msg.ID]; ok {
+			deliveryCount = n
+		}
+		evt, err := parseMessage(msg)
+		if err != nil {
+			mLog.Debugf("  P (Redis PubSub) : failed to parse message %s: %v", msg.ID, err)
+			i.deadLetter(stream, msg, deliveryCount, err.Error())
+			continue
+		}
+		i.lock.RLock()
+		dispatchers := i.matchingDispatchers(stream)
+		i.lock.RUnlock()
+		if len(dispatchers) == 0 {
+			continue
+		}
+		i.track(stream, msg.ID)
+		ack := pubsub.NewPendingAck(len(dispatchers), func(err error) {
+			i.complete(stream, msg, deliveryCount, err)
+		})
+		for _, d := range dispatchers {
+			if err := d.Enqueue(ctx, stream, evt, ack.Done); err != nil {
+				// the message was not delivered, which doesn't count as a failed delivery
+				ack.Done(pubsub.ErrSubscriptionClosed)
+			}
+		}
+		if ctx.Err() != nil {
 			return
 		}
 	}
 }
 
-func createRedisMessageWrapper(topic string, handler v1alpha2.EventHandler, msg redis.XMessage) RedisMessageWrapper {
-	var data interface{}
-	if dataValue, exists := msg.Values["data"]; exists && dataValue != nil {
-		data = dataValue
+// complete acknowledges a message that was handled, and dead-letters a message that failed its last delivery
+func (i *RedisPubSubProvider) complete(stream string, msg redis.XMessage, deliveryCount int64, err error) {
+	defer i.untrack(stream, msg.ID)
+	if err == nil {
+		if err := i.Client.XAck(stream, i.Config.ConsumerID, msg.ID).Err(); err != nil {
+			mLog.Debugf("  P (Redis PubSub) : failed to acknowledge message %s: %v", msg.ID, err)
+		}
+		return
 	}
-	return RedisMessageWrapper{
-		Topic:     topic,
-		Message:   data,
-		MessageID: msg.ID,
-		Handler:   handler,
+	if errors.Is(err, pubsub.ErrSubscriptionClosed) {
+		return
+	}
+	if i.Config.MaxDeliveryCount > 0 && deliveryCount >= int64(i.Config.MaxDeliveryCount) {
+		i.deadLetter(stream, msg, deliveryCount, err.Error())
+	}
+}
+
+func parseMessage(msg redis.XMessage) (v1alpha2.Event, error) {
+	var evt v1alpha2.Event
+	data, ok := msg.Values["data"].(string)
+	if !ok {
+		return evt, v1alpha2.NewCOAError(nil, "message has no data", v1alpha2.InternalError)
+	}
+	if err := json.Unmarshal([]byte(data), &evt); err != nil {
+		return evt, v1alpha2.NewCOAError(err, "failed to unmarshal event", v1alpha2.InternalError)
+	}
+	return evt, nil
+}
+
+// track records that a message is being handled, so it's neither reclaimed nor seen as idle
+func (i *RedisPubSubProvider) track(stream string, id string) {
+	i.flightLock.Lock()
+	defer i.flightLock.Unlock()
+	if i.inFlight[stream] == nil {
+		i.inFlight[stream] = make(map[string]struct{})
+	}
+	i.inFlight[stream][id] = struct{}{}
+}
+
+func (i *RedisPubSubProvider) untrack(stream string, id string) {
+	i.flightLock.Lock()
+	defer i.flightLock.Unlock()
+	delete(i.inFlight[stream], id)
+	if len(i.inFlight[stream]) == 0 {
+		delete(i.inFlight, stream)
 	}
 }
 
-func (i *RedisPubSubProvider) reclaimPendingMessagesLoop(topic string, handler v1alpha2.EventHandler) {
-	if i.Config.ProcessingTimeout == 0 || i.Config.RedeliverInterval == 0 {
+func (i *RedisPubSubProvider) inFlightIDs(stream string) map[string]struct{} {
+	i.flightLock.Lock()
+	defer i.flightLock.Unlock()
+	ret := make(map[string]struct{}, len(i.inFlight[stream]))
+	for id := range i.inFlight[stream] {
+		ret[id] = struct{}{}
+	}
+	return ret
+}
+
+func (i *RedisPubSubProvider) reclaimPendingMessagesLoop(ctx context.Context, stream string) {
+	if i.Config.ProcessingTimeout <= 0 || i.Config.RedeliverInterval <= 0 {
 		return
 	}
-	i.reclaimPendingMessages(topic, handler)
+	i.reclaimPendingMessages(ctx, stream)
 	reclaimTicker := time.NewTicker(i.Config.RedeliverInterval)
+	defer reclaimTicker.Stop()
 	for {
 		select {
-		case <-i.Ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-reclaimTicker.C:
-			i.reclaimPendingMessages(topic, handler)
+			i.reclaimPendingMessages(ctx, stream)
 		}
 	}
 }
 
-func (i *RedisPubSubProvider) reclaimPendingMessages(topic string, handler v1alpha2.EventHandler) {
+// reclaimPendingMessages claims the messages of a stream that have been pending for ProcessingTimeout, which
+// belong to consumers that crashed or gave up on them, and delivers them again. Messages that have already
+// been delivered MaxDeliveryCount times are moved to the dead-letter stream instead.
+func (i *RedisPubSubProvider) reclaimPendingMessages(ctx context.Context, topic string) {
+	inFlight := i.inFlightIDs(topic)
+	i.extendInFlight(topic, inFlight)
+	start := "-"
 	for {
+		if ctx.Err() != nil {
+			return
+		}
 		pendingResult, err := i.Client.XPendingExt(&redis.XPendingExtArgs{
 			Stream: topic,
 			Group:  i.Config.ConsumerID,
-			Start:  "-",
+			Start:  start,
 			End:    "+",
-			Count:  int64(i.Config.QueueDepth),
+			Count:  i.batchSize(),
 		}).Result()
 		if err != nil && !errors.Is(err, redis.Nil) {
 			mLog.Debugf("  P (Redis PubSub) : failed to get pending message %v", err)
 			break
 		}
+		if len(pendingResult) == 0 {
+			break
+		}
 		msgIDs := make([]string, 0, len(pendingResult))
+		deliveryCounts := make(map[string]int64, len(pendingResult))
 		for _, msg := range pendingResult {
-			if msg.Idle >= i.Config.ProcessingTimeout {
-				msgIDs = append(msgIDs, msg.ID)
+			if _, ok := inFlight[msg.ID]; ok || msg.Idle < i.Config.ProcessingTimeout {
+				continue
 			}
+			if i.Config.MaxDeliveryCount > 0 && msg.RetryCount >= int64(i.Config.MaxDeliveryCount) {
+				i.deadLetterPending(topic, msg.ID, msg.RetryCount)
+				continue
+			}
+			msgIDs = append(msgIDs, msg.ID)
+			// claiming a message counts as a delivery
+			deliveryCounts[msg.ID] = msg.RetryCount + 1
 		}
-		if len(msgIDs) == 0 {
-			break
-		}
-		claimResult, err := i.Client.XClaim(&redis.XClaimArgs{
-			Stream:   topic,
-			Group:    i.Config.ConsumerID,
-			Consumer: i.Config.ConsumerID,
-			MinIdle:  i.Config.ProcessingTimeout,
-			Messages: msgIDs,
-		}).Result()
-		if err != nil && !errors.Is(err, redis.Nil) {
-			mLog.Debugf("  P (Redis PubSub) : failed to reclaim pending message %v", err)
-			break
-		}
-		i.enqueueMessages(topic, handler, claimResult)
-		// If the Redis nil error is returned, it means some messages in the pending
-		// state no longer exist. We need to acknowledge these mesages to
-		// remove them from the pending list
-		if errors.Is(err, redis.Nil) {
-			// Build a set of message IDs that were not returned
-			// that potentitally no longer exist
-			expectedMsgIDs := make(map[string]struct{}, len(msgIDs))
-			for _, id := range msgIDs {
-				expectedMsgIDs[id] = struct{}{}
+		if len(msgIDs) > 0 {
+			claimResult, err := i.Client.XClaim(&redis.XClaimArgs{
+				Stream:   topic,
+				Group:    i.Config.ConsumerID,
+				Consumer: i.Config.ConsumerID,
+				MinIdle:  i.Config.ProcessingTimeout,
+				Messages: msgIDs,
+			}).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				mLog.Debugf("  P (Redis PubSub) : failed to reclaim pending message %v", err)
+				break
 			}
-			for _, claimed := range claimResult {
-				delete(expectedMsgIDs, claimed.ID)
+			i.dispatchMessages(ctx, topic, claimResult, deliveryCounts)
+			// If the Redis nil error is returned, it means some messages in the pending
+			// state no longer exist. We need to acknowledge these mesages to
+			// remove them from the pending list
+			if errors.Is(err, redis.Nil) {
+				// Build a set of message IDs that were not returned
+				// that potentitally no longer exist
+				expectedMsgIDs := make(map[string]struct{}, len(msgIDs))
+				for _, id := range msgIDs {
+					expectedMsgIDs[id] = struct{}{}
+				}
+				for _, claimed := range claimResult {
+					delete(expectedMsgIDs, claimed.ID)
+				}
+				i.removeMessagesThatNoLongerExistFromPending(ctx, topic, expectedMsgIDs)
 			}
-			i.removeMessagesThatNoLongerExistFromPending(topic, expectedMsgIDs, handler)
 		}
+		if int64(len(pendingResult)) < i.batchSize() {
+			break
+		}
+		start = nextStreamID(pendingResult[len(pendingResult)-1].ID)
+	}
+}
+
+// extendInFlight resets the idle time of the messages this provider is still handling, so that other
+// consumers of the group don't reclaim them while a slow handler runs. It doesn't count as a delivery.
+func (i *RedisPubSubProvider) extendInFlight(topic string, inFlight map[string]struct{}) {
+	if len(inFlight) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(inFlight))
+	for id := range inFlight {
+		ids = append(ids, id)
+	}
+	err := i.Client.XClaimJustID(&redis.XClaimArgs{
+		Stream:   topic,
+		Group:    i.Config.ConsumerID,
+		Consumer: i.Config.ConsumerID,
+		MinIdle:  0,
+		Messages: ids,
+	}).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		mLog.Debugf("  P (Redis PubSub) : failed to extend messages in flight %v", err)
 	}
 }
 
-func (i *RedisPubSubProvider) removeMessagesThatNoLongerExistFromPending(topic string, messageIDs map[string]struct{}, handler v1alpha2.EventHandler) {
+func (i *RedisPubSubProvider) removeMessagesThatNoLongerExistFromPending(ctx context.Context, topic string, messageIDs map[string]struct{}) {
 	for pendingID := range messageIDs {
 		claimResultSingleMsg, err := i.Client.XClaim(&redis.XClaimArgs{
 			Stream:   topic,
@@ -365,12 +691,32 @@ func (i *RedisPubSubProvider) removeMessagesThatNoLongerExistFromPending(topic s
 			if err = i.Client.XAck(topic, i.Config.ConsumerID, pendingID).Err(); err != nil {
 				mLog.Debugf("  P (Redis PubSub) : error acknowledging Redis message %s after failed claim for %s - %v", i.Config.ConsumerID, pendingID, err)
 			} else {
-				i.enqueueMessages(topic, handler, claimResultSingleMsg)
+				i.dispatchMessages(ctx, topic, claimResultSingleMsg, nil)
 			}
 		}
 	}
 }
 
+func (i *RedisPubSubProvider) batchSize() int64 {
+	if i.Config.QueueDepth > 0 {
+		return int64(i.Config.QueueDepth)
+	}
+	return defaultBatchSize
+}
+
+// nextStreamID returns the smallest stream entry ID after id, as XPENDING ranges are inclusive
+func nextStreamID(id string) string {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return id
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return id
+	}
+	return fmt.Sprintf("%s-%d", parts[0], seq+1)
+}
+
 func toRedisPubSubProviderConfig(config providers.IProviderConfig) (RedisPubSubProviderConfig, error) {
 	ret := RedisPubSubProviderConfig{}
 	data, err := json.Marshal(config)
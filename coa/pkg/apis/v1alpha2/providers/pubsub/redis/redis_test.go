@@ -7,12 +7,16 @@
 package redis
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,6 +72,22 @@ func TestInit(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestCheckHealth(t *testing.T) {
+	testRedis := os.Getenv("TEST_REDIS")
+	if testRedis == "" {
+		t.Skip("Skipping because TEST_REDIS enviornment variable is not set")
+	}
+	provider := RedisPubSubProvider{}
+	err := provider.Init(RedisPubSubProviderConfig{
+		Name: "test",
+		Host: "localhost:6379",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, provider.CheckHealth(context.Background()))
+	provider.Client.Close()
+	assert.NotNil(t, provider.CheckHealth(context.Background()))
+}
+
 func TestInitWithMap(t *testing.T) {
 	provider := RedisPubSubProvider{}
 	err := provider.InitWithMap(
@@ -298,6 +318,11 @@ func TestRedisPubSubProviderConfigFromMap(t *testing.T) {
 		"consumerID":        "test-consumer",
 		"processingTimeout": "10",
 		"redeliverInterval": "10",
+		"maxRetries":        "3",
+		"retryInterval":     "1s",
+		"discoveryInterval": "2s",
+		"maxDeliveryCount":  "5",
+		"deadLetterMaxLen":  "100",
 	}
 	config, err := RedisPubSubProviderConfigFromMap(configMap)
 	assert.Nil(t, err)
@@ -310,4 +335,87 @@ func TestRedisPubSubProviderConfigFromMap(t *testing.T) {
 	assert.Equal(t, "test-consumer", config.ConsumerID)
 	assert.Equal(t, time.Duration(10), config.ProcessingTimeout)
 	assert.Equal(t, time.Duration(10), config.RedeliverInterval)
+	assert.Equal(t, 3, config.MaxRetries)
+	assert.Equal(t, time.Second, config.RetryInterval)
+	assert.Equal(t, 2*time.Second, config.DiscoveryInterval)
+	assert.Equal(t, 5, config.MaxDeliveryCount)
+	assert.Equal(t, int64(100), config.DeadLetterMaxLen)
+}
+
+func TestDeadLetters(t *testing.T) {
+	testRedis := os.Getenv("TEST_REDIS")
+	if testRedis == "" {
+		t.Skip("Skipping because TEST_REDIS enviornment variable is not set")
+	}
+	provider := RedisPubSubProvider{}
+	err := provider.Init(RedisPubSubProviderConfig{
+		Name:              "test",
+		Host:              "localhost:6379",
+		ConsumerID:        "dead-letters",
+		NumberOfWorkers:   1,
+		ProcessingTimeout: 100 * time.Millisecond,
+		RedeliverInterval: 50 * time.Millisecond,
+		MaxDeliveryCount:  2,
+	})
+	assert.Nil(t, err)
+	topic := fmt.Sprintf("dead-letters-%d", time.Now().UnixNano())
+	var attempts int32
+	sub, err := provider.Subscribe(topic, func(topic string, message v1alpha2.Event) error {
+		atomic.AddInt32(&attempts, 1)
+		return fmt.Errorf("always fails")
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, provider.Publish(topic, v1alpha2.Event{Body: "TEST"}))
+
+	var letters []pubsub.DeadLetter
+	assert.Eventually(t, func() bool {
+		letters, err = provider.ListDeadLetters(topic, 10)
+		return err == nil && len(letters) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.Equal(t, topic, letters[0].Topic)
+	assert.Equal(t, int64(2), letters[0].DeliveryCount)
+	assert.Equal(t, "always fails", letters[0].Error)
+	assert.Equal(t, "TEST", letters[0].Event.Body)
+
+	assert.Nil(t, sub.Unsubscribe())
+	sig := make(chan string, 1)
+	sub, err = provider.Subscribe(topic, func(topic string, message v1alpha2.Event) error {
+		sig <- message.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	defer sub.Unsubscribe()
+	assert.Nil(t, provider.ReplayDeadLetter(topic, letters[0].ID))
+	assert.Equal(t, "TEST", <-sig)
+	letters, err = provider.ListDeadLetters(topic, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(letters))
+
+	err = provider.ReplayDeadLetter(topic, "0-1")
+	assert.NotNil(t, err)
+	err = provider.DeleteDeadLetter(topic, "0-1")
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+}
+
+func TestUnmarshalConfigDurations(t *testing.T) {
+	var config RedisPubSubProviderConfig
+	err := json.Unmarshal([]byte(`{"name":"test","host":"localhost:6379","processingTimeout":"2m","redeliverInterval":"30s","retryInterval":1000000,"discoveryInterval":"500ms"}`), &config)
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:6379", config.Host)
+	assert.Equal(t, 2*time.Minute, config.ProcessingTimeout)
+	assert.Equal(t, 30*time.Second, config.RedeliverInterval)
+	assert.Equal(t, time.Millisecond, config.RetryInterval)
+	assert.Equal(t, 500*time.Millisecond, config.DiscoveryInterval)
+
+	err = json.Unmarshal([]byte(`{"retryInterval":"soon"}`), &config)
+	assert.NotNil(t, err)
+}
+
+func TestNextStreamID(t *testing.T) {
+	assert.Equal(t, "1526569495631-1", nextStreamID("1526569495631-0"))
+	assert.Equal(t, "1526569495631-10", nextStreamID("1526569495631-9"))
+	assert.Equal(t, "-", nextStreamID("-"))
 }
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package pubsub
+
+import "strings"
+
+// TopicWildcard is a topic segment that matches any single segment
+const TopicWildcard = "*"
+
+// IsTopicPattern tells if a subscription topic contains wildcard segments
+func IsTopicPattern(pattern string) bool {
+	for _, segment := range strings.Split(pattern, ".") {
+		if segment == TopicWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTopic tells if a topic matches a subscription topic. Topics are made of segments separated by
+// dots, and a * segment in the pattern matches exactly one segment of the topic, so job.* matches job.123
+// but neither job nor job.123.status.
+func MatchTopic(pattern string, topic string) bool {
+	if pattern == topic {
+		return true
+	}
+	patternSegments := strings.Split(pattern, ".")
+	topicSegments := strings.Split(topic, ".")
+	if len(patternSegments) != len(topicSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != TopicWildcard && segment != topicSegments[i] {
+			return false
+		}
+	}
+	return true
+}
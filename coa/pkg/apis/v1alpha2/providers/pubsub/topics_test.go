@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTopic(t *testing.T) {
+	assert.True(t, MatchTopic("job", "job"))
+	assert.True(t, MatchTopic("job.*", "job.123"))
+	assert.True(t, MatchTopic("*.status", "job.status"))
+	assert.True(t, MatchTopic("job.*.status", "job.123.status"))
+	assert.False(t, MatchTopic("job.*", "job"))
+	assert.False(t, MatchTopic("job.*", "job.123.status"))
+	assert.False(t, MatchTopic("job.*", "jobs.123"))
+	assert.False(t, MatchTopic("job", "job.123"))
+}
+
+func TestIsTopicPattern(t *testing.T) {
+	assert.True(t, IsTopicPattern("job.*"))
+	assert.True(t, IsTopicPattern("*"))
+	assert.False(t, IsTopicPattern("job"))
+	assert.False(t, IsTopicPattern("job*"))
+}
@@ -0,0 +1,337 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package natsqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/nats-io/nats.go"
+)
+
+var mLog = logger.NewLogger("coa.runtime")
+
+const (
+	defaultURL           = nats.DefaultURL
+	defaultConsumerID    = "symphony"
+	defaultStream        = "SYMPHONY_QUEUE"
+	defaultSubjectPrefix = "symphony.queue"
+	defaultFetchTimeout  = 500 * time.Millisecond
+	// ackWait is how long a dequeued element can go unacknowledged before JetStream puts it back. Elements
+	// are acknowledged as soon as they are fetched, so it only matters when the connection drops in between.
+	ackWait = 30 * time.Second
+)
+
+type NatsQueueProviderConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// ConsumerID names the durable consumers of the provider. Providers with the same ConsumerID share the
+	// elements of a queue, and providers with different ones each get all of them.
+	ConsumerID string `json:"consumerID"`
+	// Stream is the JetStream stream that keeps the elements
+	Stream string `json:"stream,omitempty"`
+	// SubjectPrefix is prepended to queue names to make the subjects of the stream
+	SubjectPrefix string `json:"subjectPrefix,omitempty"`
+	// FetchTimeout is how long Dequeue and Peek wait for an element
+	FetchTimeout time.Duration `json:"fetchTimeout,omitempty"`
+	// MemoryStorage keeps the stream in memory instead of files
+	MemoryStorage bool `json:"memoryStorage,omitempty"`
+}
+
+// UnmarshalJSON reads the fetch timeout of the config as a duration string such as "500ms", or as a number of
+// nanoseconds
+func (c *NatsQueueProviderConfig) UnmarshalJSON(data []byte) error {
+	type config NatsQueueProviderConfig
+	aux := struct {
+		*config
+		FetchTimeout json.RawMessage `json:"fetchTimeout,omitempty"`
+	}{config: (*config)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return utils.UnmarshalDurations(data, map[string]*time.Duration{
+		"fetchTimeout": &c.FetchTimeout,
+	})
+}
+
+func NatsQueueProviderConfigFromMap(properties map[string]string) (NatsQueueProviderConfig, error) {
+	ret := NatsQueueProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = utils.ParseProperty(v)
+	}
+	if v, ok := properties["url"]; ok {
+		ret.URL = utils.ParseProperty(v)
+	}
+	if v, ok := properties["consumerID"]; ok {
+		ret.ConsumerID = utils.ParseProperty(v)
+	}
+	if v, ok := properties["stream"]; ok {
+		ret.Stream = utils.ParseProperty(v)
+	}
+	if v, ok := properties["subjectPrefix"]; ok {
+		ret.SubjectPrefix = utils.ParseProperty(v)
+	}
+	if v, ok := properties["fetchTimeout"]; ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid duration value in the 'fetchTimeout' setting of NATS queue provider", v1alpha2.BadConfig)
+		}
+		ret.FetchTimeout = d
+	}
+	if v, ok := properties["memoryStorage"]; ok && v != "" {
+		bVal, err := strconv.ParseBool(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid bool value in the 'memoryStorage' setting of NATS queue provider", v1alpha2.BadConfig)
+		}
+		ret.MemoryStorage = bVal
+	}
+	return ret, nil
+}
+
+// NatsQueueProvider keeps each queue as the subject of a JetStream stream, read through a durable consumer
+// per queue. Elements are stored as JSON, so Dequeue and Peek return them as decoded JSON values.
+type NatsQueueProvider struct {
+	Config    NatsQueueProviderConfig
+	Conn      *nats.Conn
+	JetStream nats.JetStreamContext
+	Context   *contexts.ManagerContext
+	lock      sync.Mutex
+	subs      map[string]*nats.Subscription
+}
+
+func (s *NatsQueueProvider) ID() string {
+	return s.Config.Name
+}
+
+func (s *NatsQueueProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *NatsQueueProvider) InitWithMap(properties map[string]string) error {
+	config, err := NatsQueueProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func toNatsQueueProviderConfig(config providers.IProviderConfig) (NatsQueueProviderConfig, error) {
+	ret := NatsQueueProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (s *NatsQueueProvider) Init(config providers.IProviderConfig) error {
+	queueConfig, err := toNatsQueueProviderConfig(config)
+	if err != nil {
+		return v1alpha2.NewCOAError(nil, "provided config is not a valid NATS queue provider config", v1alpha2.BadConfig)
+	}
+	s.Config = queueConfig
+	if s.Config.URL == "" {
+		s.Config.URL = defaultURL
+	}
+	if s.Config.ConsumerID == "" {
+		s.Config.ConsumerID = defaultConsumerID
+	}
+	if s.Config.Stream == "" {
+		s.Config.Stream = defaultStream
+	}
+	if s.Config.SubjectPrefix == "" {
+		s.Config.SubjectPrefix = defaultSubjectPrefix
+	}
+	if s.Config.FetchTimeout <= 0 {
+		s.Config.FetchTimeout = defaultFetchTimeout
+	}
+	conn, err := nats.Connect(s.Config.URL, nats.Name("symphony-"+s.Config.Name), nats.MaxReconnects(-1))
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: error connecting to %s", s.Config.URL), v1alpha2.InternalError)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return v1alpha2.NewCOAError(err, "NATS: failed to get JetStream context", v1alpha2.InternalError)
+	}
+	storage := nats.FileStorage
+	if s.Config.MemoryStorage {
+		storage = nats.MemoryStorage
+	}
+	if _, err := js.StreamInfo(s.Config.Stream); errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     s.Config.Stream,
+			Subjects: []string{s.Config.SubjectPrefix + ".>"},
+			Storage:  storage,
+		})
+		if err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+			conn.Close()
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: failed to create stream %s", s.Config.Stream), v1alpha2.InternalError)
+		}
+	} else if err != nil {
+		conn.Close()
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("NATS: failed to get stream %s", s.Config.Stream), v1alpha2.InternalError)
+	}
+	s.Conn = conn
+	s.JetStream = js
+	s.subs = make(map[string]*nats.Subscription)
+	return nil
+}
+
+// CheckHealth tells if the provider is connected to the NATS server and can read its stream
+func (s *NatsQueueProvider) CheckHealth(ctx context.Context) error {
+	if !s.Conn.IsConnected() {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("not connected to NATS server at %s", s.Config.URL), v1alpha2.InternalError)
+	}
+	if _, err := s.JetStream.StreamInfo(s.Config.Stream, nats.Context(ctx)); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read NATS stream '%s'", s.Config.Stream), v1alpha2.InternalError)
+	}
+	return nil
+}
+
+func (s *NatsQueueProvider) Enqueue(queue string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "failed to marshal queue element", v1alpha2.BadRequest)
+	}
+	if _, err := s.JetStream.Publish(s.subject(queue), payload); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to enqueue to %s", queue), v1alpha2.InternalError)
+	}
+	return nil
+}
+
+func (s *NatsQueueProvider) Dequeue(queue string) (interface{}, error) {
+	msg, err := s.fetch(queue)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := decode(msg)
+	if err != nil {
+		// an element that can't be decoded would block the queue, so it's removed
+		msg.Term()
+		return nil, err
+	}
+	if err := msg.AckSync(); err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to dequeue from %s", queue), v1alpha2.InternalError)
+	}
+	return ret, nil
+}
+
+// Peek fetches the first element of a queue and puts it back right away, which counts as a delivery for
+// JetStream but leaves the element at the head of the queue
+func (s *NatsQueueProvider) Peek(queue string) (interface{}, error) {
+	msg, err := s.fetch(queue)
+	if err != nil {
+		return nil, err
+	}
+	defer msg.Nak()
+	return decode(msg)
+}
+
+func (s *NatsQueueProvider) Size(queue string) int {
+	sub, err := s.subscription(queue)
+	if err != nil {
+		mLog.Debugf("  P (NATS Queue): failed to get size of %s: %v", queue, err)
+		return 0
+	}
+	info, err := sub.ConsumerInfo()
+	if err != nil {
+		mLog.Debugf("  P (NATS Queue): failed to get size of %s: %v", queue, err)
+		return 0
+	}
+	return int(info.NumPending) + info.NumAckPending
+}
+
+func (s *NatsQueueProvider) fetch(queue string) (*nats.Msg, error) {
+	sub, err := s.subscription(queue)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.FetchTimeout)
+	defer cancel()
+	msgs, err := sub.Fetch(1, nats.Context(ctx))
+	if len(msgs) == 0 {
+		if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+			return nil, v1alpha2.NewCOAError(nil, "queue is empty", v1alpha2.NotFound)
+		}
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read from %s", queue), v1alpha2.InternalError)
+	}
+	return msgs[0], nil
+}
+
+// subscription returns the pull subscription of the durable consumer of a queue, creating the consumer on
+// first use
+func (s *NatsQueueProvider) subscription(queue string) (*nats.Subscription, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if sub, ok := s.subs[queue]; ok {
+		return sub, nil
+	}
+	durable := durableName(s.Config.ConsumerID, queue)
+	_, err := s.JetStream.ConsumerInfo(s.Config.Stream, durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = s.JetStream.AddConsumer(s.Config.Stream, &nats.ConsumerConfig{
+			Durable:       durable,
+			Description:   fmt.Sprintf("%s on queue %s", s.Config.ConsumerID, queue),
+			DeliverPolicy: nats.DeliverAllPolicy,
+			AckPolicy:     nats.AckExplicitPolicy,
+			AckWait:       ackWait,
+			MaxAckPending: 1,
+			FilterSubject: s.subject(queue),
+		})
+	}
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to open queue %s", queue), v1alpha2.InternalError)
+	}
+	sub, err := s.JetStream.PullSubscribe(s.subject(queue), durable, nats.Bind(s.Config.Stream, durable))
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to open queue %s", queue), v1alpha2.InternalError)
+	}
+	s.subs[queue] = sub
+	return sub, nil
+}
+
+func (s *NatsQueueProvider) subject(queue string) string {
+	return s.Config.SubjectPrefix + "." + queue
+}
+
+func decode(msg *nats.Msg) (interface{}, error) {
+	var ret interface{}
+	if err := json.Unmarshal(msg.Data, &ret); err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to unmarshal queue element", v1alpha2.InternalError)
+	}
+	return ret, nil
+}
+
+// durableName returns the name of the durable consumer of a consumer ID and a queue. Durable names can't
+// have dots or wildcards, so the queue name is hashed.
+func durableName(consumerID string, queue string) string {
+	h := fnv.New64a()
+	h.Write([]byte(consumerID))
+	h.Write([]byte{0})
+	h.Write([]byte(queue))
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, consumerID)
+	return fmt.Sprintf("%s_%x", name, h.Sum64())
+}
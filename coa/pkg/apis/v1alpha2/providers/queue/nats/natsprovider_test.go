@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package natsqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func runServer(t *testing.T) string {
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	assert.Nil(t, err)
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("NATS server is not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s.ClientURL()
+}
+
+func newQueue(t *testing.T, url string, consumerID string) *NatsQueueProvider {
+	queue := &NatsQueueProvider{}
+	err := queue.Init(NatsQueueProviderConfig{
+		Name:         "test",
+		URL:          url,
+		ConsumerID:   consumerID,
+		FetchTimeout: 100 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	t.Cleanup(queue.Conn.Close)
+	return queue
+}
+
+func TestInitWithMap(t *testing.T) {
+	url := runServer(t)
+	queue := NatsQueueProvider{}
+	err := queue.InitWithMap(map[string]string{
+		"name":          "test",
+		"url":           url,
+		"subjectPrefix": "test.queue",
+		"fetchTimeout":  "1s",
+	})
+	assert.Nil(t, err)
+	defer queue.Conn.Close()
+	assert.Equal(t, "symphony", queue.Config.ConsumerID)
+	assert.Equal(t, "SYMPHONY_QUEUE", queue.Config.Stream)
+	assert.Equal(t, "test.queue", queue.Config.SubjectPrefix)
+	assert.Equal(t, time.Second, queue.Config.FetchTimeout)
+}
+
+func TestInitWithBadFetchTimeout(t *testing.T) {
+	queue := NatsQueueProvider{}
+	err := queue.InitWithMap(map[string]string{
+		"fetchTimeout": "soon",
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func TestInitWithFetchTimeoutString(t *testing.T) {
+	queue := NatsQueueProvider{}
+	err := queue.Init(map[string]interface{}{
+		"name":         "test",
+		"url":          runServer(t),
+		"fetchTimeout": "250ms",
+	})
+	assert.Nil(t, err)
+	defer queue.Conn.Close()
+	assert.Equal(t, 250*time.Millisecond, queue.Config.FetchTimeout)
+}
+
+func TestDequeueInOrder(t *testing.T) {
+	queue := newQueue(t, runServer(t), "test")
+	assert.Nil(t, queue.Enqueue("queue1", "a"))
+	assert.Nil(t, queue.Enqueue("queue1", "b"))
+	assert.Nil(t, queue.Enqueue("queue1", "c"))
+	assert.Equal(t, 3, queue.Size("queue1"))
+	element, err := queue.Peek("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	assert.Equal(t, 3, queue.Size("queue1"))
+	element, err = queue.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = queue.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+	assert.Equal(t, 1, queue.Size("queue1"))
+}
+
+func TestDequeueEmpty(t *testing.T) {
+	queue := newQueue(t, runServer(t), "test")
+	element, err := queue.Dequeue("queue1")
+	assert.Nil(t, element)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+	_, err = queue.Peek("queue1")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, queue.Size("queue1"))
+}
+
+func TestStructuredElement(t *testing.T) {
+	queue := newQueue(t, runServer(t), "test")
+	assert.Nil(t, queue.Enqueue("site1", v1alpha2.JobData{Id: "job1", Action: "UPDATE"}))
+	element, err := queue.Dequeue("site1")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "job1", "action": "UPDATE"}, element)
+}
+
+func TestConsumerGroups(t *testing.T) {
+	url := runServer(t)
+	queue1 := newQueue(t, url, "group1")
+	queue2 := newQueue(t, url, "group1")
+	other := newQueue(t, url, "group2")
+	assert.Nil(t, queue1.Enqueue("queue1", "a"))
+	assert.Nil(t, queue1.Enqueue("queue1", "b"))
+
+	// providers with the same consumer ID share the elements
+	element, err := queue1.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = queue2.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+	_, err = queue1.Dequeue("queue1")
+	assert.NotNil(t, err)
+
+	// providers with another consumer ID get all of them
+	assert.Equal(t, 2, other.Size("queue1"))
+	element, err = other.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+}
+
+func TestCheckHealth(t *testing.T) {
+	queue := newQueue(t, runServer(t), "test")
+	assert.Nil(t, queue.CheckHealth(context.Background()))
+	queue.Conn.Close()
+	assert.NotNil(t, queue.CheckHealth(context.Background()))
+}
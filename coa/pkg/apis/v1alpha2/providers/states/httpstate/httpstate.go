@@ -121,6 +121,23 @@ func (s *HttpStateProvider) Init(config providers.IProviderConfig) error {
 	return nil
 }
 
+// CheckHealth tells if the state store responds, without errors from the server, to a request on its URL
+func (s *HttpStateProvider) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.Config.Url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "failed to reach HTTP state store", v1alpha2.InternalError)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("HTTP state store is not healthy: [%d]", resp.StatusCode), v1alpha2.InternalError)
+	}
+	return nil
+}
+
 func (s *HttpStateProvider) Upsert(ctx context.Context, entry states.UpsertRequest) (string, error) {
 	client := &http.Client{}
 	rUrl := s.Config.Url
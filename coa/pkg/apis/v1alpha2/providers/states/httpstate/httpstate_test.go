@@ -300,3 +300,21 @@ func TestClone(t *testing.T) {
 	assert.NotNil(t, p)
 	assert.Nil(t, err)
 }
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusNotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	provider := HttpStateProvider{}
+	err := provider.Init(HttpStateProviderConfig{
+		Url: ts.URL + "/v1.0/state/statestore",
+	})
+	assert.Nil(t, err)
+	// the state store is reachable, even if it doesn't serve its root
+	assert.Nil(t, provider.CheckHealth(context.Background()))
+	status = http.StatusServiceUnavailable
+	assert.NotNil(t, provider.CheckHealth(context.Background()))
+	ts.Close()
+	assert.NotNil(t, provider.CheckHealth(context.Background()))
+}
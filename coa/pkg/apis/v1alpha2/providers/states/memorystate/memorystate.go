@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -83,6 +84,17 @@ func (s *MemoryStateProvider) Upsert(ctx context.Context, entry states.UpsertReq
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
+	key, found := s.findKey(entry.Value.ID, entry.Metadata)
+	if entry.Options.Concurrency == states.FirstWrite {
+		if found {
+			existing := s.Data[key]
+			if entry.ETag == nil || existing.(states.StateEntry).ETag != *entry.ETag {
+				err = v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' has been modified", entry.Value.ID), v1alpha2.Conflict)
+				return "", err
+			}
+		}
+	}
+
 	tag := "1"
 	if entry.Value.ETag != "" {
 		var v int64
@@ -96,7 +108,7 @@ func (s *MemoryStateProvider) Upsert(ctx context.Context, entry states.UpsertReq
 	if _, ok := entry.Value.Body.(map[string]interface{}); ok {
 		mapRef := entry.Value.Body.(map[string]interface{})
 		if mapRef["status"] != nil && mapRef["spec"] == nil {
-			dataRef := s.Data[entry.Value.ID]
+			dataRef := s.Data[key]
 			if dataRef != nil {
 				mapRef["spec"] = dataRef.(states.StateEntry).Body.(map[string]interface{})["spec"]
 			}
@@ -104,7 +116,7 @@ func (s *MemoryStateProvider) Upsert(ctx context.Context, entry states.UpsertReq
 		}
 	}
 
-	s.Data[entry.Value.ID] = entry.Value
+	s.Data[key] = entry.Value
 
 	return entry.Value.ID, nil
 }
@@ -121,7 +133,11 @@ func (s *MemoryStateProvider) List(ctx context.Context, request states.ListReque
 	sLog.Debug("  P (Memory State): list states")
 
 	var entities []states.StateEntry
-	for _, v := range s.Data {
+	resource, filtered := request.Metadata["resource"]
+	for k, v := range s.Data {
+		if filtered && !strings.HasPrefix(k, resource+"/") {
+			continue
+		}
 		vE, ok := v.(states.StateEntry)
 		if ok {
 			if request.Filter != "" {
@@ -148,11 +164,12 @@ func (s *MemoryStateProvider) Delete(ctx context.Context, request states.DeleteR
 
 	sLog.Debug("  P (Memory State): delete state")
 
-	if _, ok := s.Data[request.ID]; !ok {
+	key, found := s.findKey(request.ID, request.Metadata)
+	if !found {
 		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' is not found", request.ID), v1alpha2.NotFound)
 		return err
 	}
-	delete(s.Data, request.ID)
+	delete(s.Data, key)
 
 	return nil
 }
@@ -168,7 +185,8 @@ func (s *MemoryStateProvider) Get(ctx context.Context, request states.GetRequest
 
 	sLog.Debug("  P (Memory State): get state")
 
-	if v, ok := s.Data[request.ID]; ok {
+	if key, found := s.findKey(request.ID, request.Metadata); found {
+		v := s.Data[key]
 		vE, ok := v.(states.StateEntry)
 		if ok {
 			err = nil
@@ -182,6 +200,15 @@ func (s *MemoryStateProvider) Get(ctx context.Context, request states.GetRequest
 	return states.StateEntry{}, err
 }
 
+// findKey returns the key of an entry and whether the entry exists. Entries are partitioned by resource, like the
+// k8s state provider does, so that entries of different resources can share an ID. Requests without a resource
+// only see the entries written without one.
+func (s *MemoryStateProvider) findKey(id string, metadata map[string]string) (string, bool) {
+	key := metadata["resource"] + "/" + id
+	_, ok := s.Data[key]
+	return key, ok
+}
+
 func toMemoryStateProviderConfig(config providers.IProviderConfig) (MemoryStateProviderConfig, error) {
 	ret := MemoryStateProviderConfig{}
 	data, err := json.Marshal(config)
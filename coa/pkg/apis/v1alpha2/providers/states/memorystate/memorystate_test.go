@@ -286,3 +286,86 @@ func TestClone(t *testing.T) {
 	assert.NotNil(t, p)
 	assert.Nil(t, err)
 }
+
+func TestEntriesArePartitionedByResource(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+	for _, resource := range []string{"catalogs", "catalogrevisions"} {
+		_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+			Value:    states.StateEntry{ID: "config-v1", Body: resource},
+			Metadata: map[string]string{"resource": resource},
+		})
+		assert.Nil(t, err)
+	}
+	entity, err := provider.Get(context.Background(), states.GetRequest{
+		ID:       "config-v1",
+		Metadata: map[string]string{"resource": "catalogs"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "catalogs", entity.Body)
+	entries, _, err := provider.List(context.Background(), states.ListRequest{
+		Metadata: map[string]string{"resource": "catalogrevisions"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "catalogrevisions", entries[0].Body)
+
+	err = provider.Delete(context.Background(), states.DeleteRequest{
+		ID:       "config-v1",
+		Metadata: map[string]string{"resource": "catalogrevisions"},
+	})
+	assert.Nil(t, err)
+	_, err = provider.Get(context.Background(), states.GetRequest{
+		ID:       "config-v1",
+		Metadata: map[string]string{"resource": "catalogs"},
+	})
+	assert.Nil(t, err)
+
+	// a request without a resource only sees the entries written without one
+	_, err = provider.Get(context.Background(), states.GetRequest{
+		ID: "config-v1",
+	})
+	assert.True(t, v1alpha2.IsNotFound(err))
+	_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{ID: "config-v1", Body: "none"},
+	})
+	assert.Nil(t, err)
+	entity, err = provider.Get(context.Background(), states.GetRequest{
+		ID: "config-v1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "none", entity.Body)
+	entity, err = provider.Get(context.Background(), states.GetRequest{
+		ID:       "config-v1",
+		Metadata: map[string]string{"resource": "catalogs"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "catalogs", entity.Body)
+}
+
+func TestFirstWrite(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+	request := states.UpsertRequest{
+		Value:   states.StateEntry{ID: "123", Body: "first"},
+		Options: states.UpsertOption{Concurrency: states.FirstWrite},
+	}
+	_, err = provider.Upsert(context.Background(), request)
+	assert.Nil(t, err)
+
+	request.Value.Body = "second"
+	_, err = provider.Upsert(context.Background(), request)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.Conflict, coaErr.State)
+
+	etag := "1"
+	request.ETag = &etag
+	_, err = provider.Upsert(context.Background(), request)
+	assert.Nil(t, err)
+	entity, err := provider.Get(context.Background(), states.GetRequest{ID: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, "second", entity.Body)
+}
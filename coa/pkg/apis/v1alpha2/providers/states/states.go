@@ -46,6 +46,14 @@ type DeleteRequest struct {
 	Metadata map[string]string `json:"metadata"`
 	Options  DeleteOption      `json:"options,omitempty"`
 }
+
+const (
+	// FirstWrite makes an upsert fail with a conflict when the entry exists and the request doesn't carry its
+	// current ETag. An upsert without an ETag only creates new entries.
+	FirstWrite = "first-write"
+	LastWrite  = "last-write"
+)
+
 type UpsertOption struct {
 	Concurrency string `json:"concurrency,omitempty"` //first-write, last-write
 	Consistency string `json:"consistency"`           //eventual, strong
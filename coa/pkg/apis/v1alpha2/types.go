@@ -98,6 +98,12 @@ func (s State) String() string {
 		return "Updated"
 	case Deleted:
 		return "Deleted"
+	case Running:
+		return "Running"
+	case Paused:
+		return "Paused"
+	case Done:
+		return "Done"
 	case Delayed:
 		return "Delayed"
 	case Untouched:
@@ -110,19 +116,23 @@ func (s State) String() string {
 }
 
 const (
-	COAMetaHeader          = "COA_META_HEADER"
-	TracingExporterConsole = "tracing.exporters.console"
-	TracingExporterZipkin  = "tracing.exporters.zipkin"
-	ProvidersState         = "providers.state"
-	ProvidersConfig        = "providers.config"
-	ProvidersSecret        = "providers.secret"
-	ProvidersReference     = "providers.reference"
-	ProvidersProbe         = "providers.probe"
-	ProvidersUploader      = "providers.uploader"
-	ProvidersReporter      = "providers.reporter"
-	ProviderQueue          = "providers.queue"
-	ProviderLedger         = "providers.ledger"
-	StatusOutput           = "__status"
-	ErrorOutput            = "__error"
-	StateOutput            = "__state"
+	COAMetaHeader           = "COA_META_HEADER"
+	TracingExporterConsole  = "tracing.exporters.console"
+	TracingExporterZipkin   = "tracing.exporters.zipkin"
+	TracingExporterOTLPgRPC = "tracing.exporters.otlpgrpc"
+	TracingExporterOTLPHTTP = "tracing.exporters.otlphttp"
+	LogExporterOTLPgRPC     = "log.exporters.otlpgrpc"
+	LogExporterOTLPHTTP     = "log.exporters.otlphttp"
+	ProvidersState          = "providers.state"
+	ProvidersConfig         = "providers.config"
+	ProvidersSecret         = "providers.secret"
+	ProvidersReference      = "providers.reference"
+	ProvidersProbe          = "providers.probe"
+	ProvidersUploader       = "providers.uploader"
+	ProvidersReporter       = "providers.reporter"
+	ProviderQueue           = "providers.queue"
+	ProviderLedger          = "providers.ledger"
+	StatusOutput            = "__status"
+	ErrorOutput             = "__error"
+	StateOutput             = "__state"
 )
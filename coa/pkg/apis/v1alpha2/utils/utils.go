@@ -9,6 +9,7 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -36,6 +37,25 @@ func UnmarshalDuration(duration string) (time.Duration, error) {
 	}
 }
 
+// UnmarshalDurations reads the duration fields of a JSON object, which are either duration strings such as "5s" or
+// numbers of nanoseconds. Fields that are missing or null are left unchanged.
+func UnmarshalDurations(data []byte, durations map[string]*time.Duration) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, value := range durations {
+		if raw, ok := fields[key]; ok && string(raw) != "null" {
+			d, err := UnmarshalDuration(string(raw))
+			if err != nil {
+				return fmt.Errorf("invalid duration value in '%s': %v", key, err)
+			}
+			*value = d
+		}
+	}
+	return nil
+}
+
 func ParseProperty(val string) string {
 	if strings.HasPrefix(val, "$env:") {
 		return os.Getenv(val[5:])
@@ -52,6 +72,9 @@ type EvaluationContext struct {
 	Outputs        map[string]map[string]interface{}
 	Component      string
 	Value          interface{}
+	// Item and Index are the current item of a repeated component, read with $item() and $index()
+	Item  interface{}
+	Index int
 }
 
 func (e *EvaluationContext) Clone() *EvaluationContext {
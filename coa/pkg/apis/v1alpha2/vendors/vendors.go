@@ -7,13 +7,15 @@
 package vendors
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
@@ -30,7 +32,7 @@ type VendorConfig struct {
 }
 
 type IVendor interface {
-	RunLoop(interval time.Duration) error
+	RunLoop(ctx context.Context, interval time.Duration, registry *health.Registry) error
 	Init(config VendorConfig, managers []managers.IManagerFactroy, providers map[string]map[string]providers.IProvider, pubsubProvider pubsub.IPubSubProvider) error
 	GetEndpoints() []v1alpha2.Endpoint
 	GetInfo() VendorInfo
@@ -45,6 +47,9 @@ type IVendorFactory interface {
 	CreateVendor(config VendorConfig) (IVendor, error)
 }
 
+// loopStallTimeout is how long a round of polls can take before the loop is reported as stalled
+const loopStallTimeout = 5 * time.Minute
+
 type VendorInfo struct {
 	Version  string `json:"version"`
 	Name     string `json:"name"`
@@ -62,26 +67,73 @@ type Vendor struct {
 func (v *Vendor) SetEvaluationContext(context *utils.EvaluationContext) {
 	v.Context.EvaluationContext = context
 }
-func (v *Vendor) RunLoop(interval time.Duration) error {
-	signalChannel := make(chan os.Signal, 1)
-	signal.Notify(signalChannel, os.Interrupt)
-	go func() {
-		<-signalChannel
-		fmt.Println("CLEANING UP")
-		os.Exit(0)
-	}()
-	for true {
-		for _, m := range v.Managers {
-			if c, ok := m.(managers.ISchedulable); ok {
-				if c.Enabled() {
-					c.Poll()     //TODO: report errors
-					c.Reconcil() //TODO: report errors
-				}
+
+// RunLoop polls and reconciles the schedulable managers every interval, until ctx is canceled. The loop
+// sends heartbeats to the health registry, and the managers report their errors to it, which backs off the
+// failing ones.
+func (v *Vendor) RunLoop(ctx context.Context, interval time.Duration, registry *health.Registry) error {
+	if registry == nil {
+		registry = health.NewRegistry()
+	}
+	loopName := "loops/" + v.name()
+	defer registry.RemoveLoop(loopName)
+	for {
+		registry.Heartbeat(loopName, interval+loopStallTimeout)
+		for k, m := range v.Managers {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if c, ok := m.(managers.ISchedulable); ok && c.Enabled() {
+				v.runManager(c, fmt.Sprintf("managers/%s/%s", v.name(), v.managerName(k)), interval, registry)
 			}
 		}
-		time.Sleep(interval)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
 	}
-	return nil
+}
+
+func (v *Vendor) runManager(manager managers.ISchedulable, name string, interval time.Duration, registry *health.Registry) {
+	if !registry.Due(name) {
+		return
+	}
+	errs := manager.Poll()
+	errs = append(errs, manager.Reconcil()...)
+	if err := combineErrors(errs); err != nil {
+		backoff := registry.ReportFailure(name, err, interval)
+		v.Context.Logger.Errorf("V (%s): %s failed, retrying in %s: %+v", v.Config.Type, name, backoff, err)
+		return
+	}
+	registry.ReportSuccess(name)
+}
+
+func (v *Vendor) name() string {
+	if v.Config.Route != "" {
+		return v.Config.Route
+	}
+	return v.Config.Type
+}
+
+func (v *Vendor) managerName(index int) string {
+	if index < len(v.Config.Managers) {
+		return v.Config.Managers[index].Name
+	}
+	return fmt.Sprintf("manager-%d", index)
+}
+
+func combineErrors(errs []error) error {
+	messages := make([]string, 0)
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "; "))
 }
 
 func (v *Vendor) Init(config VendorConfig, factories []managers.IManagerFactroy, providers map[string]map[string]providers.IProvider, pubsubProvider pubsub.IPubSubProvider) error {
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package vendors
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/health"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+type scheduledManager struct {
+	polls int32
+	fail  bool
+}
+
+func (m *scheduledManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
+	return nil
+}
+func (m *scheduledManager) Poll() []error {
+	atomic.AddInt32(&m.polls, 1)
+	if m.fail {
+		return []error{fmt.Errorf("site is not reachable")}
+	}
+	return nil
+}
+func (m *scheduledManager) Reconcil() []error {
+	return nil
+}
+func (m *scheduledManager) Enabled() bool {
+	return true
+}
+
+type scheduledManagerFactory struct {
+	managers map[string]*scheduledManager
+}
+
+func (f scheduledManagerFactory) CreateManager(config managers.ManagerConfig) (managers.IManager, error) {
+	return f.managers[config.Name], nil
+}
+
+func newVendor(t *testing.T, managerMap map[string]*scheduledManager) *Vendor {
+	config := VendorConfig{Type: "vendors.test", Route: "test"}
+	for name := range managerMap {
+		config.Managers = append(config.Managers, managers.ManagerConfig{Name: name})
+	}
+	vendor := &Vendor{}
+	err := vendor.Init(config, []managers.IManagerFactroy{scheduledManagerFactory{managers: managerMap}}, nil, nil)
+	assert.Nil(t, err)
+	return vendor
+}
+
+func TestRunLoopStopsOnCancel(t *testing.T) {
+	manager := &scheduledManager{}
+	vendor := newVendor(t, map[string]*scheduledManager{"m1": manager})
+	registry := health.NewRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- vendor.RunLoop(ctx, 10*time.Millisecond, registry)
+	}()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&manager.polls) >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, registry.Liveness().Components, "loops/test")
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("loop didn't stop")
+	}
+	assert.NotContains(t, registry.Liveness().Components, "loops/test")
+}
+
+func TestRunLoopBacksOffFailingManagers(t *testing.T) {
+	failing := &scheduledManager{fail: true}
+	healthy := &scheduledManager{}
+	vendor := newVendor(t, map[string]*scheduledManager{"failing": failing, "healthy": healthy})
+	registry := health.NewRegistry()
+	registry.SetReady(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go vendor.RunLoop(ctx, 10*time.Millisecond, registry)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&healthy.polls) >= 20
+	}, 5*time.Second, 10*time.Millisecond)
+	// the failing manager waits 10ms, 20ms, 40ms... between polls
+	assert.Less(t, atomic.LoadInt32(&failing.polls), int32(10))
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, "site is not reachable", report.Components["managers/test/failing"].Error)
+	assert.Equal(t, health.StatusOK, report.Components["managers/test/healthy"].Status)
+}
+
+func TestCombineErrors(t *testing.T) {
+	assert.Nil(t, combineErrors(nil))
+	assert.Nil(t, combineErrors([]error{nil}))
+	assert.Equal(t, "a; b", combineErrors([]error{fmt.Errorf("a"), nil, fmt.Errorf("b")}).Error())
+}
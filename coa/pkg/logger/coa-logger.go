@@ -7,6 +7,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -26,6 +27,7 @@ var DaprVersion string = "unknown"
 func newDaprLogger(name string) *daprLogger {
 	newLogger := logrus.New()
 	newLogger.SetOutput(os.Stdout)
+	newLogger.AddHook(traceHook{})
 
 	dl := &daprLogger{
 		name: name,
@@ -99,6 +101,14 @@ func (l *daprLogger) WithLogType(logType string) Logger {
 	}
 }
 
+// WithContext returns a logger that adds the trace_id and span_id of the span in ctx to the log
+func (l *daprLogger) WithContext(ctx context.Context) Logger {
+	return &daprLogger{
+		name:   l.name,
+		logger: l.logger.WithContext(ctx),
+	}
+}
+
 // Info logs a message at level Info.
 func (l *daprLogger) Info(args ...interface{}) {
 	l.logger.Log(logrus.InfoLevel, args...)
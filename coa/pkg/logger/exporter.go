@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record is a log entry handed to log exporters
+type Record struct {
+	Time    time.Time
+	Level   LogLevel
+	Scope   string
+	Message string
+	// Fields holds the fields of the entry other than scope and message
+	Fields  map[string]interface{}
+	TraceID trace.TraceID
+	SpanID  trace.SpanID
+}
+
+// Exporter ships log records to a backend, such as an OpenTelemetry collector. Export is called on
+// the logging goroutine, so it must not block.
+type Exporter interface {
+	Export(record Record)
+}
+
+var exporters = []Exporter{}
+var exportersLock = sync.RWMutex{}
+
+// AddExporter sends the entries of all loggers, including loggers created later, to an exporter.
+// Entries below the output level of a logger aren't exported.
+func AddExporter(exporter Exporter) {
+	exportersLock.Lock()
+	defer exportersLock.Unlock()
+	exporters = append(exporters, exporter)
+}
+
+// RemoveExporter stops sending entries to an exporter
+func RemoveExporter(exporter Exporter) {
+	exportersLock.Lock()
+	defer exportersLock.Unlock()
+	for i, e := range exporters {
+		if e == exporter {
+			exporters = append(exporters[:i], exporters[i+1:]...)
+			return
+		}
+	}
+}
+
+// traceHook adds the trace and span IDs of the entry context to the entry, and hands the entry to
+// the registered exporters
+type traceHook struct{}
+
+func (h traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h traceHook) Fire(entry *logrus.Entry) error {
+	var sc trace.SpanContext
+	if entry.Context != nil {
+		sc = trace.SpanContextFromContext(entry.Context)
+		if sc.IsValid() {
+			entry.Data[logFieldTraceID] = sc.TraceID().String()
+			entry.Data[logFieldSpanID] = sc.SpanID().String()
+		}
+	}
+
+	exportersLock.RLock()
+	defer exportersLock.RUnlock()
+	if len(exporters) == 0 {
+		return nil
+	}
+	record := Record{
+		Time:    entry.Time,
+		Level:   toLogLevel(entry.Level.String()),
+		Message: entry.Message,
+		Fields:  make(map[string]interface{}),
+		TraceID: sc.TraceID(),
+		SpanID:  sc.SpanID(),
+	}
+	if entry.Level == logrus.WarnLevel {
+		// logrus names the level "warning"
+		record.Level = WarnLevel
+	}
+	for k, v := range entry.Data {
+		switch k {
+		case logFieldScope:
+			record.Scope, _ = v.(string)
+		case logFieldTraceID, logFieldSpanID:
+		default:
+			record.Fields[k] = v
+		}
+	}
+	for _, e := range exporters {
+		e.Export(record)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordingExporter struct {
+	records []Record
+}
+
+func (e *recordingExporter) Export(record Record) {
+	e.records = append(e.records, record)
+}
+
+func TestWithContext(t *testing.T) {
+	l := newDaprLogger("test.context")
+	buffer := &bytes.Buffer{}
+	l.logger.Logger.SetOutput(buffer)
+	exporter := &recordingExporter{}
+	AddExporter(exporter)
+	defer RemoveExporter(exporter)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	l.WithContext(ctx).Warnf("reconciling %s", "app")
+	l.Info("no span")
+
+	assert.Contains(t, buffer.String(), "trace_id=4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Contains(t, buffer.String(), "span_id=00f067aa0ba902b7")
+	assert.Equal(t, 2, len(exporter.records))
+	assert.Equal(t, WarnLevel, exporter.records[0].Level)
+	assert.Equal(t, "test.context", exporter.records[0].Scope)
+	assert.Equal(t, "reconciling app", exporter.records[0].Message)
+	assert.Equal(t, traceID, exporter.records[0].TraceID)
+	assert.Equal(t, spanID, exporter.records[0].SpanID)
+	assert.NotContains(t, exporter.records[0].Fields, "trace_id")
+	assert.False(t, exporter.records[1].TraceID.IsValid())
+
+	RemoveExporter(exporter)
+	l.Info("not exported")
+	assert.Equal(t, 2, len(exporter.records))
+}
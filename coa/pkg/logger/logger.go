@@ -7,6 +7,7 @@
 package logger
 
 import (
+	"context"
 	"strings"
 	"sync"
 )
@@ -26,6 +27,8 @@ const (
 	logFieldInstance  = "instance"
 	logFieldDaprVer   = "ver"
 	logFieldAppID     = "app_id"
+	logFieldTraceID   = "trace_id"
+	logFieldSpanID    = "span_id"
 )
 
 // LogLevel is Dapr Logger Level type
@@ -64,6 +67,8 @@ type Logger interface {
 
 	// WithLogType specify the log_type field in log. Default value is LogTypeLog
 	WithLogType(logType string) Logger
+	// WithContext returns a logger that adds the trace_id and span_id of the span in ctx to the log
+	WithContext(ctx context.Context) Logger
 
 	// Info logs a message at level Info.
 	Info(args ...interface{})
@@ -194,13 +194,22 @@ then
     echo
 
     test_folder "../pkg/apis/v1alpha2/bindings/http"
+    test_folder "../pkg/apis/v1alpha2/bindings/mqtt"
     test_folder "../pkg/apis/v1alpha2/cloudutils/azure"
+    test_folder "../pkg/apis/v1alpha2/health"
+    test_folder "../pkg/apis/v1alpha2/host"
     test_folder "../pkg/apis/v1alpha2/observability"
+    test_folder "../pkg/apis/v1alpha2/observability/exporters"
+    test_folder "../pkg/apis/v1alpha2/observability/metrics"
     test_folder "../pkg/apis/v1alpha2/providers/certs/autogen"
     test_folder "../pkg/apis/v1alpha2/providers/certs/localfile"
     test_folder "../pkg/apis/v1alpha2/providers/probe/rtsp"    
+    test_folder "../pkg/apis/v1alpha2/providers/pubsub"
+    test_folder "../pkg/apis/v1alpha2/providers/pubsub/conformance"
     test_folder "../pkg/apis/v1alpha2/providers/pubsub/memory"
+    test_folder "../pkg/apis/v1alpha2/providers/pubsub/nats"
     test_folder "../pkg/apis/v1alpha2/providers/pubsub/redis"
+    test_folder "../pkg/apis/v1alpha2/providers/queue/nats"
     test_folder "../pkg/apis/v1alpha2/providers/reference/customvision"
     test_folder "../pkg/apis/v1alpha2/providers/reference/k8s"
     test_folder "../pkg/apis/v1alpha2/providers/reference/mock"
@@ -208,6 +217,8 @@ then
     test_folder "../pkg/apis/v1alpha2/providers/states/httpstate"
     test_folder "../pkg/apis/v1alpha2/providers/states/memorystate"
     test_folder "../pkg/apis/v1alpha2/providers/uploader/azure/blob"
+    test_folder "../pkg/apis/v1alpha2/vendors"
+    test_folder "../pkg/logger"
 fi
 
 
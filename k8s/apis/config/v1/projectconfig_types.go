@@ -25,7 +25,30 @@ type ProjectConfig struct {
 
 	SyncIntervalSeconds uint `json:"syncIntervalSeconds,omitempty"`
 
+	// DeletionTimeoutSeconds is how long the controller waits for an instance's components to be removed
+	// before it reports the deletion as failed
+	DeletionTimeoutSeconds uint `json:"deletionTimeoutSeconds,omitempty"`
+	// DeletionPollIntervalSeconds is how often the controller checks the progress of a deletion
+	DeletionPollIntervalSeconds uint `json:"deletionPollIntervalSeconds,omitempty"`
+
 	ValidationPolicies map[string][]ValidationPolicy `json:"validationPolicies,omitempty"`
+
+	SymphonyAPI SymphonyAPIConfig `json:"symphonyApi,omitempty"`
+}
+
+// SymphonyAPIConfig describes how the controllers reach the Symphony API.
+// Each field can be overridden with the matching SYMPHONY_API_* environment variable.
+type SymphonyAPIConfig struct {
+	// Endpoint is the base URL of the Symphony API, for example https://symphony-service:8081/v1alpha2/
+	Endpoint string `json:"endpoint,omitempty"`
+	// User and PasswordFile are exchanged for a token through the users/auth route
+	User         string `json:"user,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// TokenFile is a bearer token mounted from a secret; it takes precedence over User
+	TokenFile string `json:"tokenFile,omitempty"`
+	// CACertFile is a PEM bundle used to verify the API's TLS certificate
+	CACertFile         string `json:"caCertFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
 }
 
 type ValidationPolicy struct {
@@ -36,6 +36,7 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 			(*out)[key] = outVal
 		}
 	}
+	out.SymphonyAPI = in.SymphonyAPI
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectConfig.
@@ -56,6 +57,21 @@ func (in *ProjectConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SymphonyAPIConfig) DeepCopyInto(out *SymphonyAPIConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SymphonyAPIConfig.
+func (in *SymphonyAPIConfig) DeepCopy() *SymphonyAPIConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SymphonyAPIConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValidationPolicy) DeepCopyInto(out *ValidationPolicy) {
 	*out = *in
@@ -22,11 +22,15 @@ type TargetStatus struct {
 	Properties         map[string]string           `json:"properties,omitempty"`
 	ProvisioningStatus apimodel.ProvisioningStatus `json:"provisioningStatus"`
 	LastModified       metav1.Time                 `json:"lastModified,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.properties.status`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // Target is the Schema for the targets API
 type Target struct {
 	metav1.TypeMeta   `json:",inline"`
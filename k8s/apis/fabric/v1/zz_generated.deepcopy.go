@@ -12,6 +12,7 @@
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -167,6 +168,13 @@ func (in *TargetStatus) DeepCopyInto(out *TargetStatus) {
 	}
 	in.ProvisioningStatus.DeepCopyInto(&out.ProvisioningStatus)
 	in.LastModified.DeepCopyInto(&out.LastModified)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetStatus.
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	k8smodel "github.com/eclipse-symphony/symphony/k8s/apis/model/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type CatalogRevisionSpec struct {
+	Catalog   string               `json:"catalog"`
+	Revision  int64                `json:"revision"`
+	CreatedAt string               `json:"createdAt,omitempty"`
+	Snapshot  k8smodel.CatalogSpec `json:"snapshot"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Catalog",type=string,JSONPath=`.spec.catalog`
+// +kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.spec.revision`
+// CatalogRevision is the Schema for the catalogrevisions API
+type CatalogRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CatalogRevisionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogRevisionList contains a list of CatalogRevision
+type CatalogRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CatalogRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CatalogRevision{}, &CatalogRevisionList{})
+}
@@ -74,6 +74,80 @@ func (in *CatalogList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevision) DeepCopyInto(out *CatalogRevision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevision.
+func (in *CatalogRevision) DeepCopy() *CatalogRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogRevision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevisionList) DeepCopyInto(out *CatalogRevisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CatalogRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevisionList.
+func (in *CatalogRevisionList) DeepCopy() *CatalogRevisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogRevisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevisionSpec) DeepCopyInto(out *CatalogRevisionSpec) {
+	*out = *in
+	in.Snapshot.DeepCopyInto(&out.Snapshot)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevisionSpec.
+func (in *CatalogRevisionSpec) DeepCopy() *CatalogRevisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CatalogStatus) DeepCopyInto(out *CatalogStatus) {
 	*out = *in
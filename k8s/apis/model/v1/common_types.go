@@ -24,6 +24,8 @@ type ComponentSpec struct {
 	Constraints  string               `json:"constraints,omitempty"`
 	Dependencies []string             `json:"dependencies,omitempty"`
 	Skills       []string             `json:"skills,omitempty"`
+	ForEach      string               `json:"forEach,omitempty"`
+	Condition    string               `json:"condition,omitempty"`
 }
 
 // Defines the desired state of Target
@@ -17,11 +17,30 @@ type InstanceStatus struct {
 	Properties         map[string]string           `json:"properties,omitempty"`
 	ProvisioningStatus apimodel.ProvisioningStatus `json:"provisioningStatus"`
 	LastModified       metav1.Time                 `json:"lastModified,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	Deletion   *DeletionStatus    `json:"deletion,omitempty"`
+}
+
+const (
+	// DeletionQueued means the removal job was queued and the controller is waiting for it to complete
+	DeletionQueued = "Queued"
+	// DeletionFailed means the removal didn't complete within the deletion timeout
+	DeletionFailed = "Failed"
+)
+
+// DeletionStatus tracks the removal of the deployed components after an Instance is deleted
+type DeletionStatus struct {
+	Phase     string      `json:"phase,omitempty"`
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	Message   string      `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.properties.status`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Targets",type=string,JSONPath=`.status.properties.targets`
 // +kubebuilder:printcolumn:name="Deployed",type=string,JSONPath=`.status.properties.deployed`
 
@@ -9,8 +9,13 @@ package v1
 import (
 	"context"
 	"fmt"
+	"reflect"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -61,14 +66,25 @@ var _ webhook.Validator = &Instance{}
 func (r *Instance) ValidateCreate() error {
 	instancelog.Info("validate create", "name", r.Name)
 
-	return r.validateCreateInstance()
+	if err := r.validateCreateInstance(); err != nil {
+		return err
+	}
+	return r.validateSolutionAndTargets()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Instance) ValidateUpdate(old runtime.Object) error {
 	instancelog.Info("validate update", "name", r.Name)
 
-	return r.validateUpdateInstance()
+	oldInstance, ok := old.(*Instance)
+	if ok && reflect.DeepEqual(oldInstance.Spec, r.Spec) {
+		// metadata-only changes, such as finalizer removal, must not be blocked by solutions or targets that changed since
+		return r.validateUpdateInstance()
+	}
+	if err := r.validateUpdateInstance(); err != nil {
+		return err
+	}
+	return r.validateSolutionAndTargets()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -99,3 +115,40 @@ func (r *Instance) validateUpdateInstance() error {
 	}
 	return nil
 }
+
+// validateSolutionAndTargets rejects instances whose solution doesn't exist or that match no targets, and checks the
+// solution's components against the validation rules of the providers on the matched targets
+func (r *Instance) validateSolutionAndTargets() error {
+	if !r.ObjectMeta.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	var allErrs field.ErrorList
+	solutionPath := field.NewPath("spec").Child("solution")
+	solution := &Solution{}
+	err := myInstanceClient.Get(context.Background(), types.NamespacedName{Name: r.Spec.Solution, Namespace: r.Namespace}, solution)
+	if apierrors.IsNotFound(err) {
+		allErrs = append(allErrs, field.NotFound(solutionPath, r.Spec.Solution))
+	} else if err != nil {
+		allErrs = append(allErrs, field.InternalError(solutionPath, err))
+		return apierrors.NewInvalid(schema.GroupKind{Group: "solution.symphony", Kind: "Instance"}, r.Name, allErrs)
+	}
+
+	targets, err := matchInstanceTargets(context.Background(), myInstanceClient, r)
+	if err != nil {
+		allErrs = append(allErrs, field.InternalError(field.NewPath("spec").Child("target"), err))
+		return apierrors.NewInvalid(schema.GroupKind{Group: "solution.symphony", Kind: "Instance"}, r.Name, allErrs)
+	}
+	if len(targets) == 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("target"), r.Spec.Target, "no targets match the instance"))
+	}
+
+	if len(allErrs) == 0 {
+		for _, e := range validateComponentsForTargets(solution.Spec.Components, targets) {
+			allErrs = append(allErrs, field.Invalid(solutionPath, r.Spec.Solution, fmt.Sprintf("component '%s': %s", e.Component, e.Error())))
+		}
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(schema.GroupKind{Group: "solution.symphony", Kind: "Instance"}, r.Name, allErrs)
+	}
+	return nil
+}
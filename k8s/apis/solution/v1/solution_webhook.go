@@ -9,8 +9,12 @@ package v1
 import (
 	"context"
 	"fmt"
+	"reflect"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -57,14 +61,24 @@ var _ webhook.Validator = &Solution{}
 func (r *Solution) ValidateCreate() error {
 	solutionlog.Info("validate create", "name", r.Name)
 
-	return r.validateCreateSolution()
+	if err := r.validateCreateSolution(); err != nil {
+		return err
+	}
+	return r.validateComponents()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Solution) ValidateUpdate(old runtime.Object) error {
 	solutionlog.Info("validate update", "name", r.Name)
 
-	return r.validateUpdateSolution()
+	oldSolution, ok := old.(*Solution)
+	if ok && reflect.DeepEqual(oldSolution.Spec, r.Spec) {
+		return r.validateUpdateSolution()
+	}
+	if err := r.validateUpdateSolution(); err != nil {
+		return err
+	}
+	return r.validateComponents()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -94,3 +108,42 @@ func (r *Solution) validateUpdateSolution() error {
 	}
 	return nil
 }
+
+// validateComponents checks the components against the validation rules of the providers on the targets matched by
+// the instances that use this solution
+func (r *Solution) validateComponents() error {
+	var instances InstanceList
+	err := mySolutionClient.List(context.Background(), &instances, client.InNamespace(r.Namespace), client.MatchingFields{"spec.solution": r.Name})
+	if err != nil {
+		return apierrors.NewInvalid(schema.GroupKind{Group: "solution.symphony", Kind: "Solution"}, r.Name,
+			field.ErrorList{field.InternalError(&field.Path{}, err)})
+	}
+	var allErrs field.ErrorList
+	reported := make(map[string]bool)
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+			continue
+		}
+		targets, err := matchInstanceTargets(context.Background(), mySolutionClient, instance)
+		if err != nil {
+			allErrs = append(allErrs, field.InternalError(&field.Path{}, err))
+			break
+		}
+		for _, e := range validateComponentsForTargets(r.Spec.Components, targets) {
+			path := field.NewPath("spec").Child("components")
+			if e.Index >= 0 {
+				path = path.Index(e.Index)
+			}
+			detail := fmt.Sprintf("instance '%s', %s", instance.Name, e.Error())
+			if !reported[path.String()+e.Error()] {
+				reported[path.String()+e.Error()] = true
+				allErrs = append(allErrs, field.Invalid(path, e.Component, detail))
+			}
+		}
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(schema.GroupKind{Group: "solution.symphony", Kind: "Solution"}, r.Name, allErrs)
+	}
+	return nil
+}
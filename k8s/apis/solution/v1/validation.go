@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	fabricv1 "gopls-workspace/apis/fabric/v1"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/validation"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	k8smodel "github.com/eclipse-symphony/symphony/k8s/apis/model/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// componentError reports a component that can't be deployed to one of its targets
+type componentError struct {
+	Index     int
+	Component string
+	Target    string
+	Provider  string
+	Err       error
+}
+
+func (e componentError) Error() string {
+	if e.Provider == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("target '%s' (%s): %s", e.Target, e.Provider, e.Err.Error())
+}
+
+// matchInstanceTargets returns the targets in the instance's namespace that the instance's target selector matches
+func matchInstanceTargets(ctx context.Context, c client.Client, instance *Instance) ([]fabricv1.Target, error) {
+	var targets fabricv1.TargetList
+	if err := c.List(ctx, &targets, client.InNamespace(instance.Namespace)); err != nil {
+		return nil, err
+	}
+	spec := instance.Spec
+	states := make([]model.TargetState, 0, len(targets.Items))
+	byName := make(map[string]fabricv1.Target, len(targets.Items))
+	for _, t := range targets.Items {
+		states = append(states, model.TargetState{Id: t.Name, Spec: &model.TargetSpec{Properties: t.Spec.Properties}})
+		byName[t.Name] = t
+	}
+	matched := api_utils.MatchTargets(model.InstanceState{Id: instance.Name, Spec: &spec}, states)
+	ret := make([]fabricv1.Target, 0, len(matched))
+	for _, m := range matched {
+		ret = append(ret, byName[m.Id])
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+// validateComponentsForTargets assigns the components to the targets the same way a deployment does and checks each
+// assigned component against the validation rule of the provider bound to the component's type on that target.
+// Bindings with provider types unknown to this build are skipped.
+func validateComponentsForTargets(components []k8smodel.ComponentSpec, targets []fabricv1.Target) []componentError {
+	errs := make([]componentError, 0)
+	sComponents := make([]model.ComponentSpec, len(components))
+	for i, c := range components {
+		data, _ := json.Marshal(c)
+		if err := json.Unmarshal(data, &sComponents[i]); err != nil {
+			errs = append(errs, componentError{Index: i, Component: c.Name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	sTargets := make(map[string]model.TargetSpec, len(targets))
+	for _, t := range targets {
+		var target model.TargetSpec
+		data, _ := json.Marshal(t.Spec)
+		if err := json.Unmarshal(data, &target); err != nil {
+			return []componentError{{Index: -1, Target: t.Name, Err: err}}
+		}
+		sTargets[t.Name] = target
+	}
+	assignments, err := api_utils.AssignComponentsToTargets(sComponents, sTargets)
+	if err != nil {
+		return []componentError{{Index: -1, Err: err}}
+	}
+
+	for _, t := range targets {
+		for i, c := range sComponents {
+			if !containsComponent(assignments[t.Name], c.Name) {
+				continue
+			}
+			provider := findBindingProvider(sTargets[t.Name], c.Type)
+			rule, ok := validation.GetTargetValidationRule(provider)
+			if !ok {
+				continue
+			}
+			if err := rule.Validate([]model.ComponentSpec{c}); err != nil {
+				errs = append(errs, componentError{Index: i, Component: c.Name, Target: t.Name, Provider: provider, Err: err})
+			}
+		}
+	}
+	return errs
+}
+
+// findBindingProvider returns the provider bound to the role of a component type, following the same role mapping
+// as the solution manager
+func findBindingProvider(target model.TargetSpec, componentType string) string {
+	role := componentType
+	if role == "" || role == "container" {
+		role = "instance"
+	}
+	for _, topology := range target.Topologies {
+		for _, binding := range topology.Bindings {
+			if binding.Role == role {
+				return binding.Provider
+			}
+		}
+	}
+	return ""
+}
+
+func containsComponent(assignment string, name string) bool {
+	return strings.Contains(assignment, "{"+name+"}")
+}
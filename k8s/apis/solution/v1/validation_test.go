@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	"testing"
+
+	fabricv1 "gopls-workspace/apis/fabric/v1"
+
+	apimodel "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	k8smodel "github.com/eclipse-symphony/symphony/k8s/apis/model/v1"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func helmTarget(name string, properties map[string]string) *fabricv1.Target {
+	return &fabricv1.Target{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: k8smodel.TargetSpec{
+			Properties: properties,
+			Topologies: []apimodel.TopologySpec{
+				{
+					Bindings: []apimodel.BindingSpec{
+						{Role: "helm.v3", Provider: "providers.target.helm"},
+						{Role: "instance", Provider: "providers.target.k8s"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func component(name string, componentType string, properties string) k8smodel.ComponentSpec {
+	return k8smodel.ComponentSpec{
+		Name:       name,
+		Type:       componentType,
+		Properties: runtime.RawExtension{Raw: []byte(properties)},
+	}
+}
+
+func newValidationClient(t *testing.T, objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, AddToScheme(scheme))
+	assert.Nil(t, fabricv1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func TestValidateComponentsForTargets(t *testing.T) {
+	targets := []fabricv1.Target{*helmTarget("target-1", nil)}
+	errs := validateComponentsForTargets([]k8smodel.ComponentSpec{
+		component("chart", "helm.v3", `{"chart":{"repo":"oci://registry/chart"}}`),
+		component("no-chart", "helm.v3", `{"values":{}}`),
+		component("no-image", "container", `{}`),
+		component("unbound", "mqtt", `{}`),
+	}, targets)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, 1, errs[0].Index)
+	assert.Equal(t, "target 'target-1' (providers.target.helm): required property 'chart' is missing", errs[0].Error())
+	assert.Equal(t, 2, errs[1].Index)
+	assert.Contains(t, errs[1].Error(), "container.image")
+}
+
+func TestValidateComponentsSkipsUnassignedTargets(t *testing.T) {
+	c := component("no-chart", "helm.v3", `{}`)
+	c.Constraints = "${{$equal($property(OS), 'linux')}}"
+	errs := validateComponentsForTargets([]k8smodel.ComponentSpec{c}, []fabricv1.Target{
+		*helmTarget("windows", map[string]string{"OS": "windows"}),
+	})
+	assert.Len(t, errs, 0)
+}
+
+func TestValidateInstanceMissingSolution(t *testing.T) {
+	myInstanceClient = newValidationClient(t, helmTarget("target-1", nil))
+	instance := &Instance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-1", Namespace: "default"},
+		Spec:       apimodel.InstanceSpec{Solution: "missing", Target: apimodel.TargetSelector{Name: "target-1"}},
+	}
+	err := instance.validateSolutionAndTargets()
+	assert.True(t, apierrors.IsInvalid(err))
+	statusErr := err.(*apierrors.StatusError)
+	assert.Equal(t, "spec.solution", statusErr.ErrStatus.Details.Causes[0].Field)
+}
+
+func TestValidateInstanceNoMatchingTargets(t *testing.T) {
+	solution := &Solution{ObjectMeta: metav1.ObjectMeta{Name: "solution-1", Namespace: "default"}}
+	myInstanceClient = newValidationClient(t, solution, helmTarget("target-1", map[string]string{"OS": "linux"}))
+	instance := &Instance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-1", Namespace: "default"},
+		Spec: apimodel.InstanceSpec{
+			Solution: "solution-1",
+			Target:   apimodel.TargetSelector{Selector: map[string]string{"OS": "windows"}},
+		},
+	}
+	err := instance.validateSolutionAndTargets()
+	assert.True(t, apierrors.IsInvalid(err))
+	statusErr := err.(*apierrors.StatusError)
+	assert.Equal(t, "spec.target", statusErr.ErrStatus.Details.Causes[0].Field)
+}
+
+func TestValidateInstanceComponents(t *testing.T) {
+	solution := &Solution{
+		ObjectMeta: metav1.ObjectMeta{Name: "solution-1", Namespace: "default"},
+		Spec: k8smodel.SolutionSpec{
+			Components: []k8smodel.ComponentSpec{component("web", "helm.v3", `{}`)},
+		},
+	}
+	myInstanceClient = newValidationClient(t, solution, helmTarget("target-1", nil))
+	instance := &Instance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-1", Namespace: "default"},
+		Spec:       apimodel.InstanceSpec{Solution: "solution-1", Target: apimodel.TargetSelector{Name: "target-1"}},
+	}
+	err := instance.validateSolutionAndTargets()
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "component 'web': target 'target-1' (providers.target.helm): required property 'chart' is missing")
+
+	solution.Spec.Components[0].Properties = runtime.RawExtension{Raw: []byte(`{"chart":{"name":"web"}}`)}
+	myInstanceClient = newValidationClient(t, solution, helmTarget("target-1", nil))
+	assert.Nil(t, instance.validateSolutionAndTargets())
+}
@@ -12,9 +12,26 @@
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionStatus) DeepCopyInto(out *DeletionStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionStatus.
+func (in *DeletionStatus) DeepCopy() *DeletionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Instance) DeepCopyInto(out *Instance) {
 	*out = *in
@@ -86,6 +103,18 @@ func (in *InstanceStatus) DeepCopyInto(out *InstanceStatus) {
 	}
 	in.ProvisioningStatus.DeepCopyInto(&out.ProvisioningStatus)
 	in.LastModified.DeepCopyInto(&out.LastModified)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Deletion != nil {
+		in, out := &in.Deletion, &out.Deletion
+		*out = new(DeletionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceStatus.
@@ -25,6 +25,16 @@ var (
 )
 
 func GetValidationPoilicies() (map[string][]configv1.ValidationPolicy, error) {
+	myConfig, err := GetProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	return myConfig.ValidationPolicies, nil
+}
+
+// GetProjectConfig reads the controller configuration from the config map named by CONFIG_NAME
+func GetProjectConfig() (configv1.ProjectConfig, error) {
+	var myConfig configv1.ProjectConfig
 	// home := homedir.HomeDir()
 	// // use the current context in kubeconfig
 	// config, err := clientcmd.BuildConfigFromFlags("", filepath.Join(home, ".kube", "config"))
@@ -37,32 +47,27 @@ func GetValidationPoilicies() (map[string][]configv1.ValidationPolicy, error) {
 
 	config, err := rest.InClusterConfig()
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
 	namespace, err := getNamespace()
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
 	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configName, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
-	var myConfig configv1.ProjectConfig
 	data := configMap.Data["controller_manager_config.yaml"]
 	err = yaml.Unmarshal([]byte(data), &myConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	return myConfig.ValidationPolicies, nil
+	return myConfig, err
 }
 func getNamespace() (string, error) {
 	// read the namespace from the file
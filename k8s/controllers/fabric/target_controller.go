@@ -21,10 +21,11 @@ import (
 	provisioningstates "gopls-workspace/utils/models"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
-	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -36,11 +37,17 @@ import (
 type TargetReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// SymphonyAPI is the client used to queue deployment jobs and read their summaries
+	SymphonyAPI *utils.SymphonyAPIClient
+	// Recorder emits events when the status conditions change
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=fabric.symphony,resources=targets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=fabric.symphony,resources=targets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=fabric.symphony,resources=targets/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -77,7 +84,8 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			}
 		}
 
-		summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
+		summary, err := r.SymphonyAPI.GetSummary(ctx, fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
+		conditionChanged := utils.SetAPIConnectionCondition(&target.Status.Conditions, target.GetGeneration(), err)
 		if err != nil && !v1alpha2.IsNotFound(err) {
 			uErr := r.updateTargetStatusToReconciling(target, err)
 			if uErr != nil {
@@ -99,7 +107,10 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
 		} else {
 			// Queue a job every 60s or when the generation is changed
-			err = api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", target.ObjectMeta.Name, target.ObjectMeta.Namespace, false, true)
+			err = r.SymphonyAPI.QueueJob(ctx, target.ObjectMeta.Name, target.ObjectMeta.Namespace, false, true)
+			if utils.SetAPIConnectionCondition(&target.Status.Conditions, target.GetGeneration(), err) {
+				conditionChanged = true
+			}
 			if err != nil {
 				uErr := r.updateTargetStatusToReconciling(target, err)
 				if uErr != nil {
@@ -119,6 +130,10 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 				if err != nil {
 					return ctrl.Result{}, err
 				}
+			} else if conditionChanged {
+				if err = r.Status().Update(ctx, target); err != nil {
+					return ctrl.Result{}, err
+				}
 			}
 
 			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
@@ -126,7 +141,13 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	} else { // remove
 		if controllerutil.ContainsFinalizer(target, myFinalizerName) {
-			err := api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", target.ObjectMeta.Name, target.ObjectMeta.Namespace, true, true)
+			if !meta.IsStatusConditionTrue(target.Status.Conditions, utils.ConditionDeleting) {
+				if err := r.updateTargetStatusToReconciling(target, nil); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			err := r.SymphonyAPI.QueueJob(ctx, target.ObjectMeta.Name, target.ObjectMeta.Namespace, true, true)
+			utils.SetAPIConnectionCondition(&target.Status.Conditions, target.GetGeneration(), err)
 
 			if err != nil {
 				uErr := r.updateTargetStatusToReconciling(target, err)
@@ -144,7 +165,7 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 					// Timeout exceeded, assume deletion failed and proceed with finalization
 					break loop
 				case <-ticker:
-					summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
+					summary, err := r.SymphonyAPI.GetSummary(ctx, fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
 					if err == nil && summary.Summary.IsRemoval == true && summary.Summary.SuccessCount == summary.Summary.TargetCount {
 						break loop
 					}
@@ -179,8 +200,22 @@ func (r *TargetReconciler) updateTargetStatusToReconciling(target *symphonyv1.Ta
 		target.Status.Properties["status-details"] = fmt.Sprintf("Reconciling due to %s", err.Error())
 	}
 	r.updateProvisioningStatusToReconciling(target, err)
+	var changed []metav1.Condition
+	if target.ObjectMeta.DeletionTimestamp.IsZero() {
+		changed = utils.SetReconcilingConditions(&target.Status.Conditions, target.GetGeneration(), err)
+	} else {
+		message := "removing deployed components"
+		if err != nil {
+			message = err.Error()
+		}
+		changed = utils.SetDeletingConditions(&target.Status.Conditions, target.GetGeneration(), message)
+	}
 	target.Status.LastModified = metav1.Now()
-	return r.Status().Update(context.Background(), target)
+	if err := r.Status().Update(context.Background(), target); err != nil {
+		return err
+	}
+	utils.RecordConditionEvents(r.Recorder, target, changed)
+	return nil
 }
 func (r *TargetReconciler) updateTargetStatus(target *symphonyv1.Target, summary model.SummarySpec) error {
 	if target.Status.Properties == nil {
@@ -215,8 +250,13 @@ func (r *TargetReconciler) updateTargetStatus(target *symphonyv1.Target, summary
 	}
 
 	r.updateProvisioningStatus(target, status, summary)
+	changed := utils.SetSummaryConditions(&target.Status.Conditions, target.GetGeneration(), summary)
 	target.Status.LastModified = metav1.Now()
-	return r.Status().Update(context.Background(), target)
+	if err := r.Status().Update(context.Background(), target); err != nil {
+		return err
+	}
+	utils.RecordConditionEvents(r.Recorder, target, changed)
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -16,14 +16,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	federationv1 "gopls-workspace/apis/federation/v1"
-
-	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"gopls-workspace/utils"
 )
 
 // CatalogReconciler reconciles a Site object
 type CatalogReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// SymphonyAPI is the client used to forward catalog changes to Symphony
+	SymphonyAPI *utils.SymphonyAPIClient
 }
 
 //+kubebuilder:rbac:groups=federation.symphony,resources=catalogs,verbs=get;list;watch;create;update;patch;delete
@@ -49,7 +51,7 @@ func (r *CatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	if catalog.ObjectMeta.DeletionTimestamp.IsZero() { // update
 		jData, _ := json.Marshal(catalog.Spec)
-		err := api_utils.CatalogHook(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", jData)
+		err := r.SymphonyAPI.CatalogHook(ctx, jData)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
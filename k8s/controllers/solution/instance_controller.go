@@ -21,11 +21,15 @@ import (
 	apimodel "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	provisioningstates "github.com/eclipse-symphony/symphony/k8s/utils/models"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
@@ -33,19 +37,47 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
-	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 )
 
 // InstanceReconciler reconciles a Instance object
 type InstanceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// SymphonyAPI is the client used to queue deployment jobs and read their summaries
+	SymphonyAPI *utils.SymphonyAPIClient
+	// Recorder emits events when the status conditions change
+	Recorder record.EventRecorder
+	// DeletionTimeout is how long to wait for the deployed components to be removed before the deletion is
+	// reported as failed. The finalizer is kept unless the instance has the force-delete annotation.
+	DeletionTimeout time.Duration
+	// DeletionPollInterval is how often the progress of a deletion is checked
+	DeletionPollInterval time.Duration
+	// DriftCheckInterval is how often a deployment job is queued to detect and correct drift, unless the instance
+	// overrides it with the drift-check-interval annotation
+	DriftCheckInterval time.Duration
+	// Notifications, when set, triggers reconciles for the instances whose deployment summary changed. Periodic
+	// requeues are then only a safety net for lost notifications.
+	Notifications <-chan event.GenericEvent
 }
 
+const (
+	// ForceDeleteAnnotation lets a user remove the finalizer of an instance whose deletion timed out
+	ForceDeleteAnnotation = "instance.solution.symphony/force-delete"
+	// DriftCheckIntervalAnnotation overrides the drift check interval of an instance, as a duration such as "10m"
+	DriftCheckIntervalAnnotation = "instance.solution.symphony/drift-check-interval"
+
+	defaultDeletionTimeout      = 5 * time.Minute
+	defaultDeletionPollInterval = 10 * time.Second
+	defaultDriftCheckInterval   = 60 * time.Second
+	// requeues are spread over an extra 20% of the interval so that instances don't poll the API in lockstep
+	driftCheckJitter = 0.2
+)
+
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -81,7 +113,8 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			}
 		}
 
-		summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+		summary, err := r.SymphonyAPI.GetSummary(ctx, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+		conditionChanged := utils.SetAPIConnectionCondition(&instance.Status.Conditions, instance.GetGeneration(), err)
 		if err != nil && !v1alpha2.IsNotFound(err) {
 			uErr := r.updateInstanceStatusToReconciling(instance, err)
 			if uErr != nil {
@@ -95,15 +128,19 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			generationMatch = v == instance.GetGeneration()
 		}
 
-		if generationMatch && time.Since(summary.Time) <= time.Duration(60)*time.Second { //TODO: this is 60 second interval. Make if configurable?
+		interval := r.driftCheckInterval(ctx, instance)
+		if generationMatch && time.Since(summary.Time) <= interval {
 			err = r.updateInstanceStatus(instance, summary.Summary)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: wait.Jitter(interval, driftCheckJitter)}, nil
 		} else {
-			// Queue a job every 60s or when the generation is changed
-			err = api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, false, false)
+			// Queue a job once the drift check interval has passed or when the generation is changed
+			err = r.SymphonyAPI.QueueJob(ctx, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, false, false)
+			if utils.SetAPIConnectionCondition(&instance.Status.Conditions, instance.GetGeneration(), err) {
+				conditionChanged = true
+			}
 			if err != nil {
 				uErr := r.updateInstanceStatusToReconciling(instance, err)
 				if uErr != nil {
@@ -114,7 +151,7 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 			// Update status to Reconciling if there is a change on generation
 			// If users uninstall a component manually without modifying manifest
-			// files, jobs queued every 60s will catch the descrepdency and
+			// files, jobs queued every drift check interval will catch the descrepdency and
 			// re-deploy the uninstalled component. As users' behavior doesn't
 			// trigger generation change, this behavior won't change the status
 			// to reconciling.
@@ -123,49 +160,119 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 				if err != nil {
 					return ctrl.Result{}, err
 				}
+			} else if conditionChanged {
+				if err = r.Client.Status().Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
 			}
 
-			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: wait.Jitter(interval, driftCheckJitter)}, nil
 		}
 	} else { // delete
 		if controllerutil.ContainsFinalizer(instance, myFinalizerName) {
-			err := api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, true, false)
+			return r.reconcileDeletion(ctx, instance, myFinalizerName)
+		}
+	}
+	return ctrl.Result{}, nil
+}
 
-			if err != nil {
-				uErr := r.updateInstanceStatusToReconciling(instance, err)
-				if uErr != nil {
-					return ctrl.Result{}, uErr
-				}
-				return ctrl.Result{}, err
-			}
-			timeout := time.After(5 * time.Minute)
-			ticker := time.Tick(10 * time.Second) //TODO: configurable? adjust based on provider SLA?
-		loop:
-			for {
-				select {
-				case <-timeout:
-					// Timeout exceeded, assume deletion failed and proceed with finalization
-					break loop
-				case <-ticker:
-					summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
-					if err == nil && summary.Summary.IsRemoval == true && summary.Summary.SuccessCount == summary.Summary.TargetCount {
-						break loop
-					}
-					if err != nil && v1alpha2.IsNotFound(err) {
-						break loop
-					}
-				}
-			}
-			// NOTE: we assume the message backend provides at-least-once delivery so that the removal event will be eventually handled.
-			// Until the corresponding provider can successfully carry out the removal job, the job event will remain available for the
-			// provider to pick up.
-			controllerutil.RemoveFinalizer(instance, myFinalizerName)
-			if err := r.Client.Update(ctx, instance); err != nil {
-				return ctrl.Result{}, err
+// reconcileDeletion drives the removal of an instance's components without blocking the worker. The first pass
+// queues the removal job; later passes, scheduled with RequeueAfter, check the deployment summary until the
+// removal completes or the deletion times out.
+func (r *InstanceReconciler) reconcileDeletion(ctx context.Context, instance *symphonyv1.Instance, finalizerName string) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	if instance.Status.Deletion == nil {
+		err := r.SymphonyAPI.QueueJob(ctx, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, true, false)
+		utils.SetAPIConnectionCondition(&instance.Status.Conditions, instance.GetGeneration(), err)
+		if err != nil {
+			uErr := r.updateInstanceStatusToReconciling(instance, err)
+			if uErr != nil {
+				return ctrl.Result{}, uErr
 			}
+			return ctrl.Result{}, err
+		}
+		instance.Status.Deletion = &symphonyv1.DeletionStatus{
+			Phase:     symphonyv1.DeletionQueued,
+			StartTime: metav1.Now(),
 		}
+		if err := r.updateInstanceStatusToReconciling(instance, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.deletionPollInterval()}, nil
 	}
-	return ctrl.Result{}, nil
+
+	// NOTE: we assume the message backend provides at-least-once delivery so that the removal event will be eventually handled.
+	// Until the corresponding provider can successfully carry out the removal job, the job event will remain available for the
+	// provider to pick up.
+	summary, err := r.SymphonyAPI.GetSummary(ctx, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+	removed := (err == nil && summary.Summary.IsRemoval && summary.Summary.SuccessCount == summary.Summary.TargetCount) ||
+		(err != nil && v1alpha2.IsNotFound(err))
+	if removed {
+		controllerutil.RemoveFinalizer(instance, finalizerName)
+		return ctrl.Result{}, r.Client.Update(ctx, instance)
+	}
+
+	if time.Since(instance.Status.Deletion.StartTime.Time) < r.deletionTimeout() {
+		return ctrl.Result{RequeueAfter: r.deletionPollInterval()}, nil
+	}
+
+	if instance.ObjectMeta.Annotations[ForceDeleteAnnotation] == "true" {
+		log.Info("deletion timed out, removing finalizer because of the force-delete annotation")
+		if r.Recorder != nil {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, utils.ReasonForcedDeletion,
+				"finalizer removed by the force-delete annotation; deployed components may not have been removed")
+		}
+		controllerutil.RemoveFinalizer(instance, finalizerName)
+		return ctrl.Result{}, r.Client.Update(ctx, instance)
+	}
+
+	if instance.Status.Deletion.Phase != symphonyv1.DeletionFailed {
+		message := fmt.Sprintf("components were not removed within %s; set the %s=true annotation to remove the finalizer anyway",
+			r.deletionTimeout(), ForceDeleteAnnotation)
+		if err != nil {
+			message = fmt.Sprintf("%s (last error: %s)", message, err.Error())
+		}
+		instance.Status.Deletion.Phase = symphonyv1.DeletionFailed
+		instance.Status.Deletion.Message = message
+		changed := utils.SetDeletionFailedConditions(&instance.Status.Conditions, instance.GetGeneration(), message)
+		instance.Status.LastModified = metav1.Now()
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		utils.RecordConditionEvents(r.Recorder, instance, changed)
+	}
+	// keep checking, the removal job may still complete
+	return ctrl.Result{RequeueAfter: r.deletionPollInterval()}, nil
+}
+
+func (r *InstanceReconciler) deletionTimeout() time.Duration {
+	if r.DeletionTimeout > 0 {
+		return r.DeletionTimeout
+	}
+	return defaultDeletionTimeout
+}
+
+func (r *InstanceReconciler) deletionPollInterval() time.Duration {
+	if r.DeletionPollInterval > 0 {
+		return r.DeletionPollInterval
+	}
+	return defaultDeletionPollInterval
+}
+
+// driftCheckInterval returns the interval set by the instance's annotation, falling back to the reconciler's setting
+func (r *InstanceReconciler) driftCheckInterval(ctx context.Context, instance *symphonyv1.Instance) time.Duration {
+	if v, ok := instance.ObjectMeta.Annotations[DriftCheckIntervalAnnotation]; ok {
+		interval, err := time.ParseDuration(v)
+		if err == nil && interval > 0 {
+			return interval
+		}
+		ctrllog.FromContext(ctx).Info("ignoring invalid drift check interval", "annotation", DriftCheckIntervalAnnotation, "value", v)
+	}
+	if r.DriftCheckInterval > 0 {
+		return r.DriftCheckInterval
+	}
+	return defaultDriftCheckInterval
 }
 
 func (r *InstanceReconciler) ensureOperationState(instance *symphonyv1.Instance, provisioningState string) {
@@ -186,8 +293,22 @@ func (r *InstanceReconciler) updateInstanceStatusToReconciling(instance *symphon
 		instance.Status.Properties["status-details"] = fmt.Sprintf("Reconciling due to %s", err.Error())
 	}
 	r.updateProvisioningStatusToReconciling(instance, err)
+	var changed []metav1.Condition
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		changed = utils.SetReconcilingConditions(&instance.Status.Conditions, instance.GetGeneration(), err)
+	} else {
+		message := "removing deployed components"
+		if err != nil {
+			message = err.Error()
+		}
+		changed = utils.SetDeletingConditions(&instance.Status.Conditions, instance.GetGeneration(), message)
+	}
 	instance.Status.LastModified = metav1.Now()
-	return r.Client.Status().Update(context.Background(), instance)
+	if err := r.Client.Status().Update(context.Background(), instance); err != nil {
+		return err
+	}
+	utils.RecordConditionEvents(r.Recorder, instance, changed)
+	return nil
 }
 func (r *InstanceReconciler) updateInstanceStatus(instance *symphonyv1.Instance, summary model.SummarySpec) error {
 	if instance.Status.Properties == nil {
@@ -222,8 +343,13 @@ func (r *InstanceReconciler) updateInstanceStatus(instance *symphonyv1.Instance,
 	}
 
 	r.updateProvisioningStatus(instance, status, summary)
+	changed := utils.SetSummaryConditions(&instance.Status.Conditions, instance.GetGeneration(), summary)
 	instance.Status.LastModified = metav1.Now()
-	return r.Client.Status().Update(context.Background(), instance)
+	if err := r.Client.Status().Update(context.Background(), instance); err != nil {
+		return err
+	}
+	utils.RecordConditionEvents(r.Recorder, instance, changed)
+	return nil
 }
 
 func (r *InstanceReconciler) updateProvisioningStatus(instance *symphonyv1.Instance, provisioningStatus string, summary model.SummarySpec) {
@@ -283,7 +409,11 @@ func (r *InstanceReconciler) updateProvisioningStatusToReconciling(instance *sym
 func (r *InstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	generationChange := predicate.GenerationChangedPredicate{}
 	annotationChange := predicate.AnnotationChangedPredicate{}
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr)
+	if r.Notifications != nil {
+		builder = builder.Watches(&source.Channel{Source: r.Notifications}, &handler.EnqueueRequestForObject{})
+	}
+	return builder.
 		For(&symphonyv1.Instance{}).
 		WithEventFilter(predicate.Or(generationChange, annotationChange)).
 		Watches(&source.Kind{Type: &symphonyv1.Solution{}}, handler.EnqueueRequestsFromMapFunc(
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	solutionv1 "gopls-workspace/apis/solution/v1"
+	"gopls-workspace/utils"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testFinalizer = "instance.solution.symphony/finalizer"
+
+// fakeSymphonyAPI serves the queue routes used by the instance controller and returns the given summary
+func fakeSymphonyAPI(t *testing.T, summary *model.SummaryResult) (*httptest.Server, *int) {
+	queued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1alpha2/users/auth":
+			json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+		case r.URL.Path == "/v1alpha2/solution/queue" && r.Method == http.MethodPost:
+			queued++
+		case r.URL.Path == "/v1alpha2/solution/queue" && summary == nil:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1alpha2/solution/queue":
+			json.NewEncoder(w).Encode(summary)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &queued
+}
+
+func newDeletingInstanceReconciler(t *testing.T, summary *model.SummaryResult, annotations map[string]string) (*InstanceReconciler, client.Client, *int) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, solutionv1.AddToScheme(scheme))
+	now := metav1.Now()
+	instance := &solutionv1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "instance-1",
+			Namespace:         "default",
+			Finalizers:        []string{testFinalizer},
+			DeletionTimestamp: &now,
+			Annotations:       annotations,
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build()
+	server, queued := fakeSymphonyAPI(t, summary)
+	return &InstanceReconciler{
+		Client:      k8sClient,
+		Scheme:      scheme,
+		SymphonyAPI: &utils.SymphonyAPIClient{BaseUrl: server.URL + "/v1alpha2/", User: "admin"},
+	}, k8sClient, queued
+}
+
+func reconcileInstance(t *testing.T, reconciler *InstanceReconciler) ctrl.Result {
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "instance-1", Namespace: "default"},
+	})
+	assert.Nil(t, err)
+	return result
+}
+
+func getInstance(t *testing.T, k8sClient client.Client) (*solutionv1.Instance, error) {
+	instance := &solutionv1.Instance{}
+	err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "instance-1", Namespace: "default"}, instance)
+	return instance, err
+}
+
+// expireDeletion moves the deletion start time past the reconciler's timeout
+func expireDeletion(t *testing.T, k8sClient client.Client, reconciler *InstanceReconciler) {
+	instance, err := getInstance(t, k8sClient)
+	assert.Nil(t, err)
+	instance.Status.Deletion.StartTime = metav1.NewTime(time.Now().Add(-2 * reconciler.deletionTimeout()))
+	assert.Nil(t, k8sClient.Status().Update(context.Background(), instance))
+}
+
+func TestInstanceDeletionQueuesAndRequeues(t *testing.T) {
+	reconciler, k8sClient, queued := newDeletingInstanceReconciler(t, &model.SummaryResult{}, nil)
+	result := reconcileInstance(t, reconciler)
+	assert.Equal(t, defaultDeletionPollInterval, result.RequeueAfter)
+	assert.Equal(t, 1, *queued)
+
+	instance, err := getInstance(t, k8sClient)
+	assert.Nil(t, err)
+	assert.Equal(t, solutionv1.DeletionQueued, instance.Status.Deletion.Phase)
+	assert.True(t, meta.IsStatusConditionTrue(instance.Status.Conditions, utils.ConditionDeleting))
+
+	// the removal job isn't queued again while waiting
+	result = reconcileInstance(t, reconciler)
+	assert.Equal(t, defaultDeletionPollInterval, result.RequeueAfter)
+	assert.Equal(t, 1, *queued)
+}
+
+func TestInstanceDeletionCompletes(t *testing.T) {
+	reconciler, k8sClient, _ := newDeletingInstanceReconciler(t, &model.SummaryResult{
+		Summary: model.SummarySpec{IsRemoval: true, TargetCount: 1, SuccessCount: 1},
+	}, nil)
+	reconcileInstance(t, reconciler)
+	result := reconcileInstance(t, reconciler)
+	assert.Equal(t, ctrl.Result{}, result)
+	_, err := getInstance(t, k8sClient)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestInstanceDeletionTimeoutKeepsFinalizer(t *testing.T) {
+	reconciler, k8sClient, _ := newDeletingInstanceReconciler(t, &model.SummaryResult{}, nil)
+	reconcileInstance(t, reconciler)
+	expireDeletion(t, k8sClient, reconciler)
+
+	result := reconcileInstance(t, reconciler)
+	assert.Equal(t, defaultDeletionPollInterval, result.RequeueAfter)
+	instance, err := getInstance(t, k8sClient)
+	assert.Nil(t, err)
+	assert.Contains(t, instance.Finalizers, testFinalizer)
+	assert.Equal(t, solutionv1.DeletionFailed, instance.Status.Deletion.Phase)
+	deleting := meta.FindStatusCondition(instance.Status.Conditions, utils.ConditionDeleting)
+	assert.Equal(t, utils.ReasonDeletionFailed, deleting.Reason)
+}
+
+func TestInstanceDeletionTimeoutWithForceAnnotation(t *testing.T) {
+	reconciler, k8sClient, _ := newDeletingInstanceReconciler(t, &model.SummaryResult{}, map[string]string{
+		ForceDeleteAnnotation: "true",
+	})
+	reconcileInstance(t, reconciler)
+	expireDeletion(t, k8sClient, reconciler)
+
+	reconcileInstance(t, reconciler)
+	_, err := getInstance(t, k8sClient)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func newInstanceReconciler(t *testing.T, summary *model.SummaryResult, annotations map[string]string) (*InstanceReconciler, *int) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, solutionv1.AddToScheme(scheme))
+	instance := &solutionv1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "instance-1",
+			Namespace:   "default",
+			Finalizers:  []string{testFinalizer},
+			Annotations: annotations,
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build()
+	server, queued := fakeSymphonyAPI(t, summary)
+	return &InstanceReconciler{
+		Client:      k8sClient,
+		Scheme:      scheme,
+		SymphonyAPI: &utils.SymphonyAPIClient{BaseUrl: server.URL + "/v1alpha2/", User: "admin"},
+	}, queued
+}
+
+func TestInstanceDriftCheckUsesAnnotation(t *testing.T) {
+	reconciler, queued := newInstanceReconciler(t, &model.SummaryResult{Time: time.Now().Add(-5 * time.Minute)}, map[string]string{
+		DriftCheckIntervalAnnotation: "10m",
+	})
+	reconciler.DriftCheckInterval = time.Minute
+	result := reconcileInstance(t, reconciler)
+	assert.Equal(t, 0, *queued)
+	assert.GreaterOrEqual(t, result.RequeueAfter, 10*time.Minute)
+	assert.Less(t, result.RequeueAfter, 12*time.Minute)
+}
+
+func TestInstanceDriftCheckQueuesStaleSummary(t *testing.T) {
+	reconciler, queued := newInstanceReconciler(t, &model.SummaryResult{Time: time.Now().Add(-5 * time.Minute)}, nil)
+	reconciler.DriftCheckInterval = time.Minute
+	result := reconcileInstance(t, reconciler)
+	assert.Equal(t, 1, *queued)
+	assert.GreaterOrEqual(t, result.RequeueAfter, time.Minute)
+	assert.Less(t, result.RequeueAfter, 72*time.Second)
+}
+
+func TestDriftCheckIntervalFallback(t *testing.T) {
+	reconciler := &InstanceReconciler{}
+	instance := &solutionv1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{DriftCheckIntervalAnnotation: "soon"},
+		},
+	}
+	assert.Equal(t, defaultDriftCheckInterval, reconciler.driftCheckInterval(context.Background(), instance))
+	reconciler.DriftCheckInterval = 3 * time.Minute
+	assert.Equal(t, 3*time.Minute, reconciler.driftCheckInterval(context.Background(), instance))
+}
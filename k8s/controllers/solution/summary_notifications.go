@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	symphonyv1 "gopls-workspace/apis/solution/v1"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// SummaryNotificationPath is the route the Symphony API posts summary notifications to
+	SummaryNotificationPath = "/summary"
+
+	// deployments of targets are tracked under this prefix and aren't instances
+	targetRuntimePrefix = "target-runtime-"
+)
+
+// SummaryNotificationServer receives the notifications the Symphony API sends when a deployment summary is saved
+// and turns them into reconcile requests for the affected instances
+type SummaryNotificationServer struct {
+	BindAddress string
+	Events      chan event.GenericEvent
+}
+
+func NewSummaryNotificationServer(bindAddress string) *SummaryNotificationServer {
+	return &SummaryNotificationServer{
+		BindAddress: bindAddress,
+		Events:      make(chan event.GenericEvent),
+	}
+}
+
+func (s *SummaryNotificationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var notification model.SummaryNotification
+	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil || notification.Instance == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if strings.HasPrefix(notification.Instance, targetRuntimePrefix) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	namespace := notification.Scope
+	if namespace == "" {
+		namespace = "default"
+	}
+	instance := &symphonyv1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      notification.Instance,
+			Namespace: namespace,
+		},
+	}
+	select {
+	case s.Events <- event.GenericEvent{Object: instance}:
+		w.WriteHeader(http.StatusAccepted)
+	case <-r.Context().Done():
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+// Start serves notifications until the context is cancelled. It implements manager.Runnable.
+func (s *SummaryNotificationServer) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx)
+	mux := http.NewServeMux()
+	mux.Handle(SummaryNotificationPath, s)
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	log.Info("serving summary notifications", "address", s.BindAddress)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
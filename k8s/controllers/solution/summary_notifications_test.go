@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func postNotification(server *SummaryNotificationServer, notification model.SummaryNotification) int {
+	data, _ := json.Marshal(notification)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, SummaryNotificationPath, bytes.NewBuffer(data)))
+	return recorder.Code
+}
+
+func TestSummaryNotificationEnqueuesInstance(t *testing.T) {
+	server := NewSummaryNotificationServer("")
+	codes := make(chan int, 1)
+	go func() {
+		codes <- postNotification(server, model.SummaryNotification{Instance: "instance-1", Scope: "tenant-a"})
+	}()
+	select {
+	case e := <-server.Events:
+		assert.Equal(t, "instance-1", e.Object.GetName())
+		assert.Equal(t, "tenant-a", e.Object.GetNamespace())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "notification was not forwarded")
+	}
+	assert.Equal(t, http.StatusAccepted, <-codes)
+}
+
+func TestSummaryNotificationIgnoresTargets(t *testing.T) {
+	server := NewSummaryNotificationServer("")
+	assert.Equal(t, http.StatusAccepted, postNotification(server, model.SummaryNotification{Instance: "target-runtime-target-1"}))
+	assert.Len(t, server.Events, 0)
+}
+
+func TestSummaryNotificationRejectsInvalidPayload(t *testing.T) {
+	server := NewSummaryNotificationServer("")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, SummaryNotificationPath, bytes.NewBufferString("{")))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -13,6 +13,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -21,6 +22,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -30,7 +32,9 @@ import (
 	federationv1 "gopls-workspace/apis/federation/v1"
 	solutionv1 "gopls-workspace/apis/solution/v1"
 	workflowv1 "gopls-workspace/apis/workflow/v1"
+	"gopls-workspace/configutils"
 	"gopls-workspace/constants"
+	"gopls-workspace/utils"
 
 	aicontrollers "gopls-workspace/controllers/ai"
 	fabriccontrollers "gopls-workspace/controllers/fabric"
@@ -61,8 +65,11 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var configFile string
+	var notificationAddr string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&notificationAddr, "notification-bind-address", "", "The address the Symphony API summary notification endpoint binds to. "+
+		"Omit this flag to rely on periodic drift checks only.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -104,6 +111,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	if configFile == "" && os.Getenv("CONFIG_NAME") != "" {
+		if projectConfig, err := configutils.GetProjectConfig(); err == nil {
+			ctrlConfig = projectConfig
+		} else {
+			setupLog.Error(err, "unable to read the config map, using default controller settings")
+		}
+	}
+	apiConfig, err := utils.SymphonyAPIConfigFromEnv(ctrlConfig.SymphonyAPI)
+	if err != nil {
+		setupLog.Error(err, "invalid Symphony API settings")
+		os.Exit(1)
+	}
+	symphonyAPI, err := utils.NewSymphonyAPIClient(apiConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create Symphony API client")
+		os.Exit(1)
+	}
+	setupLog.Info("using Symphony API", "endpoint", symphonyAPI.BaseUrl)
+
 	if err = (&solutioncontrollers.SolutionReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -119,22 +145,40 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&workflowcontrollers.ActivationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		SymphonyAPI: symphonyAPI,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Activation")
 		os.Exit(1)
 	}
+	var notifications chan event.GenericEvent
+	if notificationAddr != "" {
+		notificationServer := solutioncontrollers.NewSummaryNotificationServer(notificationAddr)
+		if err = mgr.Add(notificationServer); err != nil {
+			setupLog.Error(err, "unable to set up summary notifications")
+			os.Exit(1)
+		}
+		notifications = notificationServer.Events
+	}
 	if err = (&solutioncontrollers.InstanceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		SymphonyAPI:          symphonyAPI,
+		Recorder:             mgr.GetEventRecorderFor("instance-controller"),
+		DeletionTimeout:      time.Duration(ctrlConfig.DeletionTimeoutSeconds) * time.Second,
+		DeletionPollInterval: time.Duration(ctrlConfig.DeletionPollIntervalSeconds) * time.Second,
+		DriftCheckInterval:   time.Duration(ctrlConfig.SyncIntervalSeconds) * time.Second,
+		Notifications:        notifications,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Instance")
 		os.Exit(1)
 	}
 	if err = (&fabriccontrollers.TargetReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		SymphonyAPI: symphonyAPI,
+		Recorder:    mgr.GetEventRecorderFor("target-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Target")
 		os.Exit(1)
@@ -175,8 +219,9 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&federationcontrollers.CatalogReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		SymphonyAPI: symphonyAPI,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Catalog")
 		os.Exit(1)
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ConditionAPIConnected reports whether the controller could reach and authenticate with the Symphony API
+	ConditionAPIConnected = "APIConnected"
+	// ConditionReady is true when the last deployment succeeded on all targets for the current generation
+	ConditionReady = "Ready"
+	// ConditionReconciling is true while a deployment for the current generation is in progress
+	ConditionReconciling = "Reconciling"
+	// ConditionDegraded is true when the last deployment failed on at least one target or component
+	ConditionDegraded = "Degraded"
+	// ConditionDeleting is true while the deployed components are being removed
+	ConditionDeleting = "Deleting"
+
+	ReasonConnected            = "Connected"
+	ReasonConnectionFailed     = "ConnectionFailed"
+	ReasonUnauthorized         = "Unauthorized"
+	ReasonInvalidConfiguration = "InvalidConfiguration"
+	ReasonDeploymentSucceeded  = "DeploymentSucceeded"
+	ReasonDeploymentFailed     = "DeploymentFailed"
+	ReasonReconciling          = "Reconciling"
+	ReasonReconcileError       = "ReconcileError"
+	ReasonDeleting             = "Deleting"
+	ReasonDeletionFailed       = "DeletionFailed"
+	ReasonForcedDeletion       = "ForcedDeletion"
+)
+
+// SetAPIConnectionCondition records the outcome of a Symphony API call as the APIConnected condition.
+// Errors returned by a reachable API, such as a missing summary, count as connected.
+// It returns true if the condition changed.
+func SetAPIConnectionCondition(conditions *[]metav1.Condition, generation int64, err error) bool {
+	condition := metav1.Condition{
+		Type:               ConditionAPIConnected,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonConnected,
+		Message:            "Symphony API is reachable",
+		ObservedGeneration: generation,
+	}
+	if err != nil {
+		coaErr, isCOAError := err.(v1alpha2.COAError)
+		switch {
+		case !isCOAError:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonConnectionFailed
+			condition.Message = err.Error()
+		case coaErr.State == v1alpha2.Unauthorized:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonUnauthorized
+			condition.Message = err.Error()
+		case coaErr.State == v1alpha2.BadConfig:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonInvalidConfiguration
+			condition.Message = err.Error()
+		}
+	}
+	existing := meta.FindStatusCondition(*conditions, ConditionAPIConnected)
+	changed := existing == nil || existing.Status != condition.Status || existing.Reason != condition.Reason ||
+		existing.Message != condition.Message || existing.ObservedGeneration != condition.ObservedGeneration
+	meta.SetStatusCondition(conditions, condition)
+	return changed
+}
+
+// SetSummaryConditions derives the Ready, Reconciling and Degraded conditions from a deployment summary.
+// It returns the conditions whose status or reason changed.
+func SetSummaryConditions(conditions *[]metav1.Condition, generation int64, summary model.SummarySpec) []metav1.Condition {
+	readyMessage := fmt.Sprintf("%d of %d targets deployed", summary.SuccessCount, summary.TargetCount)
+	if summary.SuccessCount == summary.TargetCount {
+		return setConditions(conditions,
+			newCondition(ConditionReady, metav1.ConditionTrue, ReasonDeploymentSucceeded, readyMessage, generation),
+			newCondition(ConditionReconciling, metav1.ConditionFalse, ReasonDeploymentSucceeded, "", generation),
+			newCondition(ConditionDegraded, metav1.ConditionFalse, ReasonDeploymentSucceeded, "", generation),
+		)
+	}
+	return setConditions(conditions,
+		newCondition(ConditionReady, metav1.ConditionFalse, ReasonDeploymentFailed, readyMessage, generation),
+		newCondition(ConditionReconciling, metav1.ConditionFalse, ReasonDeploymentFailed, "", generation),
+		newCondition(ConditionDegraded, metav1.ConditionTrue, ReasonDeploymentFailed, SummaryFailureDetails(summary), generation),
+	)
+}
+
+// SetReconcilingConditions marks the object as reconciling, optionally because of an error.
+// It returns the conditions whose status or reason changed.
+func SetReconcilingConditions(conditions *[]metav1.Condition, generation int64, err error) []metav1.Condition {
+	reason := ReasonReconciling
+	message := "deployment is in progress"
+	if err != nil {
+		reason = ReasonReconcileError
+		message = err.Error()
+	}
+	return setConditions(conditions,
+		newCondition(ConditionReady, metav1.ConditionFalse, reason, message, generation),
+		newCondition(ConditionReconciling, metav1.ConditionTrue, reason, message, generation),
+	)
+}
+
+// SetDeletingConditions marks the object as being deleted. It returns the conditions whose status or reason changed.
+func SetDeletingConditions(conditions *[]metav1.Condition, generation int64, message string) []metav1.Condition {
+	return setConditions(conditions,
+		newCondition(ConditionReady, metav1.ConditionFalse, ReasonDeleting, message, generation),
+		newCondition(ConditionDeleting, metav1.ConditionTrue, ReasonDeleting, message, generation),
+	)
+}
+
+// SetDeletionFailedConditions reports that the deployed components weren't removed in time.
+// It returns the conditions whose status or reason changed.
+func SetDeletionFailedConditions(conditions *[]metav1.Condition, generation int64, message string) []metav1.Condition {
+	return setConditions(conditions,
+		newCondition(ConditionDeleting, metav1.ConditionTrue, ReasonDeletionFailed, message, generation),
+		newCondition(ConditionDegraded, metav1.ConditionTrue, ReasonDeletionFailed, message, generation),
+	)
+}
+
+// SummaryFailureDetails lists the targets and components that didn't deploy successfully, sorted by name
+func SummaryFailureDetails(summary model.SummarySpec) string {
+	details := make([]string, 0)
+	for target, result := range summary.TargetResults {
+		if result.Status != "OK" {
+			details = append(details, fmt.Sprintf("target %s: %s", target, joinStatus(result.Status, result.Message)))
+		}
+		for component, componentResult := range result.ComponentResults {
+			if isFailedComponentState(componentResult.Status) {
+				details = append(details, fmt.Sprintf("component %s/%s: %s", target, component, joinStatus(componentResult.Status.String(), componentResult.Message)))
+			}
+		}
+	}
+	sort.Strings(details)
+	if len(details) == 0 {
+		return summary.SummaryMessage
+	}
+	return strings.Join(details, "; ")
+}
+
+// RecordConditionEvents emits an event for each changed condition. Degraded states and reconcile errors are
+// reported as warnings.
+func RecordConditionEvents(recorder record.EventRecorder, object runtime.Object, changed []metav1.Condition) {
+	if recorder == nil {
+		return
+	}
+	for _, condition := range changed {
+		eventType := corev1.EventTypeNormal
+		if (condition.Type == ConditionDegraded && condition.Status == metav1.ConditionTrue) ||
+			condition.Reason == ReasonReconcileError || condition.Reason == ReasonDeletionFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		message := fmt.Sprintf("%s is %s", condition.Type, condition.Status)
+		if condition.Message != "" {
+			message += ": " + condition.Message
+		}
+		recorder.Event(object, eventType, condition.Reason, message)
+	}
+}
+
+func newCondition(conditionType string, status metav1.ConditionStatus, reason string, message string, generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	}
+}
+
+func setConditions(conditions *[]metav1.Condition, newConditions ...metav1.Condition) []metav1.Condition {
+	changed := make([]metav1.Condition, 0)
+	for _, condition := range newConditions {
+		existing := meta.FindStatusCondition(*conditions, condition.Type)
+		if existing == nil || existing.Status != condition.Status || existing.Reason != condition.Reason {
+			changed = append(changed, condition)
+		}
+		meta.SetStatusCondition(conditions, condition)
+	}
+	return changed
+}
+
+func isFailedComponentState(state v1alpha2.State) bool {
+	switch state {
+	case v1alpha2.OK, v1alpha2.Accepted, v1alpha2.Updated, v1alpha2.Deleted, v1alpha2.Untouched:
+		return false
+	}
+	return true
+}
+
+func joinStatus(status string, message string) string {
+	if message == "" {
+		return status
+	}
+	return status + " - " + message
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	apimodel "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSetAPIConnectionCondition(t *testing.T) {
+	var conditions []metav1.Condition
+	assert.True(t, SetAPIConnectionCondition(&conditions, 1, errors.New("dial tcp: connection refused")))
+	condition := meta.FindStatusCondition(conditions, ConditionAPIConnected)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonConnectionFailed, condition.Reason)
+
+	assert.True(t, SetAPIConnectionCondition(&conditions, 1, v1alpha2.NewCOAError(nil, "forbidden", v1alpha2.Unauthorized)))
+	assert.Equal(t, ReasonUnauthorized, meta.FindStatusCondition(conditions, ConditionAPIConnected).Reason)
+
+	// a reachable API that reports a missing summary is still connected
+	assert.True(t, SetAPIConnectionCondition(&conditions, 1, v1alpha2.NewCOAError(nil, "not found", v1alpha2.NotFound)))
+	assert.True(t, meta.IsStatusConditionTrue(conditions, ConditionAPIConnected))
+	assert.False(t, SetAPIConnectionCondition(&conditions, 1, nil))
+	assert.Equal(t, 1, len(conditions))
+}
+func TestSetSummaryConditionsSucceeded(t *testing.T) {
+	var conditions []metav1.Condition
+	changed := SetSummaryConditions(&conditions, 2, apimodel.SummarySpec{TargetCount: 1, SuccessCount: 1})
+	assert.Equal(t, 3, len(changed))
+	assert.True(t, meta.IsStatusConditionTrue(conditions, ConditionReady))
+	assert.True(t, meta.IsStatusConditionFalse(conditions, ConditionDegraded))
+	assert.Equal(t, int64(2), meta.FindStatusCondition(conditions, ConditionReady).ObservedGeneration)
+
+	// no transition, no changes
+	changed = SetSummaryConditions(&conditions, 2, apimodel.SummarySpec{TargetCount: 1, SuccessCount: 1})
+	assert.Equal(t, 0, len(changed))
+}
+func TestSetSummaryConditionsFailed(t *testing.T) {
+	var conditions []metav1.Condition
+	SetReconcilingConditions(&conditions, 1, nil)
+	assert.True(t, meta.IsStatusConditionTrue(conditions, ConditionReconciling))
+	SetSummaryConditions(&conditions, 1, apimodel.SummarySpec{
+		TargetCount:  2,
+		SuccessCount: 1,
+		TargetResults: map[string]apimodel.TargetResultSpec{
+			"t2": {
+				Status: "Update Failed",
+				ComponentResults: map[string]apimodel.ComponentResultSpec{
+					"redis": {Status: v1alpha2.UpdateFailed, Message: "image not found"},
+					"nginx": {Status: v1alpha2.Updated},
+				},
+			},
+			"t1": {
+				Status: "OK",
+			},
+		},
+	})
+	assert.True(t, meta.IsStatusConditionFalse(conditions, ConditionReady))
+	assert.True(t, meta.IsStatusConditionFalse(conditions, ConditionReconciling))
+	degraded := meta.FindStatusCondition(conditions, ConditionDegraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, "component t2/redis: Update Failed - image not found; target t2: Update Failed", degraded.Message)
+}
+func TestSetDeletingConditions(t *testing.T) {
+	var conditions []metav1.Condition
+	SetSummaryConditions(&conditions, 1, apimodel.SummarySpec{TargetCount: 1, SuccessCount: 1})
+	changed := SetDeletingConditions(&conditions, 1, "removing deployed components")
+	assert.Equal(t, 2, len(changed))
+	assert.True(t, meta.IsStatusConditionFalse(conditions, ConditionReady))
+	assert.True(t, meta.IsStatusConditionTrue(conditions, ConditionDeleting))
+}
+func TestRecordConditionEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	var conditions []metav1.Condition
+	changed := SetSummaryConditions(&conditions, 1, apimodel.SummarySpec{TargetCount: 1, SuccessCount: 0, SummaryMessage: "failed"})
+	RecordConditionEvents(recorder, &corev1.Pod{}, changed)
+	assert.Equal(t, 3, len(recorder.Events))
+	events := []string{<-recorder.Events, <-recorder.Events, <-recorder.Events}
+	assert.Contains(t, events, "Warning DeploymentFailed Degraded is True: failed")
+}
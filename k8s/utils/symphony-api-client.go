@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	configv1 "gopls-workspace/apis/config/v1"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+const defaultSymphonyAPIUser = "admin"
+
+// SymphonyAPIClient calls the Symphony API on behalf of the controllers
+type SymphonyAPIClient struct {
+	BaseUrl  string
+	User     string
+	Password string
+	Options  api_utils.ClientOptions
+}
+
+// SymphonyAPIConfigFromEnv overrides the given configuration with the SYMPHONY_API_* environment variables that are set
+func SymphonyAPIConfigFromEnv(config configv1.SymphonyAPIConfig) (configv1.SymphonyAPIConfig, error) {
+	if v, ok := os.LookupEnv("SYMPHONY_API_URL"); ok {
+		config.Endpoint = v
+	}
+	if v, ok := os.LookupEnv("SYMPHONY_API_USER"); ok {
+		config.User = v
+	}
+	if v, ok := os.LookupEnv("SYMPHONY_API_PASSWORD_FILE"); ok {
+		config.PasswordFile = v
+	}
+	if v, ok := os.LookupEnv("SYMPHONY_API_TOKEN_FILE"); ok {
+		config.TokenFile = v
+	}
+	if v, ok := os.LookupEnv("SYMPHONY_API_CA_CERT_FILE"); ok {
+		config.CACertFile = v
+	}
+	if v, ok := os.LookupEnv("SYMPHONY_API_INSECURE_SKIP_VERIFY"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid value '%s' for SYMPHONY_API_INSECURE_SKIP_VERIFY: %w", v, err)
+		}
+		config.InsecureSkipVerify = b
+	}
+	return config, nil
+}
+
+// NewSymphonyAPIClient creates a client from the controller configuration. Unset values fall back to
+// the in-cluster symphony-service endpoint and the default admin user.
+func NewSymphonyAPIClient(config configv1.SymphonyAPIConfig) (*SymphonyAPIClient, error) {
+	client := &SymphonyAPIClient{
+		BaseUrl: config.Endpoint,
+		User:    config.User,
+		Options: api_utils.ClientOptions{
+			TokenFile:          config.TokenFile,
+			CACertFile:         config.CACertFile,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		},
+	}
+	if client.BaseUrl == "" {
+		client.BaseUrl = SymphonyAPIAddressBase
+	}
+	if !strings.HasSuffix(client.BaseUrl, "/") {
+		client.BaseUrl += "/"
+	}
+	if client.User == "" && client.Options.TokenFile == "" {
+		client.User = defaultSymphonyAPIUser
+	}
+	if config.PasswordFile != "" {
+		data, err := os.ReadFile(config.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Symphony API password from '%s': %w", config.PasswordFile, err)
+		}
+		client.Password = strings.TrimSpace(string(data))
+	}
+	return client, nil
+}
+
+func (c *SymphonyAPIClient) GetSummary(ctx context.Context, id string, scope string) (model.SummaryResult, error) {
+	return api_utils.GetSummary(api_utils.WithClientOptions(ctx, c.Options), c.BaseUrl, c.User, c.Password, id, scope)
+}
+
+func (c *SymphonyAPIClient) QueueJob(ctx context.Context, id string, scope string, isDelete bool, isTarget bool) error {
+	return api_utils.QueueJob(api_utils.WithClientOptions(ctx, c.Options), c.BaseUrl, c.User, c.Password, id, scope, isDelete, isTarget)
+}
+
+func (c *SymphonyAPIClient) CatalogHook(ctx context.Context, payload []byte) error {
+	return api_utils.CatalogHook(api_utils.WithClientOptions(ctx, c.Options), c.BaseUrl, c.User, c.Password, payload)
+}
+
+func (c *SymphonyAPIClient) PublishActivationEvent(ctx context.Context, event v1alpha2.ActivationData) error {
+	return api_utils.PublishActivationEvent(api_utils.WithClientOptions(ctx, c.Options), c.BaseUrl, c.User, c.Password, event)
+}
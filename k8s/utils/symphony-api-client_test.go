@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	configv1 "gopls-workspace/apis/config/v1"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSymphonyAPIClientDefaults(t *testing.T) {
+	client, err := NewSymphonyAPIClient(configv1.SymphonyAPIConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, SymphonyAPIAddressBase, client.BaseUrl)
+	assert.Equal(t, "admin", client.User)
+	assert.Equal(t, "", client.Options.TokenFile)
+}
+func TestNewSymphonyAPIClientTokenFile(t *testing.T) {
+	client, err := NewSymphonyAPIClient(configv1.SymphonyAPIConfig{
+		Endpoint:  "https://symphony-api.symphony-system:8081/v1alpha2",
+		TokenFile: "/etc/symphony-api/token",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://symphony-api.symphony-system:8081/v1alpha2/", client.BaseUrl)
+	assert.Equal(t, "", client.User)
+	assert.Equal(t, "/etc/symphony-api/token", client.Options.TokenFile)
+}
+func TestNewSymphonyAPIClientPasswordFile(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	assert.Nil(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+	client, err := NewSymphonyAPIClient(configv1.SymphonyAPIConfig{
+		User:         "operator",
+		PasswordFile: passwordFile,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "operator", client.User)
+	assert.Equal(t, "secret", client.Password)
+}
+func TestSymphonyAPIConfigFromEnv(t *testing.T) {
+	t.Setenv("SYMPHONY_API_URL", "https://my-api:8081/v1alpha2/")
+	t.Setenv("SYMPHONY_API_TOKEN_FILE", "/etc/symphony/token")
+	t.Setenv("SYMPHONY_API_INSECURE_SKIP_VERIFY", "true")
+	config, err := SymphonyAPIConfigFromEnv(configv1.SymphonyAPIConfig{
+		Endpoint:   "http://symphony-service:8080/v1alpha2/",
+		CACertFile: "/etc/symphony/ca.crt",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://my-api:8081/v1alpha2/", config.Endpoint)
+	assert.Equal(t, "/etc/symphony/token", config.TokenFile)
+	assert.Equal(t, "/etc/symphony/ca.crt", config.CACertFile)
+	assert.True(t, config.InsecureSkipVerify)
+}
+func TestSymphonyAPIConfigFromEnvInvalidBool(t *testing.T) {
+	t.Setenv("SYMPHONY_API_INSECURE_SKIP_VERIFY", "maybe")
+	_, err := SymphonyAPIConfigFromEnv(configv1.SymphonyAPIConfig{})
+	assert.NotNil(t, err)
+}